@@ -0,0 +1,291 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"iter"
+)
+
+// A Batch is a sequence of statements to execute with [DB.ExecBatch],
+// [Conn.ExecBatch], or [Tx.ExecBatch].
+//
+// The zero value is an empty batch.
+type Batch struct {
+	stmts []batchStmt
+}
+
+type batchStmt struct {
+	query string
+	args  []any
+}
+
+// Queue adds a statement with the given query and arguments to the
+// batch.
+func (b *Batch) Queue(query string, args ...any) {
+	b.stmts = append(b.stmts, batchStmt{query, args})
+}
+
+// Len returns the number of statements in the batch.
+func (b *Batch) Len() int {
+	return len(b.stmts)
+}
+
+// A BatchError records the failure of a statement of a [Batch].
+type BatchError struct {
+	Index int // index of the statement in the batch
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("sql: batch statement %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error { return e.Err }
+
+// ExecBatch executes the statements of b in order on a single
+// connection, without returning any rows.
+//
+// If the driver implements [driver.Batcher], the statements are
+// executed together, in as few round trips to the database as the
+// driver allows. Otherwise, they are executed one at a time.
+//
+// ExecBatch returns the results of the statements that were executed
+// successfully, in order. If a statement fails, ExecBatch returns the
+// results of the statements before it and a [*BatchError] recording
+// its failure, and the statements after it are not executed. The
+// batch is not executed atomically; to do so, use [Tx.ExecBatch].
+func (db *DB) ExecBatch(ctx context.Context, b *Batch) ([]Result, error) {
+	var res []Result
+	var err error
+	retryErr := db.retry(func(strategy connReuseStrategy) error {
+		var dc *driverConn
+		dc, err = db.conn(ctx, strategy)
+		if err != nil {
+			return err
+		}
+		res, err = db.execBatchDC(ctx, dc, dc.releaseConn, b)
+		if be, ok := err.(*BatchError); ok && be.Index > 0 {
+			// Statements were executed; do not retry.
+			return nil
+		}
+		return err
+	})
+	if retryErr != nil {
+		return nil, retryErr
+	}
+	return res, err
+}
+
+// ExecBatch executes the statements of b in order on the connection,
+// as with [DB.ExecBatch].
+func (c *Conn) ExecBatch(ctx context.Context, b *Batch) ([]Result, error) {
+	dc, release, err := c.grabConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.db.execBatchDC(ctx, dc, release, b)
+}
+
+// ExecBatch executes the statements of b in order within the
+// transaction, as with [DB.ExecBatch].
+func (tx *Tx) ExecBatch(ctx context.Context, b *Batch) ([]Result, error) {
+	dc, release, err := tx.grabConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return tx.db.execBatchDC(ctx, dc, release, b)
+}
+
+func (db *DB) execBatchDC(ctx context.Context, dc *driverConn, release func(error), b *Batch) (res []Result, err error) {
+	defer func() {
+		release(err)
+	}()
+	if len(b.stmts) == 0 {
+		return nil, nil
+	}
+	if batcher, ok := dc.ci.(driver.Batcher); ok {
+		var resi []driver.Result
+		var argErr error
+		withLock(dc, func() {
+			// If the arguments of a statement cannot be converted,
+			// execute the statements before it, as they would be
+			// if the statements were executed one at a time.
+			stmts := make([]driver.BatchStatement, 0, len(b.stmts))
+			for i, s := range b.stmts {
+				args, err := driverArgsConnLocked(dc.ci, nil, s.args)
+				if err != nil {
+					argErr = &BatchError{Index: i, Err: err}
+					break
+				}
+				stmts = append(stmts, driver.BatchStatement{Query: s.query, Args: args})
+			}
+			if len(stmts) > 0 {
+				resi, err = batcher.ExecBatch(ctx, stmts)
+			}
+		})
+		if err != driver.ErrSkip {
+			for _, r := range resi {
+				res = append(res, driverResult{dc, r})
+			}
+			if err != nil {
+				return res, &BatchError{Index: len(res), Err: err}
+			}
+			return res, argErr
+		}
+	}
+
+	for i, s := range b.stmts {
+		r, err := db.execDC(ctx, dc, func(error) {}, s.query, s.args)
+		if err != nil {
+			return res, &BatchError{Index: i, Err: err}
+		}
+		res = append(res, r)
+	}
+	return res, nil
+}
+
+// A BulkCopy describes the rows to insert into a table with
+// [DB.CopyFrom], [Conn.CopyFrom], or [Tx.CopyFrom].
+type BulkCopy struct {
+	// Table is the name of the table.
+	Table string
+
+	// Columns are the names of the columns, in the order of the
+	// values of each row.
+	Columns []string
+
+	// Insert is the statement used to insert each row if the driver
+	// does not implement [driver.BulkCopier], with the values of
+	// the row as its arguments. For example:
+	//
+	//	INSERT INTO users (id, name) VALUES ($1, $2)
+	//
+	// If Insert is empty, CopyFrom fails if the driver does not
+	// implement driver.BulkCopier.
+	Insert string
+}
+
+// errNoBulkCopy is returned by CopyFrom if the driver does not support
+// bulk copies and no Insert statement is given.
+var errNoBulkCopy = errors.New("sql: driver does not support bulk copy, and BulkCopy.Insert is empty")
+
+// CopyFrom inserts the rows yielded by rows into a table, as
+// described by c, on a single connection. Each row must have one value
+// for each of c.Columns. The values of a row must not be modified
+// until the next row is requested. CopyFrom returns the number of rows
+// inserted.
+//
+// If the driver implements [driver.BulkCopier], the rows are sent to
+// the database together, in as few round trips as the driver allows.
+// Otherwise, they are inserted one at a time by executing c.Insert.
+// The rows are not inserted atomically; to do so, use [Tx.CopyFrom].
+//
+// The table and column names are passed to the driver as given, and
+// must not come from untrusted input.
+func (db *DB) CopyFrom(ctx context.Context, c BulkCopy, rows iter.Seq[[]any]) (int64, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	return conn.CopyFrom(ctx, c, rows)
+}
+
+// CopyFrom inserts the rows yielded by rows into a table on the
+// connection, as with [DB.CopyFrom].
+func (c *Conn) CopyFrom(ctx context.Context, bc BulkCopy, rows iter.Seq[[]any]) (int64, error) {
+	dc, release, err := c.grabConn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return c.db.copyFromDC(ctx, dc, release, bc, rows)
+}
+
+// CopyFrom inserts the rows yielded by rows into a table within the
+// transaction, as with [DB.CopyFrom].
+func (tx *Tx) CopyFrom(ctx context.Context, bc BulkCopy, rows iter.Seq[[]any]) (int64, error) {
+	dc, release, err := tx.grabConn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return tx.db.copyFromDC(ctx, dc, release, bc, rows)
+}
+
+func (db *DB) copyFromDC(ctx context.Context, dc *driverConn, release func(error), bc BulkCopy, rows iter.Seq[[]any]) (n int64, err error) {
+	defer func() {
+		release(err)
+	}()
+	// checkRow checks the number of values in the i'th row.
+	checkRow := func(i int, row []any) error {
+		if len(row) != len(bc.Columns) {
+			return fmt.Errorf("sql: CopyFrom row %d has %d values, not %d", i, len(row), len(bc.Columns))
+		}
+		return nil
+	}
+
+	if copier, ok := dc.ci.(driver.BulkCopier); ok {
+		// The driver requests the values while dc is locked, as
+		// driverArgsConnLocked requires.
+		values := func(yield func([]driver.Value, error) bool) {
+			var vals []driver.Value
+			i := 0
+			for row := range rows {
+				if err := checkRow(i, row); err != nil {
+					yield(nil, err)
+					return
+				}
+				nvs, err := driverArgsConnLocked(dc.ci, nil, row)
+				if err != nil {
+					yield(nil, fmt.Errorf("sql: CopyFrom row %d: %w", i, err))
+					return
+				}
+				vals = vals[:0]
+				for _, nv := range nvs {
+					vals = append(vals, nv.Value)
+				}
+				if !yield(vals, nil) {
+					return
+				}
+				i++
+			}
+		}
+		withLock(dc, func() {
+			n, err = copier.CopyFrom(ctx, bc.Table, bc.Columns, values)
+		})
+		if err != driver.ErrSkip {
+			return n, err
+		}
+		n = 0
+	}
+
+	if bc.Insert == "" {
+		return 0, errNoBulkCopy
+	}
+	var si driver.Stmt
+	withLock(dc, func() {
+		si, err = ctxDriverPrepare(ctx, dc.ci, bc.Insert)
+	})
+	if err != nil {
+		return 0, err
+	}
+	ds := &driverStmt{Locker: dc, si: si}
+	defer ds.Close()
+	i := 0
+	for row := range rows {
+		if err := checkRow(i, row); err != nil {
+			return n, err
+		}
+		if _, err := resultFromStatement(ctx, dc.ci, ds, row...); err != nil {
+			return n, fmt.Errorf("sql: CopyFrom row %d: %w", i, err)
+		}
+		n++
+		i++
+	}
+	return n, nil
+}
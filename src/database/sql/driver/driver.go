@@ -23,6 +23,16 @@
 // also allows queries to accept per-query options as a parameter by returning
 // [ErrRemoveArgument] from CheckNamedValue.
 //
+// If the database can execute several statements, or insert many rows, in
+// fewer round trips, the driver's [Conn] should implement [Batcher] and
+// [BulkCopier].
+//
+// If transactions support savepoints, the driver's [Tx] should implement
+// [Savepointer].
+//
+// If the database sends asynchronous notifications, the driver's [Conn]
+// should implement [Listener].
+//
 // If multiple result sets are supported, [Rows] should implement [RowsNextResultSet].
 // If the driver knows how to describe the types present in the returned result
 // it should implement the following interfaces: [RowsColumnTypeScanType],
@@ -41,6 +51,7 @@ package driver
 import (
 	"context"
 	"errors"
+	"iter"
 	"reflect"
 )
 
@@ -313,6 +324,103 @@ type Validator interface {
 	IsValid() bool
 }
 
+// BatchStatement is a statement of a batch executed by [Batcher].
+type BatchStatement struct {
+	// Query is the text of the statement.
+	Query string
+
+	// Args are the arguments of the statement, as they would be
+	// passed to [ExecerContext].
+	Args []NamedValue
+}
+
+// Batcher is an optional interface that may be implemented by a [Conn].
+//
+// If a [Conn] does not implement Batcher, [database/sql.DB.ExecBatch]
+// executes the statements of the batch one at a time.
+type Batcher interface {
+	// ExecBatch executes stmts in order, with as few round trips to
+	// the database as possible, for example by pipelining them.
+	//
+	// ExecBatch returns the results of the statements that were
+	// executed successfully, in order. If a statement fails,
+	// ExecBatch returns the results of the statements before it and
+	// its error, and the statements after it are not executed.
+	//
+	// ExecBatch may return ErrSkip, before executing any statement.
+	// It may return ErrBadConn only if no statement was executed.
+	//
+	// ExecBatch must honor the context timeout and return when the
+	// context is canceled.
+	ExecBatch(ctx context.Context, stmts []BatchStatement) ([]Result, error)
+}
+
+// BulkCopier is an optional interface that may be implemented by a
+// [Conn].
+//
+// If a [Conn] does not implement BulkCopier,
+// [database/sql.DB.CopyFrom] inserts the rows one at a time with an
+// INSERT statement.
+type BulkCopier interface {
+	// CopyFrom inserts rows into the given columns of table, with
+	// as few round trips to the database as possible, for example
+	// by using a COPY statement. Each row has one value for each
+	// column. It returns the number of rows inserted.
+	//
+	// If rows yields a non-nil error, CopyFrom must stop and return
+	// that error, and should insert none of the rows if possible.
+	// The values of a row must not be retained after the next row is
+	// requested.
+	//
+	// CopyFrom may return ErrSkip, before requesting any row.
+	//
+	// CopyFrom must honor the context timeout and return when the
+	// context is canceled.
+	CopyFrom(ctx context.Context, table string, columns []string, rows iter.Seq2[[]Value, error]) (int64, error)
+}
+
+// Notification is an asynchronous notification sent by the database,
+// such as with the NOTIFY command of PostgreSQL.
+type Notification struct {
+	// Channel is the name of the channel the notification was sent
+	// on.
+	Channel string
+
+	// Payload is the data sent with the notification, if any.
+	Payload string
+}
+
+// Listener is an optional interface that may be implemented by a
+// [Conn] to receive asynchronous notifications from the database.
+//
+// If a [Conn] does not implement Listener,
+// [database/sql.DB.Subscribe] returns an error.
+//
+// A connection that listens for notifications is dedicated to them:
+// the sql package calls no methods of the Conn but those of Listener
+// and Close, and does not call them concurrently. The connection is
+// closed, not returned to the connection pool, when it stops
+// listening.
+type Listener interface {
+	// Listen subscribes the connection to the notifications sent on
+	// the named channels. Channel names are passed as given, and
+	// must be quoted by the driver as needed.
+	Listen(ctx context.Context, channels []string) error
+
+	// Unlisten unsubscribes the connection from the notifications
+	// sent on the named channels.
+	Unlisten(ctx context.Context, channels []string) error
+
+	// WaitNotification waits for the next notification sent on a
+	// channel that the connection listens on.
+	//
+	// WaitNotification must return when the context is canceled,
+	// leaving the connection usable, as it is canceled to call
+	// Listen or Unlisten. It should return ErrBadConn if the
+	// connection is lost.
+	WaitNotification(ctx context.Context) (Notification, error)
+}
+
 // Result is the result of a query execution.
 type Result interface {
 	// LastInsertId returns the database's auto-generated ID
@@ -521,6 +629,33 @@ type Tx interface {
 	Rollback() error
 }
 
+// Savepointer is an optional interface that may be implemented by a
+// [Tx].
+//
+// If a [Tx] does not implement Savepointer, the savepoint methods of
+// [database/sql.Tx] return an error.
+//
+// Savepoint names are passed as given, and are valid SQL identifiers
+// of ASCII letters, digits, and underscores. The sql package tracks
+// which savepoints exist, and does not call RollbackTo or Release for
+// a name that was not created by Savepoint.
+type Savepointer interface {
+	// Savepoint creates a savepoint with the given name in the
+	// transaction. If a savepoint with the same name already exists,
+	// the new one hides it until the new one is released.
+	Savepoint(ctx context.Context, name string) error
+
+	// RollbackTo rolls back the changes made in the transaction
+	// since the named savepoint was created, and releases any
+	// savepoints created after it. The savepoint itself remains.
+	RollbackTo(ctx context.Context, name string) error
+
+	// Release releases the named savepoint, and any savepoints
+	// created after it, keeping the changes made since it was
+	// created.
+	Release(ctx context.Context, name string) error
+}
+
 // RowsAffected implements [Result] for an INSERT or UPDATE operation
 // which mutates a number of rows.
 type RowsAffected int64
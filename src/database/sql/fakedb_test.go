@@ -11,6 +11,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"iter"
 	"reflect"
 	"slices"
 	"strconv"
@@ -91,10 +92,11 @@ func (cc *fakeDriverCtx) OpenConnector(name string) (driver.Connector, error) {
 type fakeDB struct {
 	name string
 
-	mu       sync.Mutex
-	tables   map[string]*table
-	badConn  bool
-	allowAny bool
+	mu        sync.Mutex
+	tables    map[string]*table
+	badConn   bool
+	allowAny  bool
+	listeners map[*fakeConn]bool
 }
 
 type fakeError struct {
@@ -158,6 +160,11 @@ type fakeConn struct {
 	// The waiter is called before each query. May be used in place of the "WAIT"
 	// directive.
 	waiter func(context.Context)
+
+	// listening is the set of channels the connection listens on,
+	// guarded by db.mu, and notes receives their notifications.
+	listening map[string]bool
+	notes     chan driver.Notification
 }
 
 func (c *fakeConn) touchMem() {
@@ -172,6 +179,10 @@ func (c *fakeConn) incrStat(v *int) {
 
 type fakeTx struct {
 	c *fakeConn
+
+	// savepointLog records the savepoint operations of the
+	// transaction. A savepoint named "fail" fails to be created.
+	savepointLog []string
 }
 
 type boundCol struct {
@@ -439,10 +450,78 @@ func (c *fakeConn) Close() (err error) {
 	if c.stmtsMade > c.stmtsClosed {
 		return errors.New("fakedb: can't close; dangling statement(s)")
 	}
+	c.db.mu.Lock()
+	delete(c.db.listeners, c)
+	c.db.mu.Unlock()
 	c.db = nil
 	return nil
 }
 
+var _ driver.Listener = (*fakeConn)(nil)
+
+func (c *fakeConn) Listen(ctx context.Context, channels []string) error {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	if c.listening == nil {
+		c.listening = make(map[string]bool)
+		c.notes = make(chan driver.Notification, 16)
+	}
+	for _, ch := range channels {
+		c.listening[ch] = true
+	}
+	if c.db.listeners == nil {
+		c.db.listeners = make(map[*fakeConn]bool)
+	}
+	c.db.listeners[c] = true
+	return nil
+}
+
+func (c *fakeConn) Unlisten(ctx context.Context, channels []string) error {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	for _, ch := range channels {
+		delete(c.listening, ch)
+	}
+	return nil
+}
+
+func (c *fakeConn) WaitNotification(ctx context.Context) (driver.Notification, error) {
+	select {
+	case n := <-c.notes:
+		if n.Channel == "" {
+			return n, fakeError{Message: "WaitNotification: connection lost", Wrapped: driver.ErrBadConn}
+		}
+		return n, nil
+	case <-ctx.Done():
+		return driver.Notification{}, ctx.Err()
+	}
+}
+
+// notify sends a notification to the connections listening on channel.
+func (db *fakeDB) notify(channel, payload string) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for c := range db.listeners {
+		if c.listening[channel] {
+			select {
+			case c.notes <- driver.Notification{Channel: channel, Payload: payload}:
+			default:
+			}
+		}
+	}
+}
+
+// breakListeners makes WaitNotification fail with ErrBadConn on the
+// connections listening for notifications.
+func (db *fakeDB) breakListeners() {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for c := range db.listeners {
+		c.notes <- driver.Notification{}
+		delete(db.listeners, c)
+	}
+}
+
 func checkSubsetTypes(allowAny bool, args []driver.NamedValue) error {
 	for _, arg := range args {
 		switch arg.Value.(type) {
@@ -1055,6 +1134,26 @@ func (tx *fakeTx) Rollback() error {
 	return nil
 }
 
+var _ driver.Savepointer = (*fakeTx)(nil)
+
+func (tx *fakeTx) Savepoint(ctx context.Context, name string) error {
+	if name == "fail" {
+		return errors.New("fakedb: savepoint failed")
+	}
+	tx.savepointLog = append(tx.savepointLog, "SAVEPOINT "+name)
+	return nil
+}
+
+func (tx *fakeTx) RollbackTo(ctx context.Context, name string) error {
+	tx.savepointLog = append(tx.savepointLog, "ROLLBACK TO "+name)
+	return nil
+}
+
+func (tx *fakeTx) Release(ctx context.Context, name string) error {
+	tx.savepointLog = append(tx.savepointLog, "RELEASE "+name)
+	return nil
+}
+
 type rowsCursor struct {
 	db        *fakeDB
 	parentMem memToucher
@@ -1264,3 +1363,107 @@ func colTypeToReflectType(typ string) reflect.Type {
 	}
 	panic("invalid fakedb column type of " + typ)
 }
+
+// fakeBatchConnector returns connections that implement driver.Batcher
+// and driver.BulkCopier, which execute several statements per session.
+type fakeBatchConnector struct {
+	fakeConnector
+
+	skip  bool // return driver.ErrSkip from ExecBatch and CopyFrom
+	plain bool // return a *fakeConn, which implements neither
+}
+
+func (c *fakeBatchConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.fakeConnector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fc := conn.(*fakeConn)
+	fc.skipDirtySession = true
+	if c.plain {
+		return fc, nil
+	}
+	return &fakeBatchConn{fakeConn: fc, skip: c.skip}, nil
+}
+
+var (
+	_ driver.Batcher    = (*fakeBatchConn)(nil)
+	_ driver.BulkCopier = (*fakeBatchConn)(nil)
+)
+
+type fakeBatchConn struct {
+	*fakeConn
+
+	skip    bool
+	batches int // calls to ExecBatch
+	copies  int // calls to CopyFrom
+}
+
+// ExecBatch executes the statements one at a time, as if they had been
+// sent to the database together.
+func (c *fakeBatchConn) ExecBatch(ctx context.Context, stmts []driver.BatchStatement) ([]driver.Result, error) {
+	if c.skip {
+		return nil, driver.ErrSkip
+	}
+	c.batches++
+	var res []driver.Result
+	for _, s := range stmts {
+		stmt, err := c.PrepareContext(ctx, s.Query)
+		if err != nil {
+			return res, err
+		}
+		if len(s.Args) != stmt.NumInput() {
+			stmt.Close()
+			return res, errf("batch statement has %d arguments; want %d", len(s.Args), stmt.NumInput())
+		}
+		r, err := stmt.(driver.StmtExecContext).ExecContext(ctx, s.Args)
+		stmt.Close()
+		if err != nil {
+			return res, err
+		}
+		res = append(res, r)
+	}
+	return res, nil
+}
+
+// CopyFrom inserts all of the rows into the table, or none of them.
+func (c *fakeBatchConn) CopyFrom(ctx context.Context, tableName string, columns []string, rows iter.Seq2[[]driver.Value, error]) (int64, error) {
+	if c.skip {
+		return 0, driver.ErrSkip
+	}
+	c.copies++
+	c.db.mu.Lock()
+	t, ok := c.db.table(tableName)
+	c.db.mu.Unlock()
+	if !ok {
+		return 0, errf("table %q doesn't exist", tableName)
+	}
+	colidx := make([]int, len(columns))
+	for i, col := range columns {
+		if colidx[i] = t.columnIndex(col); colidx[i] == -1 {
+			return 0, errf("table %q has no column %q", tableName, col)
+		}
+	}
+	var newRows []*row
+	for vals, err := range rows {
+		if err != nil {
+			return 0, err
+		}
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		cols := make([]any, len(t.colname))
+		for i, v := range vals {
+			cv, err := converterForType(t.coltype[colidx[i]]).ConvertValue(v)
+			if err != nil {
+				return 0, errf("column %q: %v", columns[i], err)
+			}
+			cols[colidx[i]] = cv
+		}
+		newRows = append(newRows, &row{cols: cols})
+	}
+	t.mu.Lock()
+	t.rows = append(t.rows, newRows...)
+	t.mu.Unlock()
+	return int64(len(newRows)), nil
+}
@@ -0,0 +1,228 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sql
+
+import (
+	"context"
+	"time"
+)
+
+// Hooks are functions called by a [DB] around its operations, such as
+// to trace them or to record metrics. They are set with [DB.SetHooks],
+// and apply to the operations of the DB and of its [Conn], [Tx], and
+// [Stmt] values, whatever the driver.
+//
+// Any of the functions may be nil. They may be called concurrently,
+// and should return quickly, since they are called while the operation
+// holds a connection.
+type Hooks struct {
+	// OnQueryStart is called before a statement is executed by an
+	// Exec method, or a query is run by a Query or QueryRow method.
+	// The context it returns, which must be ctx or derived from
+	// it, is used for the operation and passed to OnQueryEnd.
+	OnQueryStart func(ctx context.Context, info QueryInfo) context.Context
+
+	// OnQueryEnd is called when an operation started with
+	// OnQueryStart ends: for an Exec method, when it returns; for a
+	// Query method, when it fails or when the returned [Rows] are
+	// closed.
+	OnQueryEnd func(ctx context.Context, info QueryEndInfo)
+
+	// OnConnAcquire is called when an operation has acquired a
+	// connection from the pool, or failed to.
+	OnConnAcquire func(ctx context.Context, info ConnAcquireInfo)
+
+	// OnConnRelease is called when a connection acquired by an
+	// operation is returned to the pool.
+	OnConnRelease func(info ConnReleaseInfo)
+
+	// OnTxBegin is called before a transaction is begun. The
+	// context it returns, which must be ctx or derived from it, is
+	// used for the transaction and passed to OnTxEnd.
+	OnTxBegin func(ctx context.Context, opts *TxOptions) context.Context
+
+	// OnTxEnd is called when a transaction started with OnTxBegin
+	// is committed or rolled back, including when it is rolled back
+	// because its context is done, or when it fails to begin.
+	OnTxEnd func(ctx context.Context, info TxEndInfo)
+}
+
+// QueryInfo describes an operation reported to [Hooks.OnQueryStart].
+type QueryInfo struct {
+	Query string
+	Args  []any
+	Exec  bool // whether the operation is an Exec rather than a Query
+}
+
+// QueryEndInfo describes an operation reported to [Hooks.OnQueryEnd].
+type QueryEndInfo struct {
+	QueryInfo
+
+	// Duration is the time from the start of the operation to its
+	// end. For a query, this includes the time taken to read the
+	// rows.
+	Duration time.Duration
+
+	// RowsAffected is the number of rows affected by an Exec, or -1
+	// if it is not known.
+	RowsAffected int64
+
+	// Rows is the number of rows read by a Query.
+	Rows int64
+
+	// Err is the error that ended the operation, if any.
+	Err error
+}
+
+// ConnAcquireInfo describes the acquisition of a connection reported
+// to [Hooks.OnConnAcquire].
+type ConnAcquireInfo struct {
+	// Wait is the time taken to acquire the connection, including
+	// waiting for a connection to be released, and opening a new
+	// one.
+	Wait time.Duration
+
+	// New reports whether a new connection was opened.
+	New bool
+
+	// Err is the error that prevented a connection from being
+	// acquired, if any.
+	Err error
+}
+
+// ConnReleaseInfo describes the release of a connection reported to
+// [Hooks.OnConnRelease].
+type ConnReleaseInfo struct {
+	// Held is the time for which the connection was held.
+	Held time.Duration
+
+	// Err is the last error of the operation that held the
+	// connection, if any.
+	Err error
+}
+
+// TxEndInfo describes the end of a transaction reported to
+// [Hooks.OnTxEnd].
+type TxEndInfo struct {
+	// Commit reports whether the transaction was committed, rather
+	// than rolled back.
+	Commit bool
+
+	// Duration is the time from the start of the transaction to
+	// its end.
+	Duration time.Duration
+
+	// Err is the error that the commit or rollback returned, or the
+	// error that prevented the transaction from beginning.
+	Err error
+}
+
+// SetHooks sets the hooks called by db around its operations. If h is
+// nil, no hooks are called. Operations in progress continue to call
+// the hooks that were set when they started.
+func (db *DB) SetHooks(h *Hooks) {
+	db.hooks.Store(h)
+}
+
+// A queryHook reports the end of an operation to Hooks.OnQueryEnd.
+// A nil *queryHook reports nothing.
+type queryHook struct {
+	h     *Hooks
+	ctx   context.Context
+	info  QueryInfo
+	start time.Time
+}
+
+// startQuery calls the OnQueryStart hook of db, if any. It returns the
+// context for the operation and the hook to report its end to.
+func (db *DB) startQuery(ctx context.Context, exec bool, query string, args []any) (context.Context, *queryHook) {
+	h := db.hooks.Load()
+	if h == nil || (h.OnQueryStart == nil && h.OnQueryEnd == nil) {
+		return ctx, nil
+	}
+	info := QueryInfo{Query: query, Args: args, Exec: exec}
+	if h.OnQueryStart != nil {
+		ctx = h.OnQueryStart(ctx, info)
+	}
+	return ctx, &queryHook{h: h, ctx: ctx, info: info, start: nowFunc()}
+}
+
+// endExec reports the end of an Exec operation.
+func (q *queryHook) endExec(res Result, err error) {
+	if q == nil || q.h.OnQueryEnd == nil {
+		return
+	}
+	n := int64(-1)
+	if err == nil && res != nil {
+		if ra, raErr := res.RowsAffected(); raErr == nil {
+			n = ra
+		}
+	}
+	q.h.OnQueryEnd(q.ctx, QueryEndInfo{
+		QueryInfo:    q.info,
+		Duration:     nowFunc().Sub(q.start),
+		RowsAffected: n,
+		Err:          err,
+	})
+}
+
+// endQuery reports the end of a Query operation that read rows rows.
+func (q *queryHook) endQuery(rows int64, err error) {
+	if q == nil || q.h.OnQueryEnd == nil {
+		return
+	}
+	q.h.OnQueryEnd(q.ctx, QueryEndInfo{
+		QueryInfo:    q.info,
+		Duration:     nowFunc().Sub(q.start),
+		RowsAffected: -1,
+		Rows:         rows,
+		Err:          err,
+	})
+}
+
+// acquiredConn calls the OnConnAcquire hook of db, if any, for a
+// connection acquisition that began at start.
+func (db *DB) acquiredConn(ctx context.Context, start time.Time, dc *driverConn, err error) {
+	h := db.hooks.Load()
+	if h == nil {
+		return
+	}
+	now := nowFunc()
+	if dc != nil && h.OnConnRelease != nil {
+		dc.acquiredAt = now
+	}
+	if h.OnConnAcquire != nil {
+		h.OnConnAcquire(ctx, ConnAcquireInfo{
+			Wait: now.Sub(start),
+			New:  dc != nil && !dc.createdAt.Before(start),
+			Err:  err,
+		})
+	}
+}
+
+// releasedConn calls the OnConnRelease hook of db, if any, for a
+// connection released with the error err.
+func (db *DB) releasedConn(dc *driverConn, err error) {
+	if dc.acquiredAt.IsZero() {
+		return
+	}
+	held := nowFunc().Sub(dc.acquiredAt)
+	dc.acquiredAt = time.Time{}
+	if h := db.hooks.Load(); h != nil && h.OnConnRelease != nil {
+		h.OnConnRelease(ConnReleaseInfo{Held: held, Err: err})
+	}
+}
+
+// endTx calls the OnTxEnd hook of tx, if any.
+func (tx *Tx) endTx(commit bool, err error) {
+	if tx.hooks == nil || tx.hooks.OnTxEnd == nil {
+		return
+	}
+	tx.hooks.OnTxEnd(tx.hookCtx, TxEndInfo{
+		Commit:   commit,
+		Duration: nowFunc().Sub(tx.start),
+		Err:      err,
+	})
+}
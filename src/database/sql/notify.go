@@ -0,0 +1,430 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"iter"
+	"slices"
+	"sync"
+	"time"
+)
+
+// A Notification is an asynchronous notification sent by the
+// database, received by a [Subscription].
+type Notification struct {
+	// Channel is the name of the channel the notification was sent
+	// on.
+	Channel string
+
+	// Payload is the data sent with the notification, if any.
+	Payload string
+
+	// Reconnected reports that the subscription lost its connection
+	// and reconnected, so that notifications sent while it was
+	// disconnected were lost. A Notification with Reconnected set
+	// has no Channel or Payload.
+	Reconnected bool
+}
+
+// errNoListener is returned by Subscribe if the driver does not
+// support notifications.
+var errNoListener = errors.New("sql: driver does not support notifications")
+
+// errSubscriptionClosed is returned by the methods of a Subscription
+// once it has been closed.
+var errSubscriptionClosed = errors.New("sql: subscription is closed")
+
+// Delays between attempts to reconnect a Subscription.
+const (
+	minReconnectDelay = 100 * time.Millisecond
+	maxReconnectDelay = 10 * time.Second
+)
+
+// subscriptionBuffer is the number of notifications a Subscription
+// buffers before it stops reading from its connection.
+const subscriptionBuffer = 64
+
+// A Subscription receives asynchronous notifications from the database
+// on a dedicated connection. It is created with [DB.Subscribe].
+//
+// If the connection is lost, the Subscription reconnects and listens on
+// its channels again, delivering a [Notification] with Reconnected set,
+// since notifications sent while it was disconnected are lost. It ends
+// only when it is closed, or when the [DB] is closed.
+//
+// A Subscription is safe for concurrent use by multiple goroutines.
+type Subscription struct {
+	db     *DB
+	c      chan Notification
+	ctx    context.Context // canceled by Close, or by DB.Close
+	cancel context.CancelCauseFunc
+	done   chan struct{} // closed when run returns
+	wake   chan struct{} // signals run that reqs are pending
+
+	mu         sync.Mutex // protects following fields
+	reqs       []*listenRequest
+	cancelWait func() // cancels the current WaitNotification, if any
+	finished   bool   // whether run has returned
+	err        error  // the error that ended the subscription, if any
+}
+
+// A listenRequest asks the goroutine of a Subscription to change the
+// channels it listens on.
+type listenRequest struct {
+	ctx      context.Context
+	listen   bool // Listen, rather than Unlisten
+	channels []string
+	errc     chan error // buffered
+}
+
+// Subscribe returns a [Subscription] that receives the notifications
+// sent on the named channels, on a connection dedicated to it. The
+// connection counts towards the limit set by [DB.SetMaxOpenConns], and
+// is closed, not returned to the pool, when the Subscription is closed.
+//
+// The driver's connections must implement [driver.Listener]. The
+// channel names are passed to the driver as given, and must not come
+// from untrusted input. The context is used to acquire the connection
+// and listen on the channels; the Subscription lasts until it is
+// closed, or the DB is closed.
+func (db *DB) Subscribe(ctx context.Context, channels ...string) (*Subscription, error) {
+	var dc *driverConn
+	err := db.retry(func(strategy connReuseStrategy) error {
+		var err error
+		dc, err = db.conn(ctx, strategy)
+		if err != nil {
+			return err
+		}
+		if _, ok := dc.ci.(driver.Listener); !ok {
+			dc.releaseConn(nil)
+			return errNoListener
+		}
+		if err := listenDC(ctx, dc, true, channels); err != nil {
+			// The connection may listen on some of the channels.
+			dc.releaseConn(driver.ErrBadConn)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancelCause(context.Background())
+	s := &Subscription{
+		db:     db,
+		c:      make(chan Notification, subscriptionBuffer),
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+		wake:   make(chan struct{}, 1),
+	}
+	db.mu.Lock()
+	if db.closed {
+		db.mu.Unlock()
+		cancel(nil)
+		dc.releaseConn(driver.ErrBadConn)
+		return nil, errDBClosed
+	}
+	if db.subscriptions == nil {
+		db.subscriptions = make(map[*Subscription]bool)
+	}
+	db.subscriptions[s] = true
+	db.mu.Unlock()
+	go s.run(dc, addChannels(nil, channels))
+	return s, nil
+}
+
+// Listen subscribes s to the notifications sent on the named channels,
+// in addition to those it already receives.
+func (s *Subscription) Listen(ctx context.Context, channels ...string) error {
+	return s.request(ctx, true, channels)
+}
+
+// Unlisten unsubscribes s from the notifications sent on the named
+// channels. Notifications already buffered by s are still delivered.
+func (s *Subscription) Unlisten(ctx context.Context, channels ...string) error {
+	return s.request(ctx, false, channels)
+}
+
+// request asks the goroutine of s to change the channels it listens
+// on, and waits for the result.
+func (s *Subscription) request(ctx context.Context, listen bool, channels []string) error {
+	r := &listenRequest{ctx: ctx, listen: listen, channels: channels, errc: make(chan error, 1)}
+	s.mu.Lock()
+	if s.finished {
+		s.mu.Unlock()
+		return errSubscriptionClosed
+	}
+	s.reqs = append(s.reqs, r)
+	if s.cancelWait != nil {
+		s.cancelWait()
+	}
+	s.mu.Unlock()
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	select {
+	case err := <-r.errc:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Next waits for the next notification received by s. It returns an
+// error if ctx is done, or if s has ended.
+func (s *Subscription) Next(ctx context.Context) (Notification, error) {
+	select {
+	case n, ok := <-s.c:
+		if !ok {
+			if err := s.Err(); err != nil {
+				return Notification{}, err
+			}
+			return Notification{}, errSubscriptionClosed
+		}
+		return n, nil
+	case <-ctx.Done():
+		return Notification{}, ctx.Err()
+	}
+}
+
+// All returns an iterator over the notifications received by s, as
+// returned by [Subscription.Next]. The iteration stops when s is
+// closed. If ctx is done, or s ends with an error, the error is yielded
+// and the iteration stops.
+func (s *Subscription) All(ctx context.Context) iter.Seq2[Notification, error] {
+	return func(yield func(Notification, error) bool) {
+		for {
+			n, err := s.Next(ctx)
+			if err == errSubscriptionClosed {
+				return
+			}
+			if !yield(n, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// C returns the channel on which s delivers the notifications it
+// receives, as an alternative to [Subscription.Next]. The channel is
+// closed when s ends, after which [Subscription.Err] reports why.
+func (s *Subscription) C() <-chan Notification {
+	return s.c
+}
+
+// Err returns the error that ended s, if any. It returns nil while s
+// is active, and once s has been closed with [Subscription.Close].
+func (s *Subscription) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close stops s and closes its connection. Notifications buffered by s
+// may still be received.
+func (s *Subscription) Close() error {
+	s.end(nil)
+	return nil
+}
+
+// end stops s, with the error reported by Err afterwards, and waits for
+// its connection to be closed.
+func (s *Subscription) end(err error) {
+	s.cancel(err)
+	<-s.done
+}
+
+// run receives the notifications on dc, which listens on channels, and
+// serves the requests to listen on others, until s is closed.
+func (s *Subscription) run(dc *driverConn, channels []string) {
+	var err error
+	defer func() {
+		if dc != nil {
+			// Never return a listening connection to the pool.
+			dc.releaseConn(driver.ErrBadConn)
+		}
+		if s.ctx.Err() != nil {
+			// Ended by Close, or by DB.Close.
+			err = context.Cause(s.ctx)
+			if err == context.Canceled {
+				err = nil
+			}
+		}
+		s.db.mu.Lock()
+		delete(s.db.subscriptions, s)
+		s.db.mu.Unlock()
+		s.mu.Lock()
+		s.finished = true
+		s.err = err
+		reqs := s.reqs
+		s.reqs = nil
+		s.mu.Unlock()
+		for _, r := range reqs {
+			r.errc <- errSubscriptionClosed
+		}
+		close(s.c)
+		close(s.done)
+	}()
+
+	for {
+		s.mu.Lock()
+		reqs := s.reqs
+		s.reqs = nil
+		var waitCtx context.Context
+		var cancelWait func()
+		if len(reqs) == 0 {
+			waitCtx, cancelWait = context.WithCancel(s.ctx)
+			s.cancelWait = cancelWait
+		}
+		s.mu.Unlock()
+
+		var n driver.Notification
+		var broken bool
+		if len(reqs) > 0 {
+			channels, broken = serveListenRequests(dc, channels, reqs)
+		} else {
+			withLock(dc, func() {
+				n, err = dc.ci.(driver.Listener).WaitNotification(waitCtx)
+			})
+			interrupted := waitCtx.Err() != nil
+			s.mu.Lock()
+			s.cancelWait = nil
+			s.mu.Unlock()
+			cancelWait()
+			switch {
+			case s.ctx.Err() != nil:
+				return
+			case err == nil:
+				if !s.deliver(Notification{Channel: n.Channel, Payload: n.Payload}, dc, &channels, &broken) {
+					return
+				}
+			case interrupted:
+				// Interrupted to serve requests.
+			default:
+				broken = true
+			}
+		}
+		for broken {
+			dc.releaseConn(driver.ErrBadConn)
+			dc, err = s.reconnect(channels)
+			if err != nil {
+				return
+			}
+			broken = false
+			if !s.deliver(Notification{Reconnected: true}, dc, &channels, &broken) {
+				return
+			}
+		}
+	}
+}
+
+// deliver sends n on s.c, serving any requests while it waits. It
+// reports whether n was sent before s was closed. If a request finds
+// that dc is broken, deliver sets *broken.
+func (s *Subscription) deliver(n Notification, dc *driverConn, channels *[]string, broken *bool) bool {
+	for {
+		select {
+		case s.c <- n:
+			return true
+		case <-s.ctx.Done():
+			return false
+		case <-s.wake:
+			s.mu.Lock()
+			reqs := s.reqs
+			s.reqs = nil
+			s.mu.Unlock()
+			var b bool
+			*channels, b = serveListenRequests(dc, *channels, reqs)
+			*broken = *broken || b
+		}
+	}
+}
+
+// reconnect acquires a new connection that listens on channels,
+// retrying with increasing delays until it succeeds, s is closed, or
+// the DB is closed.
+func (s *Subscription) reconnect(channels []string) (*driverConn, error) {
+	delay := minReconnectDelay
+	for {
+		dc, err := s.db.conn(s.ctx, cachedOrNewConn)
+		if err == nil {
+			err = listenDC(s.ctx, dc, true, channels)
+			if err == nil {
+				return dc, nil
+			}
+			dc.releaseConn(driver.ErrBadConn)
+		}
+		if err == errDBClosed || err == errNoListener || s.ctx.Err() != nil {
+			return nil, err
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-s.ctx.Done():
+			t.Stop()
+			return nil, s.ctx.Err()
+		case <-t.C:
+		}
+		delay = min(2*delay, maxReconnectDelay)
+	}
+}
+
+// serveListenRequests serves reqs on dc, which listens on channels,
+// and returns the channels it listens on afterwards, and whether dc
+// is broken.
+func serveListenRequests(dc *driverConn, channels []string, reqs []*listenRequest) ([]string, bool) {
+	broken := false
+	for _, r := range reqs {
+		err := listenDC(r.ctx, dc, r.listen, r.channels)
+		switch {
+		case err != nil:
+			broken = broken || errors.Is(err, driver.ErrBadConn)
+		case r.listen:
+			channels = addChannels(channels, r.channels)
+		default:
+			channels = slices.DeleteFunc(channels, func(c string) bool {
+				return slices.Contains(r.channels, c)
+			})
+		}
+		r.errc <- err
+	}
+	return channels, broken
+}
+
+// listenDC calls Listen, or Unlisten if listen is false, for channels
+// on dc.
+func listenDC(ctx context.Context, dc *driverConn, listen bool, channels []string) error {
+	l, ok := dc.ci.(driver.Listener)
+	if !ok {
+		return errNoListener
+	}
+	if len(channels) == 0 {
+		return nil
+	}
+	var err error
+	withLock(dc, func() {
+		if listen {
+			err = l.Listen(ctx, channels)
+		} else {
+			err = l.Unlisten(ctx, channels)
+		}
+	})
+	return err
+}
+
+// addChannels adds the channels in add that are not in channels to
+// channels.
+func addChannels(channels, add []string) []string {
+	for _, c := range add {
+		if !slices.Contains(channels, c) {
+			channels = append(channels, c)
+		}
+	}
+	return channels
+}
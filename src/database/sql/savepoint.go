@@ -0,0 +1,147 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// errNoSavepoints is returned by the savepoint methods of Tx if the
+// driver does not support savepoints.
+var errNoSavepoints = errors.New("sql: driver does not support savepoints")
+
+// Savepoint creates a savepoint with the given name in the
+// transaction, which may be rolled back to with [Tx.RollbackTo] or
+// released with [Tx.Release]. Savepoints allow parts of a transaction
+// to be undone without aborting the whole transaction, as by nested
+// units of work.
+//
+// The name must consist of ASCII letters, digits, and underscores, and
+// not begin with a digit. If a savepoint with the same name exists,
+// the new one hides it until the new one is released.
+//
+// The driver's transactions must implement [driver.Savepointer].
+//
+// If Savepoint, RollbackTo, or Release fails, the state of the
+// transaction is unknown, and all further operations on the
+// transaction fail with the same error, except [Tx.Rollback]. A call
+// to [Tx.Commit] rolls the transaction back.
+func (tx *Tx) Savepoint(ctx context.Context, name string) error {
+	if !validSavepointName(name) {
+		return fmt.Errorf("sql: invalid savepoint name %q", name)
+	}
+	tx.spmu.Lock()
+	defer tx.spmu.Unlock()
+	err := tx.withSavepointer(ctx, func(sp driver.Savepointer) error {
+		return sp.Savepoint(ctx, name)
+	})
+	if err != nil {
+		return err
+	}
+	tx.savepoints = append(tx.savepoints, name)
+	return nil
+}
+
+// RollbackTo rolls back the changes made in the transaction since the
+// named savepoint was created with [Tx.Savepoint], and releases any
+// savepoints created after it. The savepoint remains, and may be
+// rolled back to again.
+//
+// Statements of the transaction prepared after the savepoint was
+// created remain valid.
+func (tx *Tx) RollbackTo(ctx context.Context, name string) error {
+	tx.spmu.Lock()
+	defer tx.spmu.Unlock()
+	i, err := tx.savepointIndexLocked(name)
+	if err != nil {
+		return err
+	}
+	err = tx.withSavepointer(ctx, func(sp driver.Savepointer) error {
+		return sp.RollbackTo(ctx, name)
+	})
+	if err != nil {
+		return err
+	}
+	tx.savepoints = tx.savepoints[:i+1]
+	return nil
+}
+
+// Release releases the named savepoint, created with [Tx.Savepoint],
+// and any savepoints created after it. The changes made since it was
+// created remain part of the transaction.
+func (tx *Tx) Release(ctx context.Context, name string) error {
+	tx.spmu.Lock()
+	defer tx.spmu.Unlock()
+	i, err := tx.savepointIndexLocked(name)
+	if err != nil {
+		return err
+	}
+	err = tx.withSavepointer(ctx, func(sp driver.Savepointer) error {
+		return sp.Release(ctx, name)
+	})
+	if err != nil {
+		return err
+	}
+	tx.savepoints = tx.savepoints[:i]
+	return nil
+}
+
+// savepointIndexLocked returns the index in tx.savepoints of the most
+// recent savepoint with the given name. tx.spmu must be held.
+func (tx *Tx) savepointIndexLocked(name string) (int, error) {
+	if err := tx.failed.Load(); err != nil {
+		return 0, *err
+	}
+	if tx.isDone() {
+		return 0, ErrTxDone
+	}
+	for i, sp := range slices.Backward(tx.savepoints) {
+		if sp == name {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("sql: no savepoint %q in transaction", name)
+}
+
+// withSavepointer calls fn with the driver's transaction, holding its
+// connection. If fn fails, the transaction is marked as failed.
+func (tx *Tx) withSavepointer(ctx context.Context, fn func(driver.Savepointer) error) error {
+	dc, release, err := tx.grabConn(ctx)
+	if err != nil {
+		return err
+	}
+	sp, ok := tx.txi.(driver.Savepointer)
+	if !ok {
+		release(nil)
+		return errNoSavepoints
+	}
+	withLock(dc, func() {
+		err = fn(sp)
+	})
+	release(err)
+	if err != nil {
+		err = fmt.Errorf("sql: transaction failed after savepoint error: %w", err)
+		tx.failed.CompareAndSwap(nil, &err)
+	}
+	return err
+}
+
+// validSavepointName reports whether name may be used as the name of a
+// savepoint.
+func validSavepointName(name string) bool {
+	if name == "" || '0' <= name[0] && name[0] <= '9' {
+		return false
+	}
+	for _, c := range []byte(name) {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
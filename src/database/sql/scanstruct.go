@@ -0,0 +1,318 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sql
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ScanStruct copies the columns in the current row into the fields of
+// the struct pointed at by dest.
+//
+// Each column is copied into the field whose name is given by its
+// struct tag with the key "sql", as in `sql:"first_name"`, or, for a
+// field with no such tag, into the field whose name matches the column
+// name ignoring case. Fields tagged `sql:"-"` and unexported fields are
+// ignored. The fields of an embedded struct are treated as fields of
+// the outer struct, unless the embedded struct has a tag or its
+// pointer implements [Scanner]; an embedded pointer to a struct is
+// allocated if any of its fields receives a column.
+//
+// Every column must match exactly one field; ScanStruct returns an
+// error naming any column that matches no field, or that matches more
+// than one. Fields that match no column are left unchanged.
+//
+// Columns are copied into fields as by [Rows.Scan], so a field may be
+// of any type that Scan accepts, including a [Null] or another
+// [Scanner].
+func (rs *Rows) ScanStruct(dest any) error {
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Pointer || dv.IsNil() || dv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("sql: ScanStruct destination %T is not a non-nil pointer to a struct", dest)
+	}
+	cols, err := rs.Columns()
+	if err != nil {
+		return err
+	}
+	plan, err := newStructPlan(dv.Type().Elem(), cols)
+	if err != nil {
+		return err
+	}
+	return plan.scan(rs, dv.Elem())
+}
+
+// ScanStruct copies the columns from the matched row into the fields of
+// the struct pointed at by dest, as with [Rows.ScanStruct]. If no row
+// matches the query, ScanStruct returns [ErrNoRows].
+func (r *Row) ScanStruct(dest any) error {
+	if r.err != nil {
+		return r.err
+	}
+	defer r.rows.Close()
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Pointer || dv.IsNil() || dv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("sql: ScanStruct destination %T is not a non-nil pointer to a struct", dest)
+	}
+	if !r.rows.Next() {
+		if err := r.rows.Err(); err != nil {
+			return err
+		}
+		return ErrNoRows
+	}
+	cols, err := r.rows.Columns()
+	if err != nil {
+		return err
+	}
+	plan, err := newStructPlan(dv.Type().Elem(), cols)
+	if err != nil {
+		return err
+	}
+	if _, ok := plan.rawBytesField(); ok {
+		return errors.New("sql: RawBytes isn't allowed on Row.ScanStruct")
+	}
+	if err := plan.scan(r.rows, dv.Elem()); err != nil {
+		return err
+	}
+	// Make sure the query can be processed to completion with no errors.
+	return r.rows.Close()
+}
+
+// All returns an iterator over the rows of the current result set of
+// rs, each scanned into a new T, and closes rs when the iteration is
+// done. If T is a struct type whose pointer does not implement
+// [Scanner], and which is not [time.Time], each row is scanned into it
+// as by [Rows.ScanStruct]; otherwise, the rows must have a single
+// column, which is scanned into it as by [Rows.Scan].
+//
+// If an error occurs, it is yielded with the zero value of T, and the
+// iteration stops. This includes the error returned by [Rows.Err]
+// once the rows are exhausted.
+//
+// T may not contain [RawBytes], whose memory is only valid until the
+// next row is read.
+//
+// For example:
+//
+//	rows, err := db.QueryContext(ctx, "SELECT id, name FROM users")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for u, err := range sql.All[User](rows) {
+//		if err != nil {
+//			log.Fatal(err)
+//		}
+//		fmt.Println(u.ID, u.Name)
+//	}
+func All[T any](rs *Rows) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		defer rs.Close()
+		var zero T
+		var plan *structPlan
+		if t := reflect.TypeFor[T](); isStructDest(t) {
+			cols, err := rs.Columns()
+			if err == nil {
+				plan, err = newStructPlan(t, cols)
+			}
+			if err == nil {
+				if f, ok := plan.rawBytesField(); ok {
+					err = fmt.Errorf("sql: All: field %s of %s is RawBytes", f, t)
+				}
+			}
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+		}
+		for rs.Next() {
+			var v T
+			var err error
+			if plan != nil {
+				err = plan.scan(rs, reflect.ValueOf(&v).Elem())
+			} else if _, ok := any(&v).(*RawBytes); ok {
+				err = errors.New("sql: All of RawBytes")
+			} else {
+				err = rs.Scan(&v)
+			}
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			if !yield(v, nil) {
+				return
+			}
+		}
+		if err := rs.Err(); err != nil {
+			yield(zero, err)
+		}
+	}
+}
+
+// isStructDest reports whether values of type t are scanned into by
+// field rather than as a whole.
+func isStructDest(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct &&
+		t != timeType &&
+		!reflect.PointerTo(t).Implements(scannerType)
+}
+
+var (
+	timeType    = reflect.TypeFor[time.Time]()
+	scannerType = reflect.TypeFor[Scanner]()
+)
+
+// A structField is a field of a struct that may be scanned into.
+type structField struct {
+	index []int  // index sequence for reflect.Value.FieldByIndex
+	name  string // qualified name of the field, for errors
+	typ   reflect.Type
+}
+
+// structFields returns the fields of the struct type t that may be
+// scanned into, keyed by the column name in their tag or, for fields
+// without one, by "*" followed by their lower-cased name.
+func structFields(t reflect.Type) map[string][]structField {
+	if f, ok := structFieldsCache.Load(t); ok {
+		return f.(map[string][]structField)
+	}
+	fields := make(map[string][]structField)
+	addStructFields(fields, t, nil, t.Name())
+	f, _ := structFieldsCache.LoadOrStore(t, fields)
+	return f.(map[string][]structField)
+}
+
+var structFieldsCache sync.Map // map[reflect.Type]map[string][]structField
+
+func addStructFields(fields map[string][]structField, t reflect.Type, index []int, prefix string) {
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		tag, hasTag := sf.Tag.Lookup("sql")
+		if tag == "-" {
+			continue
+		}
+		idx := append(index[:len(index):len(index)], i)
+		name := prefix + "." + sf.Name
+		if sf.Anonymous && !hasTag {
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if isStructDest(ft) && (sf.IsExported() || sf.Type.Kind() != reflect.Pointer) {
+				addStructFields(fields, ft, idx, name)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		key := tag
+		if key == "" {
+			key = "*" + strings.ToLower(sf.Name)
+		}
+		fields[key] = append(fields[key], structField{index: idx, name: name, typ: sf.Type})
+	}
+}
+
+// A structPlan maps the columns of a result set to the fields of a
+// struct.
+type structPlan struct {
+	fields []structField // by column
+	names  []string      // field names, for errors
+}
+
+func newStructPlan(t reflect.Type, cols []string) (*structPlan, error) {
+	all := structFields(t)
+	p := &structPlan{
+		fields: make([]structField, len(cols)),
+		names:  make([]string, len(cols)),
+	}
+	var unmatched, ambiguous []string
+	for i, col := range cols {
+		fs, ok := all[col]
+		if !ok {
+			fs = all["*"+strings.ToLower(col)]
+		}
+		if len(fs) == 0 {
+			unmatched = append(unmatched, strconv.Quote(col))
+			continue
+		}
+		// As with Go's selectors, a shallower field hides deeper
+		// ones, and fields at the same depth are ambiguous.
+		f := fs[0]
+		n := 0
+		for _, g := range fs {
+			switch {
+			case len(g.index) < len(f.index):
+				f, n = g, 1
+			case len(g.index) == len(f.index):
+				n++
+			}
+		}
+		for _, g := range p.fields[:i] {
+			if slices.Equal(f.index, g.index) {
+				n++
+			}
+		}
+		if n > 1 {
+			ambiguous = append(ambiguous, strconv.Quote(col))
+			continue
+		}
+		p.fields[i] = f
+		p.names[i] = f.name
+	}
+	switch {
+	case len(unmatched) == 1:
+		return nil, fmt.Errorf("sql: column %s has no matching field in %s", unmatched[0], t)
+	case len(unmatched) > 1:
+		return nil, fmt.Errorf("sql: columns %s have no matching fields in %s", strings.Join(unmatched, ", "), t)
+	case len(ambiguous) > 0:
+		return nil, fmt.Errorf("sql: column %s does not match a unique field of %s", strings.Join(ambiguous, ", "), t)
+	}
+	return p, nil
+}
+
+// rawBytesField returns the name of a field of the plan of type
+// RawBytes, if there is one.
+func (p *structPlan) rawBytesField() (string, bool) {
+	for _, f := range p.fields {
+		if f.typ == rawBytesType {
+			return f.name, true
+		}
+	}
+	return "", false
+}
+
+var rawBytesType = reflect.TypeFor[RawBytes]()
+
+// scan scans the current row of rs into the struct v.
+func (p *structPlan) scan(rs *Rows, v reflect.Value) error {
+	dest := make([]any, len(p.fields))
+	for i, f := range p.fields {
+		dest[i] = fieldByIndexAlloc(v, f.index).Addr().Interface()
+	}
+	return rs.scan(dest, p.names)
+}
+
+// fieldByIndexAlloc returns the nested field of v with the given index
+// sequence, allocating any nil embedded pointers along the way.
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
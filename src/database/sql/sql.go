@@ -529,8 +529,9 @@ type DB struct {
 	// a goroutine running connectionOpener() reads on this chan and
 	// maybeOpenNewConnections sends on the chan (one send per needed connection)
 	// It is closed during db.Close(). The close tells the connectionOpener
-	// goroutine to exit.
-	openerCh          chan struct{}
+	// goroutine to exit. maybeFillIdleLocked sends true on the chan for
+	// connections to open for the idle pool rather than a connRequest.
+	openerCh          chan bool
 	closed            bool
 	dep               map[finalCloser]depSet
 	lastPut           map[*driverConn]string // stacktrace of last conn's put; debug only
@@ -538,11 +539,20 @@ type DB struct {
 	maxOpen           int                    // <= 0 means unlimited
 	maxLifetime       time.Duration          // maximum amount of time a connection may be reused
 	maxIdleTime       time.Duration          // maximum amount of time a connection may be idle before being closed
+	lifetimeJitter    time.Duration          // maximum random amount of time added to maxLifetime per connection
+	minIdle           int                    // minimum number of idle connections to keep open
+	numFilling        int                    // number of connections being opened to fill the idle pool
+	healthInterval    time.Duration          // interval between health checks of idle connections
+	numChecking       int                    // number of idle connections being health checked
+	subscriptions     map[*Subscription]bool // active subscriptions, ended by Close
 	cleanerCh         chan struct{}
 	waitCount         int64 // Total number of connections waited for.
 	maxIdleClosed     int64 // Total number of connections closed due to idle count.
 	maxIdleTimeClosed int64 // Total number of connections closed due to idle time.
 	maxLifetimeClosed int64 // Total number of connections closed due to max connection lifetime limit.
+	healthCheckClosed int64 // Total number of connections closed due to failed health checks.
+
+	hooks atomic.Pointer[Hooks] // set by SetHooks
 
 	stop func() // stop cancels the connection opener.
 }
@@ -566,6 +576,12 @@ const (
 type driverConn struct {
 	db        *DB
 	createdAt time.Time
+	jitter    float64 // in [0, 1), the fraction of DB.lifetimeJitter added to its lifetime
+
+	// acquiredAt is the time the connection was acquired, if the
+	// OnConnRelease hook is to be called when it is released. It is
+	// owned by the holder of the connection.
+	acquiredAt time.Time
 
 	sync.Mutex  // guards following
 	ci          driver.Conn
@@ -578,6 +594,7 @@ type driverConn struct {
 	inUse      bool
 	dbmuClosed bool      // same as closed, but guarded by db.mu, for removeClosedStmtLocked
 	returnedAt time.Time // Time the connection was created or returned.
+	checkedAt  time.Time // Time of the last health check, if any.
 	onPut      []func()  // code (with db.mu held) run when conn is next returned
 }
 
@@ -591,11 +608,17 @@ func (dc *driverConn) removeOpenStmt(ds *driverStmt) {
 	delete(dc.openStmt, ds)
 }
 
-func (dc *driverConn) expired(timeout time.Duration) bool {
+func (dc *driverConn) expired(timeout, jitter time.Duration) bool {
 	if timeout <= 0 {
 		return false
 	}
-	return dc.createdAt.Add(timeout).Before(nowFunc())
+	return dc.expiresAt(timeout, jitter).Before(nowFunc())
+}
+
+// expiresAt returns the time at which dc expires, given the maximum
+// lifetime of connections and its jitter.
+func (dc *driverConn) expiresAt(timeout, jitter time.Duration) time.Time {
+	return dc.createdAt.Add(timeout + time.Duration(dc.jitter*float64(jitter)))
 }
 
 // resetSession checks if the driver connection needs the
@@ -628,6 +651,28 @@ func (dc *driverConn) validateConnection(needsReset bool) bool {
 	return true
 }
 
+// healthCheckTimeout is the time an idle connection has to respond to
+// the ping of a health check.
+const healthCheckTimeout = 5 * time.Second
+
+// healthy reports whether the idle connection dc passes a health
+// check: whether it is valid, and responds to a ping within
+// healthCheckTimeout.
+func (dc *driverConn) healthy() bool {
+	dc.Lock()
+	defer dc.Unlock()
+
+	if cv, ok := dc.ci.(driver.Validator); ok && !cv.IsValid() {
+		return false
+	}
+	if pinger, ok := dc.ci.(driver.Pinger); ok {
+		ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+		defer cancel()
+		return pinger.Ping(ctx) == nil
+	}
+	return true
+}
+
 // prepareLocked prepares the query on dc. When cg == nil the dc must keep track of
 // the prepared statements in a pool.
 func (dc *driverConn) prepareLocked(ctx context.Context, cg stmtConnGrabber, query string) (*driverStmt, error) {
@@ -706,6 +751,7 @@ func (dc *driverConn) finalClose() error {
 	dc.db.mu.Lock()
 	dc.db.numOpen--
 	dc.db.maybeOpenNewConnections()
+	dc.db.maybeFillIdleLocked()
 	dc.db.mu.Unlock()
 
 	dc.db.numClosed.Add(1)
@@ -840,7 +886,7 @@ func OpenDB(c driver.Connector) *DB {
 	ctx, cancel := context.WithCancel(context.Background())
 	db := &DB{
 		connector: c,
-		openerCh:  make(chan struct{}, connectionRequestQueueSize),
+		openerCh:  make(chan bool, connectionRequestQueueSize),
 		lastPut:   make(map[*driverConn]string),
 		stop:      cancel,
 	}
@@ -947,7 +993,12 @@ func (db *DB) Close() error {
 	db.freeConn = nil
 	db.closed = true
 	db.connRequests.CloseAndRemoveAll()
+	subs := db.subscriptions
+	db.subscriptions = nil
 	db.mu.Unlock()
+	for s := range subs {
+		s.end(errDBClosed)
+	}
 	for _, fn := range fns {
 		err1 := fn()
 		if err1 != nil {
@@ -980,13 +1031,13 @@ func (db *DB) maxIdleConnsLocked() int {
 }
 
 func (db *DB) shortestIdleTimeLocked() time.Duration {
-	if db.maxIdleTime <= 0 {
-		return db.maxLifetime
-	}
-	if db.maxLifetime <= 0 {
-		return db.maxIdleTime
+	var d time.Duration
+	for _, t := range []time.Duration{db.maxIdleTime, db.maxLifetime, db.healthInterval} {
+		if t > 0 && (d <= 0 || t < d) {
+			d = t
+		}
 	}
-	return min(db.maxIdleTime, db.maxLifetime)
+	return d
 }
 
 // SetMaxIdleConns sets the maximum number of connections in the idle
@@ -1091,9 +1142,70 @@ func (db *DB) SetConnMaxIdleTime(d time.Duration) {
 	db.startCleanerLocked()
 }
 
+// SetConnMaxLifetimeJitter sets the maximum amount of time added to
+// the maximum lifetime of each connection, set by
+// [DB.SetConnMaxLifetime]. Each connection is given a random fraction
+// of d when it is opened, so that connections opened together do not
+// all expire together.
+//
+// If d <= 0, connections expire exactly at their maximum lifetime.
+func (db *DB) SetConnMaxLifetimeJitter(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	db.mu.Lock()
+	db.lifetimeJitter = d
+	db.mu.Unlock()
+}
+
+// SetMinIdleConns sets the minimum number of connections in the idle
+// connection pool. When there are fewer idle connections, new ones are
+// opened in the background, including immediately after SetMinIdleConns
+// is called, so that the pool is ready for use. If opening a connection
+// fails, it is retried when a connection is next acquired or closed.
+//
+// Connections are not opened beyond the limits of MaxOpenConns and
+// MaxIdleConns.
+//
+// If n <= 0, which is the default, no connections are opened in the
+// background.
+func (db *DB) SetMinIdleConns(n int) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.minIdle = max(n, 0)
+	db.maybeFillIdleLocked()
+}
+
+// SetConnHealthCheckInterval sets the interval at which idle
+// connections are checked. A connection is checked when it has been
+// idle, or unchecked, for at least d: it is closed if the driver
+// reports that it is not valid, with [driver.Validator], or if it does
+// not respond within five seconds to a ping, with [driver.Pinger].
+// Connections are checked concurrently with each other and with the
+// rest of the pool's maintenance.
+//
+// If d <= 0, which is the default, idle connections are not checked.
+func (db *DB) SetConnHealthCheckInterval(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	// Wake cleaner up when the interval is shortened.
+	if d > 0 && d < db.shortestIdleTimeLocked() && db.cleanerCh != nil {
+		select {
+		case db.cleanerCh <- struct{}{}:
+		default:
+		}
+	}
+	db.healthInterval = d
+	db.startCleanerLocked()
+}
+
 // startCleanerLocked starts connectionCleaner if needed.
 func (db *DB) startCleanerLocked() {
-	if (db.maxLifetime > 0 || db.maxIdleTime > 0) && db.numOpen > 0 && db.cleanerCh == nil {
+	if db.shortestIdleTimeLocked() > 0 && db.numOpen > 0 && db.cleanerCh == nil {
 		db.cleanerCh = make(chan struct{}, 1)
 		go db.connectionCleaner(db.shortestIdleTimeLocked())
 	}
@@ -1123,10 +1235,17 @@ func (db *DB) connectionCleaner(d time.Duration) {
 		}
 
 		d, closing := db.connectionCleanerRunLocked(d)
+		checking := db.takeUncheckedConnsLocked()
+		db.maybeFillIdleLocked()
 		db.mu.Unlock()
 		for _, c := range closing {
 			c.Close()
 		}
+		// A connection that is slow to respond must not hold up
+		// the others, or the cleaning of the pool.
+		for _, c := range checking {
+			go db.checkConn(c)
+		}
 
 		if d < minInterval {
 			d = minInterval
@@ -1176,10 +1295,11 @@ func (db *DB) connectionCleanerRunLocked(d time.Duration) (time.Duration, []*dri
 	}
 
 	if db.maxLifetime > 0 {
-		expiredSince := nowFunc().Add(-db.maxLifetime)
+		now := nowFunc()
 		for i := 0; i < len(db.freeConn); i++ {
 			c := db.freeConn[i]
-			if c.createdAt.Before(expiredSince) {
+			expiresAt := c.expiresAt(db.maxLifetime, db.lifetimeJitter)
+			if expiresAt.Before(now) {
 				closing = append(closing, c)
 
 				last := len(db.freeConn) - 1
@@ -1189,7 +1309,7 @@ func (db *DB) connectionCleanerRunLocked(d time.Duration) (time.Duration, []*dri
 				db.freeConn[last] = nil
 				db.freeConn = db.freeConn[:last]
 				i--
-			} else if d2 := c.createdAt.Sub(expiredSince); d2 < d {
+			} else if d2 := expiresAt.Sub(now); d2 < d {
 				// Prevent connections sitting the freeConn when they
 				// have expired by updating our next deadline d.
 				d = d2
@@ -1201,6 +1321,69 @@ func (db *DB) connectionCleanerRunLocked(d time.Duration) (time.Duration, []*dri
 	return d, closing
 }
 
+// takeUncheckedConnsLocked removes the connections that are due a
+// health check from freeConn and returns them. They must each be
+// returned with checkConn.
+func (db *DB) takeUncheckedConnsLocked() []*driverConn {
+	if db.healthInterval <= 0 {
+		return nil
+	}
+	uncheckedSince := nowFunc().Add(-db.healthInterval)
+	var checking []*driverConn
+	db.freeConn = slices.DeleteFunc(db.freeConn, func(c *driverConn) bool {
+		if c.returnedAt.Before(uncheckedSince) && c.checkedAt.Before(uncheckedSince) {
+			c.inUse = true
+			checking = append(checking, c)
+			return true
+		}
+		return false
+	})
+	db.numChecking += len(checking)
+	return checking
+}
+
+// checkConn checks the health of a connection taken by
+// takeUncheckedConnsLocked, and returns it to the pool if it is
+// healthy, keeping freeConn ordered by returnedAt. Otherwise, it is
+// closed.
+func (db *DB) checkConn(dc *driverConn) {
+	healthy := dc.healthy()
+	db.mu.Lock()
+	db.numChecking--
+	dc.inUse = false
+	dc.checkedAt = nowFunc()
+	if healthy && db.putCheckedConnLocked(dc) {
+		db.mu.Unlock()
+		return
+	}
+	if !healthy {
+		db.healthCheckClosed++
+	}
+	db.mu.Unlock()
+	dc.Close()
+}
+
+// putCheckedConnLocked returns a connection that has passed a health
+// check to the pool, as putConnDBLocked does, but without changing its
+// idle time. It reports whether the connection was returned.
+func (db *DB) putCheckedConnLocked(dc *driverConn) bool {
+	if db.closed || (db.maxOpen > 0 && db.numOpen > db.maxOpen) {
+		return false
+	}
+	if db.connRequests.Len() > 0 {
+		return db.putConnDBLocked(dc, nil)
+	}
+	if db.maxIdleConnsLocked() <= len(db.freeConn) {
+		db.maxIdleClosed++
+		return false
+	}
+	i, _ := slices.BinarySearchFunc(db.freeConn, dc.returnedAt, func(c *driverConn, t time.Time) int {
+		return c.returnedAt.Compare(t)
+	})
+	db.freeConn = slices.Insert(db.freeConn, i, dc)
+	return true
+}
+
 // DBStats contains database statistics.
 type DBStats struct {
 	MaxOpenConnections int // Maximum number of open connections to the database.
@@ -1216,6 +1399,7 @@ type DBStats struct {
 	MaxIdleClosed     int64         // The total number of connections closed due to SetMaxIdleConns.
 	MaxIdleTimeClosed int64         // The total number of connections closed due to SetConnMaxIdleTime.
 	MaxLifetimeClosed int64         // The total number of connections closed due to SetConnMaxLifetime.
+	HealthCheckClosed int64         // The total number of connections closed due to SetConnHealthCheckInterval.
 }
 
 // Stats returns database statistics.
@@ -1237,6 +1421,7 @@ func (db *DB) Stats() DBStats {
 		MaxIdleClosed:     db.maxIdleClosed,
 		MaxIdleTimeClosed: db.maxIdleTimeClosed,
 		MaxLifetimeClosed: db.maxLifetimeClosed,
+		HealthCheckClosed: db.healthCheckClosed,
 	}
 	return stats
 }
@@ -1258,7 +1443,26 @@ func (db *DB) maybeOpenNewConnections() {
 		if db.closed {
 			return
 		}
-		db.openerCh <- struct{}{}
+		db.openerCh <- false
+	}
+}
+
+// Assumes db.mu is locked.
+// If there are fewer than minIdle idle connections, then tell the
+// connectionOpener to open new connections, within the limits of
+// maxOpen and the maximum number of idle connections.
+func (db *DB) maybeFillIdleLocked() {
+	if db.minIdle <= 0 || db.closed {
+		return
+	}
+	n := min(db.minIdle, db.maxIdleConnsLocked()) - len(db.freeConn) - db.numChecking - db.numFilling
+	if db.maxOpen > 0 {
+		n = min(n, db.maxOpen-db.numOpen)
+	}
+	for ; n > 0; n-- {
+		db.numOpen++ // optimistically
+		db.numFilling++
+		db.openerCh <- true
 	}
 }
 
@@ -1268,20 +1472,23 @@ func (db *DB) connectionOpener(ctx context.Context) {
 		select {
 		case <-ctx.Done():
 			return
-		case <-db.openerCh:
-			db.openNewConnection(ctx)
+		case fill := <-db.openerCh:
+			db.openNewConnection(ctx, fill)
 		}
 	}
 }
 
-// Open one new connection
-func (db *DB) openNewConnection(ctx context.Context) {
+// Open one new connection, to fill the idle pool if fill is true.
+func (db *DB) openNewConnection(ctx context.Context, fill bool) {
 	// maybeOpenNewConnections has already executed db.numOpen++ before it sent
 	// on db.openerCh. This function must execute db.numOpen-- if the
 	// connection fails or is closed before returning.
 	ci, err := db.connector.Connect(ctx)
 	db.mu.Lock()
 	defer db.mu.Unlock()
+	if fill {
+		db.numFilling--
+	}
 	if db.closed {
 		if err == nil {
 			ci.Close()
@@ -1291,13 +1498,16 @@ func (db *DB) openNewConnection(ctx context.Context) {
 	}
 	if err != nil {
 		db.numOpen--
-		db.putConnDBLocked(nil, err)
+		if !fill {
+			db.putConnDBLocked(nil, err)
+		}
 		db.maybeOpenNewConnections()
 		return
 	}
 	dc := &driverConn{
 		db:         db,
 		createdAt:  nowFunc(),
+		jitter:     rand.Float64(),
 		returnedAt: nowFunc(),
 		ci:         ci,
 	}
@@ -1321,6 +1531,16 @@ var errDBClosed = errors.New("sql: database is closed")
 
 // conn returns a newly-opened or cached *driverConn.
 func (db *DB) conn(ctx context.Context, strategy connReuseStrategy) (*driverConn, error) {
+	if db.hooks.Load() == nil {
+		return db.acquireConn(ctx, strategy)
+	}
+	start := nowFunc()
+	dc, err := db.acquireConn(ctx, strategy)
+	db.acquiredConn(ctx, start, dc, err)
+	return dc, err
+}
+
+func (db *DB) acquireConn(ctx context.Context, strategy connReuseStrategy) (*driverConn, error) {
 	db.mu.Lock()
 	if db.closed {
 		db.mu.Unlock()
@@ -1333,7 +1553,7 @@ func (db *DB) conn(ctx context.Context, strategy connReuseStrategy) (*driverConn
 		db.mu.Unlock()
 		return nil, ctx.Err()
 	}
-	lifetime := db.maxLifetime
+	lifetime, jitter := db.maxLifetime, db.lifetimeJitter
 
 	// Prefer a free connection, if possible.
 	last := len(db.freeConn) - 1
@@ -1343,7 +1563,8 @@ func (db *DB) conn(ctx context.Context, strategy connReuseStrategy) (*driverConn
 		conn := db.freeConn[last]
 		db.freeConn = db.freeConn[:last]
 		conn.inUse = true
-		if conn.expired(lifetime) {
+		db.maybeFillIdleLocked()
+		if conn.expired(lifetime, jitter) {
 			db.maxLifetimeClosed++
 			db.mu.Unlock()
 			conn.Close()
@@ -1413,7 +1634,7 @@ func (db *DB) conn(ctx context.Context, strategy connReuseStrategy) (*driverConn
 			// back into the connection pool.
 			// This prioritizes giving a valid connection to a client over the exact connection
 			// lifetime, which could expire exactly after this point anyway.
-			if strategy == cachedOrNewConn && ret.err == nil && ret.conn.expired(lifetime) {
+			if strategy == cachedOrNewConn && ret.err == nil && ret.conn.expired(lifetime, jitter) {
 				db.mu.Lock()
 				db.maxLifetimeClosed++
 				db.mu.Unlock()
@@ -1434,6 +1655,7 @@ func (db *DB) conn(ctx context.Context, strategy connReuseStrategy) (*driverConn
 	}
 
 	db.numOpen++ // optimistically
+	db.maybeFillIdleLocked()
 	db.mu.Unlock()
 	ci, err := db.connector.Connect(ctx)
 	if err != nil {
@@ -1447,6 +1669,7 @@ func (db *DB) conn(ctx context.Context, strategy connReuseStrategy) (*driverConn
 	dc := &driverConn{
 		db:         db,
 		createdAt:  nowFunc(),
+		jitter:     rand.Float64(),
 		returnedAt: nowFunc(),
 		ci:         ci,
 		inUse:      true,
@@ -1486,6 +1709,7 @@ const debugGetPut = false
 // putConn adds a connection to the db's free pool.
 // err is optionally the last error that occurred on this connection.
 func (db *DB) putConn(dc *driverConn, err error, resetSession bool) {
+	db.releasedConn(dc, err)
 	if !errors.Is(err, driver.ErrBadConn) {
 		if !dc.validateConnection(resetSession) {
 			err = driver.ErrBadConn
@@ -1500,7 +1724,7 @@ func (db *DB) putConn(dc *driverConn, err error, resetSession bool) {
 		panic("sql: connection returned that was never out")
 	}
 
-	if !errors.Is(err, driver.ErrBadConn) && dc.expired(db.maxLifetime) {
+	if !errors.Is(err, driver.ErrBadConn) && dc.expired(db.maxLifetime, db.lifetimeJitter) {
 		db.maxLifetimeClosed++
 		err = driver.ErrBadConn
 	}
@@ -1704,6 +1928,10 @@ func (db *DB) execDC(ctx context.Context, dc *driverConn, release func(error), q
 	defer func() {
 		release(err)
 	}()
+	ctx, qh := db.startQuery(ctx, true, query, args)
+	defer func() {
+		qh.endExec(res, err)
+	}()
 	execerCtx, ok := dc.ci.(driver.ExecerContext)
 	var execer driver.Execer
 	if !ok {
@@ -1776,6 +2004,7 @@ func (db *DB) query(ctx context.Context, query string, args []any, strategy conn
 // The ctx context is from a query method and the txctx context is from an
 // optional transaction context.
 func (db *DB) queryDC(ctx, txctx context.Context, dc *driverConn, releaseConn func(error), query string, args []any) (*Rows, error) {
+	ctx, qh := db.startQuery(ctx, false, query, args)
 	queryerCtx, ok := dc.ci.(driver.QueryerContext)
 	var queryer driver.Queryer
 	if !ok {
@@ -1794,6 +2023,7 @@ func (db *DB) queryDC(ctx, txctx context.Context, dc *driverConn, releaseConn fu
 		})
 		if err != driver.ErrSkip {
 			if err != nil {
+				qh.endQuery(0, err)
 				releaseConn(err)
 				return nil, err
 			}
@@ -1803,6 +2033,7 @@ func (db *DB) queryDC(ctx, txctx context.Context, dc *driverConn, releaseConn fu
 				dc:          dc,
 				releaseConn: releaseConn,
 				rowsi:       rowsi,
+				hook:        qh,
 			}
 			rows.initContextClose(ctx, txctx)
 			return rows, nil
@@ -1815,6 +2046,7 @@ func (db *DB) queryDC(ctx, txctx context.Context, dc *driverConn, releaseConn fu
 		si, err = ctxDriverPrepare(ctx, dc.ci, query)
 	})
 	if err != nil {
+		qh.endQuery(0, err)
 		releaseConn(err)
 		return nil, err
 	}
@@ -1823,6 +2055,7 @@ func (db *DB) queryDC(ctx, txctx context.Context, dc *driverConn, releaseConn fu
 	rowsi, err := rowsiFromStatement(ctx, dc.ci, ds, args...)
 	if err != nil {
 		ds.Close()
+		qh.endQuery(0, err)
 		releaseConn(err)
 		return nil, err
 	}
@@ -1834,6 +2067,7 @@ func (db *DB) queryDC(ctx, txctx context.Context, dc *driverConn, releaseConn fu
 		releaseConn: releaseConn,
 		rowsi:       rowsi,
 		closeStmt:   ds,
+		hook:        qh,
 	}
 	rows.initContextClose(ctx, txctx)
 	return rows, nil
@@ -1904,6 +2138,17 @@ func (db *DB) begin(ctx context.Context, opts *TxOptions, strategy connReuseStra
 
 // beginDC starts a transaction. The provided dc must be valid and ready to use.
 func (db *DB) beginDC(ctx context.Context, dc *driverConn, release func(error), opts *TxOptions) (tx *Tx, err error) {
+	h := db.hooks.Load()
+	if h != nil && h.OnTxBegin == nil && h.OnTxEnd == nil {
+		h = nil
+	}
+	var start time.Time
+	if h != nil {
+		if h.OnTxBegin != nil {
+			ctx = h.OnTxBegin(ctx, opts)
+		}
+		start = nowFunc()
+	}
 	var txi driver.Tx
 	keepConnOnRollback := false
 	withLock(dc, func() {
@@ -1913,15 +2158,22 @@ func (db *DB) beginDC(ctx context.Context, dc *driverConn, release func(error),
 		txi, err = ctxDriverBegin(ctx, opts, dc.ci)
 	})
 	if err != nil {
+		if h != nil && h.OnTxEnd != nil {
+			h.OnTxEnd(ctx, TxEndInfo{Duration: nowFunc().Sub(start), Err: err})
+		}
 		release(err)
 		return nil, err
 	}
 
 	// Schedule the transaction to rollback when the context is canceled.
 	// The cancel function in Tx will be called after done is set to true.
+	hookCtx := ctx
 	ctx, cancel := context.WithCancel(ctx)
 	tx = &Tx{
 		db:                 db,
+		hooks:              h,
+		hookCtx:            hookCtx,
+		start:              start,
 		dc:                 dc,
 		releaseConn:        release,
 		txi:                txi,
@@ -2209,6 +2461,23 @@ type Tx struct {
 
 	// ctx lives for the life of the transaction.
 	ctx context.Context
+
+	// hooks are the hooks to call when the transaction ends, with
+	// hookCtx, the context returned by OnTxBegin. start is the time
+	// the transaction began.
+	hooks   *Hooks
+	hookCtx context.Context
+	start   time.Time
+
+	// spmu serializes savepoint operations and guards savepoints,
+	// the names of the savepoints in the transaction, oldest first.
+	spmu       sync.Mutex
+	savepoints []string
+
+	// failed is set when a savepoint operation fails, leaving the
+	// transaction in an unknown state. Once set, all operations but
+	// Rollback fail with it, and Commit rolls the transaction back.
+	failed atomic.Pointer[error]
 }
 
 // awaitDone blocks until the context in Tx is canceled and rolls back
@@ -2263,6 +2532,10 @@ func (tx *Tx) grabConn(ctx context.Context) (*driverConn, releaseConn, error) {
 		tx.closemu.RUnlock()
 		return nil, nil, ErrTxDone
 	}
+	if failed := tx.failed.Load(); failed != nil {
+		tx.closemu.RUnlock()
+		return nil, nil, *failed
+	}
 	if hookTxGrabConn != nil { // test hook
 		hookTxGrabConn()
 	}
@@ -2291,6 +2564,9 @@ func (tx *Tx) closePrepared() {
 }
 
 // Commit commits the transaction.
+//
+// If a savepoint operation of the transaction failed, Commit rolls it
+// back instead, and returns the error of that operation.
 func (tx *Tx) Commit() error {
 	// Check context first to avoid transaction leak.
 	// If put it behind tx.done CompareAndSwap statement, we can't ensure
@@ -2303,6 +2579,12 @@ func (tx *Tx) Commit() error {
 		}
 		return tx.ctx.Err()
 	}
+	if failed := tx.failed.Load(); failed != nil {
+		if err := tx.rollback(false); err == ErrTxDone {
+			return err
+		}
+		return *failed
+	}
 	if !tx.done.CompareAndSwap(false, true) {
 		return ErrTxDone
 	}
@@ -2322,6 +2604,7 @@ func (tx *Tx) Commit() error {
 	if !errors.Is(err, driver.ErrBadConn) {
 		tx.closePrepared()
 	}
+	tx.endTx(true, err)
 	tx.close(err)
 	return err
 }
@@ -2354,6 +2637,7 @@ func (tx *Tx) rollback(discardConn bool) error {
 	if !errors.Is(err, driver.ErrBadConn) {
 		tx.closePrepared()
 	}
+	tx.endTx(false, err)
 	if discardConn {
 		err = driver.ErrBadConn
 	}
@@ -2658,7 +2942,9 @@ func (s *Stmt) ExecContext(ctx context.Context, args ...any) (Result, error) {
 			return err
 		}
 
-		res, err = resultFromStatement(ctx, dc.ci, ds, args...)
+		qctx, qh := s.db.startQuery(ctx, true, s.query, args)
+		res, err = resultFromStatement(qctx, dc.ci, ds, args...)
+		qh.endExec(res, err)
 		releaseConn(err)
 		return err
 	})
@@ -2802,13 +3088,15 @@ func (s *Stmt) QueryContext(ctx context.Context, args ...any) (*Rows, error) {
 			return err
 		}
 
-		rowsi, err = rowsiFromStatement(ctx, dc.ci, ds, args...)
+		qctx, qh := s.db.startQuery(ctx, false, s.query, args)
+		rowsi, err = rowsiFromStatement(qctx, dc.ci, ds, args...)
 		if err == nil {
 			// Note: ownership of ci passes to the *Rows, to be freed
 			// with releaseConn.
 			rows = &Rows{
 				dc:    dc,
 				rowsi: rowsi,
+				hook:  qh,
 				// releaseConn set below
 			}
 			// addDep must be added before initContextClose or it could attempt
@@ -2825,10 +3113,11 @@ func (s *Stmt) QueryContext(ctx context.Context, args ...any) (*Rows, error) {
 			if s.cg != nil {
 				txctx = s.cg.txCtx()
 			}
-			rows.initContextClose(ctx, txctx)
+			rows.initContextClose(qctx, txctx)
 			return nil
 		}
 
+		qh.endQuery(0, err)
 		releaseConn(err)
 		return err
 	})
@@ -2976,6 +3265,11 @@ type Rows struct {
 	// the user is scanning into a *RawBytes, we need to copy the string.
 	// The raw buffer here lets us reuse the memory for that copy across Scan calls.
 	raw []byte
+
+	// hook, if non-nil, reports the end of the query when Rows is
+	// closed, with numRows, the number of rows read by Next.
+	hook    *queryHook
+	numRows int64
 }
 
 // lasterrOrErrLocked returns either lasterr or the provided err.
@@ -3088,6 +3382,7 @@ func (rs *Rows) nextLocked() (doClose, ok bool) {
 		}
 		return doClose, false
 	}
+	rs.numRows++
 	return false, true
 }
 
@@ -3370,6 +3665,12 @@ func rowsColumnInfoSetupConnLocked(rowsi driver.Rows) []*ColumnType {
 // If any of the first arguments implementing [Scanner] returns an error,
 // that error will be wrapped in the returned error.
 func (rs *Rows) Scan(dest ...any) error {
+	return rs.scan(dest, nil)
+}
+
+// scan implements Scan. If fields is non-nil, it holds the names of
+// the struct fields that dest points to, for errors.
+func (rs *Rows) scan(dest []any, fields []string) error {
 	if rs.closemuScanHold {
 		// This should only be possible if the user calls Scan twice in a row
 		// without calling Next.
@@ -3378,7 +3679,7 @@ func (rs *Rows) Scan(dest ...any) error {
 
 	rs.closemu.RLock()
 	rs.raw = rs.raw[:0]
-	err := rs.scanLocked(dest...)
+	err := rs.scanLocked(dest, fields)
 	if err == nil && scanArgsContainRawBytes(dest) {
 		rs.closemuScanHold = true
 	} else {
@@ -3387,7 +3688,7 @@ func (rs *Rows) Scan(dest ...any) error {
 	return err
 }
 
-func (rs *Rows) scanLocked(dest ...any) error {
+func (rs *Rows) scanLocked(dest []any, fields []string) error {
 	if rs.lasterr != nil && rs.lasterr != io.EOF {
 		return rs.lasterr
 	}
@@ -3405,6 +3706,9 @@ func (rs *Rows) scanLocked(dest ...any) error {
 	for i, sv := range rs.lastcols {
 		err := convertAssignRows(dest[i], sv, rs)
 		if err != nil {
+			if fields != nil {
+				return fmt.Errorf(`sql: Scan error on column index %d, name %q, into field %s: %w`, i, rs.rowsi.Columns()[i], fields[i], err)
+			}
 			return fmt.Errorf(`sql: Scan error on column index %d, name %q: %w`, i, rs.rowsi.Columns()[i], err)
 		}
 	}
@@ -3472,6 +3776,7 @@ func (rs *Rows) close(err error) error {
 	if rs.closeStmt != nil {
 		rs.closeStmt.Close()
 	}
+	rs.hook.endQuery(rs.numRows, rs.lasterrOrErrLocked(err))
 	rs.releaseConn(err)
 
 	rs.lasterr = rs.lasterrOrErrLocked(err)
@@ -12,6 +12,7 @@ import (
 	"fmt"
 	"internal/race"
 	"internal/testenv"
+	"iter"
 	"math/rand"
 	"reflect"
 	"runtime"
@@ -299,6 +300,203 @@ func TestQuery(t *testing.T) {
 	}
 }
 
+type personBase struct {
+	Name string
+}
+
+type PersonDates struct {
+	Birthday Null[time.Time] `sql:"bdate"`
+}
+
+type person struct {
+	personBase
+	*PersonDates
+	Years  int64 `sql:"age"`
+	Dead   *bool
+	Ignore string `sql:"-"`
+}
+
+func TestRowsScanStruct(t *testing.T) {
+	db := newTestDB(t, "people")
+	defer closeDB(t, db)
+
+	rows, err := db.Query("SELECT|people|name,age,bdate|")
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	var got []person
+	for rows.Next() {
+		p := person{Ignore: "kept"}
+		if err := rows.ScanStruct(&p); err != nil {
+			t.Fatalf("ScanStruct: %v", err)
+		}
+		got = append(got, p)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %d rows; want 3", len(got))
+	}
+	for i, want := range []struct {
+		name     string
+		age      int64
+		birthday Null[time.Time]
+	}{
+		{"Alice", 1, Null[time.Time]{}},
+		{"Bob", 2, Null[time.Time]{}},
+		{"Chris", 3, Null[time.Time]{V: chrisBirthday, Valid: true}},
+	} {
+		p := got[i]
+		if p.Name != want.name || p.Years != want.age || p.PersonDates == nil || p.Birthday.Valid != want.birthday.Valid || !p.Birthday.V.Equal(want.birthday.V) || p.Dead != nil || p.Ignore != "kept" {
+			t.Errorf("row %d = %+v, dates %+v; want %+v", i, p, p.PersonDates, want)
+		}
+	}
+
+	var p person
+	if err := db.QueryRow("SELECT|people|name,dead|name=?", "Bob").ScanStruct(&p); err != nil {
+		t.Fatalf("QueryRow.ScanStruct: %v", err)
+	}
+	if p.Name != "Bob" || p.Dead != nil || p.PersonDates != nil {
+		t.Errorf("QueryRow.ScanStruct = %+v; want Bob, NULL dead, no dates", p)
+	}
+	if err := db.QueryRow("SELECT|people|name|name=?", "Nobody").ScanStruct(&p); err != ErrNoRows {
+		t.Errorf("QueryRow.ScanStruct with no rows = %v; want ErrNoRows", err)
+	}
+}
+
+func TestRowsScanStructErrors(t *testing.T) {
+	db := newTestDB(t, "people")
+	defer closeDB(t, db)
+
+	type shadow struct {
+		personBase
+		Name string // hides personBase.Name
+	}
+	type ambiguous struct {
+		personBase
+		Other struct{ Name string } `sql:"other"`
+		Alias string                `sql:"name"`
+		Name2 string                `sql:"name"`
+	}
+	type wrongType struct {
+		Name int
+	}
+	for _, tt := range []struct {
+		query string
+		dest  any
+		want  string
+	}{
+		{"SELECT|people|name,photo|", &person{}, `sql: column "photo" has no matching field in sql.person`},
+		{"SELECT|people|name,photo,dead|", &personBase{}, `sql: columns "photo", "dead" have no matching fields in sql.personBase`},
+		{"SELECT|people|name|", &ambiguous{}, `sql: column "name" does not match a unique field of sql.ambiguous`},
+		{"SELECT|people|name,name|", &personBase{}, `sql: column "name" does not match a unique field of sql.personBase`},
+		{"SELECT|people|name|", &wrongType{}, `sql: Scan error on column index 0, name "name", into field wrongType.Name: converting driver.Value type []uint8 ("Alice") to a int: invalid syntax`},
+		{"SELECT|people|name|", person{}, `sql: ScanStruct destination sql.person is not a non-nil pointer to a struct`},
+		{"SELECT|people|name|", &shadow{}, ``},
+	} {
+		rows, err := db.Query(tt.query)
+		if err != nil {
+			t.Fatalf("Query: %v", err)
+		}
+		rows.Next()
+		err = rows.ScanStruct(tt.dest)
+		rows.Close()
+		if got := fmt.Sprint(err); tt.want == "" && err != nil || tt.want != "" && got != tt.want {
+			t.Errorf("%s: ScanStruct(%T) = %v; want %s", tt.query, tt.dest, got, tt.want)
+		}
+	}
+}
+
+func TestAll(t *testing.T) {
+	db := newTestDB(t, "people")
+	defer closeDB(t, db)
+
+	rows, err := db.Query("SELECT|people|age,name|")
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	var got []string
+	for p, err := range All[person](rows) {
+		if err != nil {
+			t.Fatalf("All: %v", err)
+		}
+		got = append(got, fmt.Sprint(p.Name, p.Years))
+	}
+	if want := []string{"Alice1", "Bob2", "Chris3"}; !slices.Equal(got, want) {
+		t.Errorf("All[person] = %q; want %q", got, want)
+	}
+	if n := db.numFreeConns(); n != 1 {
+		t.Errorf("free conns after All = %d; want 1", n)
+	}
+
+	// Stopping early closes the rows.
+	rows, err = db.Query("SELECT|people|name|")
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	for name, err := range All[string](rows) {
+		if err != nil || name != "Alice" {
+			t.Errorf("All[string] = %q, %v; want Alice", name, err)
+		}
+		break
+	}
+	if n := db.numFreeConns(); n != 1 {
+		t.Errorf("free conns after breaking out of All = %d; want 1", n)
+	}
+
+	// Scanners and time.Time are scanned as a whole.
+	rows, err = db.Query("SELECT|people|bdate|")
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	var valid int
+	for d, err := range All[Null[time.Time]](rows) {
+		if err != nil {
+			t.Fatalf("All[Null[time.Time]]: %v", err)
+		}
+		if d.Valid {
+			valid++
+		}
+	}
+	if valid != 1 {
+		t.Errorf("All[Null[time.Time]] yielded %d valid times; want 1", valid)
+	}
+
+	// Errors are yielded once.
+	for _, tt := range []struct {
+		query string
+		seq   func(*Rows) iter.Seq2[int, error]
+	}{
+		{"SELECT|people|age,name|", All[int]},
+		{"SELECT|people|name|", All[int]},
+	} {
+		rows, err = db.Query(tt.query)
+		if err != nil {
+			t.Fatalf("Query: %v", err)
+		}
+		n := 0
+		for _, err := range tt.seq(rows) {
+			if err == nil {
+				t.Errorf("%s: All[int] yielded no error", tt.query)
+			}
+			n++
+		}
+		if n != 1 {
+			t.Errorf("%s: All[int] yielded %d times; want 1", tt.query, n)
+		}
+	}
+	rows, err = db.Query("SELECT|people|age,name|")
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	for _, err := range All[personBase](rows) {
+		if err == nil || !strings.Contains(err.Error(), `column "age" has no matching field`) {
+			t.Errorf("All[personBase] = %v; want error for column age", err)
+		}
+	}
+}
+
 // TestQueryContext tests canceling the context while scanning the rows.
 func TestQueryContext(t *testing.T) {
 	db := newTestDB(t, "people")
@@ -2428,6 +2626,215 @@ func TestConnMaxLifetime(t *testing.T) {
 	}
 }
 
+func TestConnMaxLifetimeJitter(t *testing.T) {
+	t0 := time.Unix(1000000, 0)
+	offset := time.Duration(0)
+
+	nowFunc = func() time.Time { return t0.Add(offset) }
+	defer func() { nowFunc = time.Now }()
+
+	db := newTestDB(t, "people")
+	defer closeDB(t, db)
+
+	db.SetConnMaxLifetime(10 * time.Second)
+	db.SetConnMaxLifetimeJitter(4 * time.Second)
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if len(db.freeConn) != 1 {
+		t.Fatalf("free conns = %d; want 1", len(db.freeConn))
+	}
+	dc := db.freeConn[0]
+	dc.createdAt = t0
+	dc.jitter = 0.5
+
+	// The connection expires after 10s plus half of the 4s jitter.
+	offset = 11 * time.Second
+	if dc.expired(db.maxLifetime, db.lifetimeJitter) {
+		t.Errorf("connection expired after %v", offset)
+	}
+	nc, closing := db.connectionCleanerRunLocked(time.Minute)
+	if len(closing) != 0 || nc != time.Second {
+		t.Errorf("at %v, cleaner closes %d connections, next check in %v; want 0 and 1s", offset, len(closing), nc)
+	}
+
+	offset = 13 * time.Second
+	if !dc.expired(db.maxLifetime, db.lifetimeJitter) {
+		t.Errorf("connection not expired after %v", offset)
+	}
+	_, closing = db.connectionCleanerRunLocked(time.Minute)
+	if len(closing) != 1 {
+		t.Errorf("at %v, cleaner closes %d connections; want 1", offset, len(closing))
+	}
+	db.mu.Unlock()
+	for _, c := range closing {
+		c.Close()
+	}
+	db.mu.Lock()
+}
+
+func TestMinIdleConns(t *testing.T) {
+	db := newTestDB(t, "people")
+	defer closeDB(t, db)
+	ctx := context.Background()
+
+	db.SetMaxIdleConns(4)
+	db.SetMinIdleConns(3)
+	waitForFree(t, db, 3)
+
+	// Taking an idle connection opens another.
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForFree(t, db, 3)
+	if g, w := db.numOpenConns(), 4; g != w {
+		t.Errorf("open conns = %d; want %d", g, w)
+	}
+	conn.Close()
+	waitForFree(t, db, 4)
+
+	// MaxOpenConns limits the connections opened.
+	db.SetMaxIdleConns(1)
+	db.SetMaxOpenConns(2)
+	db.SetMinIdleConns(2)
+	conn1, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn1.Close()
+	conn2, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn2.Close()
+	if g, w := db.numOpenConns(), 2; g != w {
+		t.Errorf("open conns = %d; want %d", g, w)
+	}
+}
+
+func TestConnHealthCheck(t *testing.T) {
+	t0 := time.Unix(1000000, 0)
+	offset := time.Duration(0)
+
+	nowFunc = func() time.Time { return t0.Add(offset) }
+	defer func() { nowFunc = time.Now }()
+
+	db := newTestDB(t, "people")
+	defer closeDB(t, db)
+	ctx := context.Background()
+
+	// Return two connections to the pool, then break the first.
+	conn1, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn2, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn1.Close()
+	offset = time.Second
+	conn2.Close()
+	db.mu.Lock()
+	if g, w := len(db.freeConn), 2; g != w {
+		db.mu.Unlock()
+		t.Fatalf("free conns = %d; want %d", g, w)
+	}
+	db.freeConn[0].ci.(*fakeConn).stickyBad = true
+	db.mu.Unlock()
+
+	db.SetConnHealthCheckInterval(time.Minute)
+	check := func() int {
+		db.mu.Lock()
+		checking := db.takeUncheckedConnsLocked()
+		db.mu.Unlock()
+		for _, dc := range checking {
+			db.checkConn(dc)
+		}
+		return len(checking)
+	}
+
+	if n := check(); n != 0 {
+		t.Errorf("checked %d connections before the interval; want 0", n)
+	}
+	offset = 2 * time.Minute
+	if n := check(); n != 2 {
+		t.Errorf("checked %d connections; want 2", n)
+	}
+	if g, w := db.numFreeConns(), 1; g != w {
+		t.Errorf("free conns after check = %d; want %d", g, w)
+	}
+	if s := db.Stats(); s.HealthCheckClosed != 1 {
+		t.Errorf("HealthCheckClosed = %d; want 1", s.HealthCheckClosed)
+	}
+	db.mu.Lock()
+	returnedAt := db.freeConn[0].returnedAt
+	db.mu.Unlock()
+	if !returnedAt.Equal(t0.Add(time.Second)) {
+		t.Errorf("returnedAt = %v after check; want unchanged", returnedAt)
+	}
+	if n := check(); n != 0 {
+		t.Errorf("checked %d connections again within the interval; want 0", n)
+	}
+}
+
+// deadlinePingConn is a driver.Conn whose Ping reports the deadline
+// of its context, and fails.
+type deadlinePingConn struct {
+	badConn
+	deadlines chan time.Time
+}
+
+func (c deadlinePingConn) Ping(ctx context.Context) error {
+	d, _ := ctx.Deadline()
+	c.deadlines <- d
+	return errors.New("ping failed")
+}
+
+type deadlinePingDriver struct {
+	deadlines chan time.Time
+}
+
+func (d deadlinePingDriver) Open(name string) (driver.Conn, error) {
+	return deadlinePingConn{deadlines: d.deadlines}, nil
+}
+
+func TestConnHealthCheckTimeout(t *testing.T) {
+	t0 := time.Unix(1000000, 0)
+	offset := time.Duration(0)
+
+	nowFunc = func() time.Time { return t0.Add(offset) }
+	defer func() { nowFunc = time.Now }()
+
+	deadlines := make(chan time.Time, 1)
+	db := OpenDB(dsnConnector{driver: deadlinePingDriver{deadlines}})
+	defer db.Close()
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	// However long the interval between checks, a ping has little
+	// time to complete.
+	db.SetConnHealthCheckInterval(time.Hour)
+	offset = 2 * time.Hour
+	db.mu.Lock()
+	checking := db.takeUncheckedConnsLocked()
+	db.mu.Unlock()
+	if len(checking) != 1 {
+		t.Fatalf("checking %d connections; want 1", len(checking))
+	}
+	db.checkConn(checking[0])
+	if d := time.Until(<-deadlines); d <= 0 || d > healthCheckTimeout {
+		t.Errorf("ping deadline in %v; want at most %v", d, healthCheckTimeout)
+	}
+	if s := db.Stats(); s.HealthCheckClosed != 1 {
+		t.Errorf("HealthCheckClosed = %d; want 1", s.HealthCheckClosed)
+	}
+}
+
 // golang.org/issue/5323
 func TestStmtCloseDeps(t *testing.T) {
 	if testing.Short() {
@@ -5235,3 +5642,570 @@ func TestNullTypeScanNil(t *testing.T) {
 		})
 	}
 }
+
+func TestExecBatch(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		connector driver.Connector
+		batcher   bool
+	}{
+		{"Batcher", &fakeBatchConnector{}, true},
+		{"ErrSkip", &fakeBatchConnector{skip: true}, false},
+		{"Sequential", &fakeBatchConnector{plain: true}, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			db := OpenDB(tt.connector)
+			defer closeDB(t, db)
+			exec(t, db, "WIPE")
+			exec(t, db, "CREATE|people|name=string,age=int32")
+			ctx := t.Context()
+
+			var b Batch
+			b.Queue("INSERT|people|name=Alice,age=?", 1)
+			b.Queue("INSERT|people|name=?,age=?", "Bob", 2)
+			res, err := db.ExecBatch(ctx, &b)
+			if err != nil {
+				t.Fatalf("ExecBatch: %v", err)
+			}
+			if len(res) != 2 {
+				t.Fatalf("ExecBatch returned %d results; want 2", len(res))
+			}
+			for i, r := range res {
+				if n, err := r.RowsAffected(); n != 1 || err != nil {
+					t.Errorf("result %d: RowsAffected = %d, %v; want 1", i, n, err)
+				}
+			}
+
+			// A failing statement stops the batch.
+			b = Batch{}
+			b.Queue("INSERT|people|name=Chris,age=?", 3)
+			b.Queue("INSERT|nosuchtable|name=?", "Dave")
+			b.Queue("INSERT|people|name=Eve,age=?", 5)
+			res, err = db.ExecBatch(ctx, &b)
+			var be *BatchError
+			if !errors.As(err, &be) || be.Index != 1 || len(res) != 1 {
+				t.Errorf("ExecBatch with failing statement = %d results, %v; want 1 result and error at index 1", len(res), err)
+			}
+
+			// Statements before one whose arguments cannot be
+			// converted are executed.
+			b = Batch{}
+			b.Queue("INSERT|people|name=?,age=?", "Frank", 6)
+			b.Queue("INSERT|people|name=?,age=?", "Gina", make(chan int))
+			res, err = db.ExecBatch(ctx, &b)
+			if !errors.As(err, &be) || be.Index != 1 || len(res) != 1 {
+				t.Errorf("ExecBatch with bad argument = %d results, %v; want 1 result and error at index 1", len(res), err)
+			}
+
+			tx, err := db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b = Batch{}
+			b.Queue("INSERT|people|name=Hal,age=?", 8)
+			if res, err := tx.ExecBatch(ctx, &b); err != nil || len(res) != 1 {
+				t.Errorf("Tx.ExecBatch = %d results, %v; want 1", len(res), err)
+			}
+			if err := tx.Commit(); err != nil {
+				t.Fatal(err)
+			}
+
+			conn, err := db.Conn(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res, err := conn.ExecBatch(ctx, &Batch{}); err != nil || len(res) != 0 {
+				t.Errorf("Conn.ExecBatch of empty batch = %d results, %v", len(res), err)
+			}
+			var batches int
+			conn.Raw(func(dc any) error {
+				if bc, ok := dc.(*fakeBatchConn); ok {
+					batches = bc.batches
+				}
+				return nil
+			})
+			conn.Close()
+			if tt.batcher && batches == 0 {
+				t.Errorf("driver ExecBatch was not called")
+			}
+
+			var names []string
+			rows, err := db.Query("SELECT|people|name|")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, err := range All[string](rows) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				names = append(names, name)
+			}
+			if want := []string{"Alice", "Bob", "Chris", "Frank", "Hal"}; !slices.Equal(names, want) {
+				t.Errorf("people = %q; want %q", names, want)
+			}
+		})
+	}
+}
+
+func TestCopyFrom(t *testing.T) {
+	people := func(names ...string) iter.Seq[[]any] {
+		return func(yield func([]any) bool) {
+			for i, name := range names {
+				if !yield([]any{name, i}) {
+					return
+				}
+			}
+		}
+	}
+	bc := BulkCopy{
+		Table:   "people",
+		Columns: []string{"name", "age"},
+		Insert:  "INSERT|people|name=?,age=?",
+	}
+	for _, tt := range []struct {
+		name      string
+		connector driver.Connector
+		copier    bool
+	}{
+		{"BulkCopier", &fakeBatchConnector{}, true},
+		{"ErrSkip", &fakeBatchConnector{skip: true}, false},
+		{"Sequential", &fakeBatchConnector{plain: true}, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			db := OpenDB(tt.connector)
+			defer closeDB(t, db)
+			exec(t, db, "WIPE")
+			exec(t, db, "CREATE|people|name=string,age=int32")
+			ctx := t.Context()
+			count := func() int {
+				var n int
+				rows, err := db.Query("SELECT|people|name|")
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, err := range All[string](rows) {
+					if err != nil {
+						t.Fatal(err)
+					}
+					n++
+				}
+				return n
+			}
+
+			n, err := db.CopyFrom(ctx, bc, people("Alice", "Bob", "Chris"))
+			if n != 3 || err != nil {
+				t.Fatalf("CopyFrom = %d, %v; want 3", n, err)
+			}
+
+			// A row of the wrong size stops the copy. A bulk copy
+			// inserts none of the rows.
+			bad := func(yield func([]any) bool) {
+				_ = yield([]any{"Dave", 4}) && yield([]any{"Eve"})
+			}
+			n, err = db.CopyFrom(ctx, bc, bad)
+			wantN, wantCount := 1, 4
+			if tt.copier {
+				wantN, wantCount = 0, 3
+			}
+			if err == nil || !strings.Contains(err.Error(), "row 1 has 1 values, not 2") || n != int64(wantN) {
+				t.Errorf("CopyFrom with short row = %d, %v; want %d and error for row 1", n, err, wantN)
+			}
+			if got := count(); got != wantCount {
+				t.Errorf("after short row, %d people; want %d", got, wantCount)
+			}
+
+			tx, err := db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n, err := tx.CopyFrom(ctx, bc, people("Frank", "Gina")); n != 2 || err != nil {
+				t.Errorf("Tx.CopyFrom = %d, %v; want 2", n, err)
+			}
+			if err := tx.Commit(); err != nil {
+				t.Fatal(err)
+			}
+			if got := count(); got != wantCount+2 {
+				t.Errorf("after Tx.CopyFrom, %d people; want %d", got, wantCount+2)
+			}
+
+			noInsert := bc
+			noInsert.Insert = ""
+			n, err = db.CopyFrom(ctx, noInsert, people("Hal"))
+			if tt.copier {
+				if n != 1 || err != nil {
+					t.Errorf("CopyFrom without Insert = %d, %v; want 1", n, err)
+				}
+			} else if err != errNoBulkCopy {
+				t.Errorf("CopyFrom without Insert = %d, %v; want %v", n, err, errNoBulkCopy)
+			}
+		})
+	}
+}
+
+func TestHooks(t *testing.T) {
+	db := newTestDB(t, "people")
+	defer closeDB(t, db)
+	ctx := context.Background()
+
+	type hookKey struct{}
+	var events []string
+	record := func(format string, args ...any) {
+		events = append(events, fmt.Sprintf(format, args...))
+	}
+	// checkCtx records whether the context passed to an end hook
+	// is the one returned by the corresponding start hook.
+	checkCtx := func(ctx context.Context, want string) {
+		if got, _ := ctx.Value(hookKey{}).(string); got != want {
+			record("ctx %q, want %q", got, want)
+		}
+	}
+	db.SetHooks(&Hooks{
+		OnQueryStart: func(ctx context.Context, info QueryInfo) context.Context {
+			record("start exec=%v %s %v", info.Exec, info.Query, info.Args)
+			return context.WithValue(ctx, hookKey{}, info.Query)
+		},
+		OnQueryEnd: func(ctx context.Context, info QueryEndInfo) {
+			checkCtx(ctx, info.Query)
+			record("end exec=%v affected=%d rows=%d err=%v", info.Exec, info.RowsAffected, info.Rows, info.Err)
+		},
+		OnConnAcquire: func(ctx context.Context, info ConnAcquireInfo) {
+			record("acquire new=%v err=%v", info.New, info.Err)
+		},
+		OnConnRelease: func(info ConnReleaseInfo) {
+			record("release err=%v", info.Err)
+		},
+		OnTxBegin: func(ctx context.Context, opts *TxOptions) context.Context {
+			record("begin opts=%v", opts != nil)
+			return context.WithValue(ctx, hookKey{}, "tx")
+		},
+		OnTxEnd: func(ctx context.Context, info TxEndInfo) {
+			checkCtx(ctx, "tx")
+			record("end tx commit=%v err=%v", info.Commit, info.Err)
+		},
+	})
+	check := func(name string, want ...string) {
+		t.Helper()
+		if !slices.Equal(events, want) {
+			t.Errorf("%s: hook events:\n%s\nwant:\n%s", name, strings.Join(events, "\n"), strings.Join(want, "\n"))
+		}
+		events = nil
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT|people|name|")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+	}
+	rows.Close()
+	check("Query",
+		"acquire new=false err=<nil>",
+		"start exec=false SELECT|people|name| []",
+		"end exec=false affected=-1 rows=3 err=<nil>",
+		"release err=<nil>",
+	)
+
+	exec(t, db, "INSERT|people|name=?,age=?", "Dave", 4)
+	check("Exec",
+		"acquire new=false err=<nil>",
+		"start exec=true INSERT|people|name=?,age=? [Dave 4]",
+		"end exec=true affected=1 rows=0 err=<nil>",
+		"release err=<nil>",
+	)
+
+	_, err = db.ExecContext(ctx, "INSERT|nosuchtable|name=?", "Eve")
+	if err == nil {
+		t.Fatal("Exec on missing table succeeded")
+	}
+	check("Exec error",
+		"acquire new=false err=<nil>",
+		"start exec=true INSERT|nosuchtable|name=? [Eve]",
+		"end exec=true affected=-1 rows=0 err="+err.Error(),
+		"release err="+err.Error(),
+	)
+
+	tx, err := db.BeginTx(ctx, &TxOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var name string
+	if err := tx.QueryRowContext(ctx, "SELECT|people|name|age=?", 1).Scan(&name); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	check("Tx commit",
+		"acquire new=false err=<nil>",
+		"begin opts=true",
+		"start exec=false SELECT|people|name|age=? [1]",
+		"end exec=false affected=-1 rows=1 err=<nil>",
+		"end tx commit=true err=<nil>",
+		"release err=<nil>",
+	)
+
+	tx, err = db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	check("Tx rollback",
+		"acquire new=false err=<nil>",
+		"begin opts=false",
+		"end tx commit=false err=<nil>",
+		"release err=<nil>",
+	)
+
+	stmt, err := db.Prepare("INSERT|people|name=?,age=?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	events = nil
+	if _, err := stmt.Exec("Eve", 5); err != nil {
+		t.Fatal(err)
+	}
+	stmt.Close()
+	check("Stmt.Exec",
+		"acquire new=false err=<nil>",
+		"start exec=true INSERT|people|name=?,age=? [Eve 5]",
+		"end exec=true affected=1 rows=0 err=<nil>",
+		"release err=<nil>",
+	)
+
+	db.SetHooks(nil)
+	exec(t, db, "INSERT|people|name=?,age=?", "Frank", 6)
+	check("no hooks")
+}
+
+func TestTxSavepoints(t *testing.T) {
+	db := newTestDB(t, "people")
+	defer closeDB(t, db)
+	ctx := context.Background()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	for _, name := range []string{"a", "b", "a", "c"} {
+		if err := tx.Savepoint(ctx, name); err != nil {
+			t.Fatalf("Savepoint(%q): %v", name, err)
+		}
+	}
+	// Rolling back to the second "a" releases "c".
+	if err := tx.RollbackTo(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.RollbackTo(ctx, "c"); err == nil || !strings.Contains(err.Error(), `no savepoint "c"`) {
+		t.Errorf("RollbackTo released savepoint: %v; want no savepoint error", err)
+	}
+	// Releasing "b" releases the second "a".
+	if err := tx.Release(ctx, "b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Release(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Release(ctx, "a"); err == nil {
+		t.Error("Release of released savepoint succeeded")
+	}
+	for _, name := range []string{"", "1a", "a;b", "a b"} {
+		if err := tx.Savepoint(ctx, name); err == nil || !strings.Contains(err.Error(), "invalid savepoint name") {
+			t.Errorf("Savepoint(%q) = %v; want invalid name error", name, err)
+		}
+	}
+
+	want := []string{
+		"SAVEPOINT a",
+		"SAVEPOINT b",
+		"SAVEPOINT a",
+		"SAVEPOINT c",
+		"ROLLBACK TO a",
+		"RELEASE b",
+		"RELEASE a",
+	}
+	if got := tx.txi.(*fakeTx).savepointLog; !slices.Equal(got, want) {
+		t.Errorf("driver savepoint operations:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Savepoint(ctx, "d"); err != ErrTxDone {
+		t.Errorf("Savepoint after Commit = %v; want %v", err, ErrTxDone)
+	}
+}
+
+func TestTxSavepointFailure(t *testing.T) {
+	db := newTestDB(t, "people")
+	defer closeDB(t, db)
+	ctx := context.Background()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Savepoint(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	spErr := tx.Savepoint(ctx, "fail")
+	if spErr == nil {
+		t.Fatal("Savepoint succeeded; want error")
+	}
+
+	// The transaction fails every operation but Rollback, including
+	// rolling back to an earlier savepoint.
+	if _, err := tx.ExecContext(ctx, "INSERT|people|name=?,age=?", "Dave", 4); err != spErr {
+		t.Errorf("Exec after failed savepoint = %v; want %v", err, spErr)
+	}
+	if err := tx.RollbackTo(ctx, "a"); err != spErr {
+		t.Errorf("RollbackTo after failed savepoint = %v; want %v", err, spErr)
+	}
+	if err := tx.Commit(); err != spErr {
+		t.Errorf("Commit after failed savepoint = %v; want %v", err, spErr)
+	}
+	if err := tx.Rollback(); err != ErrTxDone {
+		t.Errorf("Rollback after Commit = %v; want %v", err, ErrTxDone)
+	}
+	if g, w := db.numFreeConns(), 1; g != w {
+		t.Errorf("free conns = %d; want %d", g, w)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	db := newTestDB(t, "people")
+	defer closeDB(t, db)
+	fdb := fdriver.(*fakeDriver).getDB(fakeDBName)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	s, err := db.Subscribe(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := func(want Notification) {
+		t.Helper()
+		n, err := s.Next(ctx)
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		if n != want {
+			t.Fatalf("Next = %+v; want %+v", n, want)
+		}
+	}
+
+	fdb.notify("b", "ignored")
+	fdb.notify("a", "1")
+	next(Notification{Channel: "a", Payload: "1"})
+
+	if err := s.Listen(ctx, "b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Unlisten(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	fdb.notify("a", "ignored")
+	fdb.notify("b", "2")
+	next(Notification{Channel: "b", Payload: "2"})
+
+	// After the connection is lost, the subscription reconnects and
+	// listens on the same channels.
+	fdb.breakListeners()
+	next(Notification{Reconnected: true})
+	fdb.notify("a", "ignored")
+	fdb.notify("b", "3")
+	next(Notification{Channel: "b", Payload: "3"})
+
+	shortCtx, shortCancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer shortCancel()
+	if _, err := s.Next(shortCtx); err != context.DeadlineExceeded {
+		t.Errorf("Next with no notification = %v; want %v", err, context.DeadlineExceeded)
+	}
+
+	if g, w := db.numOpenConns(), 1; g != w {
+		t.Errorf("open conns = %d; want %d", g, w)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if g, w := db.numOpenConns(), 0; g != w {
+		t.Errorf("open conns after Close = %d; want %d", g, w)
+	}
+	if _, err := s.Next(ctx); err != errSubscriptionClosed {
+		t.Errorf("Next after Close = %v; want %v", err, errSubscriptionClosed)
+	}
+	if err := s.Listen(ctx, "c"); err != errSubscriptionClosed {
+		t.Errorf("Listen after Close = %v; want %v", err, errSubscriptionClosed)
+	}
+	if err := s.Err(); err != nil {
+		t.Errorf("Err after Close = %v; want nil", err)
+	}
+}
+
+func TestSubscribeAll(t *testing.T) {
+	db := newTestDB(t, "people")
+	defer closeDB(t, db)
+	fdb := fdriver.(*fakeDriver).getDB(fakeDBName)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	s, err := db.Subscribe(ctx, "a", "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	fdb.notify("a", "1")
+	fdb.notify("b", "2")
+	fdb.notify("a", "3")
+
+	var got []string
+	for n, err := range s.All(ctx) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, n.Channel+n.Payload)
+		if len(got) == 3 {
+			break
+		}
+	}
+	if want := []string{"a1", "b2", "a3"}; !slices.Equal(got, want) {
+		t.Errorf("notifications = %q; want %q", got, want)
+	}
+
+	// The iteration stops when the context is done.
+	cancel()
+	for _, err := range s.All(ctx) {
+		if err != context.Canceled {
+			t.Errorf("All with canceled context yielded %v; want %v", err, context.Canceled)
+		}
+	}
+}
+
+func TestSubscribeDBClosed(t *testing.T) {
+	db := newTestDB(t, "people")
+	fdb := fdriver.(*fakeDriver).getDB(fakeDBName)
+	ctx := context.Background()
+
+	s, err := db.Subscribe(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Closing the DB ends the subscription and closes its connection.
+	fdb.mu.Lock()
+	listening := len(fdb.listeners)
+	fdb.mu.Unlock()
+	if listening != 0 {
+		t.Errorf("%d connections listening after DB closed; want 0", listening)
+	}
+	for range s.C() {
+		t.Error("notification received after DB closed")
+	}
+	if err := s.Err(); err != errDBClosed {
+		t.Errorf("Err = %v; want %v", err, errDBClosed)
+	}
+	s.Close()
+}
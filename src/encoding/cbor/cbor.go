@@ -0,0 +1,347 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package cbor implements encoding and decoding of the Concise Binary
+// Object Representation (CBOR) as defined in RFC 8949.
+//
+// [Marshal] and [Unmarshal] convert between Go values and CBOR data
+// items, much as the encoding/json package does for JSON, and
+// [Encoder] and [Decoder] write and read sequences of data items on
+// streams, as defined in RFC 8742.
+//
+// # Go values
+//
+// Go values are encoded as follows:
+//
+//   - Booleans are encoded as the simple values true and false, and
+//     nil pointers and interfaces as null.
+//   - Signed and unsigned integers are encoded as CBOR integers.
+//   - Floating-point numbers are encoded as the shortest of the
+//     half-, single-, and double-precision floats that represent them
+//     exactly, with NaN encoded as a half-precision quiet NaN.
+//   - Strings are encoded as text strings, and byte slices and byte
+//     arrays as byte strings.
+//   - Other slices and arrays are encoded as arrays, and maps as maps.
+//     Nil slices and maps are encoded as empty arrays and maps, unless
+//     [FormatNilSliceAsNull] or [FormatNilMapAsNull] is given.
+//   - Structs are encoded as maps, as described below.
+//   - A [time.Time] is encoded with tag 1, as the number of seconds
+//     since the Unix epoch: an integer if it is a whole number, or a
+//     float otherwise.
+//   - A [big.Int] is encoded as an integer if it fits in one, and with
+//     tag 2 or 3, as a bignum, otherwise.
+//   - A [Tag] is encoded as a tagged data item, a [SimpleValue] as a
+//     simple value, and a [RawMessage] as itself.
+//   - A value implementing [Marshaler] is encoded by its MarshalCBOR
+//     method.
+//
+// Channels, functions, and complex numbers cannot be encoded.
+//
+// CBOR data items are decoded into Go values by the reverse of these
+// rules, converting numbers to the type of the destination when they
+// fit in it. Decoded into an interface value, a data item becomes:
+//
+//   - uint64 for an unsigned integer, and int64 for a negative one,
+//     or a *big.Int if it does not fit in an int64,
+//   - float64 for a float,
+//   - []byte for a byte string, and string for a text string,
+//   - []any for an array, and map[any]any for a map,
+//   - time.Time for tags 0 and 1, and *big.Int for tags 2 and 3,
+//   - a value of the registered type for a tag in a [TagSet] given
+//     with [WithTags], and a [Tag] for other tags,
+//   - bool for true and false, and nil for null and undefined,
+//   - a [SimpleValue] for other simple values.
+//
+// Null and undefined set interfaces, pointers, maps, and slices to nil,
+// and leave other values unchanged. Tags that the destination type does
+// not use are ignored.
+//
+// Both definite and indefinite-length items are decoded; the encoder
+// always writes definite-length items. Data is checked to be well
+// formed, including that text strings are valid UTF-8, before it is
+// decoded.
+//
+// # Structs
+//
+// A struct is encoded as a map with an entry for each exported field,
+// keyed by the field name, or by the name given in the field's
+// struct tag with the key "cbor". The tag may also give options after
+// the name, separated by commas:
+//
+//   - "omitempty" omits a field whose value is false, 0, a nil
+//     pointer or interface, or empty.
+//   - "omitzero" omits a field whose value is the zero value, or
+//     whose IsZero method returns true.
+//   - "keyasint" encodes the key as the integer given as the name,
+//     as done by COSE (RFC 9052) and CWT (RFC 8392).
+//
+// A field with the tag "-" is ignored. The fields of embedded structs
+// are promoted as by encoding/json. A struct with a blank field
+// tagged ",toarray" is encoded as an array of its fields in order,
+// rather than as a map.
+//
+// When decoding a map into a struct, keys are matched with field names
+// exactly. Entries with unknown keys are ignored, unless
+// [RejectUnknownMembers] is given.
+package cbor
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Major types of data items.
+const (
+	majorUint   = 0
+	majorNegInt = 1
+	majorBytes  = 2
+	majorText   = 3
+	majorArray  = 4
+	majorMap    = 5
+	majorTag    = 6
+	majorSimple = 7
+)
+
+// Initial bytes of simple values and of the break stop code.
+const (
+	cborFalse     = 0xf4
+	cborTrue      = 0xf5
+	cborNull      = 0xf6
+	cborUndefined = 0xf7
+	cborBreak     = 0xff
+)
+
+// maxNestingDepth is the maximum depth of nested arrays, maps, and tags
+// that are encoded or decoded.
+const maxNestingDepth = 10000
+
+// maxPrealloc is the maximum number of bytes allocated for the
+// elements of a slice from the length in its header. Longer slices
+// grow as their elements are decoded, so that a short input cannot
+// claim a large allocation.
+const maxPrealloc = 64 << 10
+
+// Marshaler is the interface implemented by types that can marshal
+// themselves into a CBOR data item.
+type Marshaler interface {
+	MarshalCBOR() ([]byte, error)
+}
+
+// Unmarshaler is the interface implemented by types that can unmarshal
+// a CBOR data item of themselves. The input is a single well-formed
+// data item. UnmarshalCBOR must copy the data if it wishes to retain
+// it after returning.
+type Unmarshaler interface {
+	UnmarshalCBOR([]byte) error
+}
+
+// RawMessage is a raw encoded CBOR data item. It implements [Marshaler]
+// and [Unmarshaler], and can be used to delay decoding or to
+// precompute an encoding.
+type RawMessage []byte
+
+// MarshalCBOR returns m as the CBOR encoding of m.
+func (m RawMessage) MarshalCBOR() ([]byte, error) {
+	if m == nil {
+		return []byte{cborNull}, nil
+	}
+	return m, nil
+}
+
+// UnmarshalCBOR sets *m to a copy of data.
+func (m *RawMessage) UnmarshalCBOR(data []byte) error {
+	if m == nil {
+		return fmt.Errorf("cbor: RawMessage: UnmarshalCBOR on nil pointer")
+	}
+	*m = append((*m)[0:0], data...)
+	return nil
+}
+
+// A Tag is a tagged data item: a data item, the content, given
+// additional meaning by a tag number.
+type Tag struct {
+	Number  uint64
+	Content any
+}
+
+// A SimpleValue is a simple value other than false, true, null, and
+// undefined, which are represented by bool and nil.
+type SimpleValue uint8
+
+// Options configure [Marshal], [Unmarshal], [Encoder], and [Decoder].
+// Options given later override those given earlier.
+type Options interface {
+	apply(*options)
+}
+
+type options struct {
+	deterministic  bool
+	nilSliceAsNull bool
+	nilMapAsNull   bool
+	omitZero       bool
+	rejectUnknown  bool
+	tags           *TagSet
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(o *options) { f(o) }
+
+func makeOptions(opts []Options) options {
+	var o options
+	for _, opt := range opts {
+		if opt != nil {
+			opt.apply(&o)
+		}
+	}
+	return o
+}
+
+// Deterministic specifies that maps, including structs encoded as
+// maps, are encoded with their keys sorted in the bytewise
+// lexicographic order of their encodings, so that with the preferred
+// serialization that is always used, the encoding follows the core
+// deterministic encoding requirements of RFC 8949, section 4.2.1.
+//
+// This affects only marshaling.
+func Deterministic(v bool) Options {
+	return optionFunc(func(o *options) { o.deterministic = v })
+}
+
+// FormatNilSliceAsNull specifies that a nil slice is encoded as null,
+// rather than as an empty array or byte string.
+//
+// This affects only marshaling.
+func FormatNilSliceAsNull(v bool) Options {
+	return optionFunc(func(o *options) { o.nilSliceAsNull = v })
+}
+
+// FormatNilMapAsNull specifies that a nil map is encoded as null,
+// rather than as an empty map.
+//
+// This affects only marshaling.
+func FormatNilMapAsNull(v bool) Options {
+	return optionFunc(func(o *options) { o.nilMapAsNull = v })
+}
+
+// OmitZeroStructFields specifies that struct fields with zero values
+// are omitted, as if every field had the "omitzero" option.
+//
+// This affects only marshaling.
+func OmitZeroStructFields(v bool) Options {
+	return optionFunc(func(o *options) { o.omitZero = v })
+}
+
+// RejectUnknownMembers specifies that decoding a map into a struct
+// reports an error if a key does not match a field.
+//
+// This affects only unmarshaling.
+func RejectUnknownMembers(v bool) Options {
+	return optionFunc(func(o *options) { o.rejectUnknown = v })
+}
+
+// WithTags specifies the registry of tags used to encode and decode
+// values of the types registered in it.
+//
+// This affects marshaling and unmarshaling.
+func WithTags(tags *TagSet) Options {
+	return optionFunc(func(o *options) { o.tags = tags })
+}
+
+// Marshal returns the CBOR encoding of v, as configured by opts.
+//
+// See the package documentation for the conversion of Go values to
+// CBOR.
+func Marshal(v any, opts ...Options) ([]byte, error) {
+	e := &encodeState{opts: makeOptions(opts)}
+	if err := e.marshal(v); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+// Unmarshal decodes the CBOR data item in data, which must hold a
+// single data item, and stores the result in the value pointed to by
+// v, as configured by opts. If v is nil or not a pointer, Unmarshal
+// returns an [InvalidUnmarshalError].
+//
+// See the package documentation for the conversion of CBOR to Go
+// values.
+func Unmarshal(data []byte, v any, opts ...Options) error {
+	n, err := checkWellFormed(data, 0)
+	if err != nil {
+		return err
+	}
+	if n < len(data) {
+		return &SyntaxError{msg: "extra data after data item", Offset: int64(n)}
+	}
+	d := &decodeState{data: data, opts: makeOptions(opts)}
+	return d.unmarshal(v)
+}
+
+// A SyntaxError describes data that is not well-formed CBOR.
+type SyntaxError struct {
+	msg    string
+	Offset int64 // the offset in the input at which the error was found
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("cbor: %s at offset %d", e.msg, e.Offset)
+}
+
+// An UnmarshalTypeError describes a CBOR data item that could not be
+// stored in a value of a Go type.
+type UnmarshalTypeError struct {
+	Value  string       // description of the CBOR data item, such as "negative integer"
+	Type   reflect.Type // the type of the Go value it could not be stored in
+	Offset int64        // the offset of the data item in the input
+	Field  string       // the path of the struct field holding the value, if any
+}
+
+func (e *UnmarshalTypeError) Error() string {
+	if e.Field != "" {
+		return "cbor: cannot unmarshal " + e.Value + " into Go struct field " + e.Field + " of type " + e.Type.String()
+	}
+	return "cbor: cannot unmarshal " + e.Value + " into Go value of type " + e.Type.String()
+}
+
+// An InvalidUnmarshalError describes an invalid argument passed to
+// [Unmarshal] or [Decoder.Decode]. The argument must be a non-nil
+// pointer.
+type InvalidUnmarshalError struct {
+	Type reflect.Type
+}
+
+func (e *InvalidUnmarshalError) Error() string {
+	if e.Type == nil {
+		return "cbor: Unmarshal(nil)"
+	}
+	if e.Type.Kind() != reflect.Pointer {
+		return "cbor: Unmarshal(non-pointer " + e.Type.String() + ")"
+	}
+	return "cbor: Unmarshal(nil " + e.Type.String() + ")"
+}
+
+// An UnsupportedTypeError is returned by [Marshal] when attempting to
+// encode an unsupported value type.
+type UnsupportedTypeError struct {
+	Type reflect.Type
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	return "cbor: unsupported type: " + e.Type.String()
+}
+
+// A MarshalerError represents an error from calling a MarshalCBOR
+// method.
+type MarshalerError struct {
+	Type reflect.Type
+	Err  error
+}
+
+func (e *MarshalerError) Error() string {
+	return "cbor: error calling MarshalCBOR for type " + e.Type.String() + ": " + e.Err.Error()
+}
+
+func (e *MarshalerError) Unwrap() error { return e.Err }
@@ -0,0 +1,907 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cbor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// msgUnexpectedEnd is the message of a SyntaxError for data that ends
+// within a data item, which a Decoder treats as a need for more input.
+const msgUnexpectedEnd = "unexpected end of data"
+
+func errUnexpectedEnd(off int) error {
+	return &SyntaxError{msg: msgUnexpectedEnd, Offset: int64(off)}
+}
+
+func isUnexpectedEnd(err error) bool {
+	se, ok := err.(*SyntaxError)
+	return ok && se.msg == msgUnexpectedEnd
+}
+
+// readHead reads the head of the data item at off, returning its major
+// type, additional information, argument, and the offset following it.
+// An additional information of 31, for indefinite length or break, has
+// an argument of 0.
+func readHead(data []byte, off int) (major, ai byte, arg uint64, next int, err error) {
+	if off >= len(data) {
+		return 0, 0, 0, 0, errUnexpectedEnd(off)
+	}
+	major, ai = data[off]>>5, data[off]&0x1f
+	off++
+	switch {
+	case ai < 24:
+		return major, ai, uint64(ai), off, nil
+	case ai <= 27:
+		n := 1 << (ai - 24)
+		if len(data)-off < n {
+			return 0, 0, 0, 0, errUnexpectedEnd(len(data))
+		}
+		switch n {
+		case 1:
+			arg = uint64(data[off])
+		case 2:
+			arg = uint64(binary.BigEndian.Uint16(data[off:]))
+		case 4:
+			arg = uint64(binary.BigEndian.Uint32(data[off:]))
+		default:
+			arg = binary.BigEndian.Uint64(data[off:])
+		}
+		return major, ai, arg, off + n, nil
+	case ai == 31:
+		return major, ai, 0, off, nil
+	}
+	return 0, 0, 0, 0, &SyntaxError{msg: "reserved additional information", Offset: int64(off - 1)}
+}
+
+// checkWellFormed checks that data holds a well-formed data item at
+// off, and returns the offset following it.
+func checkWellFormed(data []byte, off int) (int, error) {
+	return wellFormed(data, off, 0)
+}
+
+func wellFormed(data []byte, off, depth int) (int, error) {
+	start := off
+	major, ai, arg, off, err := readHead(data, off)
+	if err != nil {
+		return 0, err
+	}
+	if depth > maxNestingDepth {
+		return 0, &SyntaxError{msg: "exceeded maximum nesting depth", Offset: int64(start)}
+	}
+	switch major {
+	case majorUint, majorNegInt, majorTag:
+		if ai == 31 {
+			return 0, &SyntaxError{msg: "invalid indefinite length", Offset: int64(start)}
+		}
+		if major == majorTag {
+			return wellFormed(data, off, depth+1)
+		}
+		return off, nil
+
+	case majorBytes, majorText:
+		if ai != 31 {
+			return stringEnd(data, off, major, arg)
+		}
+		for {
+			if off >= len(data) {
+				return 0, errUnexpectedEnd(off)
+			}
+			if data[off] == cborBreak {
+				return off + 1, nil
+			}
+			chunk := off
+			cmajor, cai, n, next, err := readHead(data, off)
+			if err != nil {
+				return 0, err
+			}
+			if cmajor != major || cai == 31 {
+				return 0, &SyntaxError{msg: "invalid chunk in indefinite-length string", Offset: int64(chunk)}
+			}
+			if off, err = stringEnd(data, next, major, n); err != nil {
+				return 0, err
+			}
+		}
+
+	case majorArray, majorMap:
+		if ai == 31 {
+			for i := 0; ; i++ {
+				if off >= len(data) {
+					return 0, errUnexpectedEnd(off)
+				}
+				if data[off] == cborBreak {
+					if major == majorMap && i%2 != 0 {
+						return 0, &SyntaxError{msg: "missing value in indefinite-length map", Offset: int64(off)}
+					}
+					return off + 1, nil
+				}
+				if off, err = wellFormed(data, off, depth+1); err != nil {
+					return 0, err
+				}
+			}
+		}
+		// Every data item is at least one byte long.
+		if arg > uint64(len(data)-off) {
+			return 0, errUnexpectedEnd(len(data))
+		}
+		n := int(arg)
+		if major == majorMap {
+			n *= 2
+		}
+		for range n {
+			if off, err = wellFormed(data, off, depth+1); err != nil {
+				return 0, err
+			}
+		}
+		return off, nil
+	}
+
+	switch {
+	case ai == 24 && arg < 32:
+		return 0, &SyntaxError{msg: "invalid simple value", Offset: int64(start)}
+	case ai == 31:
+		return 0, &SyntaxError{msg: "unexpected break", Offset: int64(start)}
+	}
+	return off, nil
+}
+
+// stringEnd returns the end of the content of a byte or text string of
+// length n starting at off.
+func stringEnd(data []byte, off int, major byte, n uint64) (int, error) {
+	if n > uint64(len(data)-off) {
+		return 0, errUnexpectedEnd(len(data))
+	}
+	end := off + int(n)
+	if major == majorText && !utf8.Valid(data[off:end]) {
+		return 0, &SyntaxError{msg: "invalid UTF-8 in text string", Offset: int64(off)}
+	}
+	return end, nil
+}
+
+// A decodeState decodes a well-formed data item into Go values.
+type decodeState struct {
+	data []byte
+	off  int
+	opts options
+	path []string // names of the struct fields being decoded
+}
+
+func (d *decodeState) unmarshal(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return &InvalidUnmarshalError{reflect.TypeOf(v)}
+	}
+	return d.value(rv.Elem())
+}
+
+// head reads the head of the data item at d.off.
+func (d *decodeState) head() (major, ai byte, arg uint64) {
+	major, ai, arg, d.off, _ = readHead(d.data, d.off)
+	return major, ai, arg
+}
+
+// peek returns the head of the data item at d.off without consuming it,
+// and the offset following it.
+func (d *decodeState) peek() (major, ai byte, arg uint64, next int) {
+	major, ai, arg, next, _ = readHead(d.data, d.off)
+	return major, ai, arg, next
+}
+
+// skip skips the data item at d.off.
+func (d *decodeState) skip() {
+	d.off, _ = checkWellFormed(d.data, d.off)
+}
+
+// more reports whether the i'th element of an array or map with n
+// elements, or -1 for indefinite length, follows, consuming the break
+// at the end of an indefinite-length item.
+func (d *decodeState) more(i, n int) bool {
+	if n >= 0 {
+		return i < n
+	}
+	if d.data[d.off] == cborBreak {
+		d.off++
+		return false
+	}
+	return true
+}
+
+// length reads the head of an array or map and returns the number of
+// its elements, or -1 for indefinite length.
+func (d *decodeState) length() int {
+	if _, ai, arg := d.head(); ai != 31 {
+		return int(arg)
+	}
+	return -1
+}
+
+// stringBytes reads a byte or text string, returning its content. The
+// content of a definite-length string refers to d.data.
+func (d *decodeState) stringBytes() []byte {
+	if _, ai, n := d.head(); ai != 31 {
+		b := d.data[d.off : d.off+int(n)]
+		d.off += int(n)
+		return b
+	}
+	b := []byte{}
+	for d.data[d.off] != cborBreak {
+		_, _, n := d.head()
+		b = append(b, d.data[d.off:d.off+int(n)]...)
+		d.off += int(n)
+	}
+	d.off++
+	return b
+}
+
+func (d *decodeState) typeError(start int, t reflect.Type) error {
+	return &UnmarshalTypeError{
+		Value:  describe(d.data, start),
+		Type:   t,
+		Offset: int64(start),
+		Field:  strings.Join(d.path, "."),
+	}
+}
+
+// describe describes the data item at off for an UnmarshalTypeError.
+func describe(data []byte, off int) string {
+	major, ai, arg, _, _ := readHead(data, off)
+	switch major {
+	case majorUint:
+		return "unsigned integer"
+	case majorNegInt:
+		return "negative integer"
+	case majorBytes:
+		return "byte string"
+	case majorText:
+		return "text string"
+	case majorArray:
+		return "array"
+	case majorMap:
+		return "map"
+	case majorTag:
+		return "tag " + strconv.FormatUint(arg, 10)
+	}
+	switch ai {
+	case 20, 21:
+		return "bool"
+	case 22:
+		return "null"
+	case 23:
+		return "undefined"
+	case 25, 26, 27:
+		return "float"
+	}
+	return "simple value"
+}
+
+// indirect walks down v, allocating pointers as needed, until it gets
+// to a non-pointer. If it finds an Unmarshaler on the way, it returns
+// it, with v the pointer implementing it.
+func indirect(v reflect.Value) (Unmarshaler, reflect.Value) {
+	if v.Kind() != reflect.Pointer && v.CanAddr() && reflect.PointerTo(v.Type()).Implements(unmarshalerType) {
+		v = v.Addr()
+	}
+	for {
+		// Load a value from an interface holding a non-nil pointer,
+		// so that decoding into it reuses the pointer.
+		if v.Kind() == reflect.Interface && !v.IsNil() {
+			if e := v.Elem(); e.Kind() == reflect.Pointer && !e.IsNil() {
+				v = e
+				continue
+			}
+		}
+		if v.Kind() != reflect.Pointer {
+			return nil, v
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		if v.Type().Implements(unmarshalerType) {
+			return v.Interface().(Unmarshaler), v
+		}
+		v = v.Elem()
+	}
+}
+
+// value decodes the data item at d.off into v.
+func (d *decodeState) value(v reflect.Value) error {
+	start := d.off
+	if b := d.data[start]; b == cborNull || b == cborUndefined {
+		return d.null(v)
+	}
+
+	u, v := indirect(v)
+	if u != nil {
+		// Strip the tag of a registered type, as it was added by
+		// encoding rather than by the MarshalCBOR method.
+		content := start
+		if d.opts.tags != nil {
+			major, _, arg, next := d.peek()
+			if num, ok := d.opts.tags.number(v.Type().Elem()); ok && major == majorTag && arg == num {
+				content = next
+			}
+		}
+		d.skip()
+		return u.UnmarshalCBOR(d.data[content:d.off])
+	}
+
+	t := v.Type()
+	if v.Kind() == reflect.Interface {
+		if v.NumMethod() != 0 {
+			return d.typeError(start, t)
+		}
+		x, err := d.anyValue()
+		if err != nil {
+			return err
+		}
+		if x == nil {
+			v.SetZero()
+		} else {
+			v.Set(reflect.ValueOf(x))
+		}
+		return nil
+	}
+
+	major, ai, arg, next := d.peek()
+	if major == majorTag {
+		return d.tagged(v, arg, next)
+	}
+	switch t {
+	case timeType:
+		tm, err := d.timeValue(-1)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(tm))
+		return nil
+	case bigIntType:
+		x, err := d.bigValue(-1)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(x).Elem())
+		return nil
+	case tagType:
+		return d.typeError(start, t)
+	case simpleType:
+		if major != majorSimple || ai >= 20 && ai != 24 {
+			return d.typeError(start, t)
+		}
+		d.off = next
+		v.SetUint(arg)
+		return nil
+	}
+
+	switch major {
+	case majorUint:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if arg > math.MaxInt64 || v.OverflowInt(int64(arg)) {
+				return d.typeError(start, t)
+			}
+			v.SetInt(int64(arg))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if v.OverflowUint(arg) {
+				return d.typeError(start, t)
+			}
+			v.SetUint(arg)
+		case reflect.Float32, reflect.Float64:
+			v.SetFloat(float64(arg))
+		default:
+			return d.typeError(start, t)
+		}
+		d.off = next
+		return nil
+
+	case majorNegInt:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if arg > math.MaxInt64 || v.OverflowInt(-1-int64(arg)) {
+				return d.typeError(start, t)
+			}
+			v.SetInt(-1 - int64(arg))
+		case reflect.Float32, reflect.Float64:
+			v.SetFloat(-1 - float64(arg))
+		default:
+			return d.typeError(start, t)
+		}
+		d.off = next
+		return nil
+
+	case majorBytes:
+		switch {
+		case v.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+			b := d.stringBytes()
+			v.SetBytes(append(make([]byte, 0, len(b)), b...))
+		case v.Kind() == reflect.Array && t.Elem().Kind() == reflect.Uint8:
+			b := d.stringBytes()
+			for i := range v.Len() {
+				if i < len(b) {
+					v.Index(i).SetUint(uint64(b[i]))
+				} else {
+					v.Index(i).SetZero()
+				}
+			}
+		default:
+			return d.typeError(start, t)
+		}
+		return nil
+
+	case majorText:
+		if v.Kind() != reflect.String {
+			return d.typeError(start, t)
+		}
+		v.SetString(string(d.stringBytes()))
+		return nil
+
+	case majorArray:
+		return d.array(v)
+
+	case majorMap:
+		return d.mapValue(v)
+	}
+
+	// Simple values and floats.
+	switch ai {
+	case 20, 21:
+		if v.Kind() != reflect.Bool {
+			return d.typeError(start, t)
+		}
+		v.SetBool(ai == 21)
+	case 25, 26, 27:
+		f := floatValue(ai, arg)
+		switch v.Kind() {
+		case reflect.Float32, reflect.Float64:
+			if v.OverflowFloat(f) {
+				return d.typeError(start, t)
+			}
+			v.SetFloat(f)
+		default:
+			return d.typeError(start, t)
+		}
+	default:
+		return d.typeError(start, t)
+	}
+	d.off = next
+	return nil
+}
+
+// null decodes a null or undefined into v. It sets interfaces,
+// pointers, maps, and slices to nil, and leaves other values unchanged,
+// unless they implement Unmarshaler.
+func (d *decodeState) null(v reflect.Value) error {
+	d.off++
+	if v.Kind() != reflect.Pointer && v.CanAddr() && reflect.PointerTo(v.Type()).Implements(unmarshalerType) {
+		return v.Addr().Interface().(Unmarshaler).UnmarshalCBOR(d.data[d.off-1 : d.off])
+	}
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer, reflect.Map, reflect.Slice:
+		v.SetZero()
+	}
+	return nil
+}
+
+// tagged decodes a data item with tag number num into v, which is not
+// an interface. The content of the tag starts at next.
+func (d *decodeState) tagged(v reflect.Value, num uint64, next int) error {
+	start := d.off
+	t := v.Type()
+	if d.opts.tags != nil {
+		if rt, ok := d.opts.tags.typ(num); ok {
+			if t != rt {
+				return d.typeError(start, t)
+			}
+			d.off = next
+			return d.value(v)
+		}
+	}
+	switch {
+	case t == timeType && num <= 1:
+		d.off = next
+		tm, err := d.timeValue(int(num))
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(tm))
+		return nil
+	case t == bigIntType && (num == 2 || num == 3):
+		d.off = next
+		x, err := d.bigValue(int(num))
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(x).Elem())
+		return nil
+	case t == tagType:
+		d.off = next
+		content, err := d.anyValue()
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(Tag{Number: num, Content: content}))
+		return nil
+	}
+	// Ignore a tag that the type does not know about.
+	d.off = next
+	return d.value(v)
+}
+
+// timeValue decodes the content of a tag 0 or 1, or an untagged data
+// item if num is -1, into a time.
+func (d *decodeState) timeValue(num int) (time.Time, error) {
+	start := d.off
+	major, ai, arg, next := d.peek()
+	switch {
+	case major == majorText && num != 1:
+		s := string(d.stringBytes())
+		tm, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("cbor: invalid time at offset %d: %w", start, err)
+		}
+		return tm, nil
+	case major == majorUint && num != 0 && arg <= math.MaxInt64:
+		d.off = next
+		return time.Unix(int64(arg), 0).UTC(), nil
+	case major == majorNegInt && num != 0 && arg <= math.MaxInt64:
+		d.off = next
+		return time.Unix(-1-int64(arg), 0).UTC(), nil
+	case major == majorSimple && num != 0 && 25 <= ai && ai <= 27:
+		f := floatValue(ai, arg)
+		if !(math.Abs(f) < 1<<63) {
+			break
+		}
+		d.off = next
+		sec, frac := math.Modf(f)
+		return time.Unix(int64(sec), int64(math.Round(frac*1e9))).UTC(), nil
+	}
+	return time.Time{}, d.typeError(start, timeType)
+}
+
+// bigValue decodes the content of a tag 2 or 3, or an untagged data
+// item if num is -1, into a big.Int.
+func (d *decodeState) bigValue(num int) (*big.Int, error) {
+	start := d.off
+	major, _, arg, next := d.peek()
+	switch {
+	case major == majorBytes && num != -1:
+		x := new(big.Int).SetBytes(d.stringBytes())
+		if num == 3 {
+			x.Neg(x.Add(x, big.NewInt(1)))
+		}
+		return x, nil
+	case major == majorUint && num == -1:
+		d.off = next
+		return new(big.Int).SetUint64(arg), nil
+	case major == majorNegInt && num == -1:
+		d.off = next
+		return negInt(arg), nil
+	}
+	return nil, d.typeError(start, bigIntType)
+}
+
+// negInt returns the negative integer -1-n.
+func negInt(n uint64) *big.Int {
+	x := new(big.Int).SetUint64(n)
+	return x.Neg(x.Add(x, big.NewInt(1)))
+}
+
+func floatValue(ai byte, arg uint64) float64 {
+	switch ai {
+	case 25:
+		return float16ToFloat64(uint16(arg))
+	case 26:
+		return float64(math.Float32frombits(uint32(arg)))
+	}
+	return math.Float64frombits(arg)
+}
+
+// float16ToFloat64 returns the value of the half-precision float with
+// the bits h.
+func float16ToFloat64(h uint16) float64 {
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 0x1f:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+0x400, exp-25)
+	}
+	if h&0x8000 != 0 {
+		f = -f
+	}
+	return f
+}
+
+func (d *decodeState) array(v reflect.Value) error {
+	start := d.off
+	t := v.Type()
+	switch v.Kind() {
+	case reflect.Slice:
+		n := d.length()
+		if n >= 0 {
+			c := min(n, maxPrealloc/max(1, int(t.Elem().Size())))
+			if v.Cap() < c {
+				v.Set(reflect.MakeSlice(t, 0, c))
+			}
+		}
+		v.SetLen(0)
+		i := 0
+		for ; d.more(i, n); i++ {
+			if i >= v.Cap() {
+				v.Grow(1)
+			}
+			v.SetLen(i + 1)
+			v.Index(i).SetZero()
+			if err := d.value(v.Index(i)); err != nil {
+				return err
+			}
+		}
+		if i == 0 && v.IsNil() {
+			v.Set(reflect.MakeSlice(t, 0, 0))
+		}
+		return nil
+
+	case reflect.Array:
+		n := d.length()
+		i := 0
+		for ; d.more(i, n); i++ {
+			if i >= v.Len() {
+				d.skip()
+				continue
+			}
+			if err := d.value(v.Index(i)); err != nil {
+				return err
+			}
+		}
+		for ; i < v.Len(); i++ {
+			v.Index(i).SetZero()
+		}
+		return nil
+
+	case reflect.Struct:
+		sf := cachedTypeFields(t)
+		if sf.err != nil {
+			return sf.err
+		}
+		if !sf.toArray {
+			return d.typeError(start, t)
+		}
+		n := d.length()
+		for i := 0; d.more(i, n); i++ {
+			if i >= len(sf.list) {
+				if d.opts.rejectUnknown {
+					return fmt.Errorf("cbor: too many elements in array for %v at offset %d", t, d.off)
+				}
+				d.skip()
+				continue
+			}
+			if err := d.field(v, &sf.list[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return d.typeError(start, t)
+}
+
+func (d *decodeState) mapValue(v reflect.Value) error {
+	start := d.off
+	t := v.Type()
+	switch v.Kind() {
+	case reflect.Struct:
+		return d.structValue(v)
+	case reflect.Map:
+	default:
+		return d.typeError(start, t)
+	}
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(t))
+	}
+	n := d.length()
+	for i := 0; d.more(i, n); i++ {
+		keyStart := d.off
+		k := reflect.New(t.Key()).Elem()
+		if err := d.value(k); err != nil {
+			return err
+		}
+		if !k.Comparable() {
+			return &UnmarshalTypeError{
+				Value:  describe(d.data, keyStart) + " key",
+				Type:   t,
+				Offset: int64(keyStart),
+				Field:  strings.Join(d.path, "."),
+			}
+		}
+		e := reflect.New(t.Elem()).Elem()
+		if err := d.value(e); err != nil {
+			return err
+		}
+		v.SetMapIndex(k, e)
+	}
+	return nil
+}
+
+func (d *decodeState) structValue(v reflect.Value) error {
+	start := d.off
+	t := v.Type()
+	sf := cachedTypeFields(t)
+	if sf.err != nil {
+		return sf.err
+	}
+	if sf.toArray {
+		return d.typeError(start, t)
+	}
+	n := d.length()
+	for i := 0; d.more(i, n); i++ {
+		keyStart := d.off
+		f := -1
+		ok := false
+		switch major, _, arg, next := d.peek(); major {
+		case majorText:
+			f, ok = sf.byName[string(d.stringBytes())]
+		case majorUint:
+			d.off = next
+			if arg <= math.MaxInt64 {
+				f, ok = sf.byInt[int64(arg)]
+			}
+		case majorNegInt:
+			d.off = next
+			if arg <= math.MaxInt64 {
+				f, ok = sf.byInt[-1-int64(arg)]
+			}
+		default:
+			d.skip()
+		}
+		if !ok {
+			if d.opts.rejectUnknown {
+				return fmt.Errorf("cbor: unknown key %s in %v at offset %d", keyString(d.data[keyStart:d.off]), t, keyStart)
+			}
+			d.skip()
+			continue
+		}
+		if err := d.field(v, &sf.list[f]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// field decodes the data item at d.off into the field f of the struct v.
+func (d *decodeState) field(v reflect.Value, f *field) error {
+	fv := fieldByIndex(v, f.index, true)
+	if !fv.IsValid() {
+		return fmt.Errorf("cbor: cannot set embedded pointer to unexported struct: %v", v.Type())
+	}
+	d.path = append(d.path, f.goName)
+	err := d.value(fv)
+	d.path = d.path[:len(d.path)-1]
+	return err
+}
+
+// keyString formats the encoded map key b for an error.
+func keyString(b []byte) string {
+	switch major, _, arg, _, _ := readHead(b, 0); major {
+	case majorText:
+		d := &decodeState{data: b}
+		return strconv.Quote(string(d.stringBytes()))
+	case majorUint:
+		return strconv.FormatUint(arg, 10)
+	case majorNegInt:
+		return negInt(arg).String()
+	}
+	return describe(b, 0)
+}
+
+// anyValue decodes the data item at d.off into an interface value.
+func (d *decodeState) anyValue() (any, error) {
+	major, ai, arg, next := d.peek()
+	switch major {
+	case majorUint:
+		d.off = next
+		return arg, nil
+	case majorNegInt:
+		d.off = next
+		if arg <= math.MaxInt64 {
+			return -1 - int64(arg), nil
+		}
+		return negInt(arg), nil
+	case majorBytes:
+		return bytes.Clone(d.stringBytes()), nil
+	case majorText:
+		return string(d.stringBytes()), nil
+	case majorArray:
+		n := d.length()
+		a := []any{}
+		for i := 0; d.more(i, n); i++ {
+			x, err := d.anyValue()
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, x)
+		}
+		return a, nil
+	case majorMap:
+		n := d.length()
+		m := make(map[any]any)
+		for i := 0; d.more(i, n); i++ {
+			keyStart := d.off
+			k, err := d.anyValue()
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.ValueOf(k).Comparable() {
+				return nil, &UnmarshalTypeError{
+					Value:  describe(d.data, keyStart) + " key",
+					Type:   reflect.TypeFor[map[any]any](),
+					Offset: int64(keyStart),
+					Field:  strings.Join(d.path, "."),
+				}
+			}
+			x, err := d.anyValue()
+			if err != nil {
+				return nil, err
+			}
+			m[k] = x
+		}
+		return m, nil
+	case majorTag:
+		d.off = next
+		if d.opts.tags != nil {
+			if rt, ok := d.opts.tags.typ(arg); ok {
+				v := reflect.New(rt).Elem()
+				if err := d.value(v); err != nil {
+					return nil, err
+				}
+				return v.Interface(), nil
+			}
+		}
+		switch arg {
+		case 0, 1:
+			tm, err := d.timeValue(int(arg))
+			if err != nil {
+				return nil, err
+			}
+			return tm, nil
+		case 2, 3:
+			x, err := d.bigValue(int(arg))
+			if err != nil {
+				return nil, err
+			}
+			return x, nil
+		}
+		content, err := d.anyValue()
+		if err != nil {
+			return nil, err
+		}
+		return Tag{Number: arg, Content: content}, nil
+	}
+
+	d.off = next
+	switch ai {
+	case 20, 21:
+		return ai == 21, nil
+	case 22, 23:
+		return nil, nil
+	case 25, 26, 27:
+		return floatValue(ai, arg), nil
+	}
+	return SimpleValue(arg), nil
+}
@@ -0,0 +1,349 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cbor
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"math/big"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUnmarshalRFC(t *testing.T) {
+	for _, tt := range rfcTests {
+		var got any
+		if err := Unmarshal(mustHex(tt.hex), &got); err != nil {
+			t.Errorf("Unmarshal(%s): %v", tt.hex, err)
+			continue
+		}
+		want := tt.value
+		switch w := want.(type) {
+		case *big.Int:
+			if w.IsInt64() {
+				want = w.Int64()
+			}
+		case time.Time:
+			if got, ok := got.(time.Time); ok && got.Equal(w) {
+				continue
+			}
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Unmarshal(%s) = %#v, want %#v", tt.hex, got, want)
+		}
+	}
+}
+
+// Examples from RFC 8949, appendix A, that this package does not
+// produce when encoding.
+var rfcDecodeTests = []struct {
+	hex   string
+	value any
+}{
+	{"f97e00", math.NaN()},
+	{"fa7fc00000", math.NaN()},
+	{"fb7ff8000000000000", math.NaN()},
+	{"fa7f800000", math.Inf(1)},
+	{"fb7ff0000000000000", math.Inf(1)},
+	{"faff800000", math.Inf(-1)},
+	{"f7", nil},
+	{"c074323031332d30332d32315432303a30343a30305a", time.Date(2013, 3, 21, 20, 4, 0, 0, time.UTC)},
+	{"3bffffffffffffffff", bigInt("-18446744073709551616")},
+	{"5f42010243030405ff", []byte{1, 2, 3, 4, 5}},
+	{"7f657374726561646d696e67ff", "streaming"},
+	{"9fff", []any{}},
+	{"9f018202039f0405ffff", []any{uint64(1), []any{uint64(2), uint64(3)}, []any{uint64(4), uint64(5)}}},
+	{"9f01820203820405ff", []any{uint64(1), []any{uint64(2), uint64(3)}, []any{uint64(4), uint64(5)}}},
+	{"83018202039f0405ff", []any{uint64(1), []any{uint64(2), uint64(3)}, []any{uint64(4), uint64(5)}}},
+	{"bf61610161629f0203ffff", map[any]any{"a": uint64(1), "b": []any{uint64(2), uint64(3)}}},
+	{"826161bf61626163ff", []any{"a", map[any]any{"b": "c"}}},
+	{"bf6346756ef563416d7421ff", map[any]any{"Fun": true, "Amt": int64(-2)}},
+}
+
+func TestUnmarshalIndefinite(t *testing.T) {
+	for _, tt := range rfcDecodeTests {
+		var got any
+		if err := Unmarshal(mustHex(tt.hex), &got); err != nil {
+			t.Errorf("Unmarshal(%s): %v", tt.hex, err)
+			continue
+		}
+		if f, ok := tt.value.(float64); ok && math.IsNaN(f) {
+			if g, ok := got.(float64); !ok || !math.IsNaN(g) {
+				t.Errorf("Unmarshal(%s) = %#v, want NaN", tt.hex, got)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.value) {
+			t.Errorf("Unmarshal(%s) = %#v, want %#v", tt.hex, got, tt.value)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	type inner struct {
+		B []byte
+		F float32
+	}
+	type all struct {
+		I    int8
+		U    uint16
+		S    string
+		A    [3]byte
+		L    []inner
+		M    map[int]string
+		P    *inner
+		T    time.Time
+		N    big.Int
+		R    RawMessage
+		Any  any
+		Arr  [2]int
+		Bool bool
+		Key  coseKey
+		Rec  record
+	}
+	in := all{
+		I:    -128,
+		U:    65535,
+		S:    "héllo",
+		A:    [3]byte{1, 2, 3},
+		L:    []inner{{[]byte("x"), 0.5}, {[]byte{}, float32(math.Inf(-1))}},
+		M:    map[int]string{-1: "a", 1: "b"},
+		P:    &inner{B: []byte{}, F: 1.25},
+		T:    time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		N:    *bigInt("-123456789012345678901234567890"),
+		R:    RawMessage{0x83, 0x01, 0x02, 0x03},
+		Any:  []any{"x", uint64(1)},
+		Arr:  [2]int{5, 6},
+		Bool: true,
+		Key:  coseKey{Kty: 2, Crv: 1, X: []byte{9}},
+		Rec:  record{Name: "Ann", Age: 7},
+	}
+	for _, opts := range [][]Options{nil, {Deterministic(true)}} {
+		b, err := Marshal(in, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out all
+		if err := Unmarshal(b, &out); err != nil {
+			t.Fatalf("Unmarshal(%x): %v", b, err)
+		}
+		if !reflect.DeepEqual(in, out) {
+			t.Errorf("round trip:\n got %+v\nwant %+v", out, in)
+		}
+	}
+}
+
+func TestUnmarshalStruct(t *testing.T) {
+	var p point
+	// {"x": 1, "y": 2, "z": [1, {}], 7: 0}
+	data := mustHex("a4617801617902617a820aa00700")
+	if err := Unmarshal(data, &p); err != nil || p != (point{1, 2}) {
+		t.Errorf("Unmarshal = %+v, %v, want {1 2}", p, err)
+	}
+	err := Unmarshal(data, &p, RejectUnknownMembers(true))
+	if err == nil || !strings.Contains(err.Error(), `unknown key "z"`) {
+		t.Errorf("Unmarshal with RejectUnknownMembers: %v, want unknown key error", err)
+	}
+
+	// Field names are matched exactly.
+	p = point{}
+	if err := Unmarshal(mustHex("a1615801"), &p); err != nil || p != (point{}) {
+		t.Errorf("Unmarshal of key X = %+v, %v, want no match", p, err)
+	}
+
+	// An embedded pointer is allocated.
+	var d derived
+	if err := Unmarshal(mustHex("a262494407644b696e64616b"), &d); err != nil || d.Base == nil || d.ID != 7 || d.Kind != "k" {
+		t.Errorf("Unmarshal into derived = %+v, %v", d, err)
+	}
+
+	// A toarray struct requires an array, and a map struct a map.
+	var ute *UnmarshalTypeError
+	if err := Unmarshal(mustHex("a0"), &record{}); !errors.As(err, &ute) {
+		t.Errorf("Unmarshal of map into toarray struct: %v, want UnmarshalTypeError", err)
+	}
+	if err := Unmarshal(mustHex("80"), &point{}); !errors.As(err, &ute) {
+		t.Errorf("Unmarshal of array into struct: %v, want UnmarshalTypeError", err)
+	}
+	var r record
+	if err := Unmarshal(mustHex("8363426f62182af6"), &r); err != nil || r.Name != "Bob" || r.Age != 42 {
+		t.Errorf("Unmarshal of long array = %+v, %v", r, err)
+	}
+	if err := Unmarshal(mustHex("8363426f62182af6"), &r, RejectUnknownMembers(true)); err == nil {
+		t.Errorf("Unmarshal of long array with RejectUnknownMembers succeeded")
+	}
+}
+
+func TestUnmarshalNull(t *testing.T) {
+	v := struct {
+		P *int
+		S []int
+		M map[int]int
+		I any
+		N int
+		R RawMessage
+	}{new(int), []int{1}, map[int]int{}, 1, 5, nil}
+	// {"P": null, "S": null, "M": undefined, "I": null, "N": null, "R": null}
+	data := mustHex("a66150f66153f6614df76149f6614ef66152f6")
+	if err := Unmarshal(data, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.P != nil || v.S != nil || v.M != nil || v.I != nil || v.N != 5 || string(v.R) != "\xf6" {
+		t.Errorf("Unmarshal = %+v", v)
+	}
+}
+
+func TestUnmarshalTypeErrors(t *testing.T) {
+	type nested struct {
+		Inner struct {
+			V int8 `cbor:"v"`
+		} `cbor:"inner"`
+	}
+	tests := []struct {
+		hex   string
+		v     any
+		value string
+		field string
+	}{
+		{"1880", new(int8), "unsigned integer", ""},
+		{"3880", new(int8), "negative integer", ""},
+		{"20", new(uint), "negative integer", ""},
+		{"1bffffffffffffffff", new(int64), "unsigned integer", ""},
+		{"fa47c35000", new(int), "float", ""},
+		{"fb7e37e43c8800759c", new(float32), "float", ""},
+		{"6161", new([]byte), "text string", ""},
+		{"4161", new(string), "byte string", ""},
+		{"f5", new(string), "bool", ""},
+		{"01", new(bool), "unsigned integer", ""},
+		{"a0", new([]int), "map", ""},
+		{"80", new(map[string]int), "array", ""},
+		{"f0", new(uint8), "simple value", ""},
+		{"01", new(SimpleValue), "unsigned integer", ""},
+		{"01", new(Tag), "unsigned integer", ""},
+		{"f5", new(time.Time), "bool", ""},
+		{"6161", new(big.Int), "text string", ""},
+		{"01", new(error), "unsigned integer", ""},
+		{"a165696e6e6572a1617618c8", new(nested), "unsigned integer", "Inner.V"},
+		{"a1810101", new(any), "array key", ""},
+		{"a18101f6", new(map[any]any), "array key", ""},
+	}
+	for _, tt := range tests {
+		err := Unmarshal(mustHex(tt.hex), tt.v)
+		var ute *UnmarshalTypeError
+		if !errors.As(err, &ute) {
+			t.Errorf("Unmarshal(%s, %T): %v, want UnmarshalTypeError", tt.hex, tt.v, err)
+			continue
+		}
+		if ute.Value != tt.value || ute.Field != tt.field {
+			t.Errorf("Unmarshal(%s, %T): Value %q, Field %q; want %q, %q", tt.hex, tt.v, ute.Value, ute.Field, tt.value, tt.field)
+		}
+	}
+}
+
+func TestUnmarshalInvalid(t *testing.T) {
+	var ie *InvalidUnmarshalError
+	for _, v := range []any{nil, 1, (*int)(nil)} {
+		if err := Unmarshal([]byte{0}, v); !errors.As(err, &ie) {
+			t.Errorf("Unmarshal into %#v: %v, want InvalidUnmarshalError", v, err)
+		}
+	}
+}
+
+var malformedTests = []struct {
+	hex string
+	msg string
+}{
+	{"", "unexpected end of data"},
+	{"18", "unexpected end of data"},
+	{"1a0102", "unexpected end of data"},
+	{"62c3", "unexpected end of data"},
+	{"83010203" + "04", "extra data after data item"},
+	{"8301", "unexpected end of data"},
+	{"9f01", "unexpected end of data"},
+	{"5f41", "unexpected end of data"},
+	{"1c", "reserved additional information"},
+	{"5e", "reserved additional information"},
+	{"1f", "invalid indefinite length"},
+	{"3f", "invalid indefinite length"},
+	{"df01", "invalid indefinite length"},
+	{"ff", "unexpected break"},
+	{"8201ff", "unexpected break"},
+	{"f800", "invalid simple value"},
+	{"f81f", "invalid simple value"},
+	{"62c328", "invalid UTF-8 in text string"},
+	{"5f6161ff", "invalid chunk in indefinite-length string"},
+	{"7f7f6161ffff", "invalid chunk in indefinite-length string"},
+	{"bf01ff", "missing value in indefinite-length map"},
+	{"9b00000000ffffffff", "unexpected end of data"},
+	{strings.Repeat("81", maxNestingDepth+1) + "00", "exceeded maximum nesting depth"},
+}
+
+func TestUnmarshalMalformed(t *testing.T) {
+	for _, tt := range malformedTests {
+		var v any
+		err := Unmarshal(mustHex(tt.hex), &v)
+		var se *SyntaxError
+		if !errors.As(err, &se) || !strings.Contains(err.Error(), tt.msg) {
+			name := tt.hex
+			if len(name) > 20 {
+				name = name[:20] + "..."
+			}
+			t.Errorf("Unmarshal(%s): %v, want SyntaxError %q", name, err, tt.msg)
+		}
+	}
+}
+
+type unmarshalerValue struct {
+	raw string
+}
+
+func (u *unmarshalerValue) UnmarshalCBOR(b []byte) error {
+	u.raw = string(b)
+	return nil
+}
+
+func TestUnmarshalLargeArrayHeader(t *testing.T) {
+	// An array claiming 65536 elements of 4 KiB each, of which the
+	// first cannot be decoded.
+	data := append(mustHex("9a00010000"), bytes.Repeat([]byte{0x01}, 1<<16)...)
+	var v [][4096]byte
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	err := Unmarshal(data, &v)
+	runtime.ReadMemStats(&after)
+	if _, ok := err.(*UnmarshalTypeError); !ok {
+		t.Fatalf("Unmarshal: %v, want UnmarshalTypeError", err)
+	}
+	if n := after.TotalAlloc - before.TotalAlloc; n > 16<<20 {
+		t.Errorf("Unmarshal allocated %d bytes for a %d-byte input", n, len(data))
+	}
+}
+
+func TestUnmarshaler(t *testing.T) {
+	var v struct {
+		U  unmarshalerValue
+		P  *unmarshalerValue
+		R  RawMessage
+		RS []RawMessage
+	}
+	// {"U": 1(2), "P": [1, 2], "R": "x", "RS": [1, -1]}
+	data := mustHex("a46155c1026150820102615261786252538201" + "20")
+	if err := Unmarshal(data, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.U.raw != "\xc1\x02" || v.P == nil || v.P.raw != "\x82\x01\x02" || string(v.R) != "\x61x" ||
+		len(v.RS) != 2 || string(v.RS[0]) != "\x01" || string(v.RS[1]) != "\x20" {
+		t.Errorf("Unmarshal = %+v", v)
+	}
+	// The content must not alias the input.
+	data[len(data)-1] = 0
+	if string(v.RS[1]) != "\x20" {
+		t.Errorf("RawMessage aliases the input")
+	}
+}
@@ -0,0 +1,437 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cbor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"math/big"
+	"reflect"
+	"slices"
+	"time"
+)
+
+var (
+	marshalerType   = reflect.TypeFor[Marshaler]()
+	unmarshalerType = reflect.TypeFor[Unmarshaler]()
+	timeType        = reflect.TypeFor[time.Time]()
+	bigIntType      = reflect.TypeFor[big.Int]()
+	tagType         = reflect.TypeFor[Tag]()
+	simpleType      = reflect.TypeFor[SimpleValue]()
+	rawMessageType  = reflect.TypeFor[RawMessage]()
+)
+
+// An encodeState encodes Go values into buf.
+type encodeState struct {
+	buf   []byte
+	opts  options
+	depth int
+}
+
+func (e *encodeState) marshal(v any) error {
+	return e.encode(reflect.ValueOf(v), false)
+}
+
+// encode appends the encoding of v. If skipTag is set, v is not tagged
+// even if its type is registered in the tag set.
+func (e *encodeState) encode(v reflect.Value, skipTag bool) error {
+	if !v.IsValid() {
+		e.buf = append(e.buf, cborNull)
+		return nil
+	}
+	t := v.Type()
+
+	if !skipTag && e.opts.tags != nil {
+		if num, ok := e.opts.tags.number(t); ok {
+			e.buf = appendHead(e.buf, majorTag, num)
+			return e.nested(func() error { return e.encode(v, true) })
+		}
+	}
+
+	if t.Implements(marshalerType) {
+		if t.Kind() == reflect.Pointer && v.IsNil() {
+			e.buf = append(e.buf, cborNull)
+			return nil
+		}
+		return e.encodeMarshaler(v)
+	}
+	if t.Kind() != reflect.Pointer && reflect.PointerTo(t).Implements(marshalerType) {
+		if !v.CanAddr() {
+			p := reflect.New(t)
+			p.Elem().Set(v)
+			v = p.Elem()
+		}
+		return e.encodeMarshaler(v.Addr())
+	}
+
+	switch t {
+	case timeType:
+		e.encodeTime(v.Interface().(time.Time))
+		return nil
+	case bigIntType:
+		x := v.Interface().(big.Int)
+		e.encodeBigInt(&x)
+		return nil
+	case tagType:
+		tag := v.Interface().(Tag)
+		e.buf = appendHead(e.buf, majorTag, tag.Number)
+		return e.nested(func() error { return e.encode(reflect.ValueOf(tag.Content), false) })
+	case simpleType:
+		e.appendSimple(uint8(v.Uint()))
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			e.buf = append(e.buf, cborTrue)
+		} else {
+			e.buf = append(e.buf, cborFalse)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.buf = appendInt(e.buf, v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.buf = appendHead(e.buf, majorUint, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		e.buf = appendFloat(e.buf, v.Float())
+	case reflect.String:
+		e.buf = appendHead(e.buf, majorText, uint64(v.Len()))
+		e.buf = append(e.buf, v.String()...)
+	case reflect.Interface:
+		if v.IsNil() {
+			e.buf = append(e.buf, cborNull)
+			return nil
+		}
+		return e.encode(v.Elem(), false)
+	case reflect.Pointer:
+		if v.IsNil() {
+			e.buf = append(e.buf, cborNull)
+			return nil
+		}
+		return e.nested(func() error { return e.encode(v.Elem(), false) })
+	case reflect.Slice:
+		if v.IsNil() && e.opts.nilSliceAsNull {
+			e.buf = append(e.buf, cborNull)
+			return nil
+		}
+		if t.Elem().Kind() == reflect.Uint8 && !t.Elem().Implements(marshalerType) {
+			e.buf = appendHead(e.buf, majorBytes, uint64(v.Len()))
+			e.buf = append(e.buf, v.Bytes()...)
+			return nil
+		}
+		return e.encodeArray(v)
+	case reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && !t.Elem().Implements(marshalerType) {
+			e.buf = appendHead(e.buf, majorBytes, uint64(v.Len()))
+			for i := range v.Len() {
+				e.buf = append(e.buf, byte(v.Index(i).Uint()))
+			}
+			return nil
+		}
+		return e.encodeArray(v)
+	case reflect.Map:
+		if v.IsNil() && e.opts.nilMapAsNull {
+			e.buf = append(e.buf, cborNull)
+			return nil
+		}
+		return e.nested(func() error { return e.encodeMap(v) })
+	case reflect.Struct:
+		return e.nested(func() error { return e.encodeStruct(v) })
+	default:
+		return &UnsupportedTypeError{t}
+	}
+	return nil
+}
+
+// nested calls f to encode the content of an array, map, tag, or
+// pointer, limiting the depth of nesting, which also stops the
+// encoding of cyclic values.
+func (e *encodeState) nested(f func() error) error {
+	if e.depth++; e.depth > maxNestingDepth {
+		return &UnsupportedValueError{Str: "exceeded maximum nesting depth"}
+	}
+	err := f()
+	e.depth--
+	return err
+}
+
+// An UnsupportedValueError is returned by [Marshal] when attempting to
+// encode an unsupported value, such as a cyclic data structure.
+type UnsupportedValueError struct {
+	Str string
+}
+
+func (e *UnsupportedValueError) Error() string {
+	return "cbor: unsupported value: " + e.Str
+}
+
+func (e *encodeState) encodeMarshaler(v reflect.Value) error {
+	b, err := v.Interface().(Marshaler).MarshalCBOR()
+	if err != nil {
+		return &MarshalerError{v.Type(), err}
+	}
+	n, err := checkWellFormed(b, 0)
+	if err == nil && n != len(b) {
+		err = &SyntaxError{msg: "extra data after data item", Offset: int64(n)}
+	}
+	if err != nil {
+		return &MarshalerError{v.Type(), err}
+	}
+	e.buf = append(e.buf, b...)
+	return nil
+}
+
+func (e *encodeState) encodeArray(v reflect.Value) error {
+	e.buf = appendHead(e.buf, majorArray, uint64(v.Len()))
+	return e.nested(func() error {
+		for i := range v.Len() {
+			if err := e.encode(v.Index(i), false); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (e *encodeState) encodeMap(v reflect.Value) error {
+	e.buf = appendHead(e.buf, majorMap, uint64(v.Len()))
+	if !e.opts.deterministic {
+		for iter := v.MapRange(); iter.Next(); {
+			if err := e.encode(iter.Key(), false); err != nil {
+				return err
+			}
+			if err := e.encode(iter.Value(), false); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Encode the entries, then sort them by their encoded keys.
+	type entry struct {
+		key, entry []byte
+	}
+	entries := make([]entry, 0, v.Len())
+	start := len(e.buf)
+	for iter := v.MapRange(); iter.Next(); {
+		off := len(e.buf)
+		if err := e.encode(iter.Key(), false); err != nil {
+			return err
+		}
+		keyEnd := len(e.buf)
+		if err := e.encode(iter.Value(), false); err != nil {
+			return err
+		}
+		entries = append(entries, entry{key: e.buf[off:keyEnd], entry: e.buf[off:len(e.buf)]})
+	}
+	slices.SortFunc(entries, func(a, b entry) int {
+		return bytes.Compare(a.key, b.key)
+	})
+	sorted := make([]byte, 0, len(e.buf)-start)
+	for _, ent := range entries {
+		sorted = append(sorted, ent.entry...)
+	}
+	copy(e.buf[start:], sorted)
+	return nil
+}
+
+func (e *encodeState) encodeStruct(v reflect.Value) error {
+	sf := cachedTypeFields(v.Type())
+	if sf.err != nil {
+		return sf.err
+	}
+	if sf.toArray {
+		e.buf = appendHead(e.buf, majorArray, uint64(len(sf.list)))
+		for i := range sf.list {
+			fv := fieldByIndex(v, sf.list[i].index, false)
+			if err := e.encode(fv, false); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	order := sf.sorted
+	if !e.opts.deterministic {
+		order = nil
+	}
+	// Count the entries first, since the length precedes them.
+	n := 0
+	for i := range sf.list {
+		if e.includeField(v, &sf.list[i]) {
+			n++
+		}
+	}
+	e.buf = appendHead(e.buf, majorMap, uint64(n))
+	for k := range sf.list {
+		i := k
+		if order != nil {
+			i = order[k]
+		}
+		f := &sf.list[i]
+		if !e.includeField(v, f) {
+			continue
+		}
+		e.buf = append(e.buf, f.encKey...)
+		if err := e.encode(fieldByIndex(v, f.index, false), false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// includeField reports whether the field f of the struct v is encoded.
+func (e *encodeState) includeField(v reflect.Value, f *field) bool {
+	fv := fieldByIndex(v, f.index, false)
+	if !fv.IsValid() {
+		// The field is in a nil embedded struct.
+		return false
+	}
+	if f.omitEmpty && isEmptyValue(fv) {
+		return false
+	}
+	if f.omitZero || e.opts.omitZero {
+		if f.isZero != nil {
+			return !f.isZero(fv)
+		}
+		return !fv.IsZero()
+	}
+	return true
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
+
+// encodeTime encodes t with tag 1, as seconds since the Unix epoch.
+func (e *encodeState) encodeTime(t time.Time) {
+	e.buf = appendHead(e.buf, majorTag, 1)
+	if t.Nanosecond() == 0 {
+		e.buf = appendInt(e.buf, t.Unix())
+		return
+	}
+	e.buf = appendFloat(e.buf, float64(t.Unix())+float64(t.Nanosecond())/1e9)
+}
+
+var maxUint64 = new(big.Int).SetUint64(math.MaxUint64)
+
+// encodeBigInt encodes x as an integer if it fits in one, and as a
+// bignum otherwise.
+func (e *encodeState) encodeBigInt(x *big.Int) {
+	if x.Sign() >= 0 {
+		if x.IsUint64() {
+			e.buf = appendHead(e.buf, majorUint, x.Uint64())
+			return
+		}
+		e.buf = appendHead(e.buf, majorTag, 2)
+		b := x.Bytes()
+		e.buf = appendHead(e.buf, majorBytes, uint64(len(b)))
+		e.buf = append(e.buf, b...)
+		return
+	}
+	// A negative integer n is encoded as -1-n.
+	m := new(big.Int).Neg(x)
+	m.Sub(m, big.NewInt(1))
+	if m.IsUint64() {
+		e.buf = appendHead(e.buf, majorNegInt, m.Uint64())
+		return
+	}
+	e.buf = appendHead(e.buf, majorTag, 3)
+	b := m.Bytes()
+	e.buf = appendHead(e.buf, majorBytes, uint64(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+func (e *encodeState) appendSimple(v uint8) {
+	if v < 24 {
+		e.buf = append(e.buf, majorSimple<<5|v)
+		return
+	}
+	e.buf = append(e.buf, majorSimple<<5|24, v)
+}
+
+// appendHead appends the head of a data item with the given major type
+// and argument, in its shortest form.
+func appendHead(b []byte, major byte, n uint64) []byte {
+	m := major << 5
+	switch {
+	case n < 24:
+		return append(b, m|byte(n))
+	case n <= math.MaxUint8:
+		return append(b, m|24, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, m|25), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, m|26), uint32(n))
+	}
+	return binary.BigEndian.AppendUint64(append(b, m|27), n)
+}
+
+func appendInt(b []byte, n int64) []byte {
+	if n >= 0 {
+		return appendHead(b, majorUint, uint64(n))
+	}
+	return appendHead(b, majorNegInt, uint64(-1-n))
+}
+
+// appendFloat appends f as the shortest float that represents it
+// exactly.
+func appendFloat(b []byte, f float64) []byte {
+	if math.IsNaN(f) {
+		return append(b, 0xf9, 0x7e, 0x00)
+	}
+	if f32 := float32(f); float64(f32) == f {
+		if h, ok := float16Bits(f32); ok {
+			return binary.BigEndian.AppendUint16(append(b, 0xf9), h)
+		}
+		return binary.BigEndian.AppendUint32(append(b, 0xfa), math.Float32bits(f32))
+	}
+	return binary.BigEndian.AppendUint64(append(b, 0xfb), math.Float64bits(f))
+}
+
+// float16Bits returns the bits of the half-precision float equal to f,
+// and reports whether there is one. f must not be NaN.
+func float16Bits(f float32) (uint16, bool) {
+	bits := math.Float32bits(f)
+	sign := uint16(bits>>16) & 0x8000
+	exp := int(bits>>23) & 0xff
+	mant := bits & 0x7fffff
+	switch {
+	case exp == 0xff:
+		return sign | 0x7c00, true // infinity
+	case exp == 0 && mant == 0:
+		return sign, true // zero
+	case exp == 0:
+		return 0, false // subnormal float32, too small for a float16
+	}
+	switch e := exp - 127; {
+	case -14 <= e && e <= 15:
+		// A normal float16 has 10 bits of mantissa.
+		if mant&0x1fff != 0 {
+			return 0, false
+		}
+		return sign | uint16(e+15)<<10 | uint16(mant>>13), true
+	case -24 <= e && e < -14:
+		// A subnormal float16 is a multiple of 2^-24.
+		sig := 0x800000 | mant
+		shift := uint(-e - 1)
+		if sig&(1<<shift-1) != 0 {
+			return 0, false
+		}
+		return sign | uint16(sig>>shift), true
+	}
+	return 0, false
+}
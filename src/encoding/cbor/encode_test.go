@@ -0,0 +1,365 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cbor
+
+import (
+	"encoding/hex"
+	"errors"
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func bigInt(s string) *big.Int {
+	x, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		panic("bad big.Int " + s)
+	}
+	return x
+}
+
+// Examples from RFC 8949, appendix A, that this package encodes in the
+// same way.
+var rfcTests = []struct {
+	value any
+	hex   string
+}{
+	{uint64(0), "00"},
+	{uint64(1), "01"},
+	{uint64(10), "0a"},
+	{uint64(23), "17"},
+	{uint64(24), "1818"},
+	{uint64(25), "1819"},
+	{uint64(100), "1864"},
+	{uint64(1000), "1903e8"},
+	{uint64(1000000), "1a000f4240"},
+	{uint64(1000000000000), "1b000000e8d4a51000"},
+	{uint64(18446744073709551615), "1bffffffffffffffff"},
+	{bigInt("18446744073709551616"), "c249010000000000000000"},
+	{bigInt("-18446744073709551616"), "3bffffffffffffffff"},
+	{bigInt("-18446744073709551617"), "c349010000000000000000"},
+	{int64(-1), "20"},
+	{int64(-10), "29"},
+	{int64(-100), "3863"},
+	{int64(-1000), "3903e7"},
+	{0.0, "f90000"},
+	{math.Copysign(0, -1), "f98000"},
+	{1.0, "f93c00"},
+	{1.1, "fb3ff199999999999a"},
+	{1.5, "f93e00"},
+	{65504.0, "f97bff"},
+	{100000.0, "fa47c35000"},
+	{3.4028234663852886e+38, "fa7f7fffff"},
+	{1.0e+300, "fb7e37e43c8800759c"},
+	{5.960464477539063e-8, "f90001"},
+	{0.00006103515625, "f90400"},
+	{-4.0, "f9c400"},
+	{-4.1, "fbc010666666666666"},
+	{math.Inf(1), "f97c00"},
+	{math.Inf(-1), "f9fc00"},
+	{false, "f4"},
+	{true, "f5"},
+	{nil, "f6"},
+	{SimpleValue(16), "f0"},
+	{SimpleValue(255), "f8ff"},
+	{time.Unix(1363896240, 0).UTC(), "c11a514b67b0"},
+	{time.Unix(1363896240, 500000000).UTC(), "c1fb41d452d9ec200000"},
+	{Tag{23, []byte{1, 2, 3, 4}}, "d74401020304"},
+	{Tag{24, []byte("dIETF")}, "d818456449455446"},
+	{Tag{32, "http://www.example.com"}, "d82076687474703a2f2f7777772e6578616d706c652e636f6d"},
+	{[]byte{}, "40"},
+	{[]byte{1, 2, 3, 4}, "4401020304"},
+	{"", "60"},
+	{"a", "6161"},
+	{"IETF", "6449455446"},
+	{"\"\\", "62225c"},
+	{"ü", "62c3bc"},
+	{"水", "63e6b0b4"},
+	{"\U00010151", "64f0908591"},
+	{[]any{}, "80"},
+	{[]any{uint64(1), uint64(2), uint64(3)}, "83010203"},
+	{[]any{uint64(1), []any{uint64(2), uint64(3)}, []any{uint64(4), uint64(5)}}, "8301820203820405"},
+	{map[any]any{}, "a0"},
+	{map[any]any{uint64(1): uint64(2), uint64(3): uint64(4)}, "a201020304"},
+	{map[any]any{"a": uint64(1), "b": []any{uint64(2), uint64(3)}}, "a26161016162820203"},
+	{[]any{"a", map[any]any{"b": "c"}}, "826161a161626163"},
+	{map[any]any{"a": "A", "b": "B", "c": "C", "d": "D", "e": "E"}, "a56161614161626142616361436164614461656145"},
+}
+
+func TestMarshalRFC(t *testing.T) {
+	for _, tt := range rfcTests {
+		got, err := Marshal(tt.value, Deterministic(true))
+		if err != nil {
+			t.Errorf("Marshal(%#v): %v", tt.value, err)
+			continue
+		}
+		if want := mustHex(tt.hex); string(got) != string(want) {
+			t.Errorf("Marshal(%#v) = %x, want %x", tt.value, got, want)
+		}
+	}
+}
+
+func TestMarshalNaN(t *testing.T) {
+	for _, f := range []any{math.NaN(), float32(math.NaN())} {
+		got, err := Marshal(f)
+		if err != nil || string(got) != "\xf9\x7e\x00" {
+			t.Errorf("Marshal(%v) = %x, %v, want f97e00", f, got, err)
+		}
+	}
+}
+
+func TestFloat16(t *testing.T) {
+	// Every float16 must survive a round trip through float64.
+	for h := range 1 << 16 {
+		f := float16ToFloat64(uint16(h))
+		if math.IsNaN(f) {
+			continue
+		}
+		got, ok := float16Bits(float32(f))
+		if !ok || got != uint16(h) {
+			t.Fatalf("float16Bits(%v) = %#04x, %v, want %#04x", f, got, ok, h)
+		}
+	}
+	for _, f := range []float32{1.0 / 3, 65520, 1e-8, math.SmallestNonzeroFloat32, 1 + 1.0/2048} {
+		if h, ok := float16Bits(f); ok {
+			t.Errorf("float16Bits(%v) = %#04x, want no float16", f, h)
+		}
+	}
+}
+
+type point struct {
+	X int `cbor:"x"`
+	Y int `cbor:"y,omitempty"`
+}
+
+type coseKey struct {
+	Kty   int    `cbor:"1,keyasint"`
+	Alg   int    `cbor:"3,keyasint,omitempty"`
+	Crv   int    `cbor:"-1,keyasint"`
+	X     []byte `cbor:"-2,keyasint"`
+	Extra string `cbor:"-"`
+}
+
+type record struct {
+	_    struct{} `cbor:",toarray"`
+	Name string
+	Age  uint8
+}
+
+type Base struct {
+	ID   int
+	Kind string
+}
+
+type derived struct {
+	*Base
+	Kind  string
+	Color string    `cbor:",omitzero"`
+	When  time.Time `cbor:",omitzero"`
+}
+
+var structTests = []struct {
+	value any
+	hex   string
+}{
+	{point{1, 2}, "a2617801617902"},
+	{point{1, 0}, "a1617801"},
+	{&point{}, "a1617800"},
+	// {1: 2, 3: -7, -1: 1, -2: h'0102'}
+	{coseKey{Kty: 2, Alg: -7, Crv: 1, X: []byte{1, 2}, Extra: "x"}, "a401020326200121420102"},
+	{record{Name: "Bob", Age: 42}, "8263426f62182a"},
+	{derived{Kind: "k"}, "a1644b696e64616b"},
+	{derived{Base: &Base{ID: 7}, Kind: "k", Color: "red"}, "a362494407644b696e64616b65436f6c6f7263726564"},
+}
+
+func TestMarshalStruct(t *testing.T) {
+	for _, tt := range structTests {
+		got, err := Marshal(tt.value, Deterministic(true))
+		if err != nil {
+			t.Errorf("Marshal(%#v): %v", tt.value, err)
+			continue
+		}
+		if want := mustHex(tt.hex); string(got) != string(want) {
+			t.Errorf("Marshal(%#v) = %x, want %x", tt.value, got, want)
+		}
+	}
+}
+
+func TestMarshalOptions(t *testing.T) {
+	type S struct {
+		A []int
+		M map[string]int
+		P *int
+	}
+	tests := []struct {
+		opts []Options
+		hex  string
+	}{
+		{nil, "a3614180614da06150f6"},
+		{[]Options{FormatNilSliceAsNull(true)}, "a36141f6614da06150f6"},
+		{[]Options{FormatNilMapAsNull(true)}, "a3614180614df66150f6"},
+		{[]Options{OmitZeroStructFields(true)}, "a0"},
+	}
+	for _, tt := range tests {
+		got, err := Marshal(S{}, tt.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := mustHex(tt.hex); string(got) != string(want) {
+			t.Errorf("Marshal with %d options = %x, want %x", len(tt.opts), got, want)
+		}
+	}
+}
+
+func TestDeterministicMap(t *testing.T) {
+	m := map[any]any{
+		"aa":        1,
+		"b":         2,
+		uint64(10):  3,
+		int64(-1):   4,
+		uint64(100): 5,
+		false:       6,
+		"ü":         7,
+		"":          8,
+	}
+	want := mustHex("a8" +
+		"0a03" + // 10
+		"186405" + // 100
+		"2004" + // -1
+		"6008" + // ""
+		"616202" + // "b"
+		"62616101" + // "aa"
+		"62c3bc07" + // "ü"
+		"f406") // false
+	for range 10 {
+		got, err := Marshal(m, Deterministic(true))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(want) {
+			t.Fatalf("Marshal = %x, want %x", got, want)
+		}
+	}
+}
+
+type marshalerValue int
+
+func (v marshalerValue) MarshalCBOR() ([]byte, error) {
+	if v < 0 {
+		return nil, errors.New("negative")
+	}
+	return Marshal(Tag{100, int(v)})
+}
+
+type badMarshaler struct{}
+
+func (badMarshaler) MarshalCBOR() ([]byte, error) {
+	return []byte{0x82, 0x01}, nil
+}
+
+func TestMarshaler(t *testing.T) {
+	got, err := Marshal([]any{marshalerValue(1), RawMessage{0x20}, RawMessage(nil)})
+	if want := mustHex("83d8640120f6"); err != nil || string(got) != string(want) {
+		t.Errorf("Marshal = %x, %v, want %x", got, err, want)
+	}
+
+	var me *MarshalerError
+	if _, err := Marshal(marshalerValue(-1)); !errors.As(err, &me) || me.Err.Error() != "negative" {
+		t.Errorf("Marshal of failing Marshaler: %v, want MarshalerError", err)
+	}
+	if _, err := Marshal(badMarshaler{}); !errors.As(err, &me) {
+		t.Errorf("Marshal of malformed Marshaler output: %v, want MarshalerError", err)
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	type cyclic struct {
+		Next *cyclic
+	}
+	c := &cyclic{}
+	c.Next = c
+	type badKey struct {
+		A int `cbor:"x,keyasint"`
+	}
+
+	var ute *UnsupportedTypeError
+	for _, v := range []any{make(chan int), func() {}, complex(1, 2), []any{map[string]any{"f": func() {}}}} {
+		if _, err := Marshal(v); !errors.As(err, &ute) {
+			t.Errorf("Marshal(%T) error = %v, want UnsupportedTypeError", v, err)
+		}
+	}
+	var uve *UnsupportedValueError
+	if _, err := Marshal(c); !errors.As(err, &uve) {
+		t.Errorf("Marshal of cyclic value: %v, want UnsupportedValueError", err)
+	}
+	if _, err := Marshal(badKey{}); err == nil {
+		t.Errorf("Marshal with invalid keyasint succeeded")
+	}
+}
+
+type celsius float64
+
+type tagged struct {
+	T celsius
+}
+
+func TestTagSet(t *testing.T) {
+	tags := NewTagSet()
+	if err := tags.Add(1000, reflect.TypeFor[celsius]()); err != nil {
+		t.Fatal(err)
+	}
+	for _, add := range []struct {
+		num uint64
+		typ reflect.Type
+	}{
+		{1000, reflect.TypeFor[point]()},
+		{1001, reflect.TypeFor[celsius]()},
+		{1002, reflect.TypeFor[*point]()},
+		{1003, reflect.TypeFor[any]()},
+		{1004, reflect.TypeFor[time.Time]()},
+		{1, reflect.TypeFor[point]()},
+	} {
+		if err := tags.Add(add.num, add.typ); err == nil {
+			t.Errorf("Add(%d, %v) succeeded, want error", add.num, add.typ)
+		}
+	}
+
+	b, err := Marshal(tagged{21.5}, WithTags(tags))
+	if want := mustHex("a16154d903e8f94d60"); err != nil || string(b) != string(want) {
+		t.Fatalf("Marshal = %x, %v, want %x", b, err, want)
+	}
+
+	var got tagged
+	if err := Unmarshal(b, &got, WithTags(tags)); err != nil || got.T != 21.5 {
+		t.Errorf("Unmarshal = %v, %v, want 21.5", got, err)
+	}
+	var x any
+	if err := Unmarshal(b, &x, WithTags(tags)); err != nil || !reflect.DeepEqual(x, map[any]any{"T": celsius(21.5)}) {
+		t.Errorf("Unmarshal into any = %#v, %v", x, err)
+	}
+	// Without the tag set, the tag is ignored or kept.
+	if err := Unmarshal(b, &got); err != nil || got.T != 21.5 {
+		t.Errorf("Unmarshal without tags = %v, %v, want 21.5", got, err)
+	}
+	if err := Unmarshal(b, &x); err != nil || !reflect.DeepEqual(x, map[any]any{"T": Tag{1000, 21.5}}) {
+		t.Errorf("Unmarshal into any without tags = %#v, %v", x, err)
+	}
+	// A registered tag cannot be decoded into another type.
+	var f float64
+	var ute *UnmarshalTypeError
+	if err := Unmarshal(mustHex("d903e8f94d60"), &f, WithTags(tags)); !errors.As(err, &ute) {
+		t.Errorf("Unmarshal into float64: %v, want UnmarshalTypeError", err)
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cbor_test
+
+import (
+	"bytes"
+	"encoding/cbor"
+	"fmt"
+	"io"
+	"log"
+	"reflect"
+)
+
+func ExampleMarshal() {
+	type Sensor struct {
+		ID      string    `cbor:"id"`
+		Reading []float64 `cbor:"r"`
+		Battery int       `cbor:"bat,omitempty"`
+	}
+	b, err := cbor.Marshal(Sensor{ID: "s1", Reading: []float64{21.5, 1.1}}, cbor.Deterministic(true))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%x\n", b)
+
+	var s Sensor
+	if err := cbor.Unmarshal(b, &s); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%+v\n", s)
+	// Output:
+	// a2617282f94d60fb3ff199999999999a626964627331
+	// {ID:s1 Reading:[21.5 1.1] Battery:0}
+}
+
+// This example decodes a COSE key (RFC 9052), which uses integer map
+// keys.
+func Example_keyAsInt() {
+	type Key struct {
+		Kty int    `cbor:"1,keyasint"`
+		Crv int    `cbor:"-1,keyasint"`
+		X   []byte `cbor:"-2,keyasint"`
+		Y   []byte `cbor:"-3,keyasint"`
+	}
+	// {1: 2, -1: 1, -2: h'0102', -3: h'0304'}
+	data := []byte{0xa4, 0x01, 0x02, 0x20, 0x01, 0x21, 0x42, 0x01, 0x02, 0x22, 0x42, 0x03, 0x04}
+	var k Key
+	if err := cbor.Unmarshal(data, &k); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%+v\n", k)
+	// Output:
+	// {Kty:2 Crv:1 X:[1 2] Y:[3 4]}
+}
+
+func ExampleTagSet() {
+	type Celsius float64
+	tags := cbor.NewTagSet()
+	if err := tags.Add(60000, reflect.TypeFor[Celsius]()); err != nil {
+		log.Fatal(err)
+	}
+
+	b, err := cbor.Marshal([]any{Celsius(21.5), 21.5}, cbor.WithTags(tags))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%x\n", b)
+
+	var v []any
+	if err := cbor.Unmarshal(b, &v, cbor.WithTags(tags)); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%T %T\n", v[0], v[1])
+	// Output:
+	// 82d9ea60f94d60f94d60
+	// cbor_test.Celsius float64
+}
+
+func ExampleDecoder() {
+	// A CBOR sequence (RFC 8742) of three data items.
+	data := []byte{0x01, 0x63, 'a', 'b', 'c', 0x82, 0xf5, 0xf6}
+	dec := cbor.NewDecoder(bytes.NewReader(data))
+	for {
+		var v any
+		if err := dec.Decode(&v); err == io.EOF {
+			break
+		} else if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%#v\n", v)
+	}
+	// Output:
+	// 0x1
+	// "abc"
+	// []interface {}{true, interface {}(nil)}
+}
@@ -0,0 +1,255 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cbor
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// A field is a struct field encoded as a map entry or array element.
+type field struct {
+	name      string // key of the field, as a string
+	goName    string // name of the Go field, for errors
+	keyAsInt  bool   // whether the key is the integer key
+	key       int64
+	encKey    []byte // encoded key
+	index     []int
+	typ       reflect.Type
+	tagged    bool
+	omitEmpty bool
+	omitZero  bool
+	isZero    func(reflect.Value) bool // IsZero method, if any
+}
+
+// structFields describes the encoding of a struct type.
+type structFields struct {
+	list    []field
+	sorted  []int // indexes of list in the order of encKey
+	toArray bool
+	byName  map[string]int
+	byInt   map[int64]int
+	err     error // error in the struct tags, if any
+}
+
+var fieldCache sync.Map // map[reflect.Type]*structFields
+
+// cachedTypeFields is like typeFields but uses a cache to avoid
+// repeated work.
+func cachedTypeFields(t reflect.Type) *structFields {
+	if f, ok := fieldCache.Load(t); ok {
+		return f.(*structFields)
+	}
+	f, _ := fieldCache.LoadOrStore(t, typeFields(t))
+	return f.(*structFields)
+}
+
+type isZeroer interface {
+	IsZero() bool
+}
+
+var isZeroerType = reflect.TypeFor[isZeroer]()
+
+// typeFields returns the fields that should be encoded for the struct
+// type t, promoting the fields of embedded structs with the rules of
+// encoding/json: among fields with the same key, a field at a shallower
+// depth hides the others, and otherwise a single tagged field hides the
+// others, while several are all ignored.
+func typeFields(t reflect.Type) *structFields {
+	sf := &structFields{byName: make(map[string]int), byInt: make(map[int64]int)}
+
+	type queued struct {
+		typ   reflect.Type
+		index []int
+	}
+	current := []queued{}
+	next := []queued{{typ: t}}
+	visited := map[reflect.Type]bool{}
+	var fields []field
+	depthOf := map[int]int{} // depth of each field in fields
+	for depth := 0; len(next) > 0; depth++ {
+		current, next = next, current[:0]
+		for _, q := range current {
+			if visited[q.typ] {
+				continue
+			}
+			visited[q.typ] = true
+			for i := range q.typ.NumField() {
+				f := q.typ.Field(i)
+				tag := f.Tag.Get("cbor")
+				if tag == "-" {
+					continue
+				}
+				name, opts, _ := strings.Cut(tag, ",")
+				if f.Name == "_" {
+					if depth == 0 && hasOption(opts, "toarray") {
+						sf.toArray = true
+					}
+					continue
+				}
+				ft := f.Type
+				if f.Anonymous {
+					if ft.Kind() == reflect.Pointer {
+						ft = ft.Elem()
+					}
+					if !f.IsExported() && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !f.IsExported() {
+					continue
+				}
+				index := append(slices.Clip(q.index), i)
+				if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+					next = append(next, queued{typ: ft, index: index})
+					continue
+				}
+				fld := field{
+					name:   f.Name,
+					goName: f.Name,
+					index:  index,
+					typ:    f.Type,
+					tagged: name != "",
+				}
+				if name != "" {
+					fld.name = name
+				}
+				for opt := range strings.SplitSeq(opts, ",") {
+					switch opt {
+					case "omitempty":
+						fld.omitEmpty = true
+					case "omitzero":
+						fld.omitZero = true
+					case "keyasint":
+						n, err := strconv.ParseInt(name, 10, 64)
+						if err != nil {
+							sf.err = fmt.Errorf("cbor: invalid keyasint name %q on field %s of %s", name, f.Name, t)
+							return sf
+						}
+						fld.keyAsInt = true
+						fld.key = n
+					}
+				}
+				if f.Type.Implements(isZeroerType) {
+					fld.isZero = func(v reflect.Value) bool {
+						if v.Kind() == reflect.Pointer && v.IsNil() {
+							return true
+						}
+						return v.Interface().(isZeroer).IsZero()
+					}
+				} else if reflect.PointerTo(f.Type).Implements(isZeroerType) {
+					fld.isZero = func(v reflect.Value) bool {
+						if !v.CanAddr() {
+							p := reflect.New(v.Type())
+							p.Elem().Set(v)
+							v = p.Elem()
+						}
+						return v.Addr().Interface().(isZeroer).IsZero()
+					}
+				}
+				if fld.keyAsInt {
+					fld.encKey = appendInt(nil, fld.key)
+				} else {
+					fld.encKey = appendHead(nil, majorText, uint64(len(fld.name)))
+					fld.encKey = append(fld.encKey, fld.name...)
+				}
+				depthOf[len(fields)] = depth
+				fields = append(fields, fld)
+			}
+		}
+	}
+
+	// Resolve fields with the same key.
+	byKey := map[string][]int{}
+	var keys []string
+	for i, f := range fields {
+		k := string(f.encKey)
+		if byKey[k] == nil {
+			keys = append(keys, k)
+		}
+		byKey[k] = append(byKey[k], i)
+	}
+	keep := map[int]bool{}
+	for _, k := range keys {
+		idx := byKey[k]
+		depth := depthOf[idx[0]]
+		for _, i := range idx {
+			depth = min(depth, depthOf[i])
+		}
+		var dominant []int
+		for _, i := range idx {
+			if depthOf[i] == depth {
+				dominant = append(dominant, i)
+			}
+		}
+		if len(dominant) > 1 {
+			var tagged []int
+			for _, i := range dominant {
+				if fields[i].tagged {
+					tagged = append(tagged, i)
+				}
+			}
+			dominant = tagged
+		}
+		if len(dominant) == 1 {
+			keep[dominant[0]] = true
+		}
+	}
+	for i, f := range fields {
+		if keep[i] {
+			sf.list = append(sf.list, f)
+		}
+	}
+	// Order fields by their index sequence, as they appear in the
+	// struct.
+	slices.SortFunc(sf.list, func(a, b field) int {
+		return slices.Compare(a.index, b.index)
+	})
+
+	for i, f := range sf.list {
+		if f.keyAsInt {
+			sf.byInt[f.key] = i
+		} else {
+			sf.byName[f.name] = i
+		}
+		sf.sorted = append(sf.sorted, i)
+	}
+	slices.SortFunc(sf.sorted, func(i, j int) int {
+		return bytes.Compare(sf.list[i].encKey, sf.list[j].encKey)
+	})
+	return sf
+}
+
+func hasOption(opts, name string) bool {
+	for opt := range strings.SplitSeq(opts, ",") {
+		if opt == name {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldByIndex returns the field of the struct v with the given index,
+// allocating embedded pointers to structs if alloc is set. It returns
+// an invalid Value if an embedded pointer is nil and alloc is not set.
+func fieldByIndex(v reflect.Value, index []int, alloc bool) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !alloc || !v.CanSet() {
+					return reflect.Value{}
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
@@ -0,0 +1,140 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cbor
+
+import (
+	"io"
+)
+
+// An Encoder writes a sequence of CBOR data items to an output stream.
+type Encoder struct {
+	w    io.Writer
+	opts options
+	err  error
+}
+
+// NewEncoder returns a new encoder that writes to w, configured by opts.
+func NewEncoder(w io.Writer, opts ...Options) *Encoder {
+	return &Encoder{w: w, opts: makeOptions(opts)}
+}
+
+// Encode writes the CBOR encoding of v to the stream.
+//
+// See the documentation for [Marshal] for details about the conversion
+// of Go values to CBOR.
+func (enc *Encoder) Encode(v any) error {
+	if enc.err != nil {
+		return enc.err
+	}
+	e := &encodeState{opts: enc.opts}
+	if err := e.marshal(v); err != nil {
+		return err
+	}
+	if _, err := enc.w.Write(e.buf); err != nil {
+		enc.err = err
+		return err
+	}
+	return nil
+}
+
+// A Decoder reads and decodes a sequence of CBOR data items from an
+// input stream.
+type Decoder struct {
+	r       io.Reader
+	opts    options
+	buf     []byte
+	scanp   int   // start of unread data in buf
+	scanned int64 // amount of data discarded from buf
+	err     error
+}
+
+// NewDecoder returns a new decoder that reads from r, configured by
+// opts.
+//
+// The decoder introduces its own buffering and may read data from r
+// beyond the data items requested.
+func NewDecoder(r io.Reader, opts ...Options) *Decoder {
+	return &Decoder{r: r, opts: makeOptions(opts)}
+}
+
+// Decode reads the next CBOR data item from its input and stores it in
+// the value pointed to by v. At the end of the input, Decode returns
+// [io.EOF], or [io.ErrUnexpectedEOF] if the input ends within a data
+// item.
+//
+// See the documentation for [Unmarshal] for details about the
+// conversion of CBOR into a Go value.
+func (dec *Decoder) Decode(v any) error {
+	if dec.err != nil {
+		return dec.err
+	}
+	n, err := dec.readItem()
+	if err != nil {
+		return err
+	}
+	d := &decodeState{data: dec.buf[dec.scanp : dec.scanp+n], opts: dec.opts}
+	dec.scanp += n
+	return d.unmarshal(v)
+}
+
+// InputOffset returns the offset in the input stream of the end of the
+// last data item decoded.
+func (dec *Decoder) InputOffset() int64 {
+	return dec.scanned + int64(dec.scanp)
+}
+
+// readItem reads a complete data item into dec.buf[dec.scanp:],
+// returning its length.
+func (dec *Decoder) readItem() (int, error) {
+	for {
+		if dec.scanp < len(dec.buf) {
+			n, err := checkWellFormed(dec.buf[dec.scanp:], 0)
+			if err == nil {
+				return n, nil
+			}
+			if !isUnexpectedEnd(err) {
+				err.(*SyntaxError).Offset += dec.InputOffset()
+				dec.err = err
+				return 0, err
+			}
+		}
+		if err := dec.refill(); err != nil {
+			if err == io.EOF && dec.scanp < len(dec.buf) {
+				err = io.ErrUnexpectedEOF
+			}
+			dec.err = err
+			return 0, err
+		}
+	}
+}
+
+// refill reads more data into dec.buf, discarding the data already
+// decoded.
+func (dec *Decoder) refill() error {
+	if dec.scanp > 0 {
+		dec.scanned += int64(dec.scanp)
+		n := copy(dec.buf, dec.buf[dec.scanp:])
+		dec.buf = dec.buf[:n]
+		dec.scanp = 0
+	}
+
+	const minRead = 512
+	if cap(dec.buf)-len(dec.buf) < minRead {
+		buf := make([]byte, len(dec.buf), 2*cap(dec.buf)+minRead)
+		copy(buf, dec.buf)
+		dec.buf = buf
+	}
+
+	for {
+		n, err := dec.r.Read(dec.buf[len(dec.buf):cap(dec.buf)])
+		dec.buf = dec.buf[:len(dec.buf)+n]
+		if n > 0 {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cbor
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf, Deterministic(true))
+	for _, v := range []any{1, "a", map[string]int{"b": 2, "a": 1}, nil} {
+		if err := enc.Encode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := mustHex("016161a2616101616202f6"); !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("Encode wrote %x, want %x", buf.Bytes(), want)
+	}
+	if err := enc.Encode(make(chan int)); err == nil {
+		t.Errorf("Encode(chan) succeeded")
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	want := []any{
+		uint64(1),
+		strings.Repeat("x", 1000),
+		[]any{"a", map[any]any{"b": "c"}},
+		[]byte{1, 2, 3, 4, 5},
+		nil,
+	}
+	var data []byte
+	for _, v := range want {
+		b, err := Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = append(data, b...)
+	}
+	// An indefinite-length byte string.
+	data = data[:len(data)-7]
+	data = append(data, mustHex("5f42010243030405fff6")...)
+
+	readers := map[string]func(io.Reader) io.Reader{
+		"full":    func(r io.Reader) io.Reader { return r },
+		"onebyte": iotest.OneByteReader,
+		"dataerr": iotest.DataErrReader,
+	}
+	for name, wrap := range readers {
+		dec := NewDecoder(wrap(bytes.NewReader(data)))
+		var got []any
+		var offsets []int64
+		for {
+			var v any
+			err := dec.Decode(&v)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s: Decode: %v", name, err)
+			}
+			got = append(got, v)
+			offsets = append(offsets, dec.InputOffset())
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: decoded %#v, want %#v", name, got, want)
+		}
+		if n := offsets[len(offsets)-1]; n != int64(len(data)) {
+			t.Errorf("%s: final InputOffset = %d, want %d", name, n, len(data))
+		}
+		if offsets[0] != 1 {
+			t.Errorf("%s: first InputOffset = %d, want 1", name, offsets[0])
+		}
+	}
+}
+
+func TestDecoderErrors(t *testing.T) {
+	var v any
+	dec := NewDecoder(bytes.NewReader(mustHex("018301")))
+	if err := dec.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	if err := dec.Decode(&v); err != io.ErrUnexpectedEOF {
+		t.Errorf("Decode of truncated item: %v, want io.ErrUnexpectedEOF", err)
+	}
+
+	dec = NewDecoder(bytes.NewReader(mustHex("0102ff03")))
+	for range 2 {
+		if err := dec.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var se *SyntaxError
+	if err := dec.Decode(&v); !errors.As(err, &se) || se.Offset != 2 {
+		t.Errorf("Decode of break: %v, want SyntaxError at offset 2", err)
+	}
+	// The error is sticky.
+	if err := dec.Decode(&v); !errors.As(err, &se) {
+		t.Errorf("Decode after error: %v, want SyntaxError", err)
+	}
+
+	readErr := errors.New("read error")
+	dec = NewDecoder(iotest.ErrReader(readErr))
+	if err := dec.Decode(&v); err != readErr {
+		t.Errorf("Decode with failing reader: %v, want %v", err, readErr)
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cbor
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+// A TagSet is a registry of tag numbers for Go types. Given to
+// [Marshal] or [Unmarshal] with [WithTags], it makes values of a
+// registered type encode as data items tagged with its number, and
+// data items with a registered tag number decode into values of its
+// type when decoded into an interface value.
+//
+// A TagSet is safe for concurrent use by multiple goroutines.
+type TagSet struct {
+	mu       sync.RWMutex
+	byType   map[reflect.Type]uint64
+	byNumber map[uint64]reflect.Type
+}
+
+// NewTagSet returns an empty TagSet.
+func NewTagSet() *TagSet {
+	return &TagSet{
+		byType:   make(map[reflect.Type]uint64),
+		byNumber: make(map[uint64]reflect.Type),
+	}
+}
+
+// Add registers the tag number for the type typ. It returns an error
+// if the number or type is already registered, if typ is a pointer or
+// interface type, or if number or typ is one that the package itself
+// encodes with a tag, such as tag 1 and [time.Time].
+func (s *TagSet) Add(number uint64, typ reflect.Type) error {
+	if typ == nil {
+		return errors.New("cbor: TagSet.Add of nil type")
+	}
+	switch typ.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return errors.New("cbor: TagSet.Add of " + typ.Kind().String() + " type " + typ.String())
+	}
+	switch typ {
+	case timeType, bigIntType, tagType, simpleType, rawMessageType:
+		return errors.New("cbor: TagSet.Add of built-in type " + typ.String())
+	}
+	if number <= 3 {
+		return errors.New("cbor: TagSet.Add of built-in tag " + strconv.FormatUint(number, 10))
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t, ok := s.byNumber[number]; ok {
+		return errors.New("cbor: tag " + strconv.FormatUint(number, 10) + " already registered for " + t.String())
+	}
+	if n, ok := s.byType[typ]; ok {
+		return errors.New("cbor: type " + typ.String() + " already registered for tag " + strconv.FormatUint(n, 10))
+	}
+	s.byType[typ] = number
+	s.byNumber[number] = typ
+	return nil
+}
+
+// number returns the tag number registered for t.
+func (s *TagSet) number(t reflect.Type) (uint64, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	n, ok := s.byType[t]
+	return n, ok
+}
+
+// typ returns the type registered for the tag number n.
+func (s *TagSet) typ(n uint64) (reflect.Type, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	t, ok := s.byNumber[n]
+	return t, ok
+}
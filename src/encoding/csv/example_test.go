@@ -11,6 +11,7 @@ import (
 	"log"
 	"os"
 	"strings"
+	"time"
 )
 
 func ExampleReader() {
@@ -129,3 +130,55 @@ func ExampleWriter_WriteAll() {
 	// Ken,Thompson,ken
 	// Robert,Griesemer,gri
 }
+
+func ExampleMarshal() {
+	type User struct {
+		FirstName string `csv:"first_name"`
+		LastName  string `csv:"last_name"`
+		Username  string `csv:"username"`
+		Admin     bool   `csv:"admin,omitempty"`
+	}
+	users := []User{
+		{"Rob", "Pike", "rob", true},
+		{"Ken", "Thompson", "ken", false},
+	}
+
+	data, err := csv.Marshal(users)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(string(data))
+	// Output:
+	// first_name,last_name,username,admin
+	// Rob,Pike,rob,true
+	// Ken,Thompson,ken,
+}
+
+func ExampleDecoder() {
+	in := `username,joined,first_name
+rob,2009-11-10,Rob
+ken,2009-11-10,Ken
+`
+	type User struct {
+		FirstName string    `csv:"first_name"`
+		Username  string    `csv:"username"`
+		Joined    time.Time `csv:"joined,format:DateOnly"`
+	}
+	d := csv.NewDecoder(csv.NewReader(strings.NewReader(in)))
+
+	for {
+		var u User
+		err := d.Decode(&u)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		fmt.Println(u.Username, u.FirstName, u.Joined.Format(time.DateOnly))
+	}
+	// Output:
+	// rob Rob 2009-11-10
+	// ken Ken 2009-11-10
+}
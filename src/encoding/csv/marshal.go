@@ -0,0 +1,596 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package csv
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Marshal returns the CSV encoding of v, which must be a slice or array
+// of structs or of pointers to structs. The encoding is a header record
+// of column names followed by one record for each element of v, as
+// written by an [Encoder].
+func Marshal(v any) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("csv: Marshal of %T, not a slice or array", v)
+	}
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	e := NewEncoder(w)
+	if err := e.init(rv.Type().Elem()); err != nil {
+		return nil, err
+	}
+	if err := e.writeHeader(); err != nil {
+		return nil, err
+	}
+	for i := range rv.Len() {
+		if err := e.Encode(rv.Index(i).Interface()); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal parses the CSV-encoded data, which must begin with a
+// header record of column names, and appends one element for each of
+// the following records to the slice pointed to by v. The elements of
+// the slice must be structs or pointers to structs, and the records are
+// stored in them as by a [Decoder].
+func Unmarshal(data []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("csv: Unmarshal of %T, not a non-nil pointer to a slice", v)
+	}
+	slice := rv.Elem()
+	et := slice.Type().Elem()
+	st := et
+	if st.Kind() == reflect.Pointer {
+		st = st.Elem()
+	}
+	if st.Kind() != reflect.Struct {
+		return fmt.Errorf("csv: Unmarshal of %T, not a slice of structs", v)
+	}
+	d := NewDecoder(NewReader(bytes.NewReader(data)))
+	for {
+		elem := reflect.New(st)
+		err := d.Decode(elem.Interface())
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if et.Kind() != reflect.Pointer {
+			elem = elem.Elem()
+		}
+		slice.Set(reflect.Append(slice, elem))
+	}
+}
+
+// An Encoder writes structs as CSV records to a [Writer].
+//
+// Each exported field of a struct is written as a column, named by the
+// field's struct tag with the key "csv", or by the field's name if it
+// has none. The tag may also give options after the name, separated by
+// commas:
+//
+//   - "omitempty" writes the zero value of the field as an empty field.
+//   - "format:" followed by a layout, for a [time.Time] field, writes and
+//     reads the time with [time.Time.Format] and [time.Parse] and the
+//     layout, which is either the name of a layout constant of the time
+//     package, such as "DateOnly", or a literal layout. It must be the
+//     last option, and the layout extends to the end of the tag.
+//
+// A field with the tag "-" is ignored. The fields of an embedded struct
+// without a tag are treated as fields of the outer struct.
+//
+// A field whose type implements [encoding.TextMarshaler] is written as
+// its text. Otherwise, the field must be a string, a boolean, an
+// integer, or a floating-point number, or a pointer to one of these, in
+// which case a nil pointer is written as an empty field.
+type Encoder struct {
+	w      *Writer
+	typ    reflect.Type // type of the structs, once known
+	fields []field
+	header bool // whether the header has been written
+	record []string
+}
+
+// NewEncoder returns a new Encoder that writes to w.
+func NewEncoder(w *Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes the struct v, or the struct v points to, as a record.
+// The first call writes a header record of column names before it;
+// later calls must be passed structs of the same type.
+//
+// Records are buffered by the [Writer], so [Writer.Flush] must
+// eventually be called to ensure that they are written.
+func (e *Encoder) Encode(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return fmt.Errorf("csv: Encode of nil %T", v)
+		}
+		rv = rv.Elem()
+	}
+	if e.typ == nil {
+		if err := e.init(rv.Type()); err != nil {
+			return err
+		}
+	}
+	if rv.Type() != e.typ {
+		return fmt.Errorf("csv: Encode of %s after %s", rv.Type(), e.typ)
+	}
+	if !e.header {
+		if err := e.writeHeader(); err != nil {
+			return err
+		}
+	}
+	e.record = e.record[:0]
+	for i := range e.fields {
+		f := &e.fields[i]
+		s, err := f.encode(rv)
+		if err != nil {
+			return err
+		}
+		e.record = append(e.record, s)
+	}
+	return e.w.Write(e.record)
+}
+
+// init sets the type of the structs encoded by e to t, or to the type
+// t points to.
+func (e *Encoder) init(t reflect.Type) error {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return fmt.Errorf("csv: cannot encode %s, not a struct", t)
+	}
+	fields, err := cachedTypeFields(t)
+	if err != nil {
+		return err
+	}
+	e.typ, e.fields = t, fields
+	return nil
+}
+
+func (e *Encoder) writeHeader() error {
+	e.header = true
+	header := make([]string, len(e.fields))
+	for i, f := range e.fields {
+		header[i] = f.name
+	}
+	return e.w.Write(header)
+}
+
+// A Decoder reads CSV records from a [Reader] into structs.
+//
+// The first record read is a header record of column names. Each
+// column is stored in the struct field it names, as described for
+// [Encoder], with names compared exactly or, failing that, ignoring
+// case. Columns that name no field are ignored, and fields that are
+// not named by a column are left unchanged.
+//
+// An empty field sets the struct field to its zero value, or to nil if
+// it is a pointer. A field whose type implements
+// [encoding.TextUnmarshaler] is set by calling UnmarshalText with the
+// text of the column.
+//
+// If a column cannot be stored in its field, the error is a
+// [*ParseError] giving its position, whose Err is an
+// [*UnmarshalTypeError].
+type Decoder struct {
+	r      *Reader
+	typ    reflect.Type // type of the structs, once known
+	header []string
+	fields []*field // by column; nil for ignored columns
+}
+
+// NewDecoder returns a new Decoder that reads from r.
+func NewDecoder(r *Reader) *Decoder {
+	return &Decoder{r: r}
+}
+
+// Decode reads the next record and stores it in the struct pointed to
+// by v. The first call reads the header record before it; later calls
+// must be passed pointers to structs of the same type. At the end of
+// the input, Decode returns [io.EOF].
+func (d *Decoder) Decode(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("csv: Decode of %T, not a non-nil pointer to a struct", v)
+	}
+	rv = rv.Elem()
+	if d.header == nil {
+		header, err := d.r.Read()
+		if err != nil {
+			return err
+		}
+		d.header = header
+		if d.r.ReuseRecord {
+			d.header = append([]string(nil), header...)
+		}
+	}
+	if d.typ == nil {
+		if err := d.init(rv.Type()); err != nil {
+			return err
+		}
+	}
+	if rv.Type() != d.typ {
+		return fmt.Errorf("csv: Decode of %s after %s", rv.Type(), d.typ)
+	}
+
+	record, err := d.r.Read()
+	if err != nil {
+		return err
+	}
+	for i, s := range record {
+		if i >= len(d.fields) || d.fields[i] == nil {
+			continue
+		}
+		f := d.fields[i]
+		if err := f.decode(rv, s); err != nil {
+			startLine, _ := d.r.FieldPos(0)
+			line, col := d.r.FieldPos(i)
+			return &ParseError{
+				StartLine: startLine,
+				Line:      line,
+				Column:    col,
+				Err: &UnmarshalTypeError{
+					Value:  s,
+					Column: d.header[i],
+					Field:  f.goName,
+					Type:   f.typ,
+					Err:    err,
+				},
+			}
+		}
+	}
+	return nil
+}
+
+// init maps the columns of the header to the fields of t.
+func (d *Decoder) init(t reflect.Type) error {
+	fields, err := cachedTypeFields(t)
+	if err != nil {
+		return err
+	}
+	d.typ = t
+	d.fields = make([]*field, len(d.header))
+	used := make([]bool, len(fields))
+	for i, col := range d.header {
+		j := -1
+		for k := range fields {
+			if fields[k].name == col {
+				j = k
+				break
+			}
+		}
+		if j < 0 {
+			for k := range fields {
+				if strings.EqualFold(fields[k].name, col) {
+					j = k
+					break
+				}
+			}
+		}
+		if j < 0 {
+			continue
+		}
+		if used[j] {
+			return fmt.Errorf("csv: columns of header name field %s more than once", fields[j].goName)
+		}
+		used[j] = true
+		d.fields[i] = &fields[j]
+	}
+	return nil
+}
+
+// An UnmarshalTypeError describes a CSV field that could not be stored
+// in a struct field.
+type UnmarshalTypeError struct {
+	Value  string       // the text of the CSV field
+	Column string       // the name of its column
+	Field  string       // the name of the struct field, qualified by its type
+	Type   reflect.Type // the type of the struct field
+	Err    error        // the error from parsing Value, if any
+}
+
+func (e *UnmarshalTypeError) Error() string {
+	return fmt.Sprintf("csv: cannot unmarshal %q in column %q into field %s of type %s: %v", e.Value, e.Column, e.Field, e.Type, e.Err)
+}
+
+func (e *UnmarshalTypeError) Unwrap() error { return e.Err }
+
+// A field is a struct field encoded as a column.
+type field struct {
+	name      string // column name
+	goName    string // name of the field, qualified by its type, for errors
+	index     []int
+	typ       reflect.Type
+	omitEmpty bool
+	layout    string // time layout from the format option, if any
+}
+
+var (
+	textMarshalerType   = reflect.TypeFor[encoding.TextMarshaler]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+	timeType            = reflect.TypeFor[time.Time]()
+)
+
+// timeLayouts are the layout constants of the time package that may be
+// named by the format option.
+var timeLayouts = map[string]string{
+	"ANSIC":       time.ANSIC,
+	"UnixDate":    time.UnixDate,
+	"RubyDate":    time.RubyDate,
+	"RFC822":      time.RFC822,
+	"RFC822Z":     time.RFC822Z,
+	"RFC850":      time.RFC850,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"Kitchen":     time.Kitchen,
+	"Stamp":       time.Stamp,
+	"StampMilli":  time.StampMilli,
+	"StampMicro":  time.StampMicro,
+	"StampNano":   time.StampNano,
+	"DateTime":    time.DateTime,
+	"DateOnly":    time.DateOnly,
+	"TimeOnly":    time.TimeOnly,
+}
+
+type typeFieldsResult struct {
+	fields []field
+	err    error
+}
+
+var fieldCache sync.Map // map[reflect.Type]typeFieldsResult
+
+// cachedTypeFields is like typeFields but uses a cache.
+func cachedTypeFields(t reflect.Type) ([]field, error) {
+	if r, ok := fieldCache.Load(t); ok {
+		r := r.(typeFieldsResult)
+		return r.fields, r.err
+	}
+	fields, err := typeFields(t)
+	r, _ := fieldCache.LoadOrStore(t, typeFieldsResult{fields, err})
+	return r.(typeFieldsResult).fields, r.(typeFieldsResult).err
+}
+
+// typeFields returns the fields of the struct type t that are encoded
+// as columns, in order.
+func typeFields(t reflect.Type) ([]field, error) {
+	var fields []field
+	if err := addFields(&fields, t, nil, t.Name()); err != nil {
+		return nil, err
+	}
+	for i := range fields {
+		for j := range i {
+			if fields[i].name == fields[j].name {
+				return nil, fmt.Errorf("csv: fields %s and %s have the same column name %q", fields[j].goName, fields[i].goName, fields[i].name)
+			}
+		}
+	}
+	return fields, nil
+}
+
+func addFields(fields *[]field, t reflect.Type, index []int, prefix string) error {
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		tag, hasTag := sf.Tag.Lookup("csv")
+		if tag == "-" {
+			continue
+		}
+		idx := append(index[:len(index):len(index)], i)
+		goName := prefix + "." + sf.Name
+		if sf.Anonymous && !hasTag && sf.Type.Kind() == reflect.Struct && !isText(sf.Type) {
+			if err := addFields(fields, sf.Type, idx, goName); err != nil {
+				return err
+			}
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		f := field{name: sf.Name, goName: goName, index: idx, typ: sf.Type}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name != "" {
+			f.name = name
+		}
+		for opts != "" {
+			var opt string
+			if layout, ok := strings.CutPrefix(opts, "format:"); ok {
+				if l, ok := timeLayouts[layout]; ok {
+					layout = l
+				}
+				f.layout = layout
+				break
+			}
+			opt, opts, _ = strings.Cut(opts, ",")
+			switch opt {
+			case "omitempty":
+				f.omitEmpty = true
+			default:
+				return fmt.Errorf("csv: unknown option %q in tag of field %s", opt, goName)
+			}
+		}
+		if err := checkFieldType(&f); err != nil {
+			return err
+		}
+		*fields = append(*fields, f)
+	}
+	return nil
+}
+
+// checkFieldType reports an error if the type of f cannot be encoded.
+func checkFieldType(f *field) error {
+	t := f.typ
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if f.layout != "" {
+		if t != timeType {
+			return fmt.Errorf("csv: format option on field %s of type %s, not time.Time", f.goName, f.typ)
+		}
+		return nil
+	}
+	if isText(t) {
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return nil
+	}
+	return fmt.Errorf("csv: unsupported type %s of field %s", f.typ, f.goName)
+}
+
+// isText reports whether values of type t are encoded as text with
+// encoding.TextMarshaler and encoding.TextUnmarshaler.
+func isText(t reflect.Type) bool {
+	pt := reflect.PointerTo(t)
+	return t.Implements(textMarshalerType) || pt.Implements(textMarshalerType) || pt.Implements(textUnmarshalerType)
+}
+
+// encode returns the text of the field f of the struct v.
+func (f *field) encode(v reflect.Value) (string, error) {
+	for i, x := range f.index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	if f.omitEmpty && v.IsZero() {
+		return "", nil
+	}
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+	if f.layout != "" {
+		return v.Interface().(time.Time).Format(f.layout), nil
+	}
+	m, ok := v.Interface().(encoding.TextMarshaler)
+	if !ok && v.CanAddr() {
+		m, ok = v.Addr().Interface().(encoding.TextMarshaler)
+	}
+	if !ok && reflect.PointerTo(v.Type()).Implements(textMarshalerType) {
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		m, ok = p.Interface().(encoding.TextMarshaler)
+	}
+	if ok {
+		b, err := m.MarshalText()
+		if err != nil {
+			return "", fmt.Errorf("csv: marshaling field %s: %w", f.goName, err)
+		}
+		return string(b), nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	}
+	return "", fmt.Errorf("csv: cannot marshal field %s of type %s", f.goName, f.typ)
+}
+
+var errTextUnmarshaler = errors.New("type does not implement encoding.TextUnmarshaler")
+
+// decode stores s in the field f of the struct v.
+func (f *field) decode(v reflect.Value, s string) error {
+	for i, x := range f.index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	if s == "" {
+		v.SetZero()
+		return nil
+	}
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if f.layout != "" {
+		t, err := time.Parse(f.layout, s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	if isText(v.Type()) {
+		u, ok := v.Addr().Interface().(encoding.TextUnmarshaler)
+		if !ok {
+			return errTextUnmarshaler
+		}
+		return u.UnmarshalText([]byte(s))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package csv
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/netip"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type marshalInner struct {
+	Note string `csv:"note"`
+}
+
+type marshalRecord struct {
+	Name    string     `csv:"name"`
+	Age     int        `csv:"age"`
+	Score   float64    `csv:"score,omitempty"`
+	Active  bool       `csv:"active"`
+	Count   *uint16    `csv:"count"`
+	Addr    netip.Addr `csv:"addr"`
+	Day     time.Time  `csv:"day,format:DateOnly"`
+	Skipped string     `csv:"-"`
+	private int
+	marshalInner
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	seven := uint16(7)
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	in := []marshalRecord{
+		{Name: "Ann, Jr.", Age: 31, Score: 1.5, Active: true, Count: &seven,
+			Addr: netip.MustParseAddr("10.0.0.1"), Day: day, Skipped: "x",
+			marshalInner: marshalInner{Note: `says "hi"`}},
+		{Name: "Bob", Age: -2},
+	}
+	data, err := Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	want := `name,age,score,active,count,addr,day,note
+"Ann, Jr.",31,1.5,true,7,10.0.0.1,2026-03-01,"says ""hi"""
+Bob,-2,,false,,,0001-01-01,
+`
+	if string(data) != want {
+		t.Fatalf("Marshal:\n%s\nwant:\n%s", data, want)
+	}
+
+	var out []marshalRecord
+	if err := Unmarshal(data, &out); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	in[0].Skipped = ""
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("Unmarshal:\n%+v\nwant:\n%+v", out, in)
+	}
+}
+
+func TestUnmarshalPointers(t *testing.T) {
+	type rec struct {
+		A string
+		B *int
+	}
+	var out []*rec
+	if err := Unmarshal([]byte("a,b,extra\nx,1,y\n"), &out); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if len(out) != 1 || out[0].A != "x" || out[0].B == nil || *out[0].B != 1 {
+		t.Errorf("Unmarshal = %+v", out)
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	type rec struct {
+		X int `csv:"x"`
+	}
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	e := NewEncoder(w)
+	for i := range 3 {
+		if err := e.Encode(&rec{i}); err != nil {
+			t.Fatalf("Encode: %v", err)
+		}
+	}
+	if err := e.Encode(struct{ Y int }{}); err == nil {
+		t.Errorf("Encode of a different type succeeded")
+	}
+	w.Flush()
+	if got, want := buf.String(), "x\n0\n1\n2\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestDecoderErrors(t *testing.T) {
+	type rec struct {
+		Name string
+		Age  int
+	}
+	d := NewDecoder(NewReader(strings.NewReader("name,age\nann,31\n\"bob\nby\",old\n")))
+	var r rec
+	if err := d.Decode(&r); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	err := d.Decode(&r)
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("Decode error = %v, want a *ParseError", err)
+	}
+	if pe.StartLine != 3 || pe.Line != 4 || pe.Column != 5 {
+		t.Errorf("error position = %d:%d:%d, want 3:4:5", pe.StartLine, pe.Line, pe.Column)
+	}
+	var ute *UnmarshalTypeError
+	if !errors.As(err, &ute) {
+		t.Fatalf("Decode error = %v, want an *UnmarshalTypeError", err)
+	}
+	if ute.Value != "old" || ute.Column != "age" || ute.Field != "rec.Age" || ute.Type != reflect.TypeFor[int]() {
+		t.Errorf("UnmarshalTypeError = %+v", ute)
+	}
+	if !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("Decode error = %v, want strconv.ErrSyntax", err)
+	}
+	if err := d.Decode(&r); err != io.EOF {
+		t.Errorf("Decode at end = %v, want io.EOF", err)
+	}
+}
+
+func TestMarshalPlanErrors(t *testing.T) {
+	tests := []struct {
+		v    any
+		want string
+	}{
+		{[]int{1}, "not a struct"},
+		{struct{}{}, "not a slice or array"},
+		{[]struct {
+			A int `csv:"x"`
+			B int `csv:"x"`
+		}{}, "same column name"},
+		{[]struct {
+			A int `csv:"a,format:DateOnly"`
+		}{}, "not time.Time"},
+		{[]struct {
+			A int `csv:"a,bogus"`
+		}{}, "unknown option"},
+		{[]struct{ A []int }{}, "unsupported type"},
+	}
+	for _, tt := range tests {
+		_, err := Marshal(tt.v)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Marshal(%T) error = %v, want %q", tt.v, err, tt.want)
+		}
+	}
+}
+
+func TestDecoderDuplicateColumn(t *testing.T) {
+	var out []struct{ Name string }
+	err := Unmarshal([]byte("Name,name\na,b\n"), &out)
+	if err == nil || !strings.Contains(err.Error(), "more than once") {
+		t.Errorf("Unmarshal error = %v, want duplicate column error", err)
+	}
+}
+
+func TestTimeLayout(t *testing.T) {
+	type rec struct {
+		T *time.Time `csv:"t,format:2006/01/02 15h"`
+	}
+	var out []rec
+	if err := Unmarshal([]byte("t\n2026/10/19 08h\n\n"), &out); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	want := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	if len(out) != 1 || out[0].T == nil || !out[0].T.Equal(want) {
+		t.Fatalf("Unmarshal = %+v", out)
+	}
+	data, err := Marshal(out)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if got, want := string(data), "t\n2026/10/19 08h\n"; got != want {
+		t.Errorf("Marshal = %q, want %q", got, want)
+	}
+}
@@ -50,6 +50,10 @@
 //
 //	{`Multi-line
 //	field`, `comma is ,`}
+//
+// [Marshal] and [Unmarshal], and the streaming [Encoder] and [Decoder],
+// convert between records and structs, mapping the columns named by a
+// header record to struct fields, as directed by "csv" struct tags.
 package csv
 
 import (
@@ -0,0 +1,418 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xml
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// CanonicalOptions configures a [CanonicalEncoder].
+type CanonicalOptions struct {
+	// Exclusive selects Exclusive XML Canonicalization 1.0,
+	// https://www.w3.org/TR/xml-exc-c14n/, in which an element
+	// declares only the name spaces its name and attributes use,
+	// rather than Canonical XML 1.0, https://www.w3.org/TR/xml-c14n/,
+	// in which the first element declares every name space in scope.
+	Exclusive bool
+
+	// WithComments keeps comments in the output. By default they are
+	// removed.
+	WithComments bool
+
+	// InclusivePrefixes lists the prefixes whose declarations are
+	// written as by Canonical XML 1.0 when Exclusive is set: the
+	// InclusiveNamespaces PrefixList of the specification, in which
+	// "#default" names the default name space.
+	InclusivePrefixes []string
+
+	// Namespaces holds the name space declarations in scope at the
+	// start of the stream, mapping each prefix to its URL, with ""
+	// for the default name space. When canonicalizing an element of
+	// a larger document, such as a signed element, it holds the
+	// declarations of the element's ancestors.
+	Namespaces map[string]string
+}
+
+// A CanonicalEncoder writes a stream of XML tokens in canonical form,
+// so that equivalent documents are written as the same bytes, as
+// needed to sign and verify XML with XML Signature.
+//
+// The tokens must name elements and attributes by name space prefix,
+// as returned by [Decoder.RawToken], or by [Decoder.Token] with
+// [Decoder.PreservePrefixes] set, since canonical XML keeps the
+// prefixes of the input. The name space declarations are taken from
+// the xmlns attributes of the tokens, and written as the canonical
+// form requires.
+//
+// The XML declaration and document type declaration are removed, as
+// is white space outside the document element. Empty elements must be
+// given as a start element followed by an end element, as the Decoder
+// returns them. The xml: attributes of the ancestors of the first
+// element are not inherited by it.
+type CanonicalEncoder struct {
+	w      *bufio.Writer
+	opts   CanonicalOptions
+	root   c14nScope   // scope at the start of the stream
+	stk    []c14nScope // scopes of the open elements
+	seen   bool        // whether an element has been written
+	closed bool
+}
+
+// A c14nScope holds the name spaces in scope in an element.
+type c14nScope struct {
+	name     Name
+	ns       map[string]string // name spaces in scope, by prefix
+	rendered map[string]string // name spaces declared in the output, by prefix
+}
+
+// NewCanonicalEncoder returns a new CanonicalEncoder that writes to w,
+// as configured by opts, which may be nil.
+func NewCanonicalEncoder(w io.Writer, opts *CanonicalOptions) *CanonicalEncoder {
+	enc := &CanonicalEncoder{w: bufio.NewWriter(w)}
+	if opts != nil {
+		enc.opts = *opts
+	}
+	enc.root.ns = map[string]string{"": "", xmlPrefix: xmlURL}
+	maps.Copy(enc.root.ns, enc.opts.Namespaces)
+	enc.root.rendered = map[string]string{"": ""}
+	return enc
+}
+
+// Canonicalize reads tokens from r until [io.EOF] and writes them in
+// canonical form to w, as by a [CanonicalEncoder] configured by opts,
+// which may be nil.
+func Canonicalize(w io.Writer, r TokenReader, opts *CanonicalOptions) error {
+	enc := NewCanonicalEncoder(w, opts)
+	for {
+		t, err := r.Token()
+		if t != nil {
+			if err := enc.EncodeToken(t); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return enc.Close()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// EncodeToken writes the canonical form of t. It returns an error if
+// [StartElement] and [EndElement] tokens are not properly matched, or
+// if a name uses an undeclared prefix.
+//
+// EncodeToken does not flush its output; call [CanonicalEncoder.Close]
+// when done.
+func (enc *CanonicalEncoder) EncodeToken(t Token) error {
+	if enc.closed {
+		return fmt.Errorf("xml: use of closed CanonicalEncoder")
+	}
+	top := len(enc.stk) == 0
+	switch t := t.(type) {
+	case StartElement:
+		if err := enc.writeStart(&t); err != nil {
+			return err
+		}
+		enc.seen = true
+	case EndElement:
+		if top {
+			return fmt.Errorf("xml: end tag </%s> without start tag", t.Name.Local)
+		}
+		if name := enc.stk[len(enc.stk)-1].name; name != t.Name {
+			return fmt.Errorf("xml: end tag </%s> does not match start tag <%s>", qualifiedName(t.Name), qualifiedName(name))
+		}
+		enc.stk = enc.stk[:len(enc.stk)-1]
+		enc.w.WriteString("</")
+		enc.w.WriteString(qualifiedName(t.Name))
+		enc.w.WriteByte('>')
+	case CharData:
+		if top {
+			if len(bytes.TrimLeft(t, " \t\r\n")) > 0 {
+				return fmt.Errorf("xml: character data outside the document element")
+			}
+			return nil
+		}
+		escapeCanonicalText(enc.w, t)
+	case Comment:
+		if !enc.opts.WithComments {
+			return nil
+		}
+		if bytes.Contains(t, endComment) {
+			return fmt.Errorf("xml: EncodeToken of Comment containing --> marker")
+		}
+		enc.beforeTopLevel(top)
+		enc.w.WriteString("<!--")
+		enc.w.Write(t)
+		enc.w.WriteString("-->")
+		enc.afterTopLevel(top)
+	case ProcInst:
+		if t.Target == "xml" {
+			// The XML declaration is removed.
+			return nil
+		}
+		if !isNameString(t.Target) {
+			return fmt.Errorf("xml: EncodeToken of ProcInst with invalid Target")
+		}
+		if bytes.Contains(t.Inst, endProcInst) {
+			return fmt.Errorf("xml: EncodeToken of ProcInst containing ?> marker")
+		}
+		enc.beforeTopLevel(top)
+		enc.w.WriteString("<?")
+		enc.w.WriteString(t.Target)
+		if len(t.Inst) > 0 {
+			enc.w.WriteByte(' ')
+			enc.w.Write(t.Inst)
+		}
+		enc.w.WriteString("?>")
+		enc.afterTopLevel(top)
+	case Directive:
+		// The document type declaration is removed.
+	default:
+		return fmt.Errorf("xml: EncodeToken of invalid token type")
+	}
+	_, err := enc.w.Write(nil)
+	return err
+}
+
+// beforeTopLevel and afterTopLevel write the line breaks that separate
+// a comment or processing instruction outside the document element
+// from the document element.
+func (enc *CanonicalEncoder) beforeTopLevel(top bool) {
+	if top && enc.seen {
+		enc.w.WriteByte('\n')
+	}
+}
+
+func (enc *CanonicalEncoder) afterTopLevel(top bool) {
+	if top && !enc.seen {
+		enc.w.WriteByte('\n')
+	}
+}
+
+// writeStart writes the start element, with its name space
+// declarations and attributes in canonical order.
+func (enc *CanonicalEncoder) writeStart(start *StartElement) error {
+	parent := &enc.root
+	if len(enc.stk) > 0 {
+		parent = &enc.stk[len(enc.stk)-1]
+	}
+	if start.Name.Local == "" {
+		return fmt.Errorf("xml: start tag with no name")
+	}
+
+	// Apply the name space declarations, and set aside the other
+	// attributes.
+	scope := c14nScope{name: start.Name, ns: parent.ns, rendered: parent.rendered}
+	var attrs []Attr
+	cloned := false
+	for _, a := range start.Attr {
+		var prefix string
+		switch {
+		case a.Name.Space == xmlnsPrefix:
+			prefix = a.Name.Local
+		case a.Name.Space == "" && a.Name.Local == xmlnsPrefix:
+			prefix = ""
+		default:
+			attrs = append(attrs, a)
+			continue
+		}
+		if prefix == xmlPrefix || prefix == xmlnsPrefix {
+			// These prefixes are predefined, and never declared
+			// in the output.
+			continue
+		}
+		if scope.ns[prefix] == a.Value {
+			continue
+		}
+		if !cloned {
+			scope.ns = maps.Clone(parent.ns)
+			cloned = true
+		}
+		scope.ns[prefix] = a.Value
+	}
+
+	// Choose the declarations to write.
+	var prefixes []string
+	if enc.opts.Exclusive {
+		prefixes = append(prefixes, start.Name.Space)
+		for _, a := range attrs {
+			if a.Name.Space != "" {
+				prefixes = append(prefixes, a.Name.Space)
+			}
+		}
+		for _, p := range enc.opts.InclusivePrefixes {
+			if p == "#default" {
+				p = ""
+			}
+			if _, ok := scope.ns[p]; ok {
+				prefixes = append(prefixes, p)
+			}
+		}
+	} else {
+		prefixes = slices.Collect(maps.Keys(scope.ns))
+	}
+	slices.Sort(prefixes)
+	prefixes = slices.Compact(prefixes)
+	var decls []string
+	for _, p := range prefixes {
+		if p == xmlPrefix {
+			continue
+		}
+		url, ok := scope.ns[p]
+		if !ok {
+			return fmt.Errorf("xml: undeclared name space prefix %q in <%s>", p, qualifiedName(start.Name))
+		}
+		if url == "" && p != "" {
+			continue
+		}
+		if r, ok := scope.rendered[p]; ok && r == url {
+			continue
+		}
+		decls = append(decls, p)
+	}
+	if len(decls) > 0 {
+		scope.rendered = maps.Clone(parent.rendered)
+		for _, p := range decls {
+			scope.rendered[p] = scope.ns[p]
+		}
+	}
+
+	// Sort the attributes by name space URL, then local name.
+	urls := make(map[string]string)
+	for _, a := range attrs {
+		if a.Name.Space == "" {
+			continue
+		}
+		url, ok := scope.ns[a.Name.Space]
+		if !ok {
+			return fmt.Errorf("xml: undeclared name space prefix %q in attribute %s", a.Name.Space, qualifiedName(a.Name))
+		}
+		urls[a.Name.Space] = url
+	}
+	if _, ok := scope.ns[start.Name.Space]; !ok {
+		return fmt.Errorf("xml: undeclared name space prefix %q in <%s>", start.Name.Space, qualifiedName(start.Name))
+	}
+	slices.SortStableFunc(attrs, func(a, b Attr) int {
+		if c := strings.Compare(urls[a.Name.Space], urls[b.Name.Space]); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name.Local, b.Name.Local)
+	})
+
+	enc.w.WriteByte('<')
+	enc.w.WriteString(qualifiedName(start.Name))
+	for _, p := range decls {
+		enc.w.WriteString(" xmlns")
+		if p != "" {
+			enc.w.WriteByte(':')
+			enc.w.WriteString(p)
+		}
+		enc.w.WriteString(`="`)
+		escapeCanonicalAttr(enc.w, scope.ns[p])
+		enc.w.WriteByte('"')
+	}
+	for _, a := range attrs {
+		enc.w.WriteByte(' ')
+		enc.w.WriteString(qualifiedName(a.Name))
+		enc.w.WriteString(`="`)
+		escapeCanonicalAttr(enc.w, a.Value)
+		enc.w.WriteByte('"')
+	}
+	enc.w.WriteByte('>')
+	enc.stk = append(enc.stk, scope)
+	return nil
+}
+
+// Flush flushes any buffered output to the underlying writer.
+func (enc *CanonicalEncoder) Flush() error {
+	return enc.w.Flush()
+}
+
+// Close flushes any buffered output to the underlying writer, and
+// returns an error if an element is not closed. No more tokens may be
+// written after Close.
+func (enc *CanonicalEncoder) Close() error {
+	if enc.closed {
+		return nil
+	}
+	enc.closed = true
+	if err := enc.w.Flush(); err != nil {
+		return err
+	}
+	if len(enc.stk) > 0 {
+		return fmt.Errorf("xml: unclosed tag <%s>", qualifiedName(enc.stk[len(enc.stk)-1].name))
+	}
+	return nil
+}
+
+// qualifiedName returns the name as written with a prefix.
+func qualifiedName(name Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+// escapeCanonicalText writes the character data s, escaped as in
+// canonical XML.
+func escapeCanonicalText(w *bufio.Writer, s []byte) {
+	last := 0
+	for i, c := range s {
+		var esc []byte
+		switch c {
+		case '&':
+			esc = escAmp
+		case '<':
+			esc = escLT
+		case '>':
+			esc = escGT
+		case '\r':
+			esc = escCR
+		default:
+			continue
+		}
+		w.Write(s[last:i])
+		w.Write(esc)
+		last = i + 1
+	}
+	w.Write(s[last:])
+}
+
+// escapeCanonicalAttr writes the attribute value s, escaped as in
+// canonical XML.
+func escapeCanonicalAttr(w *bufio.Writer, s string) {
+	last := 0
+	for i := 0; i < len(s); i++ {
+		var esc string
+		switch s[i] {
+		case '&':
+			esc = "&amp;"
+		case '<':
+			esc = "&lt;"
+		case '"':
+			esc = "&quot;"
+		case '\t':
+			esc = "&#x9;"
+		case '\n':
+			esc = "&#xA;"
+		case '\r':
+			esc = "&#xD;"
+		default:
+			continue
+		}
+		w.WriteString(s[last:i])
+		w.WriteString(esc)
+		last = i + 1
+	}
+	w.WriteString(s[last:])
+}
@@ -0,0 +1,182 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xml
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// From section 3.3 of the Canonical XML 1.0 specification, without the
+// attribute default given by the document type declaration.
+const c14nStartEndInput = `<!DOCTYPE doc>
+<doc>
+   <e1   />
+   <e2   ></e2>
+   <e3   name = "elem3"   id="elem3"   />
+   <e4   name="elem4"   id="elem4"   ></e4>
+   <e5 a:attr="out" b:attr="sorted" attr2="all" attr="I'm"
+      xmlns:b="http://www.ietf.org"
+      xmlns:a="http://www.w3.org"
+      xmlns="http://example.org"/>
+   <e6 xmlns="" xmlns:a="http://www.w3.org">
+      <e7 xmlns="http://www.ietf.org">
+         <e8 xmlns="" xmlns:a="http://www.w3.org">
+            <e9 xmlns="" xmlns:a="http://www.ietf.org" attr="default"/>
+         </e8>
+      </e7>
+   </e6>
+</doc>`
+
+const c14nStartEndOutput = `<doc>
+   <e1></e1>
+   <e2></e2>
+   <e3 id="elem3" name="elem3"></e3>
+   <e4 id="elem4" name="elem4"></e4>
+   <e5 xmlns="http://example.org" xmlns:a="http://www.w3.org" xmlns:b="http://www.ietf.org" attr="I'm" attr2="all" b:attr="sorted" a:attr="out"></e5>
+   <e6 xmlns:a="http://www.w3.org">
+      <e7 xmlns="http://www.ietf.org">
+         <e8 xmlns="">
+            <e9 xmlns:a="http://www.ietf.org" attr="default"></e9>
+         </e8>
+      </e7>
+   </e6>
+</doc>`
+
+// From section 2.2 of the Exclusive XML Canonicalization 1.0
+// specification.
+const c14nExclusiveInput = `<n0:local xmlns:n0="foo:bar" xmlns:n3="ftp://example.org"><n1:elem2 xmlns:n1="http://example.net" xml:lang="en"><n3:stuff xmlns:n3="ftp://example.org"/></n1:elem2></n0:local>`
+
+var canonicalizeTests = []struct {
+	name string
+	in   string
+	opts *CanonicalOptions
+	out  string
+}{
+	{
+		name: "StartEndTags",
+		in:   c14nStartEndInput,
+		out:  c14nStartEndOutput,
+	},
+	{
+		name: "Inclusive",
+		in:   c14nExclusiveInput,
+		out:  `<n0:local xmlns:n0="foo:bar" xmlns:n3="ftp://example.org"><n1:elem2 xmlns:n1="http://example.net" xml:lang="en"><n3:stuff></n3:stuff></n1:elem2></n0:local>`,
+	},
+	{
+		name: "Exclusive",
+		in:   c14nExclusiveInput,
+		opts: &CanonicalOptions{Exclusive: true},
+		out:  `<n0:local xmlns:n0="foo:bar"><n1:elem2 xmlns:n1="http://example.net" xml:lang="en"><n3:stuff xmlns:n3="ftp://example.org"></n3:stuff></n1:elem2></n0:local>`,
+	},
+	{
+		name: "ExclusiveInclusivePrefixes",
+		in:   c14nExclusiveInput,
+		opts: &CanonicalOptions{Exclusive: true, InclusivePrefixes: []string{"n3", "#default", "missing"}},
+		out:  `<n0:local xmlns:n0="foo:bar" xmlns:n3="ftp://example.org"><n1:elem2 xmlns:n1="http://example.net" xml:lang="en"><n3:stuff></n3:stuff></n1:elem2></n0:local>`,
+	},
+	{
+		name: "InclusiveSubset",
+		in:   `<n1:elem2 xmlns:n1="http://example.net" xml:lang="en"><n3:stuff xmlns:n3="ftp://example.org"/></n1:elem2>`,
+		opts: &CanonicalOptions{Namespaces: map[string]string{"n0": "foo:bar", "n3": "ftp://example.org"}},
+		out:  `<n1:elem2 xmlns:n0="foo:bar" xmlns:n1="http://example.net" xmlns:n3="ftp://example.org" xml:lang="en"><n3:stuff></n3:stuff></n1:elem2>`,
+	},
+	{
+		name: "ExclusiveSubset",
+		in:   `<n1:elem2 xmlns:n1="http://example.net" xml:lang="en"><n3:stuff/></n1:elem2>`,
+		opts: &CanonicalOptions{Exclusive: true, Namespaces: map[string]string{"n0": "foo:bar", "n3": "ftp://example.org"}},
+		out:  `<n1:elem2 xmlns:n1="http://example.net" xml:lang="en"><n3:stuff xmlns:n3="ftp://example.org"></n3:stuff></n1:elem2>`,
+	},
+	{
+		name: "ExclusiveDefault",
+		in:   `<a xmlns="urn:a"><b xmlns=""><c xmlns="urn:a"/></b></a>`,
+		opts: &CanonicalOptions{Exclusive: true},
+		out:  `<a xmlns="urn:a"><b xmlns=""><c xmlns="urn:a"></c></b></a>`,
+	},
+	{
+		name: "Escaping",
+		in:   "<doc attr=\"a&#x9;b&#xA;c &quot; &lt; &gt; '\">x &amp; &lt; &gt; &#xD; \"'<![CDATA[<&>]]></doc>",
+		out:  "<doc attr=\"a&#x9;b&#xA;c &quot; &lt; > '\">x &amp; &lt; &gt; &#xD; \"'&lt;&amp;&gt;</doc>",
+	},
+	{
+		name: "Comments",
+		in:   "<?xml version=\"1.0\"?>\n<?xml-stylesheet   href=\"doc.xsl\"?>\n<!-- c1 -->\n<doc>x<!-- c2 --></doc>\n<!-- c3 -->\n",
+		opts: &CanonicalOptions{WithComments: true},
+		out:  "<?xml-stylesheet href=\"doc.xsl\"?>\n<!-- c1 -->\n<doc>x<!-- c2 --></doc>\n<!-- c3 -->",
+	},
+	{
+		name: "NoComments",
+		in:   "<?xml version=\"1.0\"?>\n<?xml-stylesheet   href=\"doc.xsl\"?>\n<!-- c1 -->\n<doc>x<!-- c2 --></doc>\n<!-- c3 -->\n",
+		out:  "<?xml-stylesheet href=\"doc.xsl\"?>\n<doc>x</doc>",
+	},
+}
+
+func TestCanonicalize(t *testing.T) {
+	for _, tt := range canonicalizeTests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDecoder(strings.NewReader(tt.in))
+			d.PreservePrefixes = true
+			var buf bytes.Buffer
+			if err := Canonicalize(&buf, d, tt.opts); err != nil {
+				t.Fatalf("Canonicalize: %v", err)
+			}
+			if got := buf.String(); got != tt.out {
+				t.Errorf("Canonicalize:\n%s\nwant:\n%s", got, tt.out)
+			}
+		})
+	}
+}
+
+func TestCanonicalizeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		toks []Token
+		want string
+	}{
+		{
+			name: "UndeclaredElementPrefix",
+			toks: []Token{StartElement{Name: Name{"p", "a"}}},
+			want: `undeclared name space prefix "p"`,
+		},
+		{
+			name: "UndeclaredAttrPrefix",
+			toks: []Token{StartElement{Name: Name{"", "a"}, Attr: []Attr{{Name{"p", "x"}, "1"}}}},
+			want: `undeclared name space prefix "p"`,
+		},
+		{
+			name: "Mismatch",
+			toks: []Token{StartElement{Name: Name{"", "a"}}, EndElement{Name{"", "b"}}},
+			want: "does not match",
+		},
+		{
+			name: "Unclosed",
+			toks: []Token{StartElement{Name: Name{"", "a"}}},
+			want: "unclosed tag <a>",
+		},
+		{
+			name: "TopLevelText",
+			toks: []Token{CharData("x")},
+			want: "outside the document element",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc := NewCanonicalEncoder(new(bytes.Buffer), nil)
+			var err error
+			for _, tok := range tt.toks {
+				if err = enc.EncodeToken(tok); err != nil {
+					break
+				}
+			}
+			if err == nil {
+				err = enc.Close()
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
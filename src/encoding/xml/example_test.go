@@ -8,6 +8,7 @@ import (
 	"encoding/xml"
 	"fmt"
 	"os"
+	"strings"
 )
 
 func ExampleMarshalIndent() {
@@ -149,3 +150,22 @@ func ExampleUnmarshal() {
 	// Groups: [Friends Squash]
 	// Address: {Hanga Roa Easter Island}
 }
+
+func ExampleCanonicalize() {
+	const doc = `<?xml version="1.0"?>
+<saml:Assertion xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion"
+    xmlns:xs="http://www.w3.org/2001/XMLSchema" Version="2.0" ID="a1">
+  <saml:Issuer>https://idp.example.com</saml:Issuer>
+</saml:Assertion>`
+
+	d := xml.NewDecoder(strings.NewReader(doc))
+	d.PreservePrefixes = true
+	opts := &xml.CanonicalOptions{Exclusive: true}
+	if err := xml.Canonicalize(os.Stdout, d, opts); err != nil {
+		fmt.Println(err)
+	}
+	// Output:
+	// <saml:Assertion xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" ID="a1" Version="2.0">
+	//   <saml:Issuer>https://idp.example.com</saml:Issuer>
+	// </saml:Assertion>
+}
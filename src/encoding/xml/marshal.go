@@ -162,6 +162,26 @@ func (enc *Encoder) Indent(prefix, indent string) {
 	enc.p.indent = indent
 }
 
+// SetPreservePrefixes sets whether the encoder writes the names of
+// elements and attributes with their Space as a name space prefix,
+// rather than as a name space URL.
+//
+// By default, the encoder treats the Space of each [Name] as a URL,
+// writing an xmlns attribute for it on each start element and
+// inventing prefixes for attribute names, so name space declarations
+// read by a [Decoder] are not written back as they were. If preserve
+// is true, each name is written as Space:Local, or as Local if Space
+// is empty, and xmlns attributes are written like any other, so the
+// tokens returned by [Decoder.RawToken], or by [Decoder.Token] with
+// [Decoder.PreservePrefixes] set, are written with their prefixes and
+// name space declarations unchanged. The setting applies to the
+// names written by [Encoder.Encode] and [Encoder.EncodeElement] as
+// well, so the name spaces in struct tags are then written as
+// prefixes.
+func (enc *Encoder) SetPreservePrefixes(preserve bool) {
+	enc.p.preservePrefixes = preserve
+}
+
 // Encode writes the XML encoding of v to the stream.
 //
 // See the documentation for [Marshal] for details about the conversion
@@ -332,6 +352,8 @@ type printer struct {
 	tags       []Name
 	closed     bool
 	err        error
+
+	preservePrefixes bool // write Name.Space as a prefix
 }
 
 // createAttrPrefix finds the name space prefix attribute to use for the given name space,
@@ -733,15 +755,29 @@ func (p *printer) writeStart(start *StartElement) error {
 	if start.Name.Local == "" {
 		return fmt.Errorf("xml: start tag with no name")
 	}
+	if p.preservePrefixes {
+		if err := checkPrefix(start.Name); err != nil {
+			return err
+		}
+		for _, attr := range start.Attr {
+			if err := checkPrefix(attr.Name); err != nil {
+				return err
+			}
+		}
+	}
 
 	p.tags = append(p.tags, start.Name)
 	p.markPrefix()
 
 	p.writeIndent(1)
 	p.WriteByte('<')
+	if p.preservePrefixes && start.Name.Space != "" {
+		p.WriteString(start.Name.Space)
+		p.WriteByte(':')
+	}
 	p.WriteString(start.Name.Local)
 
-	if start.Name.Space != "" {
+	if start.Name.Space != "" && !p.preservePrefixes {
 		p.WriteString(` xmlns="`)
 		p.EscapeString(start.Name.Space)
 		p.WriteByte('"')
@@ -755,7 +791,11 @@ func (p *printer) writeStart(start *StartElement) error {
 		}
 		p.WriteByte(' ')
 		if name.Space != "" {
-			p.WriteString(p.createAttrPrefix(name.Space))
+			if p.preservePrefixes {
+				p.WriteString(name.Space)
+			} else {
+				p.WriteString(p.createAttrPrefix(name.Space))
+			}
 			p.WriteByte(':')
 		}
 		p.WriteString(name.Local)
@@ -785,12 +825,25 @@ func (p *printer) writeEnd(name Name) error {
 	p.writeIndent(-1)
 	p.WriteByte('<')
 	p.WriteByte('/')
+	if p.preservePrefixes && name.Space != "" {
+		p.WriteString(name.Space)
+		p.WriteByte(':')
+	}
 	p.WriteString(name.Local)
 	p.WriteByte('>')
 	p.popPrefix()
 	return nil
 }
 
+// checkPrefix reports an error if the Space of name cannot be written
+// as a name space prefix.
+func checkPrefix(name Name) error {
+	if name.Space != "" && (!isNameString(name.Space) || strings.Contains(name.Space, ":")) {
+		return fmt.Errorf("xml: invalid name space prefix %q in name %s", name.Space, name.Local)
+	}
+	return nil
+}
+
 func (p *printer) marshalSimple(typ reflect.Type, val reflect.Value) (string, []byte, error) {
 	switch val.Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
@@ -2403,6 +2403,65 @@ func TestRace9796(t *testing.T) {
 	wg.Wait()
 }
 
+func TestPreservePrefixes(t *testing.T) {
+	const input = `<a:root xmlns:a="urn:a" xmlns="urn:d" a:x="1"><child xmlns:b="urn:b" b:y="2" z="3"><b:leaf>text</b:leaf><xml:p xml:lang="en"></xml:p></child></a:root>`
+	d := NewDecoder(strings.NewReader(input))
+	d.PreservePrefixes = true
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetPreservePrefixes(true)
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Token: %v", err)
+		}
+		if se, ok := tok.(StartElement); ok && se.Name.Local == "root" && se.Name.Space != "a" {
+			t.Errorf("Token returned name %v, want prefix a", se.Name)
+		}
+		if err := enc.EncodeToken(tok); err != nil {
+			t.Fatalf("EncodeToken: %v", err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if got := buf.String(); got != input {
+		t.Errorf("round trip:\n%s\nwant:\n%s", got, input)
+	}
+
+	d = NewDecoder(strings.NewReader(`<a:x xmlns:a="urn:a" xmlns:b="urn:a"></b:x>`))
+	d.PreservePrefixes = true
+	var err error
+	for err == nil {
+		_, err = d.Token()
+	}
+	if _, ok := err.(*SyntaxError); !ok {
+		t.Errorf("Token with mismatched prefixes returned %v, want a SyntaxError", err)
+	}
+
+	buf.Reset()
+	enc = NewEncoder(&buf)
+	enc.SetPreservePrefixes(true)
+	if err := enc.EncodeToken(StartElement{Name: Name{"urn:a", "x"}}); err == nil {
+		t.Errorf("EncodeToken with invalid prefix succeeded")
+	}
+	// The rejected element was not left open.
+	for _, tok := range []Token{StartElement{Name: Name{"", "y"}}, EndElement{Name: Name{"", "y"}}} {
+		if err := enc.EncodeToken(tok); err != nil {
+			t.Fatalf("EncodeToken after invalid prefix: %v", err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Close after invalid prefix: %v", err)
+	}
+	if got, want := buf.String(), "<y></y>"; got != want {
+		t.Errorf("after invalid prefix, encoded %q, want %q", got, want)
+	}
+}
+
 func TestIsValidDirective(t *testing.T) {
 	testOK := []string{
 		"<>",
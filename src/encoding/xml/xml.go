@@ -197,6 +197,17 @@ type Decoder struct {
 	// the attribute xmlns="DefaultSpace".
 	DefaultSpace string
 
+	// PreservePrefixes, if set, stops Token from translating name
+	// space prefixes: the Space of each element and attribute name it
+	// returns is the prefix written in the input, as returned by
+	// RawToken, rather than the name space URL the prefix refers to.
+	// Token still checks that start and end elements match, and
+	// returns the name space declarations as attributes, so the
+	// tokens can be written unchanged by an [Encoder] with
+	// [Encoder.SetPreservePrefixes] or by a [CanonicalEncoder].
+	// DefaultSpace is ignored.
+	PreservePrefixes bool
+
 	r              io.ByteReader
 	t              TokenReader
 	buf            bytes.Buffer
@@ -271,6 +282,7 @@ func NewTokenDecoder(t TokenReader) *Decoder {
 // set to the URL identifying its name space when known.
 // If Token encounters an unrecognized name space prefix,
 // it uses the prefix as the Space rather than report an error.
+// If [Decoder.PreservePrefixes] is set, the Space is always the prefix.
 func (d *Decoder) Token() (Token, error) {
 	var t Token
 	var err error
@@ -318,9 +330,11 @@ func (d *Decoder) Token() (Token, error) {
 		}
 
 		d.pushElement(t1.Name)
-		d.translate(&t1.Name, true)
-		for i := range t1.Attr {
-			d.translate(&t1.Attr[i].Name, false)
+		if !d.PreservePrefixes {
+			d.translate(&t1.Name, true)
+			for i := range t1.Attr {
+				d.translate(&t1.Attr[i].Name, false)
+			}
 		}
 		t = t1
 
@@ -501,7 +515,9 @@ func (d *Decoder) popElement(t *EndElement) bool {
 		return false
 	}
 
-	d.translate(&t.Name, true)
+	if !d.PreservePrefixes {
+		d.translate(&t.Name, true)
+	}
 
 	// Pop stack until a Start or EOF is on the top, undoing the
 	// translations that were associated with the element we just closed.
@@ -0,0 +1,49 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xpath_test
+
+import (
+	"encoding/xml/xpath"
+	"fmt"
+	"log"
+	"strings"
+)
+
+func Example() {
+	const feed = `<catalog xmlns="urn:example:catalog">
+  <product sku="A-1"><title>Lamp</title><price currency="EUR">30</price></product>
+  <product sku="B-2"><title>Desk</title><price currency="EUR">120</price></product>
+  <product sku="C-3"><title>Chair</title><price currency="EUR">85</price></product>
+</catalog>`
+
+	doc, err := xpath.Parse(strings.NewReader(feed))
+	if err != nil {
+		log.Fatal(err)
+	}
+	ns := map[string]string{"c": "urn:example:catalog"}
+
+	products, err := xpath.MustCompile("//c:product[c:price > 50]", ns).Select(doc)
+	if err != nil {
+		log.Fatal(err)
+	}
+	title := xpath.MustCompile("string(c:title)", ns)
+	for _, p := range products {
+		t, err := title.EvaluateString(p)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(t)
+	}
+
+	total, err := xpath.MustCompile("sum(//c:price)", ns).EvaluateNumber(doc)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("total:", total)
+	// Output:
+	// Desk
+	// Chair
+	// total: 235
+}
@@ -0,0 +1,280 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xpath
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// A function is a function of the core function library.
+type function struct {
+	minArgs, maxArgs int  // maxArgs is -1 for no limit
+	contextArg       bool // whether an omitted argument is the context node
+	fn               func(ctx *context, args []any) (any, error)
+}
+
+var functions = map[string]function{
+	// Node-set functions.
+	"last":          {0, 0, false, fnLast},
+	"position":      {0, 0, false, fnPosition},
+	"count":         {1, 1, false, fnCount},
+	"local-name":    {0, 1, true, fnLocalName},
+	"namespace-uri": {0, 1, true, fnNamespaceURI},
+	"name":          {0, 1, true, fnName},
+
+	// String functions.
+	"string":           {0, 1, true, fnString},
+	"concat":           {2, -1, false, fnConcat},
+	"starts-with":      {2, 2, false, fnStartsWith},
+	"contains":         {2, 2, false, fnContains},
+	"substring-before": {2, 2, false, fnSubstringBefore},
+	"substring-after":  {2, 2, false, fnSubstringAfter},
+	"substring":        {2, 3, false, fnSubstring},
+	"string-length":    {0, 1, true, fnStringLength},
+	"normalize-space":  {0, 1, true, fnNormalizeSpace},
+	"translate":        {3, 3, false, fnTranslate},
+
+	// Boolean functions.
+	"boolean": {1, 1, false, fnBoolean},
+	"not":     {1, 1, false, fnNot},
+	"true":    {0, 0, false, fnTrue},
+	"false":   {0, 0, false, fnFalse},
+	"lang":    {1, 1, false, fnLang},
+
+	// Number functions.
+	"number":  {0, 1, true, fnNumber},
+	"sum":     {1, 1, false, fnSum},
+	"floor":   {1, 1, false, fnFloor},
+	"ceiling": {1, 1, false, fnCeiling},
+	"round":   {1, 1, false, fnRound},
+}
+
+func fnLast(ctx *context, args []any) (any, error) {
+	return float64(ctx.size), nil
+}
+
+func fnPosition(ctx *context, args []any) (any, error) {
+	return float64(ctx.pos), nil
+}
+
+// nodeSetArg returns the argument v, which must be a node-set.
+func nodeSetArg(v any) (nodeSet, error) {
+	ns, ok := v.(nodeSet)
+	if !ok {
+		return nil, fmt.Errorf("argument is a %s, not a node-set", typeName(v))
+	}
+	return ns, nil
+}
+
+func fnCount(ctx *context, args []any) (any, error) {
+	ns, err := nodeSetArg(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return float64(len(ns)), nil
+}
+
+// firstNode returns the first node of the node-set v, or nil if it is
+// empty.
+func firstNode(v any) (*Node, error) {
+	ns, err := nodeSetArg(v)
+	if err != nil || len(ns) == 0 {
+		return nil, err
+	}
+	return ns[0], nil
+}
+
+func fnLocalName(ctx *context, args []any) (any, error) {
+	n, err := firstNode(args[0])
+	if n == nil {
+		return "", err
+	}
+	switch n.Type {
+	case ElementNode, AttributeNode, ProcInstNode:
+		return n.Name.Local, nil
+	}
+	return "", nil
+}
+
+func fnNamespaceURI(ctx *context, args []any) (any, error) {
+	n, err := firstNode(args[0])
+	if n == nil {
+		return "", err
+	}
+	switch n.Type {
+	case ElementNode, AttributeNode:
+		return n.Name.Space, nil
+	}
+	return "", nil
+}
+
+func fnName(ctx *context, args []any) (any, error) {
+	n, err := firstNode(args[0])
+	if n == nil {
+		return "", err
+	}
+	return n.qualifiedName(), nil
+}
+
+func fnString(ctx *context, args []any) (any, error) {
+	return toString(args[0]), nil
+}
+
+func fnConcat(ctx *context, args []any) (any, error) {
+	var sb strings.Builder
+	for _, a := range args {
+		sb.WriteString(toString(a))
+	}
+	return sb.String(), nil
+}
+
+func fnStartsWith(ctx *context, args []any) (any, error) {
+	return strings.HasPrefix(toString(args[0]), toString(args[1])), nil
+}
+
+func fnContains(ctx *context, args []any) (any, error) {
+	return strings.Contains(toString(args[0]), toString(args[1])), nil
+}
+
+func fnSubstringBefore(ctx *context, args []any) (any, error) {
+	before, _, found := strings.Cut(toString(args[0]), toString(args[1]))
+	if !found {
+		return "", nil
+	}
+	return before, nil
+}
+
+func fnSubstringAfter(ctx *context, args []any) (any, error) {
+	_, after, found := strings.Cut(toString(args[0]), toString(args[1]))
+	if !found {
+		return "", nil
+	}
+	return after, nil
+}
+
+// fnSubstring returns the characters of its first argument at the
+// positions p, counting from 1, such that round(start) <= p and
+// p < round(start) + round(length), so that NaN and infinite arguments
+// behave as the specification requires.
+func fnSubstring(ctx *context, args []any) (any, error) {
+	s := toString(args[0])
+	start := round(toNumber(args[1]))
+	end := math.Inf(1)
+	if len(args) == 3 {
+		end = start + round(toNumber(args[2]))
+	}
+	var sb strings.Builder
+	p := 1.0
+	for _, r := range s {
+		if p >= start && p < end {
+			sb.WriteRune(r)
+		}
+		p++
+	}
+	return sb.String(), nil
+}
+
+func fnStringLength(ctx *context, args []any) (any, error) {
+	return float64(len([]rune(toString(args[0])))), nil
+}
+
+func fnNormalizeSpace(ctx *context, args []any) (any, error) {
+	return strings.Join(strings.FieldsFunc(toString(args[0]), func(r rune) bool {
+		return r == ' ' || r == '\t' || r == '\r' || r == '\n'
+	}), " "), nil
+}
+
+func fnTranslate(ctx *context, args []any) (any, error) {
+	from := []rune(toString(args[1]))
+	to := []rune(toString(args[2]))
+	return strings.Map(func(r rune) rune {
+		for i, f := range from {
+			if f == r {
+				if i < len(to) {
+					return to[i]
+				}
+				return -1
+			}
+		}
+		return r
+	}, toString(args[0])), nil
+}
+
+func fnBoolean(ctx *context, args []any) (any, error) {
+	return toBool(args[0]), nil
+}
+
+func fnNot(ctx *context, args []any) (any, error) {
+	return !toBool(args[0]), nil
+}
+
+func fnTrue(ctx *context, args []any) (any, error) {
+	return true, nil
+}
+
+func fnFalse(ctx *context, args []any) (any, error) {
+	return false, nil
+}
+
+// fnLang reports whether the language of the context node, given by
+// the xml:lang attribute of it or its nearest ancestor, is its
+// argument or a sublanguage of it.
+func fnLang(ctx *context, args []any) (any, error) {
+	want := toString(args[0])
+	for n := ctx.node; n != nil; n = n.Parent {
+		for _, a := range n.Attr {
+			if a.Name.Space == xmlURL && a.Name.Local == "lang" {
+				lang := a.Data
+				if len(lang) > len(want) && lang[len(want)] == '-' {
+					lang = lang[:len(want)]
+				}
+				return strings.EqualFold(lang, want), nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func fnNumber(ctx *context, args []any) (any, error) {
+	return toNumber(args[0]), nil
+}
+
+func fnSum(ctx *context, args []any) (any, error) {
+	ns, err := nodeSetArg(args[0])
+	if err != nil {
+		return nil, err
+	}
+	sum := 0.0
+	for _, n := range ns {
+		sum += parseNumber(n.Text())
+	}
+	return sum, nil
+}
+
+func fnFloor(ctx *context, args []any) (any, error) {
+	return math.Floor(toNumber(args[0])), nil
+}
+
+func fnCeiling(ctx *context, args []any) (any, error) {
+	return math.Ceil(toNumber(args[0])), nil
+}
+
+func fnRound(ctx *context, args []any) (any, error) {
+	return round(toNumber(args[0])), nil
+}
+
+// round rounds x to the closest integer, rounding halves towards
+// positive infinity, as the round function does.
+func round(x float64) float64 {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return x
+	}
+	if x < 0 && x >= -0.5 {
+		return math.Copysign(0, -1)
+	}
+	return math.Floor(x + 0.5)
+}
@@ -0,0 +1,248 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xpath
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"maps"
+	"strings"
+)
+
+// A NodeType is the type of a [Node].
+type NodeType int
+
+const (
+	DocumentNode  NodeType = iota // the root of a tree
+	ElementNode                   // an element
+	AttributeNode                 // an attribute of an element
+	TextNode                      // character data
+	CommentNode                   // a comment
+	ProcInstNode                  // a processing instruction
+)
+
+var nodeTypeNames = [...]string{
+	DocumentNode:  "DocumentNode",
+	ElementNode:   "ElementNode",
+	AttributeNode: "AttributeNode",
+	TextNode:      "TextNode",
+	CommentNode:   "CommentNode",
+	ProcInstNode:  "ProcInstNode",
+}
+
+func (t NodeType) String() string {
+	if t >= 0 && int(t) < len(nodeTypeNames) {
+		return nodeTypeNames[t]
+	}
+	return fmt.Sprintf("NodeType(%d)", int(t))
+}
+
+// A Node is a node of a tree built from XML tokens, in the data model
+// of XPath 1.0.
+//
+// Element and attribute names have the name space URL as their Space,
+// as returned by [xml.Decoder.Token]. Name space declarations are not
+// attributes of their elements. Adjacent character data, including
+// CDATA sections, forms a single text node.
+//
+// A Node must not be modified once it is in a tree that an expression
+// is evaluated against.
+type Node struct {
+	Type NodeType
+
+	// Name is the name of an element or attribute. For a processing
+	// instruction, Name.Local is its target.
+	Name xml.Name
+
+	// Data is the text of a text node or comment, the value of an
+	// attribute, or the instruction of a processing instruction.
+	Data string
+
+	Parent   *Node   // nil for the document node
+	Children []*Node // the children of a document or element node
+	Attr     []*Node // the attributes of an element node
+
+	order  int    // position in document order
+	prefix string // name space prefix of Name in the input, if known
+}
+
+// Parse parses the XML document read from r, and returns its document
+// node.
+func Parse(r io.Reader) (*Node, error) {
+	return Build(xml.NewDecoder(r))
+}
+
+// Build reads tokens from r until [io.EOF], and returns the document
+// node of the tree they form. The names of the tokens must be as
+// returned by [xml.Decoder.Token], with the name space URL as their
+// Space.
+func Build(r xml.TokenReader) (*Node, error) {
+	b := &builder{doc: &Node{Type: DocumentNode}}
+	b.cur = b.doc
+	b.scopes = []map[string]string{{xmlURL: "xml"}}
+	for {
+		t, err := r.Token()
+		if t != nil {
+			if err := b.add(t); err != nil {
+				return nil, err
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if b.cur != b.doc {
+		return nil, fmt.Errorf("xpath: unclosed element <%s>", b.cur.Name.Local)
+	}
+	return b.doc, nil
+}
+
+const xmlURL = "http://www.w3.org/XML/1998/namespace"
+
+// A builder builds a tree from tokens.
+type builder struct {
+	doc    *Node
+	cur    *Node
+	order  int
+	scopes []map[string]string // name space prefixes in scope, by URL
+}
+
+func (b *builder) newNode(typ NodeType, parent *Node) *Node {
+	b.order++
+	return &Node{Type: typ, Parent: parent, order: b.order}
+}
+
+func (b *builder) add(t xml.Token) error {
+	switch t := t.(type) {
+	case xml.StartElement:
+		scope := b.scopes[len(b.scopes)-1]
+		cloned := false
+		for _, a := range t.Attr {
+			var prefix string
+			switch {
+			case a.Name.Space == "xmlns":
+				prefix = a.Name.Local
+			case a.Name.Space == "" && a.Name.Local == "xmlns":
+				prefix = ""
+			default:
+				continue
+			}
+			if !cloned {
+				scope = maps.Clone(scope)
+				cloned = true
+			}
+			// Forget any other prefix bound to the URL, so that the
+			// innermost declaration is used.
+			for url, p := range scope {
+				if p == prefix {
+					delete(scope, url)
+				}
+			}
+			scope[a.Value] = prefix
+		}
+		b.scopes = append(b.scopes, scope)
+
+		n := b.newNode(ElementNode, b.cur)
+		n.Name = t.Name
+		n.prefix = scope[t.Name.Space]
+		for _, a := range t.Attr {
+			if a.Name.Space == "xmlns" || a.Name.Space == "" && a.Name.Local == "xmlns" {
+				continue
+			}
+			an := b.newNode(AttributeNode, n)
+			an.Name = a.Name
+			an.Data = a.Value
+			if a.Name.Space != "" {
+				an.prefix = scope[a.Name.Space]
+			}
+			n.Attr = append(n.Attr, an)
+		}
+		b.cur.Children = append(b.cur.Children, n)
+		b.cur = n
+	case xml.EndElement:
+		if b.cur == b.doc {
+			return fmt.Errorf("xpath: unexpected end element </%s>", t.Name.Local)
+		}
+		b.cur = b.cur.Parent
+		b.scopes = b.scopes[:len(b.scopes)-1]
+	case xml.CharData:
+		if b.cur == b.doc {
+			// Character data outside the document element is not
+			// part of the data model.
+			return nil
+		}
+		if k := len(b.cur.Children); k > 0 && b.cur.Children[k-1].Type == TextNode {
+			b.cur.Children[k-1].Data += string(t)
+			return nil
+		}
+		n := b.newNode(TextNode, b.cur)
+		n.Data = string(t)
+		b.cur.Children = append(b.cur.Children, n)
+	case xml.Comment:
+		n := b.newNode(CommentNode, b.cur)
+		n.Data = string(t)
+		b.cur.Children = append(b.cur.Children, n)
+	case xml.ProcInst:
+		if t.Target == "xml" {
+			return nil
+		}
+		n := b.newNode(ProcInstNode, b.cur)
+		n.Name.Local = t.Target
+		n.Data = string(t.Inst)
+		b.cur.Children = append(b.cur.Children, n)
+	}
+	return nil
+}
+
+// Text returns the string-value of n: the concatenation of the text
+// nodes it contains for a document or element node, and its Data
+// otherwise.
+func (n *Node) Text() string {
+	switch n.Type {
+	case DocumentNode, ElementNode:
+		var sb strings.Builder
+		n.appendText(&sb)
+		return sb.String()
+	}
+	return n.Data
+}
+
+func (n *Node) appendText(sb *strings.Builder) {
+	for _, c := range n.Children {
+		switch c.Type {
+		case TextNode:
+			sb.WriteString(c.Data)
+		case ElementNode:
+			c.appendText(sb)
+		}
+	}
+}
+
+// qualifiedName returns the name of n as written in the input, with
+// its prefix, if known.
+func (n *Node) qualifiedName() string {
+	switch n.Type {
+	case ElementNode, AttributeNode:
+		if n.prefix != "" {
+			return n.prefix + ":" + n.Name.Local
+		}
+		return n.Name.Local
+	case ProcInstNode:
+		return n.Name.Local
+	}
+	return ""
+}
+
+// root returns the document node, or the topmost ancestor, of n.
+func (n *Node) root() *Node {
+	for n.Parent != nil {
+		n = n.Parent
+	}
+	return n
+}
@@ -0,0 +1,616 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xpath
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A tokenKind is the kind of a token of an expression.
+type tokenKind int
+
+const (
+	tokEOF      tokenKind = iota
+	tokPunct              // ( ) [ ] . .. @ , ::
+	tokOperator           // and or mod div / // | + - = != < <= > >= *
+	tokNameTest           // * prefix:* QName
+	tokNodeType           // comment text processing-instruction node
+	tokFunction           // a function name
+	tokAxis               // an axis name
+	tokLiteral
+	tokNumber
+	tokVariable
+)
+
+type token struct {
+	kind tokenKind
+	val  string // text of the token; the value of a literal
+	num  float64
+	pos  int // byte offset in the expression
+}
+
+// lex splits the expression s into tokens, following the rules of
+// section 3.7 of the XPath 1.0 specification to tell operators from
+// names.
+func lex(s string) ([]token, error) {
+	var toks []token
+	i := 0
+	for {
+		for i < len(s) && isSpace(s[i]) {
+			i++
+		}
+		if i == len(s) {
+			toks = append(toks, token{kind: tokEOF, pos: i})
+			return toks, nil
+		}
+		start := i
+		// An operator is expected after a token that can end an
+		// operand.
+		operatorNext := false
+		if n := len(toks); n > 0 {
+			switch prev := toks[n-1]; prev.kind {
+			case tokPunct:
+				operatorNext = prev.val == ")" || prev.val == "]" || prev.val == "." || prev.val == ".."
+			case tokOperator:
+			default:
+				operatorNext = true
+			}
+		}
+		c := s[i]
+		switch {
+		case c == '(' || c == ')' || c == '[' || c == ']' || c == '@' || c == ',':
+			toks = append(toks, token{kind: tokPunct, val: s[i : i+1], pos: start})
+			i++
+		case c == '.' && i+1 < len(s) && isDigit(s[i+1]):
+			tok, n, err := lexNumber(s[i:])
+			if err != nil {
+				return nil, errorAt(s, start, "%v", err)
+			}
+			tok.pos = start
+			toks = append(toks, tok)
+			i += n
+		case c == '.':
+			if strings.HasPrefix(s[i:], "..") {
+				toks = append(toks, token{kind: tokPunct, val: "..", pos: start})
+				i += 2
+			} else {
+				toks = append(toks, token{kind: tokPunct, val: ".", pos: start})
+				i++
+			}
+		case c == ':' && strings.HasPrefix(s[i:], "::"):
+			toks = append(toks, token{kind: tokPunct, val: "::", pos: start})
+			i += 2
+		case isDigit(c):
+			tok, n, err := lexNumber(s[i:])
+			if err != nil {
+				return nil, errorAt(s, start, "%v", err)
+			}
+			tok.pos = start
+			toks = append(toks, tok)
+			i += n
+		case c == '"' || c == '\'':
+			j := strings.IndexByte(s[i+1:], c)
+			if j < 0 {
+				return nil, errorAt(s, start, "unterminated string literal")
+			}
+			toks = append(toks, token{kind: tokLiteral, val: s[i+1 : i+1+j], pos: start})
+			i += j + 2
+		case c == '$':
+			name, n := lexQName(s[i+1:])
+			if n == 0 {
+				return nil, errorAt(s, start, "missing variable name")
+			}
+			toks = append(toks, token{kind: tokVariable, val: name, pos: start})
+			i += n + 1
+		case c == '*':
+			if operatorNext {
+				toks = append(toks, token{kind: tokOperator, val: "*", pos: start})
+			} else {
+				toks = append(toks, token{kind: tokNameTest, val: "*", pos: start})
+			}
+			i++
+		case strings.ContainsRune("/|+-=!<>", rune(c)):
+			op := s[i : i+1]
+			if i+1 < len(s) {
+				switch two := s[i : i+2]; two {
+				case "//", "!=", "<=", ">=":
+					op = two
+				}
+			}
+			if op == "!" {
+				return nil, errorAt(s, start, "unexpected '!'")
+			}
+			toks = append(toks, token{kind: tokOperator, val: op, pos: start})
+			i += len(op)
+		default:
+			name, n := lexNCName(s[i:])
+			if n == 0 {
+				r, _ := utf8.DecodeRuneInString(s[i:])
+				return nil, errorAt(s, start, "unexpected character %q", r)
+			}
+			if operatorNext {
+				switch name {
+				case "and", "or", "mod", "div":
+					toks = append(toks, token{kind: tokOperator, val: name, pos: start})
+					i += n
+					continue
+				}
+				return nil, errorAt(s, start, "unexpected name %s", name)
+			}
+			i += n
+			// A prefix, followed by * or a local name.
+			if i+1 < len(s) && s[i] == ':' && s[i+1] != ':' {
+				if s[i+1] == '*' {
+					toks = append(toks, token{kind: tokNameTest, val: name + ":*", pos: start})
+					i += 2
+					continue
+				}
+				local, m := lexNCName(s[i+1:])
+				if m == 0 {
+					return nil, errorAt(s, i, "missing local name after prefix %s", name)
+				}
+				name += ":" + local
+				i += 1 + m
+			}
+			j := i
+			for j < len(s) && isSpace(s[j]) {
+				j++
+			}
+			kind := tokNameTest
+			switch {
+			case strings.HasPrefix(s[j:], "::") && !strings.Contains(name, ":"):
+				kind = tokAxis
+			case strings.HasPrefix(s[j:], "("):
+				kind = tokFunction
+				switch name {
+				case "comment", "text", "processing-instruction", "node":
+					kind = tokNodeType
+				}
+			}
+			toks = append(toks, token{kind: kind, val: name, pos: start})
+		}
+	}
+}
+
+func lexNumber(s string) (token, int, error) {
+	n := 0
+	for n < len(s) && isDigit(s[n]) {
+		n++
+	}
+	if n < len(s) && s[n] == '.' {
+		n++
+		for n < len(s) && isDigit(s[n]) {
+			n++
+		}
+	}
+	f, err := strconv.ParseFloat(s[:n], 64)
+	if err != nil {
+		return token{}, 0, err
+	}
+	return token{kind: tokNumber, val: s[:n], num: f}, n, nil
+}
+
+// lexQName returns the qualified name at the start of s, and its
+// length.
+func lexQName(s string) (string, int) {
+	name, n := lexNCName(s)
+	if n == 0 || n+1 >= len(s) || s[n] != ':' {
+		return name, n
+	}
+	local, m := lexNCName(s[n+1:])
+	if m == 0 {
+		return name, n
+	}
+	return name + ":" + local, n + 1 + m
+}
+
+// lexNCName returns the name without colons at the start of s, and
+// its length.
+func lexNCName(s string) (string, int) {
+	n := 0
+	for n < len(s) {
+		r, size := utf8.DecodeRuneInString(s[n:])
+		if !(r == '_' || unicode.IsLetter(r) || n > 0 && (r == '-' || r == '.' || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Mc, r))) {
+			break
+		}
+		n += size
+	}
+	return s[:n], n
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func errorAt(s string, pos int, format string, args ...any) error {
+	return fmt.Errorf("xpath: %s at offset %d in %q", fmt.Sprintf(format, args...), pos, s)
+}
+
+// A parser parses the tokens of an expression.
+type parser struct {
+	src  string
+	toks []token
+	i    int
+	ns   map[string]string
+}
+
+func (p *parser) peek() token { return p.toks[p.i] }
+
+func (p *parser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+// is reports whether the next token is of the given kind and value.
+func (p *parser) is(kind tokenKind, val string) bool {
+	t := p.peek()
+	return t.kind == kind && t.val == val
+}
+
+func (p *parser) accept(kind tokenKind, val string) bool {
+	if p.is(kind, val) {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(kind tokenKind, val string) error {
+	if !p.accept(kind, val) {
+		return p.errorf("expected %q", val)
+	}
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	t := p.peek()
+	if t.kind == tokEOF {
+		return fmt.Errorf("xpath: %s at end of %q", fmt.Sprintf(format, args...), p.src)
+	}
+	return errorAt(p.src, t.pos, format, args...)
+}
+
+func parse(src string, ns map[string]string) (expr, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{src: src, toks: toks, ns: ns}
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokEOF {
+		return nil, p.errorf("unexpected %q", p.peek().val)
+	}
+	return e, nil
+}
+
+// parseBinary parses operands separated by the operators ops, each
+// operand parsed by operand.
+func (p *parser) parseBinary(operand func() (expr, error), ops ...string) (expr, error) {
+	l, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if t.kind != tokOperator || !slices.Contains(ops, t.val) {
+			return l, nil
+		}
+		p.next()
+		r, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		l = &binaryExpr{op: t.val, l: l, r: r}
+	}
+}
+
+func (p *parser) parseOr() (expr, error) {
+	return p.parseBinary(p.parseAnd, "or")
+}
+
+func (p *parser) parseAnd() (expr, error) {
+	return p.parseBinary(p.parseEquality, "and")
+}
+
+func (p *parser) parseEquality() (expr, error) {
+	return p.parseBinary(p.parseRelational, "=", "!=")
+}
+
+func (p *parser) parseRelational() (expr, error) {
+	return p.parseBinary(p.parseAdditive, "<", "<=", ">", ">=")
+}
+
+func (p *parser) parseAdditive() (expr, error) {
+	return p.parseBinary(p.parseMultiplicative, "+", "-")
+}
+
+func (p *parser) parseMultiplicative() (expr, error) {
+	return p.parseBinary(p.parseUnary, "*", "div", "mod")
+}
+
+func (p *parser) parseUnary() (expr, error) {
+	if p.accept(tokOperator, "-") {
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &negExpr{e}, nil
+	}
+	return p.parseUnion()
+}
+
+func (p *parser) parseUnion() (expr, error) {
+	return p.parseBinary(p.parsePath, "|")
+}
+
+// parsePath parses a location path, or a filter expression optionally
+// followed by a relative location path.
+func (p *parser) parsePath() (expr, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokLiteral, t.kind == tokNumber, t.kind == tokFunction,
+		t.kind == tokVariable, t.kind == tokPunct && t.val == "(":
+		e, err := p.parseFilter()
+		if err != nil {
+			return nil, err
+		}
+		if !p.is(tokOperator, "/") && !p.is(tokOperator, "//") {
+			return e, nil
+		}
+		path := &pathExpr{filter: e}
+		if err := p.parseRelativePath(path); err != nil {
+			return nil, err
+		}
+		return path, nil
+	}
+
+	path := new(pathExpr)
+	switch {
+	case p.accept(tokOperator, "/"):
+		path.absolute = true
+		if !p.startsStep() {
+			return path, nil
+		}
+	case p.is(tokOperator, "//"):
+		path.absolute = true
+	}
+	if err := p.parseRelativePath(path); err != nil {
+		return nil, err
+	}
+	return path, nil
+}
+
+// startsStep reports whether the next token begins a location step.
+func (p *parser) startsStep() bool {
+	t := p.peek()
+	switch t.kind {
+	case tokNameTest, tokNodeType, tokAxis:
+		return true
+	case tokPunct:
+		return t.val == "." || t.val == ".." || t.val == "@"
+	}
+	return false
+}
+
+// parseRelativePath parses steps separated by / or //, and appends
+// them to path. If path has a filter or is absolute, the first step
+// must be preceded by / or //.
+func (p *parser) parseRelativePath(path *pathExpr) error {
+	first := path.filter == nil && len(path.steps) == 0
+	for {
+		if !first || p.is(tokOperator, "//") {
+			switch {
+			case p.accept(tokOperator, "//"):
+				path.steps = append(path.steps, &step{axis: axisDescendantOrSelf, test: nodeTest{kind: testNode}})
+			case p.accept(tokOperator, "/"):
+			default:
+				return nil
+			}
+		}
+		first = false
+		s, err := p.parseStep()
+		if err != nil {
+			return err
+		}
+		path.steps = append(path.steps, s)
+	}
+}
+
+func (p *parser) parseStep() (*step, error) {
+	if p.accept(tokPunct, ".") {
+		return &step{axis: axisSelf, test: nodeTest{kind: testNode}}, nil
+	}
+	if p.accept(tokPunct, "..") {
+		return &step{axis: axisParent, test: nodeTest{kind: testNode}}, nil
+	}
+	s := &step{axis: axisChild}
+	switch t := p.peek(); {
+	case p.accept(tokPunct, "@"):
+		s.axis = axisAttribute
+	case t.kind == tokAxis:
+		p.next()
+		a, ok := axisNames[t.val]
+		if !ok {
+			return nil, errorAt(p.src, t.pos, "unknown axis %s", t.val)
+		}
+		if a == axisNamespace {
+			return nil, errorAt(p.src, t.pos, "namespace axis is not supported")
+		}
+		s.axis = a
+		if err := p.expect(tokPunct, "::"); err != nil {
+			return nil, err
+		}
+	}
+
+	t := p.peek()
+	switch t.kind {
+	case tokNameTest, tokNodeType:
+		p.next()
+	default:
+		return nil, p.errorf("expected a node test")
+	}
+	switch t.kind {
+	case tokNameTest:
+		test, err := p.nameTest(t)
+		if err != nil {
+			return nil, err
+		}
+		s.test = test
+	case tokNodeType:
+		if err := p.expect(tokPunct, "("); err != nil {
+			return nil, err
+		}
+		switch t.val {
+		case "node":
+			s.test.kind = testNode
+		case "text":
+			s.test.kind = testText
+		case "comment":
+			s.test.kind = testComment
+		case "processing-instruction":
+			s.test.kind = testProcInst
+			if lit := p.peek(); lit.kind == tokLiteral {
+				p.next()
+				s.test.local = lit.val
+				s.test.hasName = true
+			}
+		}
+		if err := p.expect(tokPunct, ")"); err != nil {
+			return nil, err
+		}
+	}
+	preds, err := p.parsePredicates()
+	if err != nil {
+		return nil, err
+	}
+	s.preds = preds
+	return s, nil
+}
+
+// nameTest returns the node test for a name test token, resolving its
+// prefix.
+func (p *parser) nameTest(t token) (nodeTest, error) {
+	if t.val == "*" {
+		return nodeTest{kind: testName}, nil
+	}
+	test := nodeTest{kind: testName, hasName: true, local: t.val}
+	if prefix, local, ok := strings.Cut(t.val, ":"); ok {
+		space, ok := p.ns[prefix]
+		if prefix == "xml" {
+			space, ok = xmlURL, true
+		}
+		if !ok {
+			return nodeTest{}, errorAt(p.src, t.pos, "undeclared name space prefix %s", prefix)
+		}
+		test.space = space
+		test.local = local
+		if local == "*" {
+			test.hasName = false
+			test.hasSpace = true
+		}
+	}
+	return test, nil
+}
+
+func (p *parser) parsePredicates() ([]expr, error) {
+	var preds []expr
+	for p.accept(tokPunct, "[") {
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokPunct, "]"); err != nil {
+			return nil, err
+		}
+		preds = append(preds, e)
+	}
+	return preds, nil
+}
+
+func (p *parser) parseFilter() (expr, error) {
+	var e expr
+	t := p.next()
+	switch t.kind {
+	case tokLiteral:
+		e = literalExpr(t.val)
+	case tokNumber:
+		e = numberExpr(t.num)
+	case tokVariable:
+		return nil, errorAt(p.src, t.pos, "variable references are not supported")
+	case tokFunction:
+		f, err := p.parseCall(t)
+		if err != nil {
+			return nil, err
+		}
+		e = f
+	default: // (
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokPunct, ")"); err != nil {
+			return nil, err
+		}
+		e = inner
+	}
+	preds, err := p.parsePredicates()
+	if err != nil {
+		return nil, err
+	}
+	if len(preds) > 0 {
+		e = &filterExpr{e: e, preds: preds}
+	}
+	return e, nil
+}
+
+func (p *parser) parseCall(t token) (expr, error) {
+	fn, ok := functions[t.val]
+	if !ok {
+		return nil, errorAt(p.src, t.pos, "unknown function %s", t.val)
+	}
+	if err := p.expect(tokPunct, "("); err != nil {
+		return nil, err
+	}
+	var args []expr
+	if !p.accept(tokPunct, ")") {
+		for {
+			a, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, a)
+			if p.accept(tokPunct, ")") {
+				break
+			}
+			if err := p.expect(tokPunct, ","); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(args) < fn.minArgs || fn.maxArgs >= 0 && len(args) > fn.maxArgs {
+		return nil, errorAt(p.src, t.pos, "wrong number of arguments to %s", t.val)
+	}
+	if len(args) == 0 && fn.contextArg {
+		// The argument defaults to a node-set containing the context
+		// node.
+		args = []expr{&pathExpr{steps: []*step{{axis: axisSelf, test: nodeTest{kind: testNode}}}}}
+	}
+	return &callExpr{name: t.val, fn: fn.fn, args: args}, nil
+}
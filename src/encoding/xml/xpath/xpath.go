@@ -0,0 +1,717 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package xpath evaluates XPath 1.0 expressions, as defined by
+// https://www.w3.org/TR/1999/REC-xpath-19991116/, over trees of XML
+// nodes built from the tokens of an [xml.Decoder].
+//
+// A tree is built with [Parse] or [Build], and an expression compiled
+// with [Compile] is evaluated against a node of it:
+//
+//	doc, err := xpath.Parse(r)
+//	...
+//	expr := xpath.MustCompile("/feed/item[price > 10]/@id", nil)
+//	nodes, err := expr.Select(doc)
+//
+// Names in expressions are matched with the name space URLs of
+// elements and attributes. As in XPath 1.0, a name without a prefix
+// matches only names in no name space, so matching an element in a
+// default name space requires binding a prefix to its URL when
+// compiling the expression.
+//
+// The namespace axis, the id function, and variable references are
+// not supported.
+package xpath
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// An Expr is a compiled XPath expression. It is safe for concurrent
+// use by multiple goroutines.
+type Expr struct {
+	src string
+	e   expr
+}
+
+// Compile parses an XPath expression. The name space prefixes used by
+// names in the expression are resolved with namespaces, which maps
+// each prefix to a name space URL, and may be nil. The prefix "xml" is
+// always bound to the XML name space.
+func Compile(expr string, namespaces map[string]string) (*Expr, error) {
+	e, err := parse(expr, namespaces)
+	if err != nil {
+		return nil, err
+	}
+	return &Expr{src: expr, e: e}, nil
+}
+
+// MustCompile is like [Compile] but panics if the expression cannot
+// be parsed.
+func MustCompile(expr string, namespaces map[string]string) *Expr {
+	e, err := Compile(expr, namespaces)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// String returns the source text of the expression.
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Evaluate evaluates the expression with n as the context node. The
+// result is a []*Node holding a node-set in document order, a string,
+// a float64, or a bool.
+func (e *Expr) Evaluate(n *Node) (any, error) {
+	v, err := e.e.eval(&context{node: n, pos: 1, size: 1})
+	if err != nil {
+		return nil, err
+	}
+	if ns, ok := v.(nodeSet); ok {
+		return []*Node(ns), nil
+	}
+	return v, nil
+}
+
+// Select evaluates the expression, which must return a node-set, with
+// n as the context node, and returns the nodes in document order.
+func (e *Expr) Select(n *Node) ([]*Node, error) {
+	v, err := e.e.eval(&context{node: n, pos: 1, size: 1})
+	if err != nil {
+		return nil, err
+	}
+	ns, ok := v.(nodeSet)
+	if !ok {
+		return nil, fmt.Errorf("xpath: %s returned a %s, not a node-set", e.src, typeName(v))
+	}
+	return ns, nil
+}
+
+// EvaluateString evaluates the expression with n as the context node,
+// and converts the result to a string as by the string function.
+func (e *Expr) EvaluateString(n *Node) (string, error) {
+	v, err := e.e.eval(&context{node: n, pos: 1, size: 1})
+	if err != nil {
+		return "", err
+	}
+	return toString(v), nil
+}
+
+// EvaluateNumber evaluates the expression with n as the context node,
+// and converts the result to a number as by the number function.
+func (e *Expr) EvaluateNumber(n *Node) (float64, error) {
+	v, err := e.e.eval(&context{node: n, pos: 1, size: 1})
+	if err != nil {
+		return 0, err
+	}
+	return toNumber(v), nil
+}
+
+// EvaluateBool evaluates the expression with n as the context node,
+// and converts the result to a boolean as by the boolean function.
+func (e *Expr) EvaluateBool(n *Node) (bool, error) {
+	v, err := e.e.eval(&context{node: n, pos: 1, size: 1})
+	if err != nil {
+		return false, err
+	}
+	return toBool(v), nil
+}
+
+// A nodeSet is a node-set, in document order without duplicates.
+type nodeSet []*Node
+
+// A context is the context of the evaluation of an expression.
+type context struct {
+	node      *Node
+	pos, size int
+}
+
+// An expr is a parsed expression. Its value is a nodeSet, string,
+// float64, or bool.
+type expr interface {
+	eval(ctx *context) (any, error)
+}
+
+type literalExpr string
+
+func (e literalExpr) eval(*context) (any, error) { return string(e), nil }
+
+type numberExpr float64
+
+func (e numberExpr) eval(*context) (any, error) { return float64(e), nil }
+
+type negExpr struct{ e expr }
+
+func (e *negExpr) eval(ctx *context) (any, error) {
+	v, err := e.e.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return -toNumber(v), nil
+}
+
+type binaryExpr struct {
+	op   string
+	l, r expr
+}
+
+func (e *binaryExpr) eval(ctx *context) (any, error) {
+	l, err := e.l.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	switch e.op {
+	case "or", "and":
+		// The right operand is not evaluated if the left one
+		// determines the result.
+		if toBool(l) == (e.op == "or") {
+			return e.op == "or", nil
+		}
+		r, err := e.r.eval(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return toBool(r), nil
+	}
+	r, err := e.r.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	switch e.op {
+	case "|":
+		ls, lok := l.(nodeSet)
+		rs, rok := r.(nodeSet)
+		if !lok || !rok {
+			return nil, fmt.Errorf("xpath: operands of | must be node-sets, not %s and %s", typeName(l), typeName(r))
+		}
+		return union(ls, rs), nil
+	case "=", "!=", "<", "<=", ">", ">=":
+		return compare(e.op, l, r), nil
+	}
+	x, y := toNumber(l), toNumber(r)
+	switch e.op {
+	case "+":
+		return x + y, nil
+	case "-":
+		return x - y, nil
+	case "*":
+		return x * y, nil
+	case "div":
+		return x / y, nil
+	case "mod":
+		return math.Mod(x, y), nil
+	}
+	panic("xpath: unknown operator " + e.op)
+}
+
+// compare compares a and b with the operator op, comparing the members
+// of node-sets in turn, as described in section 3.4 of the
+// specification.
+func compare(op string, a, b any) bool {
+	as, aok := a.(nodeSet)
+	bs, bok := b.(nodeSet)
+	switch {
+	case aok && bok:
+		for _, x := range as {
+			sx := x.Text()
+			for _, y := range bs {
+				if compareValues(op, sx, y.Text()) {
+					return true
+				}
+			}
+		}
+		return false
+	case aok:
+		if bb, ok := b.(bool); ok {
+			return compareValues(op, len(as) > 0, bb)
+		}
+		for _, x := range as {
+			if compareValues(op, x.Text(), b) {
+				return true
+			}
+		}
+		return false
+	case bok:
+		if ab, ok := a.(bool); ok {
+			return compareValues(op, ab, len(bs) > 0)
+		}
+		for _, y := range bs {
+			if compareValues(op, a, y.Text()) {
+				return true
+			}
+		}
+		return false
+	}
+	return compareValues(op, a, b)
+}
+
+// compareValues compares two values that are not node-sets.
+func compareValues(op string, a, b any) bool {
+	if op == "=" || op == "!=" {
+		var eq bool
+		_, abool := a.(bool)
+		_, bbool := b.(bool)
+		_, anum := a.(float64)
+		_, bnum := b.(float64)
+		switch {
+		case abool || bbool:
+			eq = toBool(a) == toBool(b)
+		case anum || bnum:
+			eq = toNumber(a) == toNumber(b)
+		default:
+			eq = toString(a) == toString(b)
+		}
+		return eq == (op == "=")
+	}
+	x, y := toNumber(a), toNumber(b)
+	switch op {
+	case "<":
+		return x < y
+	case "<=":
+		return x <= y
+	case ">":
+		return x > y
+	}
+	return x >= y
+}
+
+// A filterExpr filters the node-set returned by an expression with
+// predicates.
+type filterExpr struct {
+	e     expr
+	preds []expr
+}
+
+func (e *filterExpr) eval(ctx *context) (any, error) {
+	v, err := e.e.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ns, ok := v.(nodeSet)
+	if !ok {
+		return nil, fmt.Errorf("xpath: predicate applied to a %s, not a node-set", typeName(v))
+	}
+	for _, pred := range e.preds {
+		if ns, err = filter(ns, pred); err != nil {
+			return nil, err
+		}
+	}
+	return ns, nil
+}
+
+// filter returns the nodes of ns, in their order, that satisfy the
+// predicate pred.
+func filter(ns []*Node, pred expr) ([]*Node, error) {
+	var out []*Node
+	for i, n := range ns {
+		v, err := pred.eval(&context{node: n, pos: i + 1, size: len(ns)})
+		if err != nil {
+			return nil, err
+		}
+		if f, ok := v.(float64); ok {
+			if f == float64(i+1) {
+				out = append(out, n)
+			}
+		} else if toBool(v) {
+			out = append(out, n)
+		}
+	}
+	return out, nil
+}
+
+// A pathExpr is a location path, or a filter expression followed by
+// the steps of a relative location path.
+type pathExpr struct {
+	filter   expr // if non-nil, the expression returning the initial nodes
+	absolute bool // whether the initial node is the root
+	steps    []*step
+}
+
+func (e *pathExpr) eval(ctx *context) (any, error) {
+	var ns nodeSet
+	switch {
+	case e.filter != nil:
+		v, err := e.filter.eval(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var ok bool
+		if ns, ok = v.(nodeSet); !ok {
+			return nil, fmt.Errorf("xpath: path applied to a %s, not a node-set", typeName(v))
+		}
+	case e.absolute:
+		ns = nodeSet{ctx.node.root()}
+	default:
+		ns = nodeSet{ctx.node}
+	}
+	for _, s := range e.steps {
+		var err error
+		if ns, err = s.eval(ns); err != nil {
+			return nil, err
+		}
+	}
+	return ns, nil
+}
+
+// A step is a location step.
+type step struct {
+	axis  axis
+	test  nodeTest
+	preds []expr
+}
+
+// eval returns the nodes selected by the step from each of the nodes
+// in ns.
+func (s *step) eval(ns nodeSet) (nodeSet, error) {
+	var out nodeSet
+	var sel []*Node
+	for _, n := range ns {
+		sel = sel[:0]
+		s.axis.walk(n, func(m *Node) {
+			if s.test.match(m, s.axis.principal()) {
+				sel = append(sel, m)
+			}
+		})
+		matched := sel
+		for _, pred := range s.preds {
+			var err error
+			if matched, err = filter(matched, pred); err != nil {
+				return nil, err
+			}
+		}
+		out = append(out, matched...)
+	}
+	if len(ns) > 1 || s.axis.reverse() {
+		out = sortNodes(out)
+	}
+	return out, nil
+}
+
+type axis int
+
+const (
+	axisAncestor axis = iota
+	axisAncestorOrSelf
+	axisAttribute
+	axisChild
+	axisDescendant
+	axisDescendantOrSelf
+	axisFollowing
+	axisFollowingSibling
+	axisNamespace
+	axisParent
+	axisPreceding
+	axisPrecedingSibling
+	axisSelf
+)
+
+var axisNames = map[string]axis{
+	"ancestor":           axisAncestor,
+	"ancestor-or-self":   axisAncestorOrSelf,
+	"attribute":          axisAttribute,
+	"child":              axisChild,
+	"descendant":         axisDescendant,
+	"descendant-or-self": axisDescendantOrSelf,
+	"following":          axisFollowing,
+	"following-sibling":  axisFollowingSibling,
+	"namespace":          axisNamespace,
+	"parent":             axisParent,
+	"preceding":          axisPreceding,
+	"preceding-sibling":  axisPrecedingSibling,
+	"self":               axisSelf,
+}
+
+// reverse reports whether a is a reverse axis, whose nodes are visited
+// in reverse document order.
+func (a axis) reverse() bool {
+	switch a {
+	case axisAncestor, axisAncestorOrSelf, axisPreceding, axisPrecedingSibling:
+		return true
+	}
+	return false
+}
+
+// principal returns the principal node type of a.
+func (a axis) principal() NodeType {
+	if a == axisAttribute {
+		return AttributeNode
+	}
+	return ElementNode
+}
+
+// walk calls f for each node on the axis a from n, in the order of the
+// axis.
+func (a axis) walk(n *Node, f func(*Node)) {
+	switch a {
+	case axisSelf:
+		f(n)
+	case axisChild:
+		for _, c := range n.Children {
+			f(c)
+		}
+	case axisAttribute:
+		for _, c := range n.Attr {
+			f(c)
+		}
+	case axisParent:
+		if n.Parent != nil {
+			f(n.Parent)
+		}
+	case axisAncestorOrSelf:
+		f(n)
+		fallthrough
+	case axisAncestor:
+		for p := n.Parent; p != nil; p = p.Parent {
+			f(p)
+		}
+	case axisDescendantOrSelf:
+		f(n)
+		fallthrough
+	case axisDescendant:
+		walkDescendants(n, f)
+	case axisFollowingSibling, axisPrecedingSibling:
+		if n.Parent == nil || n.Type == AttributeNode {
+			return
+		}
+		sibs := n.Parent.Children
+		i := slices.Index(sibs, n)
+		if a == axisFollowingSibling {
+			for _, c := range sibs[i+1:] {
+				f(c)
+			}
+		} else {
+			for j := i - 1; j >= 0; j-- {
+				f(sibs[j])
+			}
+		}
+	case axisFollowing:
+		// The nodes after n in document order, except its
+		// descendants and attributes.
+		if n.Type == AttributeNode {
+			n = n.Parent
+			walkDescendants(n, f)
+		}
+		for ; n.Parent != nil; n = n.Parent {
+			sibs := n.Parent.Children
+			for _, c := range sibs[slices.Index(sibs, n)+1:] {
+				f(c)
+				walkDescendants(c, f)
+			}
+		}
+	case axisPreceding:
+		// The nodes before n in document order, except its
+		// ancestors and attributes, in reverse order.
+		if n.Type == AttributeNode {
+			n = n.Parent
+		}
+		for ; n.Parent != nil; n = n.Parent {
+			sibs := n.Parent.Children
+			for j := slices.Index(sibs, n) - 1; j >= 0; j-- {
+				walkDescendantsReverse(sibs[j], f)
+				f(sibs[j])
+			}
+		}
+	}
+}
+
+// walkDescendants calls f for each descendant of n in document order.
+func walkDescendants(n *Node, f func(*Node)) {
+	for _, c := range n.Children {
+		f(c)
+		walkDescendants(c, f)
+	}
+}
+
+// walkDescendantsReverse calls f for each descendant of n in reverse
+// document order.
+func walkDescendantsReverse(n *Node, f func(*Node)) {
+	for i := len(n.Children) - 1; i >= 0; i-- {
+		c := n.Children[i]
+		walkDescendantsReverse(c, f)
+		f(c)
+	}
+}
+
+type testKind int
+
+const (
+	testName     testKind = iota // a name test, or *
+	testNode                     // node()
+	testText                     // text()
+	testComment                  // comment()
+	testProcInst                 // processing-instruction()
+)
+
+// A nodeTest is the node test of a step.
+type nodeTest struct {
+	kind     testKind
+	hasName  bool   // whether local is tested, rather than *
+	hasSpace bool   // for prefix:*, whether space is tested
+	space    string // name space URL
+	local    string // local name, or target of a processing instruction
+}
+
+func (t *nodeTest) match(n *Node, principal NodeType) bool {
+	switch t.kind {
+	case testNode:
+		return true
+	case testText:
+		return n.Type == TextNode
+	case testComment:
+		return n.Type == CommentNode
+	case testProcInst:
+		return n.Type == ProcInstNode && (!t.hasName || n.Name.Local == t.local)
+	}
+	if n.Type != principal {
+		return false
+	}
+	if t.hasName {
+		return n.Name.Local == t.local && n.Name.Space == t.space
+	}
+	return !t.hasSpace || n.Name.Space == t.space
+}
+
+// A callExpr is a function call.
+type callExpr struct {
+	name string
+	fn   func(ctx *context, args []any) (any, error)
+	args []expr
+}
+
+func (e *callExpr) eval(ctx *context) (any, error) {
+	args := make([]any, len(e.args))
+	for i, a := range e.args {
+		v, err := a.eval(ctx)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	v, err := e.fn(ctx, args)
+	if err != nil {
+		return nil, fmt.Errorf("xpath: %s: %w", e.name, err)
+	}
+	return v, nil
+}
+
+// union returns the nodes in a or b, in document order.
+func union(a, b nodeSet) nodeSet {
+	out := make(nodeSet, 0, len(a)+len(b))
+	out = append(out, a...)
+	out = append(out, b...)
+	return sortNodes(out)
+}
+
+// sortNodes sorts ns in document order and removes duplicates.
+func sortNodes(ns nodeSet) nodeSet {
+	slices.SortFunc(ns, func(a, b *Node) int {
+		return cmp.Compare(a.order, b.order)
+	})
+	return slices.Compact(ns)
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case nodeSet:
+		return "node-set"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// toString converts v to a string, as by the string function.
+func toString(v any) string {
+	switch v := v.(type) {
+	case nodeSet:
+		if len(v) == 0 {
+			return ""
+		}
+		return v[0].Text()
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		switch {
+		case math.IsNaN(v):
+			return "NaN"
+		case math.IsInf(v, 1):
+			return "Infinity"
+		case math.IsInf(v, -1):
+			return "-Infinity"
+		case v == 0:
+			return "0"
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+// toNumber converts v to a number, as by the number function.
+func toNumber(v any) float64 {
+	switch v := v.(type) {
+	case nodeSet:
+		return toNumber(toString(v))
+	case string:
+		return parseNumber(v)
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	case float64:
+		return v
+	}
+	return math.NaN()
+}
+
+// parseNumber parses s as an optionally negative decimal number
+// surrounded by white space, returning NaN if it is not one.
+func parseNumber(s string) float64 {
+	s = strings.Trim(s, " \t\r\n")
+	digits := strings.TrimPrefix(s, "-")
+	valid := digits != "" && digits != "."
+	seenDot := false
+	for i := 0; i < len(digits) && valid; i++ {
+		switch c := digits[i]; {
+		case c == '.' && !seenDot:
+			seenDot = true
+		case !isDigit(c):
+			valid = false
+		}
+	}
+	if !valid {
+		return math.NaN()
+	}
+	// A number too large for a float64 is infinite.
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
+
+// toBool converts v to a boolean, as by the boolean function.
+func toBool(v any) bool {
+	switch v := v.(type) {
+	case nodeSet:
+		return len(v) > 0
+	case string:
+		return v != ""
+	case bool:
+		return v
+	case float64:
+		return v != 0 && !math.IsNaN(v)
+	}
+	return false
+}
@@ -0,0 +1,313 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xpath
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+const testDoc = `<?xml version="1.0"?>
+<?feed-version 2?>
+<feed xmlns:v="urn:vendor" xml:lang="en-GB">
+  <!-- items -->
+  <item id="a1" v:rank="2"><name>Apple</name><price>1.50</price></item>
+  <item id="b2" v:rank="1"><name>Banana</name><price>0.25</price><tag>fruit</tag></item>
+  <item id="c3"><name>Cherry <![CDATA[& co]]></name><price>12</price><tag>fruit</tag><tag>red</tag></item>
+  <v:extra xmlns="urn:default"><note>n1</note></v:extra>
+</feed>`
+
+var testNS = map[string]string{"v": "urn:vendor", "d": "urn:default"}
+
+func parseTestDoc(t *testing.T) *Node {
+	t.Helper()
+	doc, err := Parse(strings.NewReader(testDoc))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return doc
+}
+
+// describe returns a short description of the nodes in a node-set.
+func describe(ns []*Node) string {
+	var parts []string
+	for _, n := range ns {
+		switch n.Type {
+		case AttributeNode:
+			parts = append(parts, "@"+n.Name.Local+"="+n.Data)
+		case ElementNode:
+			if id := attrValue(n, "id"); id != "" {
+				parts = append(parts, n.Name.Local+"#"+id)
+			} else {
+				parts = append(parts, n.Name.Local)
+			}
+		case TextNode:
+			parts = append(parts, "text:"+n.Data)
+		case CommentNode:
+			parts = append(parts, "comment:"+n.Data)
+		case ProcInstNode:
+			parts = append(parts, "pi:"+n.Name.Local)
+		case DocumentNode:
+			parts = append(parts, "/")
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func attrValue(n *Node, local string) string {
+	for _, a := range n.Attr {
+		if a.Name.Local == local && a.Name.Space == "" {
+			return a.Data
+		}
+	}
+	return ""
+}
+
+var selectTests = []struct {
+	expr string
+	want string
+}{
+	{"/", "/"},
+	{"/feed/item", "item#a1 item#b2 item#c3"},
+	{"//item[2]", "item#b2"},
+	{"//item[last()]", "item#c3"},
+	{"//item[position() < 3]/@id", "@id=a1 @id=b2"},
+	{"//item[price > 1]/name", "name name"},
+	{"//item[tag = 'red']", "item#c3"},
+	{"//item[not(tag)]", "item#a1"},
+	{"//item[@v:rank]", "item#a1 item#b2"},
+	{"//item[@v:rank = 1]", "item#b2"},
+	{"//@v:*", "@rank=2 @rank=1"},
+	{"//v:extra/d:note", "note"},
+	{"//note", ""},
+	{"//d:*", "note"},
+	{"//tag/..", "item#b2 item#c3"},
+	{"//tag[1]", "tag tag"},
+	{"(//tag)[1]", "tag"},
+	{"(//tag)[last()]/text()", "text:red"},
+	{"//item[3]/ancestor::*", "feed"},
+	{"//item[3]/ancestor-or-self::*[1]", "item#c3"},
+	{"//item[3]/preceding-sibling::item", "item#a1 item#b2"},
+	{"//item[3]/preceding-sibling::item[1]", "item#b2"},
+	{"//item[1]/following-sibling::*", "item#b2 item#c3 extra"},
+	{"//item[2]/following::tag", "tag tag"},
+	{"//item[2]/preceding::name", "name"},
+	{"//item[1]/@id/following::item", "item#b2 item#c3"},
+	{"//item[1]/descendant::text()", "text:Apple text:1.50"},
+	{"/feed/comment()", "comment: items "},
+	{"/processing-instruction()", "pi:feed-version"},
+	{"/processing-instruction('other')", ""},
+	{"//name | //item[1] | //name", "item#a1 name name name"},
+	{"//item[@id='a1' or @id='c3']", "item#a1 item#c3"},
+	{"//item[@id!='a1' and tag]", "item#b2 item#c3"},
+	{"//item[count(tag) = 2]", "item#c3"},
+	{"//item[starts-with(name, 'B')]", "item#b2"},
+	{"//item[contains(name, '&')]", "item#c3"},
+	{"//*[lang('en')]/@id", "@id=a1 @id=b2 @id=c3"},
+	{"//item[.//tag = 'fruit'][2]", "item#c3"},
+	{"//item/self::item[@id = 'b2']", "item#b2"},
+	{"child::feed/child::item[attribute::id = 'c3']", "item#c3"},
+}
+
+func TestSelect(t *testing.T) {
+	doc := parseTestDoc(t)
+	for _, tt := range selectTests {
+		e, err := Compile(tt.expr, testNS)
+		if err != nil {
+			t.Errorf("Compile(%q): %v", tt.expr, err)
+			continue
+		}
+		ns, err := e.Select(doc)
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+		if got := describe(ns); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.expr, got, tt.want)
+		}
+	}
+}
+
+var evaluateTests = []struct {
+	expr string
+	want any
+}{
+	{"count(//item)", 3.0},
+	{"sum(//price)", 13.75},
+	{"sum(//price) div count(//price)", 13.75 / 3},
+	{"string(//item[3]/name)", "Cherry & co"},
+	{"//item[1]/name", "Apple"},
+	{"local-name(//v:extra)", "extra"},
+	{"name(//v:extra)", "v:extra"},
+	{"name(//@v:rank)", "v:rank"},
+	{"name(//d:note)", "note"},
+	{"namespace-uri(//d:note)", "urn:default"},
+	{"concat('a', 1, true(), 2.5)", "a1true2.5"},
+	{"substring('12345', 1.5, 2.6)", "234"},
+	{"substring('12345', 0, 3)", "12"},
+	{"substring('12345', 0 div 0, 3)", ""},
+	{"substring('12345', 1, 0 div 0)", ""},
+	{"substring('12345', -42, 1 div 0)", "12345"},
+	{"substring('12345', -1 div 0, 1 div 0)", ""},
+	{"substring-before('1999/04/01', '/')", "1999"},
+	{"substring-after('1999/04/01', '/')", "04/01"},
+	{"substring-after('1999', '/')", ""},
+	{"string-length('héllo')", 5.0},
+	{"normalize-space('  a \n b  ')", "a b"},
+	{"translate('--aaa--', 'abc-', 'ABC')", "AAA"},
+	{"1 + 2 * 3 - 4 div 2", 5.0},
+	{"7 mod -2", 1.0},
+	{"-7 mod 2", -1.0},
+	{"- - 3", 3.0},
+	{"round(2.5)", 3.0},
+	{"round(-2.5)", -2.0},
+	{"floor(-1.5)", -2.0},
+	{"ceiling(1.1)", 2.0},
+	{"number(' 12.5 ')", 12.5},
+	{"number('1e3')", math.NaN()},
+	{"string(1 div 0)", "Infinity"},
+	{"string(-0)", "0"},
+	{"string(0.1 + 0.2 = 0.3)", "false"},
+	{"string(100000000000000000000)", "100000000000000000000"},
+	{"//item = 'x'", false},
+	{"//price > 10", true},
+	{"//price < //price", true},
+	{"//nothing != ''", false},
+	{"true() = //item", true},
+	{"'1' = 1.0", true},
+	{"boolean('') or 0", false},
+	{"1 and 2 and 'x'", true},
+	{"position() = last()", true},
+}
+
+func TestEvaluate(t *testing.T) {
+	doc := parseTestDoc(t)
+	for _, tt := range evaluateTests {
+		e, err := Compile(tt.expr, testNS)
+		if err != nil {
+			t.Errorf("Compile(%q): %v", tt.expr, err)
+			continue
+		}
+		var got any
+		switch tt.want.(type) {
+		case string:
+			got, err = e.EvaluateString(doc)
+		case float64:
+			got, err = e.EvaluateNumber(doc)
+		case bool:
+			got, err = e.EvaluateBool(doc)
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+		if f, ok := tt.want.(float64); ok && math.IsNaN(f) {
+			if !math.IsNaN(got.(float64)) {
+				t.Errorf("%s = %v, want NaN", tt.expr, got)
+			}
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s = %#v, want %#v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestEvaluateTypes(t *testing.T) {
+	doc := parseTestDoc(t)
+	for expr, want := range map[string]any{
+		"count(//item)": 3.0,
+		"'x'":           "x",
+		"1 < 2":         true,
+	} {
+		got, err := MustCompile(expr, nil).Evaluate(doc)
+		if err != nil || got != want {
+			t.Errorf("Evaluate(%s) = %#v, %v, want %#v", expr, got, err, want)
+		}
+	}
+	got, err := MustCompile("//price", nil).Evaluate(doc)
+	if ns, ok := got.([]*Node); err != nil || !ok || len(ns) != 3 {
+		t.Errorf("Evaluate(//price) = %#v, %v, want 3 nodes", got, err)
+	}
+}
+
+func TestRelativeContext(t *testing.T) {
+	doc := parseTestDoc(t)
+	items, err := MustCompile("//item", nil).Select(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := MustCompile("name", nil)
+	root := MustCompile("count(/feed/item)", nil)
+	for i, want := range []string{"Apple", "Banana", "Cherry & co"} {
+		if got, err := name.EvaluateString(items[i]); err != nil || got != want {
+			t.Errorf("name of item %d = %q, %v, want %q", i, got, err, want)
+		}
+		if got, err := root.EvaluateNumber(items[i]); err != nil || got != 3 {
+			t.Errorf("count(/feed/item) from item %d = %v, %v, want 3", i, got, err)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"//",
+		"/feed/",
+		"item[",
+		"item[1",
+		"'unterminated",
+		"x:item",
+		"namespace::*",
+		"bogus::item",
+		"unknown()",
+		"count()",
+		"count(1, 2)",
+		"$var",
+		"item item",
+		"1 +",
+		"a!b",
+		"@",
+		"processing-instruction(1)",
+	} {
+		if _, err := Compile(expr, nil); err == nil {
+			t.Errorf("Compile(%q) succeeded, want error", expr)
+		}
+	}
+}
+
+func TestEvaluateErrors(t *testing.T) {
+	doc := parseTestDoc(t)
+	for _, expr := range []string{
+		"1 | //item",
+		"'a'[1]",
+		"count('a')",
+		"'a'/item",
+	} {
+		e, err := Compile(expr, nil)
+		if err != nil {
+			t.Errorf("Compile(%q): %v", expr, err)
+			continue
+		}
+		if _, err := e.Evaluate(doc); err == nil {
+			t.Errorf("Evaluate(%q) succeeded, want error", expr)
+		}
+	}
+	if _, err := MustCompile("count(//item)", nil).Select(doc); err == nil {
+		t.Errorf("Select of a number succeeded, want error")
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	for _, doc := range []string{
+		"<a>",
+		"<a></b>",
+	} {
+		if _, err := Parse(strings.NewReader(doc)); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", doc)
+		}
+	}
+}
@@ -268,6 +268,9 @@ var depsRules = `
 	< encoding/ascii85, encoding/csv, encoding/gob, encoding/hex,
 	  encoding/pem, encoding/xml, mime;
 
+	encoding/xml
+	< encoding/xml/xpath;
+
 	STR, errors
 	< encoding/json/internal
 	< encoding/json/internal/jsonflags
@@ -297,6 +300,9 @@ var depsRules = `
 	FMT, math/rand
 	< math/big;
 
+	FMT, encoding/binary, math/big
+	< encoding/cbor;
+
 	# compression
 	FMT, encoding/binary, hash/adler32, hash/crc32, sort
 	< compress/bzip2, compress/flate, compress/lzw, internal/zstd
@@ -479,6 +485,9 @@ var depsRules = `
 	NET, log
 	< net/mail;
 
+	NET, log
+	< net/socks;
+
 	# FIPS is the FIPS 140 module.
 	# It must not depend on external crypto packages.
 	# Package hash is ok as it's only the interface.
@@ -670,8 +679,17 @@ var depsRules = `
 	encoding/json, net/http
 	< expvar;
 
-	net/http, net/http/internal/ascii
-	< net/http/cookiejar, net/http/httputil;
+	net/http, net/http/internal/ascii, hash/fnv
+	< net/http/cookiejar, net/http/httpcache, net/http/httputil, net/http/websocket;
+
+	net/http
+	< net/http/sse;
+
+	encoding/json/v2, net/http
+	< net/http/httpapi;
+
+	net/http, net/http/internal/ascii, net/http/internal/httpsfv
+	< net/http/httpsig;
 
 	net/http, flag
 	< net/http/httptest;
@@ -20,3 +20,14 @@ func GetPipeFds(p *SplicePipe) (int, int) {
 }
 
 type SplicePipe = splicePipe
+
+// UringEnabled reports whether file I/O is using io_uring.
+func UringEnabled() bool {
+	return getUring() != nil
+}
+
+// UringSocketsEnabled reports whether socket I/O is using io_uring.
+func UringSocketsEnabled() bool {
+	r := getUring()
+	return r != nil && r.sockets
+}
@@ -45,6 +45,15 @@ type FD struct {
 
 	// Whether this is a file rather than a network socket.
 	isFile bool
+
+	// Whether reads and writes may be submitted to io_uring.
+	// Set for descriptors that the runtime poller rejects as
+	// not supporting readiness notification, such as regular files.
+	useUring bool
+
+	// For a socket whose I/O is submitted to io_uring, its state
+	// there. Nil otherwise.
+	uringSock *uringSocket
 }
 
 // Init initializes the FD. The Sysfd field should already be set.
@@ -68,8 +77,13 @@ func (fd *FD) Init(net string, pollable bool) error {
 		// If we could not initialize the runtime poller,
 		// assume we are using blocking mode.
 		fd.isBlocking = 1
+		fd.useUring = err == syscall.EPERM
+		return err
 	}
-	return err
+	if !fd.isFile {
+		fd.uringSock = newUringSocket()
+	}
+	return nil
 }
 
 // Destroy closes the file descriptor. This is called when there are
@@ -99,6 +113,7 @@ func (fd *FD) Close() error {
 	// fairly quickly, since all the I/O is non-blocking, and any
 	// attempts to block in the pollDesc will return errClosing(fd.isFile).
 	fd.pd.evict()
+	uringEvict(fd)
 
 	// The call to decref will call destroy if there are no other
 	// references.
@@ -158,7 +173,7 @@ func (fd *FD) Read(p []byte) (int, error) {
 		p = p[:maxRW]
 	}
 	for {
-		n, err := ignoringEINTRIO(syscall.Read, fd.Sysfd, p)
+		n, err := fd.read(p)
 		if err != nil {
 			n = 0
 			if err == syscall.EAGAIN && fd.pd.pollable() {
@@ -172,6 +187,31 @@ func (fd *FD) Read(p []byte) (int, error) {
 	}
 }
 
+// read reads from the descriptor, using io_uring if it is in use.
+func (fd *FD) read(p []byte) (int, error) {
+	if fd.useUring {
+		if n, err, handled := uringRead(fd.Sysfd, p, -1); handled {
+			return n, err
+		}
+	}
+	if n, err, handled := uringRecv(fd, p); handled {
+		return n, err
+	}
+	return ignoringEINTRIO(syscall.Read, fd.Sysfd, p)
+}
+
+// pread is like read, but reads at the given offset.
+func (fd *FD) pread(p []byte, off int64) (int, error) {
+	if fd.useUring {
+		if n, err, handled := uringRead(fd.Sysfd, p, off); handled {
+			return n, err
+		}
+	}
+	return ignoringEINTR2(func() (int, error) {
+		return syscall.Pread(fd.Sysfd, p, off)
+	})
+}
+
 // Pread wraps the pread system call.
 func (fd *FD) Pread(p []byte, off int64) (int, error) {
 	// Call incref, not readLock, because since pread specifies the
@@ -183,9 +223,7 @@ func (fd *FD) Pread(p []byte, off int64) (int, error) {
 	if fd.IsStream && len(p) > maxRW {
 		p = p[:maxRW]
 	}
-	n, err := ignoringEINTR2(func() (int, error) {
-		return syscall.Pread(fd.Sysfd, p, off)
-	})
+	n, err := fd.pread(p, off)
 	if err != nil {
 		n = 0
 	}
@@ -371,7 +409,7 @@ func (fd *FD) Write(p []byte) (int, error) {
 		if fd.IsStream && max-nn > maxRW {
 			max = nn + maxRW
 		}
-		n, err := ignoringEINTRIO(syscall.Write, fd.Sysfd, p[nn:max])
+		n, err := fd.write(p[nn:max])
 		if n > 0 {
 			if n > max-nn {
 				// This can reportedly happen when using
@@ -400,6 +438,30 @@ func (fd *FD) Write(p []byte) (int, error) {
 	}
 }
 
+// write writes to the descriptor, using io_uring if it is in use.
+func (fd *FD) write(p []byte) (int, error) {
+	if fd.useUring {
+		if n, err, handled := uringWrite(fd.Sysfd, p, -1); handled {
+			return n, err
+		}
+	}
+	if n, err, handled := uringSend(fd, p); handled {
+		return n, err
+	}
+	return ignoringEINTRIO(syscall.Write, fd.Sysfd, p)
+}
+
+// pwrite is like write, but writes at the given offset.
+// An EINTR error is returned to the caller.
+func (fd *FD) pwrite(p []byte, off int64) (int, error) {
+	if fd.useUring {
+		if n, err, handled := uringWrite(fd.Sysfd, p, off); handled {
+			return n, err
+		}
+	}
+	return syscall.Pwrite(fd.Sysfd, p, off)
+}
+
 // Pwrite wraps the pwrite system call.
 func (fd *FD) Pwrite(p []byte, off int64) (int, error) {
 	// Call incref, not writeLock, because since pwrite specifies the
@@ -415,7 +477,7 @@ func (fd *FD) Pwrite(p []byte, off int64) (int, error) {
 		if fd.IsStream && max-nn > maxRW {
 			max = nn + maxRW
 		}
-		n, err := syscall.Pwrite(fd.Sysfd, p[nn:max], off+int64(nn))
+		n, err := fd.pwrite(p[nn:max], off+int64(nn))
 		if err == syscall.EINTR {
 			continue
 		}
@@ -601,7 +663,7 @@ func (fd *FD) Accept() (int, syscall.Sockaddr, string, error) {
 		return -1, nil, "", err
 	}
 	for {
-		s, rsa, errcall, err := accept(fd.Sysfd)
+		s, rsa, errcall, err := fd.accept()
 		if err == nil {
 			return s, rsa, "", err
 		}
@@ -624,6 +686,14 @@ func (fd *FD) Accept() (int, syscall.Sockaddr, string, error) {
 	}
 }
 
+// accept accepts a connection, using io_uring if it is in use.
+func (fd *FD) accept() (int, syscall.Sockaddr, string, error) {
+	if s, rsa, errcall, err, handled := uringAccept(fd); handled {
+		return s, rsa, errcall, err
+	}
+	return accept(fd.Sysfd)
+}
+
 // Fchmod wraps syscall.Fchmod.
 func (fd *FD) Fchmod(mode uint32) error {
 	if err := fd.incref(); err != nil {
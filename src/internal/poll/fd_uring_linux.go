@@ -0,0 +1,673 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package poll
+
+import (
+	"internal/abi"
+	"internal/godebug"
+	"internal/syscall/unix"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// When GODEBUG=iouring=1 is set, reads and writes on descriptors that
+// are not managed by the runtime poller (in practice, regular files)
+// are submitted to a shared io_uring instead of being issued as
+// blocking system calls. Completions are signaled through an eventfd
+// that is itself registered with the runtime poller, so a goroutine
+// waiting for file I/O is parked rather than holding an OS thread.
+//
+// Sockets also submit accepts, receives (for Read) and sends (for
+// Write) to the uring when the kernel can wait for socket readiness
+// itself. Their other operations still use the runtime poller. Since
+// the runtime poller does not expose deadlines, each socket keeps its
+// own copy, and an operation in flight is canceled when its deadline
+// expires or the socket is closed, as with CancelIoEx on Windows.
+//
+// Buffers on the heap are passed to the kernel directly, and pinned
+// until the operation completes. Buffers elsewhere, such as on the
+// stack of the goroutine, which may move while it is parked waiting for
+// the operation, are copied through a buffer on the heap. The buffers
+// are hidden from escape analysis, so that callers of Read and Write
+// can keep their buffers on the stack whether or not the uring is used.
+//
+// If the kernel does not support io_uring, or the process is not
+// permitted to use it, the ordinary system calls are used.
+var iouring = godebug.New("iouring")
+
+const (
+	uringOpAccept      = 13 // IORING_OP_ACCEPT
+	uringOpAsyncCancel = 14 // IORING_OP_ASYNC_CANCEL
+	uringOpRead        = 22 // IORING_OP_READ
+	uringOpWrite       = 23 // IORING_OP_WRITE
+	uringOpSend        = 26 // IORING_OP_SEND
+	uringOpRecv        = 27 // IORING_OP_RECV
+
+	// uringEntries is the size of the submission queue.
+	uringEntries = 256
+
+	// uringBounceSize is the size of the buffer through which data
+	// is copied for buffers that are not on the heap. Transfers
+	// through it are limited to its size.
+	uringBounceSize = 64 << 10
+
+	// uringFeatNoDrop is IORING_FEAT_NODROP: completions that do
+	// not fit in the completion queue are held by the kernel rather
+	// than dropped, so the number of operations in flight need not
+	// be bounded by its size.
+	uringFeatNoDrop = 1 << 1
+
+	// uringFeatRWCurPos is IORING_FEAT_RW_CUR_POS, which allows
+	// an offset of -1 to mean the current file position.
+	uringFeatRWCurPos = 1 << 3
+
+	// uringFeatFastPoll is IORING_FEAT_FAST_POLL: an operation on a
+	// socket that is not ready waits for readiness in the kernel
+	// instead of blocking a kernel worker thread, which is what
+	// allows it to be canceled promptly.
+	uringFeatFastPoll = 1 << 5
+
+	// uringSQCQOverflow is IORING_SQ_CQ_OVERFLOW, set in the
+	// submission queue flags while the kernel holds completions
+	// that did not fit in the completion queue.
+	uringSQCQOverflow = 1 << 1
+)
+
+// uringSQE mirrors struct io_uring_sqe.
+type uringSQE struct {
+	opcode      uint8
+	flags       uint8
+	ioprio      uint16
+	fd          int32
+	off         uint64
+	addr        uint64
+	len         uint32
+	opFlags     uint32
+	userData    uint64
+	bufIndex    uint16
+	personality uint16
+	spliceFdIn  int32
+	addr3       uint64
+	_           uint64
+}
+
+// uringCQE mirrors struct io_uring_cqe.
+type uringCQE struct {
+	userData uint64
+	res      int32
+	flags    uint32
+}
+
+// A uringOp holds the state of a single operation.
+// Operations are reused through uringOpPool.
+type uringOp struct {
+	pinner runtime.Pinner
+	done   chan int32
+	bounce *[uringBounceSize]byte // allocated on first use
+}
+
+// A uringBuf is the buffer of an operation. The pointer to it is
+// hidden from escape analysis, see hideBuf.
+type uringBuf struct {
+	ptr unsafe.Pointer
+	len int
+}
+
+// hideBuf returns p as a uringBuf, without p escaping. The uringBuf
+// must only be kept in local variables, whose pointers into the stack
+// are adjusted when the stack moves.
+func hideBuf(p []byte) uringBuf {
+	return uringBuf{ptr: abi.NoEscape(unsafe.Pointer(unsafe.SliceData(p))), len: len(p)}
+}
+
+// runtime_isHeapPointer reports whether p points into the Go heap.
+// Provided by the runtime.
+//
+//go:noescape
+func runtime_isHeapPointer(p unsafe.Pointer) bool
+
+// setBuf sets b as the buffer of sqe, and pins it. If b is not on the
+// heap, op.bounce is used in its place, holding a copy of the data of
+// a write.
+func (op *uringOp) setBuf(sqe *uringSQE, b uringBuf) {
+	ptr, n := b.ptr, min(b.len, maxRW)
+	if !runtime_isHeapPointer(ptr) {
+		if op.bounce == nil {
+			op.bounce = new([uringBounceSize]byte)
+		}
+		n = min(n, uringBounceSize)
+		if sqe.opcode == uringOpWrite || sqe.opcode == uringOpSend {
+			copy(op.bounce[:n], unsafe.Slice((*byte)(ptr), n))
+		}
+		ptr = unsafe.Pointer(op.bounce)
+	}
+	op.pinner.Pin(ptr)
+	sqe.addr = uint64(uintptr(ptr))
+	sqe.len = uint32(n)
+}
+
+// releaseBuf unpins the buffer set by setBuf for sqe, after the
+// operation completed with result res. If op.bounce was used in place
+// of b, the data of a read is copied from it to b.
+func (op *uringOp) releaseBuf(sqe *uringSQE, b uringBuf, res int32) {
+	op.pinner.Unpin()
+	if op.bounce == nil || sqe.addr != uint64(uintptr(unsafe.Pointer(op.bounce))) {
+		return
+	}
+	if res > 0 && (sqe.opcode == uringOpRead || sqe.opcode == uringOpRecv) {
+		copy(unsafe.Slice((*byte)(b.ptr), res), op.bounce[:res])
+	}
+}
+
+var uringOpPool = sync.Pool{
+	New: func() any {
+		return &uringOp{done: make(chan int32, 1)}
+	},
+}
+
+// A uring is a submission and completion queue pair.
+type uring struct {
+	fd int
+
+	// Memory shared with the kernel.
+	mmaps [][]byte
+
+	// Completions are signaled on efd.
+	efd FD
+
+	sqHead  *uint32
+	sqTail  *uint32
+	sqFlags *uint32
+	sqMask  uint32
+	sqArray []uint32
+	sqes    []uringSQE
+
+	cqHead *uint32
+	cqTail *uint32
+	cqMask uint32
+	cqes   []uringCQE
+
+	// sockets reports whether socket operations may be submitted.
+	sockets bool
+
+	// mu serializes submissions.
+	mu sync.Mutex
+
+	// cqMu serializes consumption of the completion queue.
+	cqMu sync.Mutex
+
+	// ops holds the operations in flight, indexed by user data.
+	// User data 0 marks operations whose completions are ignored.
+	opsMu sync.Mutex
+	ops   map[uint64]*uringOp
+	seq   uint64
+}
+
+var (
+	uringOnce     sync.Once
+	uringInstance *uring
+)
+
+// getUring returns the process-wide uring, or nil if io_uring is not
+// enabled or not available.
+func getUring() *uring {
+	uringOnce.Do(func() {
+		if iouring.Value() != "1" {
+			return
+		}
+		// IORING_OP_READ and IORING_OP_WRITE require Linux 5.6.
+		if !unix.KernelVersionGE(5, 6) {
+			return
+		}
+		r, err := newUring(uringEntries)
+		if err != nil {
+			return
+		}
+		uringInstance = r
+	})
+	return uringInstance
+}
+
+// newUring sets up a new uring and starts the goroutine that
+// dispatches its completions.
+func newUring(entries uint32) (r *uring, err error) {
+	var params unix.IoUringParams
+	fd, err := unix.IoUringSetup(entries, &params)
+	if err != nil {
+		return nil, err
+	}
+	r = &uring{fd: fd}
+	defer func() {
+		if err != nil {
+			r.close()
+		}
+	}()
+	if params.Features&uringFeatRWCurPos == 0 || params.Features&uringFeatNoDrop == 0 {
+		return r, syscall.ENOSYS
+	}
+	r.sockets = params.Features&uringFeatFastPoll != 0
+
+	sqRing, err := r.mmap(unix.IORING_OFF_SQ_RING, params.SqOff.Array+params.SqEntries*4)
+	if err != nil {
+		return r, err
+	}
+	cqRing, err := r.mmap(unix.IORING_OFF_CQ_RING, params.CqOff.Cqes+params.CqEntries*uint32(unsafe.Sizeof(uringCQE{})))
+	if err != nil {
+		return r, err
+	}
+	sqes, err := r.mmap(unix.IORING_OFF_SQES, params.SqEntries*uint32(unsafe.Sizeof(uringSQE{})))
+	if err != nil {
+		return r, err
+	}
+
+	r.sqHead = (*uint32)(unsafe.Pointer(&sqRing[params.SqOff.Head]))
+	r.sqTail = (*uint32)(unsafe.Pointer(&sqRing[params.SqOff.Tail]))
+	r.sqFlags = (*uint32)(unsafe.Pointer(&sqRing[params.SqOff.Flags]))
+	r.sqMask = *(*uint32)(unsafe.Pointer(&sqRing[params.SqOff.RingMask]))
+	r.sqArray = unsafe.Slice((*uint32)(unsafe.Pointer(&sqRing[params.SqOff.Array])), params.SqEntries)
+	r.sqes = unsafe.Slice((*uringSQE)(unsafe.Pointer(&sqes[0])), params.SqEntries)
+	r.cqHead = (*uint32)(unsafe.Pointer(&cqRing[params.CqOff.Head]))
+	r.cqTail = (*uint32)(unsafe.Pointer(&cqRing[params.CqOff.Tail]))
+	r.cqMask = *(*uint32)(unsafe.Pointer(&cqRing[params.CqOff.RingMask]))
+	r.cqes = unsafe.Slice((*uringCQE)(unsafe.Pointer(&cqRing[params.CqOff.Cqes])), params.CqEntries)
+
+	efd, err := unix.Eventfd(0, unix.EFD_CLOEXEC|unix.EFD_NONBLOCK)
+	if err != nil {
+		return r, err
+	}
+	// Register the eventfd with the runtime poller directly rather
+	// than with Init, which would consult the uring being set up.
+	r.efd = FD{Sysfd: efd, IsStream: true}
+	if err := r.efd.pd.init(&r.efd); err != nil {
+		syscall.Close(efd)
+		r.efd.Sysfd = -1
+		return r, err
+	}
+	efd32 := int32(efd)
+	if err := unix.IoUringRegister(fd, unix.IORING_REGISTER_EVENTFD, unsafe.Pointer(&efd32), 1); err != nil {
+		return r, err
+	}
+
+	r.ops = make(map[uint64]*uringOp)
+	go r.reap()
+	return r, nil
+}
+
+// mmap maps size bytes of the ring at the given offset.
+func (r *uring) mmap(offset int64, size uint32) ([]byte, error) {
+	b, err := syscall.Mmap(r.fd, offset, int(size), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED|syscall.MAP_POPULATE)
+	if err != nil {
+		return nil, err
+	}
+	r.mmaps = append(r.mmaps, b)
+	return b, nil
+}
+
+// close releases the ring's resources. It is only used when setup
+// fails; a running uring lives for the remainder of the process.
+func (r *uring) close() {
+	if r.efd.Sysfd > 0 {
+		r.efd.Close()
+	}
+	for _, b := range r.mmaps {
+		syscall.Munmap(b)
+	}
+	syscall.Close(r.fd)
+}
+
+// reap waits for completions and hands their results to the
+// goroutines that submitted them.
+func (r *uring) reap() {
+	var buf [8]byte
+	for {
+		if _, err := r.efd.Read(buf[:]); err != nil && err != syscall.EINTR {
+			// The eventfd is never closed while the ring
+			// is in use, so this should not happen.
+			panic("poll: io_uring eventfd read failed: " + err.Error())
+		}
+		r.drain()
+	}
+}
+
+// drain consumes all entries currently in the completion queue,
+// including those the kernel held back because the queue was full.
+func (r *uring) drain() {
+	r.cqMu.Lock()
+	defer r.cqMu.Unlock()
+	for {
+		head := atomic.LoadUint32(r.cqHead)
+		tail := atomic.LoadUint32(r.cqTail)
+		for ; head != tail; head++ {
+			cqe := r.cqes[head&r.cqMask]
+			r.opsMu.Lock()
+			op := r.ops[cqe.userData]
+			delete(r.ops, cqe.userData)
+			r.opsMu.Unlock()
+			if op != nil {
+				op.done <- cqe.res
+			}
+		}
+		atomic.StoreUint32(r.cqHead, head)
+		if atomic.LoadUint32(r.sqFlags)&uringSQCQOverflow == 0 {
+			return
+		}
+		// Have the kernel move the completions it held back
+		// into the queue, which now has room.
+		ignoringEINTR2(func() (int, error) {
+			return unix.IoUringEnter(r.fd, 0, 0, unix.IORING_ENTER_GETEVENTS)
+		})
+	}
+}
+
+// submit queues sqe and returns the user data identifying it. If op
+// is not nil, its result is sent on op.done when it completes.
+func (r *uring) submit(op *uringOp, sqe uringSQE) (uint64, error) {
+	if op != nil {
+		r.opsMu.Lock()
+		r.seq++
+		sqe.userData = r.seq
+		r.ops[sqe.userData] = op
+		r.opsMu.Unlock()
+	}
+
+	for {
+		r.mu.Lock()
+		tail := *r.sqTail
+		idx := tail & r.sqMask
+		r.sqes[idx] = sqe
+		r.sqArray[idx] = idx
+		atomic.StoreUint32(r.sqTail, tail+1)
+		_, err := ignoringEINTR2(func() (int, error) {
+			return unix.IoUringEnter(r.fd, 1, 0, 0)
+		})
+		if err != nil {
+			if atomic.LoadUint32(r.sqHead) == tail {
+				// The entry was not consumed. Since
+				// submissions are serialized by mu,
+				// withdraw it so that the queue is left
+				// as it was.
+				atomic.StoreUint32(r.sqTail, tail)
+			} else {
+				// The kernel owns the entry now, and
+				// will post a completion for it.
+				err = nil
+			}
+		}
+		r.mu.Unlock()
+		switch err {
+		case nil:
+			return sqe.userData, nil
+		case syscall.EBUSY:
+			// Older kernels refuse submissions while they
+			// hold back completions.
+			r.drain()
+			continue
+		case syscall.EAGAIN:
+			// The kernel could not allocate memory for
+			// the request; try again later.
+			runtime.Gosched()
+			continue
+		}
+		if op != nil {
+			r.opsMu.Lock()
+			delete(r.ops, sqe.userData)
+			r.opsMu.Unlock()
+		}
+		return 0, err
+	}
+}
+
+// cancel asks the kernel to cancel the operation identified by
+// userData. The operation then completes, with -ECANCELED if it was
+// canceled before it could finish.
+func (r *uring) cancel(userData uint64) {
+	r.submit(nil, uringSQE{opcode: uringOpAsyncCancel, fd: -1, addr: userData})
+}
+
+// do submits an operation and waits for its completion. It returns
+// the result of the operation: a count, a descriptor, or a negated
+// errno. The buffer b, if not empty, is pinned until then.
+func (r *uring) do(sqe uringSQE, b uringBuf) (res int32, err error) {
+	op := uringOpPool.Get().(*uringOp)
+	defer uringOpPool.Put(op)
+	if b.len > 0 {
+		op.setBuf(&sqe, b)
+		defer func() { op.releaseBuf(&sqe, b, res) }()
+	}
+	if _, err := r.submit(op, sqe); err != nil {
+		return 0, err
+	}
+	return <-op.done, nil
+}
+
+// result converts the result of a transfer into a count and an error.
+func result(res int32) (int, error) {
+	if res < 0 {
+		return 0, syscall.Errno(-res)
+	}
+	return int(res), nil
+}
+
+// uringRead reads into p from fd at offset off using the uring.
+// An offset of -1 means the current file position.
+// It reports handled as false if the uring is not in use.
+func uringRead(fd int, p []byte, off int64) (n int, err error, handled bool) {
+	r := getUring()
+	if r == nil {
+		return 0, nil, false
+	}
+	iouring.IncNonDefault()
+	res, err := r.do(uringSQE{opcode: uringOpRead, fd: int32(fd), off: uint64(off)}, hideBuf(p))
+	if err != nil {
+		return 0, err, true
+	}
+	n, err = result(res)
+	return n, err, true
+}
+
+// uringWrite writes p to fd at offset off using the uring.
+// An offset of -1 means the current file position.
+// It reports handled as false if the uring is not in use.
+func uringWrite(fd int, p []byte, off int64) (n int, err error, handled bool) {
+	r := getUring()
+	if r == nil {
+		return 0, nil, false
+	}
+	iouring.IncNonDefault()
+	res, err := r.do(uringSQE{opcode: uringOpWrite, fd: int32(fd), off: uint64(off)}, hideBuf(p))
+	if err != nil {
+		return 0, err, true
+	}
+	n, err = result(res)
+	return n, err, true
+}
+
+// A uringSocket is the uring state of a socket.
+type uringSocket struct {
+	r *uring
+
+	mu      sync.Mutex
+	rd, wd  time.Time // read and write deadlines
+	closing bool
+
+	// wake is closed, and replaced, when a deadline changes or
+	// the socket is closed, to wake the goroutines waiting for
+	// its operations.
+	wake chan struct{}
+}
+
+// newUringSocket returns the uring state for a socket, or nil if
+// socket operations are not submitted to the uring.
+func newUringSocket() *uringSocket {
+	r := getUring()
+	if r == nil || !r.sockets {
+		return nil
+	}
+	return &uringSocket{r: r, wake: make(chan struct{})}
+}
+
+// setDeadline records the deadline t for mode, which is 'r', 'w',
+// or 'r'+'w'.
+func (s *uringSocket) setDeadline(t time.Time, mode int) {
+	s.mu.Lock()
+	if mode == 'r' || mode == 'r'+'w' {
+		s.rd = t
+	}
+	if mode == 'w' || mode == 'r'+'w' {
+		s.wd = t
+	}
+	close(s.wake)
+	s.wake = make(chan struct{})
+	s.mu.Unlock()
+}
+
+// evict wakes the goroutines waiting for the socket's operations,
+// which are then canceled, because the socket is being closed.
+func (s *uringSocket) evict() {
+	s.mu.Lock()
+	if !s.closing {
+		s.closing = true
+		close(s.wake)
+		s.wake = make(chan struct{})
+	}
+	s.mu.Unlock()
+}
+
+// do submits an operation of the given mode, 'r' or 'w', on the
+// socket, and waits for its completion. If the socket is closed or the
+// deadline for mode expires first, the operation is canceled, and
+// if that succeeds the corresponding error is returned.
+func (s *uringSocket) do(mode int, sqe uringSQE, b uringBuf) (res int32, err error) {
+	op := uringOpPool.Get().(*uringOp)
+	defer uringOpPool.Put(op)
+	if b.len > 0 {
+		op.setBuf(&sqe, b)
+		defer func() { op.releaseBuf(&sqe, b, res) }()
+	}
+	userData, err := s.r.submit(op, sqe)
+	if err != nil {
+		return 0, err
+	}
+	for {
+		s.mu.Lock()
+		closing, deadline, wake := s.closing, s.rd, s.wake
+		if mode == 'w' {
+			deadline = s.wd
+		}
+		s.mu.Unlock()
+
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		switch {
+		case closing:
+			err = ErrNetClosing
+		case deadline.IsZero():
+		case time.Until(deadline) <= 0:
+			err = ErrDeadlineExceeded
+		default:
+			timer = time.NewTimer(time.Until(deadline))
+			timeout = timer.C
+		}
+		if err == nil {
+			select {
+			case res = <-op.done:
+				if timer != nil {
+					timer.Stop()
+				}
+				return res, nil
+			case <-timeout:
+			case <-wake:
+			}
+			if timer != nil {
+				timer.Stop()
+			}
+			continue
+		}
+
+		s.r.cancel(userData)
+		res = <-op.done
+		if res == -int32(syscall.ECANCELED) || res == -int32(syscall.EINTR) {
+			return 0, err
+		}
+		// The operation finished before it could be canceled.
+		return res, nil
+	}
+}
+
+// uringRecv reads into p from the socket fd using the uring.
+// It reports handled as false if the uring is not in use.
+func uringRecv(fd *FD, p []byte) (n int, err error, handled bool) {
+	if fd.uringSock == nil {
+		return 0, nil, false
+	}
+	iouring.IncNonDefault()
+	res, err := fd.uringSock.do('r', uringSQE{opcode: uringOpRecv, fd: int32(fd.Sysfd)}, hideBuf(p))
+	if err != nil {
+		return 0, err, true
+	}
+	n, err = result(res)
+	return n, err, true
+}
+
+// uringSend writes p to the socket fd using the uring.
+// It reports handled as false if the uring is not in use.
+func uringSend(fd *FD, p []byte) (n int, err error, handled bool) {
+	if fd.uringSock == nil {
+		return 0, nil, false
+	}
+	iouring.IncNonDefault()
+	res, err := fd.uringSock.do('w', uringSQE{opcode: uringOpSend, fd: int32(fd.Sysfd), opFlags: syscall.MSG_NOSIGNAL}, hideBuf(p))
+	if err != nil {
+		return 0, err, true
+	}
+	n, err = result(res)
+	return n, err, true
+}
+
+// uringAccept accepts a connection on the socket fd using the uring.
+// It reports handled as false if the uring is not in use.
+func uringAccept(fd *FD) (s int, rsa syscall.Sockaddr, errcall string, err error, handled bool) {
+	if fd.uringSock == nil {
+		return -1, nil, "", nil, false
+	}
+	iouring.IncNonDefault()
+	res, err := fd.uringSock.do('r', uringSQE{opcode: uringOpAccept, fd: int32(fd.Sysfd), opFlags: syscall.SOCK_NONBLOCK | syscall.SOCK_CLOEXEC}, uringBuf{})
+	if err != nil {
+		return -1, nil, "", err, true
+	}
+	if res < 0 {
+		return -1, nil, "accept4", syscall.Errno(-res), true
+	}
+	s = int(res)
+	// The kernel could report the peer's address, but converting it
+	// to a Sockaddr is up to package syscall, so ask for it again.
+	rsa, err = syscall.Getpeername(s)
+	if err != nil {
+		// The connection is already gone; treat it like
+		// one aborted before it was accepted.
+		CloseFunc(s)
+		return -1, nil, "getpeername", syscall.ECONNABORTED, true
+	}
+	return s, rsa, "", nil, true
+}
+
+// uringSetDeadline records a deadline set on fd.
+func uringSetDeadline(fd *FD, t time.Time, mode int) {
+	if fd.uringSock != nil {
+		fd.uringSock.setDeadline(t, mode)
+	}
+}
+
+// uringEvict cancels the operations in flight on fd, which is being
+// closed.
+func uringEvict(fd *FD) {
+	if fd.uringSock != nil {
+		fd.uringSock.evict()
+	}
+}
@@ -0,0 +1,322 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package poll_test
+
+import (
+	"bytes"
+	"errors"
+	"internal/poll"
+	"internal/testenv"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// inUringChild reports whether the test is running with
+// GODEBUG=iouring=1. If not, it runs the test again in a child process
+// with that setting.
+func inUringChild(t *testing.T) bool {
+	if strings.Contains(os.Getenv("GODEBUG"), "iouring=1") {
+		return true
+	}
+	cmd := testenv.Command(t, testenv.Executable(t), "-test.run=^"+t.Name()+"$", "-test.v")
+	cmd.Env = append(cmd.Environ(), "GODEBUG=iouring=1")
+	out, err := cmd.CombinedOutput()
+	t.Logf("%s", out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return false
+}
+
+func TestUringFileIO(t *testing.T) {
+	if !inUringChild(t) {
+		return
+	}
+	if !poll.UringEnabled() {
+		t.Skip("io_uring not available")
+	}
+
+	name := filepath.Join(t.TempDir(), "file")
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// Larger than a single io_uring transfer.
+	want := bytes.Repeat([]byte("0123456789abcdef"), 20000)
+	if n, err := f.Write(want); n != len(want) || err != nil {
+		t.Fatalf("Write = %d, %v; want %d, nil", n, err, len(want))
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("ReadAll returned %d bytes, not the %d bytes written", len(got), len(want))
+	}
+
+	if _, err := f.WriteAt([]byte("xyz"), 16); err != nil {
+		t.Fatal(err)
+	}
+	copy(want[16:], "xyz")
+
+	var wg sync.WaitGroup
+	for i := range 16 {
+		wg.Go(func() {
+			off := int64(i * 1000)
+			buf := make([]byte, 100)
+			if _, err := f.ReadAt(buf, off); err != nil {
+				t.Errorf("ReadAt(%d): %v", off, err)
+				return
+			}
+			if !bytes.Equal(buf, want[off:off+100]) {
+				t.Errorf("ReadAt(%d) = %q, want %q", off, buf, want[off:off+100])
+			}
+		})
+	}
+	wg.Wait()
+
+	buf := make([]byte, 10)
+	if n, err := f.ReadAt(buf, int64(len(want))); n != 0 || err != io.EOF {
+		t.Errorf("ReadAt at end of file = %d, %v; want 0, EOF", n, err)
+	}
+}
+
+func TestUringSocketIO(t *testing.T) {
+	if !inUringChild(t) {
+		return
+	}
+	if !poll.UringSocketsEnabled() {
+		t.Skip("io_uring not available for sockets")
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	want := bytes.Repeat([]byte("0123456789abcdef"), 1<<16)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	wg.Go(func() {
+		c, err := ln.Accept()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer c.Close()
+		if c.RemoteAddr() == nil {
+			t.Errorf("accepted connection has no remote address")
+		}
+		if _, err := c.Write(want); err != nil {
+			t.Error(err)
+		}
+	})
+
+	c, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	got, err := io.ReadAll(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("ReadAll returned %d bytes, not the %d bytes written", len(got), len(want))
+	}
+}
+
+func TestUringSocketInterrupt(t *testing.T) {
+	if !inUringChild(t) {
+		return
+	}
+	if !poll.UringSocketsEnabled() {
+		t.Skip("io_uring not available for sockets")
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	c1, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c1.Close()
+	c2, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c2.Close()
+
+	// A deadline set before the read.
+	buf := make([]byte, 10)
+	c1.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+	if _, err := c1.Read(buf); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("Read past deadline: %v; want %v", err, os.ErrDeadlineExceeded)
+	}
+
+	// A deadline set while the read is in flight.
+	c1.SetReadDeadline(time.Time{})
+	done := make(chan error)
+	go func() {
+		_, err := c1.Read(buf)
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	c1.SetReadDeadline(time.Now())
+	if err := <-done; !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("Read interrupted by deadline: %v; want %v", err, os.ErrDeadlineExceeded)
+	}
+
+	// The connection is still usable.
+	c1.SetReadDeadline(time.Time{})
+	if _, err := c2.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := c1.Read(buf); err != nil || string(buf[:n]) != "hello" {
+		t.Errorf("Read = %q, %v; want %q, nil", buf[:n], err, "hello")
+	}
+
+	// Close while a read and an accept are in flight.
+	go func() {
+		_, err := c1.Read(buf)
+		done <- err
+	}()
+	go func() {
+		_, err := ln.Accept()
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	c1.Close()
+	ln.Close()
+	for range 2 {
+		if err := <-done; !errors.Is(err, net.ErrClosed) {
+			t.Errorf("operation interrupted by Close: %v; want %v", err, net.ErrClosed)
+		}
+	}
+}
+
+// stackRead reads from c into a buffer on the stack of the goroutine,
+// which the garbage collector may move while the read is in flight.
+//
+//go:noinline
+func stackRead(c *net.TCPConn) (string, error) {
+	var buf [64]byte
+	n := 0
+	for n < len(buf) {
+		m, err := c.Read(buf[n:])
+		n += m
+		if err != nil {
+			return string(buf[:n]), err
+		}
+	}
+	return string(buf[:]), nil
+}
+
+// growStack grows the stack of the goroutine, so that it is shrunk,
+// and moved, by a later garbage collection.
+//
+//go:noinline
+func growStack(n int) {
+	var pad [128]byte
+	if n > 0 {
+		growStack(n - 1)
+	}
+	runtime.KeepAlive(pad)
+}
+
+func TestUringStackBuffer(t *testing.T) {
+	if !inUringChild(t) {
+		return
+	}
+	if !poll.UringSocketsEnabled() {
+		t.Skip("io_uring not available for sockets")
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	c1, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c1.Close()
+	c2, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c2.Close()
+
+	want := strings.Repeat("0123456789abcdef", 4)
+	for range 10 {
+		type result struct {
+			s   string
+			err error
+		}
+		done := make(chan result)
+		go func() {
+			growStack(1000)
+			s, err := stackRead(c1.(*net.TCPConn))
+			done <- result{s, err}
+		}()
+		// Garbage collections shrink the stacks of parked
+		// goroutines, moving them.
+		for range 3 {
+			time.Sleep(time.Millisecond)
+			runtime.GC()
+		}
+		var buf [64]byte
+		copy(buf[:], want)
+		if _, err := c2.Write(buf[:]); err != nil {
+			t.Fatal(err)
+		}
+		if r := <-done; r.s != want || r.err != nil {
+			t.Fatalf("read into stack buffer = %q, %v; want %q, nil", r.s, r.err, want)
+		}
+	}
+}
+
+func TestReadWriteAllocs(t *testing.T) {
+	if testenv.OptimizationOff() {
+		t.Skip("escape analysis is disabled")
+	}
+	f, err := os.Create(filepath.Join(t.TempDir(), "file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// Buffers that do not escape stay on the stack, whether or not
+	// io_uring is in use.
+	allocs := testing.AllocsPerRun(100, func() {
+		var buf [16]byte
+		if _, err := f.WriteAt(buf[:], 0); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.ReadAt(buf[:], 0); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("got %v allocs per ReadAt and WriteAt, want 0", allocs)
+	}
+}
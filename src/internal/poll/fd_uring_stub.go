@@ -0,0 +1,42 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build (unix && !linux) || (js && wasm) || wasip1 || windows
+
+package poll
+
+import (
+	"syscall"
+	"time"
+)
+
+type uringSocket struct{}
+
+func newUringSocket() *uringSocket {
+	return nil
+}
+
+func uringRead(fd int, p []byte, off int64) (n int, err error, handled bool) {
+	return 0, nil, false
+}
+
+func uringWrite(fd int, p []byte, off int64) (n int, err error, handled bool) {
+	return 0, nil, false
+}
+
+func uringRecv(fd *FD, p []byte) (n int, err error, handled bool) {
+	return 0, nil, false
+}
+
+func uringSend(fd *FD, p []byte) (n int, err error, handled bool) {
+	return 0, nil, false
+}
+
+func uringAccept(fd *FD) (s int, rsa syscall.Sockaddr, errcall string, err error, handled bool) {
+	return -1, nil, "", nil, false
+}
+
+func uringSetDeadline(fd *FD, t time.Time, mode int) {}
+
+func uringEvict(fd *FD) {}
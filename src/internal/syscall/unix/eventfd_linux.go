@@ -0,0 +1,21 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package unix
+
+import "syscall"
+
+const (
+	EFD_CLOEXEC  = syscall.O_CLOEXEC
+	EFD_NONBLOCK = syscall.O_NONBLOCK
+)
+
+// Eventfd wraps the eventfd2 system call.
+func Eventfd(initval uint, flags int) (int, error) {
+	fd, _, errno := syscall.Syscall(syscall.SYS_EVENTFD2, uintptr(initval), uintptr(flags), 0)
+	if errno != 0 {
+		return -1, errno
+	}
+	return int(fd), nil
+}
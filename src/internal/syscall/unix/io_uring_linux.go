@@ -0,0 +1,88 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package unix
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// IoUringSqringOffsets mirrors struct io_sqring_offsets.
+type IoUringSqringOffsets struct {
+	Head        uint32
+	Tail        uint32
+	RingMask    uint32
+	RingEntries uint32
+	Flags       uint32
+	Dropped     uint32
+	Array       uint32
+	Resv1       uint32
+	UserAddr    uint64
+}
+
+// IoUringCqringOffsets mirrors struct io_cqring_offsets.
+type IoUringCqringOffsets struct {
+	Head        uint32
+	Tail        uint32
+	RingMask    uint32
+	RingEntries uint32
+	Overflow    uint32
+	Cqes        uint32
+	Flags       uint32
+	Resv1       uint32
+	UserAddr    uint64
+}
+
+// IoUringParams mirrors struct io_uring_params.
+type IoUringParams struct {
+	SqEntries    uint32
+	CqEntries    uint32
+	Flags        uint32
+	SqThreadCPU  uint32
+	SqThreadIdle uint32
+	Features     uint32
+	WqFd         uint32
+	Resv         [3]uint32
+	SqOff        IoUringSqringOffsets
+	CqOff        IoUringCqringOffsets
+}
+
+const (
+	IORING_OFF_SQ_RING = 0
+	IORING_OFF_CQ_RING = 0x8000000
+	IORING_OFF_SQES    = 0x10000000
+
+	IORING_ENTER_GETEVENTS = 1 << 0
+
+	IORING_REGISTER_EVENTFD = 4
+)
+
+// IoUringSetup wraps the io_uring_setup system call.
+func IoUringSetup(entries uint32, params *IoUringParams) (int, error) {
+	fd, _, errno := syscall.Syscall(ioUringSetupTrap, uintptr(entries), uintptr(unsafe.Pointer(params)), 0)
+	if errno != 0 {
+		return -1, errno
+	}
+	return int(fd), nil
+}
+
+// IoUringEnter wraps the io_uring_enter system call.
+// No signal mask is passed to the kernel.
+func IoUringEnter(fd int, toSubmit, minComplete, flags uint32) (int, error) {
+	n, _, errno := syscall.Syscall6(ioUringEnterTrap, uintptr(fd), uintptr(toSubmit), uintptr(minComplete), uintptr(flags), 0, 0)
+	if errno != 0 {
+		return 0, errno
+	}
+	return int(n), nil
+}
+
+// IoUringRegister wraps the io_uring_register system call.
+func IoUringRegister(fd int, opcode uint32, arg unsafe.Pointer, nrArgs uint32) error {
+	_, _, errno := syscall.Syscall6(ioUringRegisterTrap, uintptr(fd), uintptr(opcode), uintptr(arg), uintptr(nrArgs), 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
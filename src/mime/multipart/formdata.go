@@ -16,7 +16,9 @@ import (
 )
 
 // ErrMessageTooLarge is returned by ReadForm if the message form
-// data is too large to be processed.
+// data is too large to be processed, and by [Reader] and [Part]
+// methods when a limit set by [Reader.SetMaxParts] or
+// [Reader.SetMaxPartSize] is exceeded.
 var ErrMessageTooLarge = errors.New("multipart: message too large")
 
 // TODO(adg,bradfitz): find a way to unify the DoS-prevention strategy here
@@ -57,6 +59,9 @@ func (r *Reader) readForm(maxMemory int64) (_ *Form, err error) {
 			multipartmaxparts.IncNonDefault()
 		}
 	}
+	if r.maxParts > 0 {
+		maxParts = r.maxParts
+	}
 	maxHeaders := maxMIMEHeaders()
 
 	defer func() {
@@ -452,6 +452,58 @@ func TestReadFormLimits(t *testing.T) {
 	}
 }
 
+func TestReaderLimits(t *testing.T) {
+	var buf bytes.Buffer
+	fw := NewWriter(&buf)
+	for i := range 3 {
+		w, _ := fw.CreateFormFile(fmt.Sprintf("file%v", i), "f")
+		w.Write(bytes.Repeat([]byte{'a'}, 10*(i+1)))
+	}
+	fw.Close()
+	for _, test := range []struct {
+		maxParts    int
+		maxPartSize int64
+		wantErr     error
+	}{
+		{},
+		{maxParts: 3, maxPartSize: 30},
+		{maxParts: 2, wantErr: ErrMessageTooLarge},
+		{maxPartSize: 29, wantErr: ErrMessageTooLarge},
+	} {
+		name := fmt.Sprintf("maxParts=%v/maxPartSize=%v", test.maxParts, test.maxPartSize)
+		t.Run(name+"/ReadForm", func(t *testing.T) {
+			fr := NewReader(bytes.NewReader(buf.Bytes()), fw.Boundary())
+			fr.SetMaxParts(test.maxParts)
+			fr.SetMaxPartSize(test.maxPartSize)
+			form, err := fr.ReadForm(1 << 10)
+			if err == nil {
+				defer form.RemoveAll()
+			}
+			if err != test.wantErr {
+				t.Errorf("ReadForm = %v, want %v", err, test.wantErr)
+			}
+		})
+		t.Run(name+"/NextPart", func(t *testing.T) {
+			fr := NewReader(bytes.NewReader(buf.Bytes()), fw.Boundary())
+			fr.SetMaxParts(test.maxParts)
+			fr.SetMaxPartSize(test.maxPartSize)
+			var err error
+			for err == nil {
+				var p *Part
+				if p, err = fr.NextPart(); err == nil {
+					_, err = io.ReadAll(p)
+				}
+			}
+			if err == io.EOF {
+				err = nil
+			}
+			if err != test.wantErr {
+				t.Errorf("reading parts: %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
 func TestReadFormEndlessHeaderLine(t *testing.T) {
 	for _, test := range []struct {
 		name   string
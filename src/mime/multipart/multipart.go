@@ -217,6 +217,12 @@ func (pr partReader) Read(d []byte) (int, error) {
 	if n > p.n {
 		n = p.n
 	}
+	if max := p.mr.maxPartSize; max > 0 {
+		if p.total >= max {
+			return 0, ErrMessageTooLarge
+		}
+		n = int(min(int64(n), max-p.total))
+	}
 	n, _ = br.Read(d[:n])
 	p.total += int64(n)
 	p.n -= n
@@ -337,6 +343,9 @@ type Reader struct {
 	currentPart *Part
 	partsRead   int
 
+	maxParts    int   // if positive, the maximum number of parts
+	maxPartSize int64 // if positive, the maximum size of a part's content
+
 	nl               []byte // "\r\n" or "\n" (set after seeing first boundary line)
 	nlDashBoundary   []byte // nl + "--boundary"
 	dashBoundaryDash []byte // "--boundary--"
@@ -362,6 +371,26 @@ func maxMIMEHeaders() int64 {
 	return 10000
 }
 
+// SetMaxParts sets the maximum number of parts that r will read.
+// Once that many parts have been read, [Reader.NextPart],
+// [Reader.NextRawPart], and [Reader.ReadForm] return
+// [ErrMessageTooLarge] rather than another part.
+// If n is not positive, NextPart and NextRawPart read any number of
+// parts, and ReadForm reads at most 1000, or the number set by the
+// GODEBUG setting multipartmaxparts.
+func (r *Reader) SetMaxParts(n int) {
+	r.maxParts = n
+}
+
+// SetMaxPartSize sets the maximum size of the content of each part that
+// r reads. A Read from a [Part] returns [ErrMessageTooLarge] once that
+// many bytes have been read from it and more remain, as does
+// [Reader.ReadForm] if a part is larger. If n is not positive, which is
+// the default, there is no limit.
+func (r *Reader) SetMaxPartSize(n int64) {
+	r.maxPartSize = n
+}
+
 // NextPart returns the next part in the multipart or an error.
 // When there are no more parts, the error [io.EOF] is returned.
 //
@@ -405,6 +434,9 @@ func (r *Reader) nextPart(rawPart bool, maxMIMEHeaderSize, maxMIMEHeaders int64)
 		}
 
 		if r.isBoundaryDelimiterLine(line) {
+			if r.maxParts > 0 && r.partsRead >= r.maxParts {
+				return nil, ErrMessageTooLarge
+			}
 			r.partsRead++
 			bp, err := newPart(r, rawPart, maxMIMEHeaderSize, maxMIMEHeaders)
 			if err != nil {
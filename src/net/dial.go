@@ -224,6 +224,11 @@ type Dialer struct {
 	// If ControlContext is not nil, Control is ignored.
 	ControlContext func(ctx context.Context, network, address string, c syscall.RawConn) error
 
+	// SocketOptions specifies socket options to set on the network
+	// connection before dialing. They are set before Control or
+	// ControlContext is called.
+	SocketOptions SocketOptions
+
 	// If mptcpStatus is set to a value allowing Multipath TCP (MPTCP) to be
 	// used, any call to Dial with "tcp(4|6)" as network will use MPTCP if
 	// supported by the operating system.
@@ -232,6 +237,18 @@ type Dialer struct {
 
 func (d *Dialer) dualStack() bool { return d.FallbackDelay >= 0 }
 
+// controlContext returns the function to call on a new network
+// connection before dialing, if any.
+func (d *Dialer) controlContext() func(context.Context, string, string, syscall.RawConn) error {
+	ctrlCtxFn := d.ControlContext
+	if ctrlCtxFn == nil && d.Control != nil {
+		ctrlCtxFn = func(ctx context.Context, network, address string, c syscall.RawConn) error {
+			return d.Control(network, address, c)
+		}
+	}
+	return d.SocketOptions.control("dial", ctrlCtxFn)
+}
+
 func minNonzeroTime(a, b time.Time) time.Time {
 	if a.IsZero() {
 		return b
@@ -308,6 +325,8 @@ func parseNetwork(ctx context.Context, network string, needsProto bool) (afnet s
 				return "", 0, UnknownNetworkError(network)
 			}
 		case "unix", "unixgram", "unixpacket":
+		case "packet":
+			return network, ethAll, nil
 		default:
 			return "", 0, UnknownNetworkError(network)
 		}
@@ -325,6 +344,22 @@ func parseNetwork(ctx context.Context, network string, needsProto bool) (afnet s
 			}
 		}
 		return afnet, proto, nil
+	case "packet":
+		proto, ok := parseLinkProtocol(network[i+1:])
+		if !ok {
+			return "", 0, UnknownNetworkError(network)
+		}
+		return afnet, proto, nil
+	case "netlink":
+		protostr := network[i+1:]
+		proto, i, ok := dtoi(protostr)
+		if !ok || i != len(protostr) {
+			proto, ok = netlinkProtocols[protostr]
+			if !ok {
+				return "", 0, UnknownNetworkError(network)
+			}
+		}
+		return afnet, proto, nil
 	}
 	return "", 0, UnknownNetworkError(network)
 }
@@ -333,7 +368,7 @@ func parseNetwork(ctx context.Context, network string, needsProto bool) (afnet s
 // addresses. The result contains at least one address when error is
 // nil.
 func (r *Resolver) resolveAddrList(ctx context.Context, op, network, addr string, hint Addr) (addrList, error) {
-	afnet, _, err := parseNetwork(ctx, network, true)
+	afnet, proto, err := parseNetwork(ctx, network, true)
 	if err != nil {
 		return nil, err
 	}
@@ -341,6 +376,14 @@ func (r *Resolver) resolveAddrList(ctx context.Context, op, network, addr string
 		return nil, errMissingAddress
 	}
 	switch afnet {
+	case "packet":
+		return addrList{&LinkAddr{Interface: addr, Protocol: uint16(proto)}}, nil
+	case "netlink":
+		groups, err := parseNetlinkGroups(addr)
+		if err != nil {
+			return nil, err
+		}
+		return addrList{&NetlinkAddr{Groups: groups}}, nil
 	case "unix", "unixgram", "unixpacket":
 		addr, err := ResolveUnixAddr(afnet, addr)
 		if err != nil {
@@ -840,12 +883,29 @@ type ListenConfig struct {
 	// keep-alive probes are disabled.
 	KeepAliveConfig KeepAliveConfig
 
+	// SocketOptions specifies socket options to set on the network
+	// connection before binding it. They are set before Control
+	// is called.
+	SocketOptions SocketOptions
+
 	// If mptcpStatus is set to a value allowing Multipath TCP (MPTCP) to be
 	// used, any call to Listen with "tcp(4|6)" as network will use MPTCP if
 	// supported by the operating system.
 	mptcpStatus mptcpStatusListen
 }
 
+// controlContext returns the function to call on a new network
+// connection before binding it, if any.
+func (lc *ListenConfig) controlContext() func(context.Context, string, string, syscall.RawConn) error {
+	var ctrlCtxFn func(ctx context.Context, network, address string, c syscall.RawConn) error
+	if lc.Control != nil {
+		ctrlCtxFn = func(ctx context.Context, network, address string, c syscall.RawConn) error {
+			return lc.Control(network, address, c)
+		}
+	}
+	return lc.SocketOptions.control("listen", ctrlCtxFn)
+}
+
 // MultipathTCP reports whether MPTCP will be used.
 //
 // This method doesn't check if MPTCP is supported by the operating
@@ -927,6 +987,10 @@ func (lc *ListenConfig) ListenPacket(ctx context.Context, network, address strin
 		c, err = sl.listenIP(ctx, la)
 	case *UnixAddr:
 		c, err = sl.listenUnixgram(ctx, la)
+	case *LinkAddr:
+		c, err = sl.listenLink(ctx, la)
+	case *NetlinkAddr:
+		c, err = sl.listenNetlink(ctx, la)
 	default:
 		return nil, &OpError{Op: "listen", Net: sl.network, Source: nil, Addr: la, Err: &AddrError{Err: "unexpected address type", Addr: address}}
 	}
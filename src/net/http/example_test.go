@@ -6,6 +6,7 @@ package http_test
 
 import (
 	"context"
+	"expvar"
 	"fmt"
 	"io"
 	"log"
@@ -241,3 +242,28 @@ func ExampleCrossOriginProtection() {
 
 	log.Fatal(srv.ListenAndServe())
 }
+
+func ExampleTransport_Stats() {
+	tr := &http.Transport{MaxConnsPerHost: 10}
+	client := &http.Client{Transport: tr}
+
+	// Publish the Transport's connection statistics, so they are
+	// served by the expvar package's handler at /debug/vars.
+	expvar.Publish("http_client", expvar.Func(func() any {
+		return tr.Stats()
+	}))
+
+	// Or log the destinations whose connections are exhausted.
+	go func() {
+		for range time.Tick(time.Minute) {
+			for _, h := range tr.Stats().Hosts {
+				if h.WaitingRequests > 0 {
+					log.Printf("%s://%s: %d requests waiting for one of %d connections",
+						h.Scheme, h.Addr, h.WaitingRequests, h.ActiveConns)
+				}
+			}
+		}
+	}()
+
+	_ = client // Send requests with client.
+}
@@ -33,6 +33,8 @@ var (
 	Export_writeStatusLine            = writeStatusLine
 	Export_is408Message               = is408Message
 	MaxPostCloseReadTime              = maxPostCloseReadTime
+	ExportParseRetryAfter             = parseRetryAfter
+	ExportRetryBackoff                = (*RetryTransport).backoff
 )
 
 var MaxWriteWaitBeforeConnReuse = &maxWriteWaitBeforeConnReuse
@@ -317,4 +317,14 @@ type HTTP2Config struct {
 	// The errType contains only lowercase letters, digits, and underscores
 	// (a-z, 0-9, _).
 	CountError func(errType string)
+
+	// EnableConnectProtocol, if true, permits clients to use the
+	// extended CONNECT method defined in RFC 8441, which is used to
+	// carry WebSockets and other protocols over HTTP/2 streams.
+	// Extended CONNECT requests are delivered to handlers with the
+	// method "CONNECT" and the requested protocol in the
+	// ":protocol" header.
+	//
+	// This parameter only applies to Servers.
+	EnableConnectProtocol bool
 }
@@ -0,0 +1,346 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.jsonv2
+
+package httpapi
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json/jsontext"
+	"encoding/json/v2"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrUnsupportedMediaType is the error reported by [Bind] for a
+// request body whose media type is neither JSON nor a form.
+var ErrUnsupportedMediaType = errors.New("unsupported media type")
+
+// errMissing is the error reported for a required value that is
+// absent.
+var errMissing = errors.New("missing required value")
+
+// A ParamError records a value of the request that [Bind] could not
+// bind.
+type ParamError struct {
+	// In is where the value is in the request: "path", "query",
+	// "header", "form", or "body".
+	In string
+
+	// Name is the name of the path wildcard, query parameter, header
+	// field, or form field. For a value in a JSON body, it is a JSON
+	// Pointer to the value, or empty for the body as a whole.
+	Name string
+
+	Err error
+}
+
+func (e *ParamError) Error() string {
+	return e.param() + ": " + e.Err.Error()
+}
+
+func (e *ParamError) Unwrap() error { return e.Err }
+
+// param describes the value in error.
+func (e *ParamError) param() string {
+	if e.In == "body" && e.Name == "" {
+		return "request body"
+	}
+	return e.In + " parameter " + strconv.Quote(e.Name)
+}
+
+// Bind binds the values of r to the fields of the struct pointed to
+// by v. Each field is bound according to its struct tags:
+//
+//   - A field tagged `path:"name"` is set from the path wildcard name,
+//     as returned by [http.Request.PathValue].
+//   - A field tagged `query:"name"` is set from the query parameter
+//     name.
+//   - A field tagged `header:"Name"` is set from the header field Name.
+//   - A field tagged `form:"name"` is set from the field name of a
+//     form body, of media type "application/x-www-form-urlencoded" or
+//     "multipart/form-data".
+//
+// The name may be omitted, in which case the name of the struct field
+// is used. If the tag has the option ",required", as in
+// `query:"limit,required"`, the value must be present in r.
+//
+// The values are strings, which are converted to the type of the field
+// as if they were JSON strings, with numbers also permitted to be
+// quoted; bool fields accept the values of [strconv.ParseBool]. A
+// slice field receives each value of a repeated parameter. A form
+// field of type [*multipart.FileHeader] or []*multipart.FileHeader
+// receives the uploaded files.
+//
+// Other fields are bound from a JSON body, of media type
+// "application/json" or ending in "+json", as with [json.Unmarshal]:
+// they are the members of the JSON object. A field tagged
+// `required:"true"` must be present in the object. Fields with any of
+// the tags above are never set from a JSON body.
+//
+// The fields of embedded structs without tags are bound as if they
+// were fields of the outer struct.
+//
+// Bind returns an error wrapping a [*ParamError] for each value that
+// could not be bound. A request body of another media type is reported
+// with [ErrUnsupportedMediaType].
+//
+// Bind reads at most [DefaultMaxBodyBytes] of a JSON body; a larger
+// body is reported with an error wrapping a [*http.MaxBytesError]. To
+// use a different limit, call [BindLimit].
+//
+// Bind panics if v is not a non-nil pointer to a struct.
+func Bind(r *http.Request, v any) error {
+	return BindLimit(r, v, DefaultMaxBodyBytes)
+}
+
+// DefaultMaxBodyBytes is the maximum size of a JSON request body read
+// by [Bind].
+const DefaultMaxBodyBytes = 10 << 20
+
+// BindLimit is like [Bind], but reads at most limit bytes of a JSON
+// body. If limit is negative, the body is not limited.
+func BindLimit(r *http.Request, v any, limit int64) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		panic("httpapi: Bind of " + reflect.TypeOf(v).String() + ", not a non-nil pointer to a struct")
+	}
+	rv = rv.Elem()
+	fields := cachedFields(rv.Type())
+
+	var errs []error
+	members, err := bindBody(r, v, limit)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for _, f := range fields {
+		fv := rv.FieldByIndex(f.index)
+		if f.in == "" {
+			if f.required && members != nil && !members[f.name] {
+				errs = append(errs, &ParamError{In: "body", Name: "/" + f.name, Err: errMissing})
+			}
+			continue
+		}
+		// Discard any value set by the JSON body.
+		fv.SetZero()
+		if err := f.bind(r, fv); err != nil {
+			errs = append(errs, &ParamError{In: f.in, Name: f.name, Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// bindBody decodes a JSON body of r into v, or parses a form body.
+// For a JSON body, or no body, it returns the set of the names of the
+// members of the object decoded. It reads at most limit bytes of a
+// JSON body, unless limit is negative.
+func bindBody(r *http.Request, v any, limit int64) (map[string]bool, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return map[string]bool{}, nil
+	}
+	ct := r.Header.Get("Content-Type")
+	mt, _, _ := mime.ParseMediaType(ct)
+	switch {
+	case mt == "application/x-www-form-urlencoded":
+		return nil, bodyError(r.ParseForm())
+	case mt == "multipart/form-data":
+		return nil, bodyError(r.ParseMultipartForm(defaultMaxMemory))
+	}
+
+	var src io.Reader = r.Body
+	if limit >= 0 {
+		src = http.MaxBytesReader(nil, r.Body, limit)
+	}
+	body, err := io.ReadAll(src)
+	if err != nil {
+		return nil, bodyError(err)
+	}
+	switch {
+	case len(bytes.TrimLeft(body, " \t\r\n")) == 0:
+		return map[string]bool{}, nil
+	case mt != "application/json" && !strings.HasSuffix(mt, "+json"):
+		return nil, bodyError(ErrUnsupportedMediaType)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		if serr, ok := errors.AsType[*json.SemanticError](err); ok && serr.JSONPointer != "" {
+			return nil, &ParamError{In: "body", Name: string(serr.JSONPointer), Err: err}
+		}
+		return nil, bodyError(err)
+	}
+
+	// Record the names of the members present, so that required
+	// members can be checked.
+	members := make(map[string]bool)
+	dec := jsontext.NewDecoder(bytes.NewReader(body))
+	if tok, err := dec.ReadToken(); err != nil || tok.Kind() != '{' {
+		return members, nil
+	}
+	for dec.PeekKind() == '"' {
+		tok, err := dec.ReadToken()
+		if err != nil {
+			break
+		}
+		members[tok.String()] = true
+		if err := dec.SkipValue(); err != nil {
+			break
+		}
+	}
+	return members, nil
+}
+
+// defaultMaxMemory is the maximum memory used to parse a multipart
+// form, as with [http.Request.FormValue].
+const defaultMaxMemory = 32 << 20
+
+func bodyError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ParamError{In: "body", Err: err}
+}
+
+// A field is a struct field bound by Bind.
+type field struct {
+	index    []int
+	in       string // "path", "query", "header", "form", or "" for a JSON member
+	name     string
+	required bool
+}
+
+var sources = []string{"path", "query", "header", "form"}
+
+var fieldCache sync.Map // map[reflect.Type][]field
+
+// cachedFields returns the fields of the struct type t.
+func cachedFields(t reflect.Type) []field {
+	if f, ok := fieldCache.Load(t); ok {
+		return f.([]field)
+	}
+	f, _ := fieldCache.LoadOrStore(t, typeFields(t, nil))
+	return f.([]field)
+}
+
+func typeFields(t reflect.Type, index []int) []field {
+	var fields []field
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		idx := append(index[:len(index):len(index)], i)
+		f := field{index: idx}
+		for _, in := range sources {
+			if tag, ok := sf.Tag.Lookup(in); ok {
+				name, opts, _ := strings.Cut(tag, ",")
+				f.in = in
+				f.name = cmp.Or(name, sf.Name)
+				f.required = opts == "required"
+				break
+			}
+		}
+		if f.in == "" {
+			tag, hasTag := sf.Tag.Lookup("json")
+			if sf.Anonymous && !hasTag && sf.Type.Kind() == reflect.Struct {
+				fields = append(fields, typeFields(sf.Type, idx)...)
+				continue
+			}
+			if !sf.IsExported() || tag == "-" {
+				continue
+			}
+			name, _, _ := strings.Cut(tag, ",")
+			f.name = cmp.Or(name, sf.Name)
+			f.required, _ = strconv.ParseBool(sf.Tag.Get("required"))
+		} else if !sf.IsExported() {
+			continue
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+var fileHeaderType = reflect.TypeFor[*multipart.FileHeader]()
+
+// bind sets v from the values of r for f.
+func (f *field) bind(r *http.Request, v reflect.Value) error {
+	var vals []string
+	switch f.in {
+	case "path":
+		if s := r.PathValue(f.name); s != "" {
+			vals = []string{s}
+		}
+	case "query":
+		vals = r.URL.Query()[f.name]
+	case "header":
+		vals = r.Header.Values(f.name)
+	case "form":
+		if r.MultipartForm != nil {
+			if files := r.MultipartForm.File[f.name]; v.Type() == fileHeaderType || v.Type() == reflect.SliceOf(fileHeaderType) {
+				if len(files) == 0 {
+					break
+				}
+				if v.Kind() == reflect.Slice {
+					v.Set(reflect.ValueOf(files))
+				} else {
+					v.Set(reflect.ValueOf(files[0]))
+				}
+				return nil
+			}
+		}
+		vals = r.PostForm[f.name]
+	}
+	if len(vals) == 0 {
+		if f.required {
+			return errMissing
+		}
+		return nil
+	}
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+		s := reflect.MakeSlice(v.Type(), len(vals), len(vals))
+		for i, val := range vals {
+			if err := setString(s.Index(i), val); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	}
+	return setString(v, vals[0])
+}
+
+// setString sets v from the string s.
+func setString(v reflect.Value, s string) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		p := reflect.New(v.Type().Elem())
+		if err := setString(p.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(p)
+		return nil
+	case reflect.String:
+		v.SetString(s)
+		return nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return errors.New("invalid boolean " + strconv.Quote(s))
+		}
+		v.SetBool(b)
+		return nil
+	}
+	q, err := jsontext.AppendQuote(nil, s)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(q, v.Addr().Interface(), json.StringifyNumbers(true)); err != nil {
+		return errors.New("invalid value " + strconv.Quote(s) + " for type " + v.Type().String())
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.jsonv2
+
+package httpapi_test
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httpapi"
+	"net/http/httptest"
+	"strings"
+)
+
+type GetGreetingRequest struct {
+	Name  string `path:"name"`
+	Shout bool   `query:"shout"`
+}
+
+type Greeting struct {
+	Message string `json:"message"`
+}
+
+func getGreeting(r *http.Request, in GetGreetingRequest) (Greeting, error) {
+	if in.Name == "nobody" {
+		return Greeting{}, &httpapi.Problem{
+			Status: http.StatusNotFound,
+			Detail: "there is nobody to greet",
+		}
+	}
+	msg := "hello, " + in.Name
+	if in.Shout {
+		msg = strings.ToUpper(msg)
+	}
+	return Greeting{Message: msg}, nil
+}
+
+func ExampleHandler() {
+	mux := http.NewServeMux()
+	mux.Handle("GET /greetings/{name}", httpapi.Handler(getGreeting))
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	for _, path := range []string{"/greetings/gopher?shout=true", "/greetings/gopher?shout=loudly", "/greetings/nobody"} {
+		res, err := http.Get(ts.URL + path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		fmt.Print(res.StatusCode, " ", string(body))
+	}
+	// Output:
+	// 200 {"message":"HELLO, GOPHER"}
+	// 400 {"title":"Bad Request","status":400,"detail":"query parameter \"shout\" is invalid","errors":[{"detail":"invalid boolean \"loudly\"","in":"query","name":"shout"}]}
+	// 404 {"title":"Not Found","status":404,"detail":"there is nobody to greet"}
+}
@@ -0,0 +1,91 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.jsonv2
+
+// Package httpapi provides helpers for writing HTTP handlers that
+// exchange JSON, in the style of an API described by OpenAPI.
+//
+// [Handler] adapts a function that takes a request struct and returns
+// a response to an [http.Handler]. The request struct is filled in by
+// [Bind] from the path values, query, headers, and body of the
+// request, as described by its struct tags:
+//
+//	type UpdateItemRequest struct {
+//		ID     string `path:"id,required"`
+//		DryRun bool   `query:"dry_run"`
+//		Token  string `header:"Authorization,required"`
+//		Name   string `json:"name" required:"true"`
+//		Tags   []string `json:"tags"`
+//	}
+//
+//	mux.Handle("PUT /items/{id}", httpapi.Handler(updateItem))
+//
+// The response is encoded as JSON. Errors are reported as problem
+// details, as defined by RFC 9457.
+//
+// The handlers returned by Handler are ordinary [http.Handler] values,
+// and may be registered with a [http.ServeMux] and wrapped by
+// middleware like any other.
+//
+// JSON is encoded and decoded with the encoding/json/v2 package.
+package httpapi
+
+import (
+	"encoding/json/v2"
+	"net/http"
+	"reflect"
+)
+
+// Handler returns a handler that binds each request to a new In with
+// [Bind], calls fn, and writes the result it returns as JSON with
+// status 200 OK. If Bind or fn returns an error, the handler writes it
+// with [WriteError].
+//
+// Handler panics if In is not a struct type.
+func Handler[In, Out any](fn func(r *http.Request, in In) (Out, error)) http.Handler {
+	if t := reflect.TypeFor[In](); t.Kind() != reflect.Struct {
+		panic("httpapi: Handler request type " + t.String() + " is not a struct")
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var in In
+		if err := Bind(r, &in); err != nil {
+			WriteError(w, err)
+			return
+		}
+		out, err := fn(r, in)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+		WriteJSON(w, http.StatusOK, out)
+	})
+}
+
+// WriteJSON writes v encoded as JSON as the response, with the given
+// status code. If v cannot be encoded, WriteJSON writes a 500 Internal
+// Server Error problem instead and returns the error.
+func WriteJSON(w http.ResponseWriter, status int, v any) error {
+	return writeJSON(w, "application/json", status, v)
+}
+
+func writeJSON(w http.ResponseWriter, contentType string, status int, v any) error {
+	// Encode v before writing the header, so that an encoding error
+	// can still be reported.
+	b, err := json.Marshal(v, json.Deterministic(true))
+	if err != nil {
+		if _, ok := v.(*Problem); !ok {
+			WriteProblem(w, &Problem{Status: http.StatusInternalServerError})
+		} else {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+		return err
+	}
+	h := w.Header()
+	h.Set("Content-Type", contentType)
+	h.Del("Content-Length")
+	w.WriteHeader(status)
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
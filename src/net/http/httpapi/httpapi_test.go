@@ -0,0 +1,414 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.jsonv2
+
+package httpapi
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json/v2"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type Paging struct {
+	Limit  int `query:"limit"`
+	Cursor string
+}
+
+type item struct {
+	ID      int64     `path:"id,required"`
+	Verbose *bool     `query:"verbose"`
+	Tags    []string  `query:"tag"`
+	Since   time.Time `query:"since"`
+	Token   string    `header:"X-Token,required"`
+	Name    string    `json:"name" required:"true"`
+	Count   uint8     `json:"count,omitzero"`
+	Paging
+	secret string
+}
+
+// bindRequest binds r, as routed by a ServeMux with the given pattern,
+// to a new item.
+func bindRequest(t *testing.T, pattern string, r *http.Request) (item, error) {
+	t.Helper()
+	var (
+		got item
+		err error
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		err = Bind(r, &got)
+	})
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+	return got, err
+}
+
+func TestBind(t *testing.T) {
+	body := `{"name":"widget","count":3,"Cursor":"c1","ID":99,"Token":"forged"}`
+	r := httptest.NewRequest("PUT", "/items/42?verbose=true&tag=a&tag=b&limit=10&since=2026-01-02T03:04:05Z", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json; charset=utf-8")
+	r.Header.Set("X-Token", "secret")
+	got, err := bindRequest(t, "PUT /items/{id}", r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verbose := true
+	want := item{
+		ID:      42,
+		Verbose: &verbose,
+		Tags:    []string{"a", "b"},
+		Since:   time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Token:   "secret",
+		Name:    "widget",
+		Count:   3,
+		Paging:  Paging{Limit: 10, Cursor: "c1"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Bind =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestBindErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		target      string
+		contentType string
+		body        string
+		want        []ParamError // Err is not compared
+	}{{
+		name: "missing",
+		body: `{}`,
+		want: []ParamError{{In: "header", Name: "X-Token"}, {In: "body", Name: "/name"}},
+	}, {
+		name:   "invalid query",
+		target: "/items/1?limit=ten&verbose=maybe",
+		body:   `{"name":"x"}`,
+		want:   []ParamError{{In: "query", Name: "verbose"}, {In: "header", Name: "X-Token"}, {In: "query", Name: "limit"}},
+	}, {
+		name:   "invalid path",
+		target: "/items/x",
+		body:   `{"name":"x"}`,
+		want:   []ParamError{{In: "path", Name: "id"}, {In: "header", Name: "X-Token"}},
+	}, {
+		name: "invalid member",
+		body: `{"name":"x","count":300}`,
+		want: []ParamError{{In: "body", Name: "/count"}, {In: "header", Name: "X-Token"}},
+	}, {
+		name: "syntax",
+		body: `{"name":`,
+		want: []ParamError{{In: "body"}, {In: "header", Name: "X-Token"}},
+	}, {
+		name:        "media type",
+		contentType: "text/plain",
+		body:        `name`,
+		want:        []ParamError{{In: "body"}, {In: "header", Name: "X-Token"}},
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			target := cmp.Or(tt.target, "/items/1")
+			r := httptest.NewRequest("PUT", target, strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", cmp.Or(tt.contentType, "application/json"))
+			_, err := bindRequest(t, "PUT /items/{id}", r)
+			if err == nil {
+				t.Fatal("Bind succeeded")
+			}
+			var got []ParamError
+			for _, e := range unwrapAll(err) {
+				var pe *ParamError
+				if !errors.As(e, &pe) {
+					t.Fatalf("error %v is not a *ParamError", e)
+				}
+				got = append(got, ParamError{In: pe.In, Name: pe.Name})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Bind errors = %+v; want %+v\nerror: %v", got, tt.want, err)
+			}
+		})
+	}
+}
+
+func TestBindNoBody(t *testing.T) {
+	r := httptest.NewRequest("GET", "/items/7?limit=5", nil)
+	r.Header.Set("X-Token", "t")
+	_, err := bindRequest(t, "GET /items/{id}", r)
+	var pe *ParamError
+	if !errors.As(err, &pe) || pe.In != "body" || pe.Name != "/name" {
+		t.Errorf("Bind = %v; want missing body member /name", err)
+	}
+}
+
+func TestBindLimit(t *testing.T) {
+	name := strings.Repeat("x", 100)
+	body := `{"name":"` + name + `"}`
+	for _, tt := range []struct {
+		limit   int64
+		tooLong bool
+	}{
+		{limit: 10, tooLong: true},
+		{limit: int64(len(body)), tooLong: false},
+		{limit: -1, tooLong: false},
+	} {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		var in createRequest
+		err := BindLimit(r, &in, tt.limit)
+		var maxErr *http.MaxBytesError
+		if got := errors.As(err, &maxErr); got != tt.tooLong {
+			t.Errorf("BindLimit(%d) = %v; want MaxBytesError %v", tt.limit, err, tt.tooLong)
+		}
+		if tt.tooLong {
+			if p := problemOf(err); p.Status != http.StatusRequestEntityTooLarge {
+				t.Errorf("BindLimit(%d) problem status = %d; want 413", tt.limit, p.Status)
+			}
+		} else if in.Name != name {
+			t.Errorf("BindLimit(%d) bound name of length %d; want %d", tt.limit, len(in.Name), len(name))
+		}
+	}
+}
+
+func TestBindDefaultLimit(t *testing.T) {
+	body := `{"name":"` + strings.Repeat("x", DefaultMaxBodyBytes) + `"}`
+	r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	var in createRequest
+	var maxErr *http.MaxBytesError
+	if err := Bind(r, &in); !errors.As(err, &maxErr) || maxErr.Limit != DefaultMaxBodyBytes {
+		t.Errorf("Bind of large body = %v; want MaxBytesError with limit %d", err, DefaultMaxBodyBytes)
+	}
+}
+
+func TestBindForm(t *testing.T) {
+	type upload struct {
+		Title string                  `form:"title,required"`
+		Page  int                     `form:"page"`
+		Files []*multipart.FileHeader `form:"file"`
+		First *multipart.FileHeader   `form:"file"`
+		Q     string                  `query:"q"`
+	}
+	bind := func(r *http.Request) (upload, error) {
+		var u upload
+		err := Bind(r, &u)
+		return u, err
+	}
+
+	r := httptest.NewRequest("POST", "/?q=x&title=ignored", strings.NewReader(url.Values{"title": {"t"}, "page": {"2"}}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	got, err := bind(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (upload{Title: "t", Page: 2, Q: "x"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("urlencoded: Bind = %+v; want %+v", got, want)
+	}
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	mw.WriteField("title", "files")
+	for _, name := range []string{"a.txt", "b.txt"} {
+		fw, _ := mw.CreateFormFile("file", name)
+		fw.Write([]byte(name))
+	}
+	mw.Close()
+	r = httptest.NewRequest("POST", "/", &buf)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	got, err = bind(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "files" || len(got.Files) != 2 || got.First == nil || got.First.Filename != "a.txt" {
+		t.Errorf("multipart: Bind = %+v; want title and two files", got)
+	}
+
+	r = httptest.NewRequest("POST", "/", strings.NewReader("page=1"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if _, err := bind(r); err == nil || !strings.Contains(err.Error(), `form parameter "title"`) {
+		t.Errorf("missing title: Bind = %v; want error for title", err)
+	}
+}
+
+func TestBindPanics(t *testing.T) {
+	for _, v := range []any{nil, item{}, new(int), (*item)(nil)} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Bind(%T) did not panic", v)
+				}
+			}()
+			Bind(httptest.NewRequest("GET", "/", nil), v)
+		}()
+	}
+}
+
+type createRequest struct {
+	Tenant string `path:"tenant"`
+	Name   string `json:"name" required:"true"`
+}
+
+type createResponse struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+var errNotAllowed = &Problem{
+	Type:   "https://example.com/probs/not-allowed",
+	Title:  "Tenant not allowed",
+	Status: http.StatusForbidden,
+}
+
+func create(r *http.Request, in createRequest) (*createResponse, error) {
+	switch in.Tenant {
+	case "blocked":
+		return nil, fmt.Errorf("create: %w", errNotAllowed)
+	case "broken":
+		return nil, errors.New("database password is hunter2")
+	}
+	return &createResponse{ID: in.Tenant + "-1", Name: in.Name}, nil
+}
+
+func TestHandler(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("POST /{tenant}/items", Handler(create))
+	// Middleware wrapping the handler.
+	var sawStatus []int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w}
+		mux.ServeHTTP(rec, r)
+		sawStatus = append(sawStatus, rec.status)
+	}))
+	defer ts.Close()
+
+	for _, tt := range []struct {
+		tenant, contentType, body string
+		wantStatus                int
+		wantType                  string
+		wantBody                  map[string]any
+	}{{
+		tenant:     "acme",
+		body:       `{"name":"widget"}`,
+		wantStatus: 200,
+		wantType:   "application/json",
+		wantBody:   map[string]any{"id": "acme-1", "name": "widget"},
+	}, {
+		tenant:     "acme",
+		body:       `{}`,
+		wantStatus: 400,
+		wantType:   "application/problem+json",
+		wantBody: map[string]any{
+			"title":  "Bad Request",
+			"status": 400.0,
+			"detail": `body parameter "/name" is invalid`,
+			"errors": []any{map[string]any{"in": "body", "name": "/name", "detail": "missing required value"}},
+		},
+	}, {
+		tenant:      "acme",
+		contentType: "application/xml",
+		body:        `<name/>`,
+		wantStatus:  415,
+		wantType:    "application/problem+json",
+		wantBody: map[string]any{
+			"title":  "Unsupported Media Type",
+			"status": 415.0,
+			"detail": "request body is invalid",
+			"errors": []any{map[string]any{"in": "body", "name": "", "detail": "unsupported media type"}},
+		},
+	}, {
+		tenant:     "blocked",
+		body:       `{"name":"widget"}`,
+		wantStatus: 403,
+		wantType:   "application/problem+json",
+		wantBody: map[string]any{
+			"type":   "https://example.com/probs/not-allowed",
+			"title":  "Tenant not allowed",
+			"status": 403.0,
+		},
+	}, {
+		tenant:     "broken",
+		body:       `{"name":"widget"}`,
+		wantStatus: 500,
+		wantType:   "application/problem+json",
+		wantBody: map[string]any{
+			"title":  "Internal Server Error",
+			"status": 500.0,
+		},
+	}} {
+		res, err := http.Post(ts.URL+"/"+tt.tenant+"/items", cmp.Or(tt.contentType, "application/json"), strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		if res.StatusCode != tt.wantStatus || res.Header.Get("Content-Type") != tt.wantType {
+			t.Errorf("%s %s: status %d, Content-Type %q; want %d, %q", tt.tenant, tt.body, res.StatusCode, res.Header.Get("Content-Type"), tt.wantStatus, tt.wantType)
+		}
+		var got map[string]any
+		if err := json.Unmarshal(b, &got); err != nil {
+			t.Errorf("%s %s: response %q: %v", tt.tenant, tt.body, b, err)
+		} else if !reflect.DeepEqual(got, tt.wantBody) {
+			t.Errorf("%s %s: response %s; want %v", tt.tenant, tt.body, b, tt.wantBody)
+		}
+	}
+	if want := []int{200, 400, 415, 403, 500}; !reflect.DeepEqual(sawStatus, want) {
+		t.Errorf("middleware saw statuses %v; want %v", sawStatus, want)
+	}
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func TestHandlerMaxBytes(t *testing.T) {
+	h := http.MaxBytesHandler(Handler(create), 8)
+	r := httptest.NewRequest("POST", "/", strings.NewReader(`{"name":"a long name"}`))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d; want 413", w.Code)
+	}
+}
+
+func TestHandlerNotStruct(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Handler with int request type did not panic")
+		}
+	}()
+	Handler(func(*http.Request, int) (int, error) { return 0, nil })
+}
+
+func TestProblemExtensions(t *testing.T) {
+	w := httptest.NewRecorder()
+	WriteProblem(w, &Problem{
+		Type:       "https://example.com/probs/out-of-credit",
+		Title:      "You do not have enough credit.",
+		Status:     http.StatusForbidden,
+		Detail:     "Your current balance is 30, but that costs 50.",
+		Instance:   "/account/12345/msgs/abc",
+		Extensions: map[string]any{"balance": 30, "accounts": []string{"/account/12345", "/account/67890"}},
+	})
+	want := `{"type":"https://example.com/probs/out-of-credit","title":"You do not have enough credit.","status":403,"detail":"Your current balance is 30, but that costs 50.","instance":"/account/12345/msgs/abc","accounts":["/account/12345","/account/67890"],"balance":30}` + "\n"
+	if w.Code != 403 || w.Body.String() != want {
+		t.Errorf("WriteProblem wrote %d %s; want 403 %s", w.Code, w.Body, want)
+	}
+	if got, want := errNotAllowed.Error(), "Tenant not allowed"; got != want {
+		t.Errorf("Error() = %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.jsonv2
+
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+)
+
+// A Problem is a problem details object, as defined by RFC 9457, which
+// describes an error in an HTTP API.
+//
+// A *Problem is an error. When a function adapted by [Handler] returns
+// an error that wraps a *Problem, the Problem is written as the
+// response.
+type Problem struct {
+	// Type is a URI reference that identifies the problem type.
+	// If it is empty, the type is "about:blank", meaning that the
+	// problem has no semantics beyond those of the status code.
+	Type string `json:"type,omitempty"`
+
+	// Title is a short summary of the problem type. If Type and
+	// Title are empty, WriteProblem uses the text of the status code.
+	Title string `json:"title,omitempty"`
+
+	// Status is the HTTP status code. If it is zero, WriteProblem
+	// uses 500 Internal Server Error.
+	Status int `json:"status,omitempty"`
+
+	// Detail explains this occurrence of the problem.
+	Detail string `json:"detail,omitempty"`
+
+	// Instance is a URI reference that identifies this occurrence of
+	// the problem.
+	Instance string `json:"instance,omitempty"`
+
+	// Extensions holds additional members of the problem details
+	// object. Their names must differ from those of the members
+	// above.
+	Extensions map[string]any `json:",inline"`
+}
+
+// Error returns a description of the problem.
+func (p *Problem) Error() string {
+	s := p.Title
+	if s == "" {
+		s = http.StatusText(p.status())
+	}
+	if p.Detail != "" {
+		s += ": " + p.Detail
+	}
+	return s
+}
+
+func (p *Problem) status() int {
+	if p.Status == 0 {
+		return http.StatusInternalServerError
+	}
+	return p.Status
+}
+
+// WriteProblem writes p as the response, with its status code and the
+// media type "application/problem+json".
+func WriteProblem(w http.ResponseWriter, p *Problem) {
+	q := *p
+	q.Status = p.status()
+	if q.Type == "" && q.Title == "" {
+		q.Title = http.StatusText(q.Status)
+	}
+	writeJSON(w, "application/problem+json", q.Status, &q)
+}
+
+// WriteError writes err as a problem details response.
+//
+// If err wraps a [*Problem], that Problem is written. If err was
+// returned by [Bind], a problem with status 400 Bad Request, 413
+// Content Too Large, or 415 Unsupported Media Type is written, with
+// an "errors" member listing the parameters that could not be bound.
+// Otherwise, a problem with status 500 Internal Server Error is
+// written; the text of err is not included, so as not to expose the
+// internals of the server.
+func WriteError(w http.ResponseWriter, err error) {
+	WriteProblem(w, problemOf(err))
+}
+
+// problemOf returns the problem that describes err.
+func problemOf(err error) *Problem {
+	var p *Problem
+	if errors.As(err, &p) {
+		return p
+	}
+	var params []*ParamError
+	status := 0
+	for _, e := range unwrapAll(err) {
+		var pe *ParamError
+		if !errors.As(e, &pe) {
+			continue
+		}
+		var maxErr *http.MaxBytesError
+		switch {
+		case errors.As(pe.Err, &maxErr):
+			status = http.StatusRequestEntityTooLarge
+		case errors.Is(pe.Err, ErrUnsupportedMediaType):
+			status = http.StatusUnsupportedMediaType
+		case status == 0:
+			status = http.StatusBadRequest
+		}
+		params = append(params, pe)
+	}
+	if status == 0 {
+		return &Problem{Status: http.StatusInternalServerError}
+	}
+	p = &Problem{Status: status, Detail: "the request is invalid"}
+	if len(params) == 1 {
+		p.Detail = params[0].param() + " is invalid"
+	}
+	list := make([]map[string]string, len(params))
+	for i, pe := range params {
+		list[i] = map[string]string{
+			"in":     pe.In,
+			"name":   pe.Name,
+			"detail": pe.Err.Error(),
+		}
+	}
+	p.Extensions = map[string]any{"errors": list}
+	return p
+}
+
+// unwrapAll returns the errors joined in err, or err itself.
+func unwrapAll(err error) []error {
+	if j, ok := err.(interface{ Unwrap() []error }); ok {
+		return j.Unwrap()
+	}
+	return []error{err}
+}
@@ -0,0 +1,128 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package httpcache
+
+import (
+	"net/http"
+	"net/http/internal/ascii"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// directives holds the directives of a Cache-Control header field.
+// Directives without an argument map to "".
+type directives map[string]string
+
+// parseCacheControl parses the Cache-Control fields of h. Directive
+// names are case-insensitive; if a directive appears more than once,
+// the first occurrence is used.
+func parseCacheControl(h http.Header) directives {
+	d := directives{}
+	for _, v := range h.Values("Cache-Control") {
+		for v != "" {
+			var item string
+			item, v = cutDirective(v)
+			name, arg, _ := strings.Cut(item, "=")
+			name, ok := ascii.ToLower(textproto.TrimString(name))
+			if !ok || name == "" {
+				continue
+			}
+			arg = textproto.TrimString(arg)
+			if len(arg) >= 2 && arg[0] == '"' && arg[len(arg)-1] == '"' {
+				arg = unquote(arg[1 : len(arg)-1])
+			}
+			if _, dup := d[name]; !dup {
+				d[name] = arg
+			}
+		}
+	}
+	return d
+}
+
+// cutDirective returns the first comma-separated item of s, skipping
+// commas in quoted strings, and the rest of s.
+func cutDirective(s string) (item, rest string) {
+	quoted := false
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && quoted:
+			i++
+		case c == '"':
+			quoted = !quoted
+		case c == ',' && !quoted:
+			return s[:i], s[i+1:]
+		}
+	}
+	return s, ""
+}
+
+// unquote removes the backslashes of quoted-pairs from the contents
+// of a quoted-string.
+func unquote(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func (d directives) has(name string) bool {
+	_, ok := d[name]
+	return ok
+}
+
+// maxDeltaSeconds is the largest delta-seconds value a cache must
+// handle; larger values are treated as this one (RFC 9111, section 1.2.2).
+const maxDeltaSeconds = 1<<31 - 1
+
+// seconds returns the value of a delta-seconds directive, and whether
+// it is present. An invalid argument is treated as zero.
+func (d directives) seconds(name string) (time.Duration, bool) {
+	arg, ok := d[name]
+	if !ok {
+		return 0, false
+	}
+	return parseDeltaSeconds(arg), true
+}
+
+// parseDeltaSeconds parses a non-negative number of seconds. It returns
+// zero for invalid values.
+func parseDeltaSeconds(s string) time.Duration {
+	if s == "" {
+		return 0
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return 0
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n > maxDeltaSeconds {
+		n = maxDeltaSeconds
+	}
+	return time.Duration(n) * time.Second
+}
+
+// headerList returns the elements of the comma-separated list fields
+// named key, canonicalized as header field names.
+func headerList(h http.Header, key string) []string {
+	var names []string
+	for _, v := range h.Values(key) {
+		for name := range strings.SplitSeq(v, ",") {
+			if name = textproto.TrimString(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	return names
+}
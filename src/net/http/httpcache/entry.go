@@ -0,0 +1,316 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package httpcache
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// An entry is a stored response.
+type entry struct {
+	requestTime  time.Time   // when the request that got the response was sent
+	responseTime time.Time   // when the response was received
+	vary         http.Header // request fields named by the response's Vary field
+	statusCode   int
+	status       string
+	header       http.Header
+	body         []byte
+}
+
+// entryMagic starts every encoded entry, so that entries written in
+// a different format are not misread.
+const entryMagic = "GOHTTPCACHE/1"
+
+// encode returns the serialized entry. It consists of a line with
+// entryMagic, a line with the request and response times, the Vary
+// request fields and a blank line, the status line, the response
+// header and a blank line, and the body.
+func (e *entry) encode() []byte {
+	var b bytes.Buffer
+	b.WriteString(entryMagic + "\r\n")
+	b.WriteString(strconv.FormatInt(e.requestTime.UnixNano(), 10) + " " +
+		strconv.FormatInt(e.responseTime.UnixNano(), 10) + "\r\n")
+	e.vary.Write(&b)
+	b.WriteString("\r\n")
+	b.WriteString(e.status + "\r\n")
+	e.header.Write(&b)
+	b.WriteString("\r\n")
+	b.Write(e.body)
+	return b.Bytes()
+}
+
+var errMalformedEntry = errors.New("httpcache: malformed cache entry")
+
+// decodeEntry parses an entry serialized by encode.
+func decodeEntry(data []byte) (*entry, error) {
+	r := bytes.NewReader(data)
+	tp := textproto.NewReader(bufio.NewReader(r))
+	line, err := tp.ReadLine()
+	if err != nil || line != entryMagic {
+		return nil, errMalformedEntry
+	}
+	line, err = tp.ReadLine()
+	if err != nil {
+		return nil, errMalformedEntry
+	}
+	reqTime, respTime, ok := strings.Cut(line, " ")
+	if !ok {
+		return nil, errMalformedEntry
+	}
+	e := &entry{}
+	for _, t := range []struct {
+		s string
+		t *time.Time
+	}{{reqTime, &e.requestTime}, {respTime, &e.responseTime}} {
+		n, err := strconv.ParseInt(t.s, 10, 64)
+		if err != nil {
+			return nil, errMalformedEntry
+		}
+		*t.t = time.Unix(0, n)
+	}
+	vary, err := tp.ReadMIMEHeader()
+	if err != nil {
+		return nil, errMalformedEntry
+	}
+	e.vary = http.Header(vary)
+	if e.status, err = tp.ReadLine(); err != nil {
+		return nil, errMalformedEntry
+	}
+	code, _, _ := strings.Cut(e.status, " ")
+	if e.statusCode, err = strconv.Atoi(code); err != nil || len(code) != 3 {
+		return nil, errMalformedEntry
+	}
+	header, err := tp.ReadMIMEHeader()
+	if err != nil {
+		return nil, errMalformedEntry
+	}
+	e.header = http.Header(header)
+	if e.body, err = io.ReadAll(tp.R); err != nil {
+		return nil, errMalformedEntry
+	}
+	return e, nil
+}
+
+// variantsMagic starts every encoded variants index.
+const variantsMagic = "GOHTTPCACHE-VARIANTS/1"
+
+// A variants is the index of the responses stored for a URL whose
+// responses have a Vary field. It is stored under the key of the URL
+// in place of an entry, and each response is stored under a key of
+// its own, computed by variantKey.
+type variants struct {
+	names []string // the field names of the Vary field, as by varyNames
+	keys  []string // the keys of the stored responses, oldest first
+}
+
+// encode returns the serialized index. It consists of a line with
+// variantsMagic, a line with the field names, and a line for each key.
+func (v *variants) encode() []byte {
+	var b bytes.Buffer
+	b.WriteString(variantsMagic + "\r\n")
+	b.WriteString(strings.Join(v.names, ", ") + "\r\n")
+	for _, k := range v.keys {
+		b.WriteString(k + "\r\n")
+	}
+	return b.Bytes()
+}
+
+// decodeVariants parses an index serialized by encode. It reports
+// whether data is one.
+func decodeVariants(data []byte) (*variants, bool) {
+	lines := strings.Split(string(data), "\r\n")
+	if len(lines) < 3 || lines[0] != variantsMagic || lines[len(lines)-1] != "" {
+		return nil, false
+	}
+	return &variants{
+		names: strings.Split(lines[1], ", "),
+		keys:  lines[2 : len(lines)-1],
+	}, true
+}
+
+// newEntry returns an entry for res, whose body is body.
+func newEntry(req *http.Request, res *http.Response, body []byte, requestTime, responseTime time.Time) *entry {
+	e := &entry{
+		requestTime:  requestTime,
+		responseTime: responseTime,
+		vary:         http.Header{},
+		statusCode:   res.StatusCode,
+		status:       res.Status,
+		header:       res.Header.Clone(),
+		body:         body,
+	}
+	if e.header == nil {
+		e.header = http.Header{}
+	}
+	if !strings.HasPrefix(e.status, strconv.Itoa(res.StatusCode)+" ") {
+		e.status = strconv.Itoa(res.StatusCode) + " " + http.StatusText(res.StatusCode)
+	}
+	for _, k := range hopHeaders {
+		e.header.Del(k)
+	}
+	e.header.Del("Content-Length")
+	for _, name := range headerList(res.Header, "Vary") {
+		if vv := req.Header.Values(name); vv != nil {
+			e.vary[name] = vv
+		}
+	}
+	return e
+}
+
+// hopHeaders are the hop-by-hop fields, which are not stored.
+var hopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// matches reports whether the stored response can be used for req,
+// according to its Vary field (RFC 9111, section 4.1).
+func (e *entry) matches(req *http.Request) bool {
+	for _, name := range headerList(e.header, "Vary") {
+		if name == "*" {
+			return false
+		}
+		if normalizeField(req.Header.Values(name)) != normalizeField(e.vary.Values(name)) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeField combines the values of a field into one, removing
+// the optional whitespace around list elements.
+func normalizeField(vv []string) string {
+	var b strings.Builder
+	for _, v := range vv {
+		for elem := range strings.SplitSeq(v, ",") {
+			if b.Len() > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(textproto.TrimString(elem))
+		}
+	}
+	return b.String()
+}
+
+// age returns the current age of the response (RFC 9111, section 4.2.3).
+func (e *entry) age(now time.Time) time.Duration {
+	date := e.date()
+	apparentAge := max(0, e.responseTime.Sub(date))
+	ageValue := parseDeltaSeconds(e.header.Get("Age"))
+	responseDelay := e.responseTime.Sub(e.requestTime)
+	correctedInitialAge := max(apparentAge, ageValue+responseDelay)
+	residentTime := now.Sub(e.responseTime)
+	return correctedInitialAge + residentTime
+}
+
+// date returns the value of the Date field, or the response time if
+// it is missing or invalid.
+func (e *entry) date() time.Time {
+	if t, err := http.ParseTime(e.header.Get("Date")); err == nil {
+		return t
+	}
+	return e.responseTime
+}
+
+// maxHeuristicLifetime limits heuristic freshness lifetimes.
+const maxHeuristicLifetime = 24 * time.Hour
+
+// freshnessLifetime returns how long the response is fresh for
+// (RFC 9111, section 4.2.1).
+func (e *entry) freshnessLifetime(cc directives, shared bool) time.Duration {
+	if shared {
+		if d, ok := cc.seconds("s-maxage"); ok {
+			return d
+		}
+	}
+	if d, ok := cc.seconds("max-age"); ok {
+		return d
+	}
+	if v := e.header.Values("Expires"); len(v) > 0 {
+		expires, err := http.ParseTime(v[0])
+		if err != nil {
+			return 0 // invalid dates are in the past
+		}
+		return max(0, expires.Sub(e.date()))
+	}
+	if heuristicallyCacheable(e.statusCode) || cc.has("public") {
+		if lm, err := http.ParseTime(e.header.Get("Last-Modified")); err == nil {
+			return min(max(0, e.date().Sub(lm))/10, maxHeuristicLifetime)
+		}
+	}
+	return 0
+}
+
+// heuristicallyCacheable reports whether responses with the status
+// code may be stored and given a heuristic lifetime without explicit
+// freshness information (RFC 9110, section 15.1).
+func heuristicallyCacheable(code int) bool {
+	switch code {
+	case 200, 203, 204, 300, 301, 308, 404, 405, 410, 414, 501:
+		return true
+	}
+	return false
+}
+
+// hasValidator reports whether the response can be revalidated.
+func (e *entry) hasValidator() bool {
+	return e.header.Get("Etag") != "" || e.header.Get("Last-Modified") != ""
+}
+
+// update replaces the stored header fields with those of a 304 Not
+// Modified response (RFC 9111, section 3.2).
+func (e *entry) update(h http.Header, requestTime, responseTime time.Time) {
+	for k, vv := range h {
+		switch k {
+		case "Content-Length", "Content-Encoding", "Content-Range":
+			continue
+		}
+		if isHopHeader(k) {
+			continue
+		}
+		e.header[k] = vv
+	}
+	e.requestTime = requestTime
+	e.responseTime = responseTime
+}
+
+func isHopHeader(k string) bool {
+	for _, h := range hopHeaders {
+		if k == h {
+			return true
+		}
+	}
+	return false
+}
+
+// response returns the stored response as a response to req.
+func (e *entry) response(req *http.Request, now time.Time) *http.Response {
+	h := e.header.Clone()
+	h.Set("Age", strconv.FormatInt(int64(e.age(now)/time.Second), 10))
+	return &http.Response{
+		Status:        e.status,
+		StatusCode:    e.statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        h,
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
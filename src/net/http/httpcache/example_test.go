@@ -0,0 +1,36 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package httpcache_test
+
+import (
+	"log"
+	"net/http"
+	"net/http/httpcache"
+	"os"
+	"path/filepath"
+)
+
+func ExampleTransport() {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		log.Fatal(err)
+	}
+	storage, err := httpcache.NewDiskStorage(filepath.Join(dir, "myapp", "http"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	client := &http.Client{
+		Transport: &httpcache.Transport{Storage: storage},
+	}
+
+	// Responses are reused while they are fresh, and revalidated
+	// when they are stale.
+	res, err := client.Get("https://example.com/data.json")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer res.Body.Close()
+	log.Println(res.Status, res.Header.Get("Age"))
+}
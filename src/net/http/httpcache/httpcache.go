@@ -0,0 +1,602 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package httpcache implements an HTTP cache for clients, as specified
+// by RFC 9111.
+//
+// A [Transport] wraps another [http.RoundTripper], storing the
+// responses to GET requests and reusing them while they are fresh.
+// Stale responses are revalidated with conditional requests using
+// their ETag and Last-Modified fields. The Cache-Control directives
+// of requests and responses, including stale-while-revalidate and
+// stale-if-error (RFC 5861), and the Vary field are honored.
+//
+// Responses are kept in a [Storage]: [MemoryStorage] keeps them in
+// memory, and [DiskStorage] in files.
+package httpcache
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultMemoryStorageSize is the size of the MemoryStorage used by a
+// Transport without a Storage.
+const defaultMemoryStorageSize = 64 << 20
+
+// defaultMaxBodySize is the default maximum size of a stored response
+// body.
+const defaultMaxBodySize = 8 << 20
+
+// maxVariants is the maximum number of responses with different
+// values of the request fields named by their Vary field that are
+// stored for a URL. The oldest is removed to make room for a new one.
+const maxVariants = 32
+
+// A Transport is an [http.RoundTripper] that caches responses.
+//
+// Only responses to GET requests are cached. Requests with a Range
+// field or their own conditional fields (If-None-Match,
+// If-Modified-Since, If-Match, If-Unmodified-Since, or If-Range) are
+// passed through. A successful response to an unsafe request, such as
+// a POST, invalidates the responses stored for its URL, and for its
+// Location and Content-Location if they have the same origin.
+//
+// A URL whose responses have a Vary field may have several responses
+// stored, one for each combination of values of the request fields
+// that the Vary field names, up to 32.
+//
+// Responses served from the cache have an Age field. A request with
+// the only-if-cached directive and no usable stored response gets a
+// 504 Gateway Timeout response.
+type Transport struct {
+	// Transport is used to send requests.
+	// If nil, http.DefaultTransport is used.
+	Transport http.RoundTripper
+
+	// Storage holds the cached responses. If nil, a MemoryStorage
+	// of 64MiB is used.
+	Storage Storage
+
+	// Shared configures the cache as a shared cache, such as one in
+	// a proxy, rather than a private one. A shared cache does not
+	// store responses with the private directive, or responses to
+	// requests with an Authorization field unless the response
+	// allows it, and honors the s-maxage directive.
+	Shared bool
+
+	// MaxBodySize is the maximum size of a response body that is
+	// stored. If zero, it is 8MiB.
+	MaxBodySize int64
+
+	now func() time.Time // for testing
+
+	storageOnce   sync.Once
+	storage       Storage
+	mu            sync.Mutex
+	revalidations map[string]bool // keys being revalidated in the background
+
+	variantsMu sync.Mutex // serializes updates of variants indexes
+}
+
+func (t *Transport) transport() http.RoundTripper {
+	if t.Transport != nil {
+		return t.Transport
+	}
+	return http.DefaultTransport
+}
+
+func (t *Transport) getStorage() Storage {
+	t.storageOnce.Do(func() {
+		t.storage = t.Storage
+		if t.storage == nil {
+			t.storage = NewMemoryStorage(defaultMemoryStorageSize)
+		}
+	})
+	return t.storage
+}
+
+func (t *Transport) timeNow() time.Time {
+	if t.now != nil {
+		return t.now()
+	}
+	return time.Now()
+}
+
+// cacheKey returns the storage key for a URL.
+func cacheKey(u *url.URL) string {
+	u2 := *u
+	u2.Fragment = ""
+	u2.RawFragment = ""
+	return u2.String()
+}
+
+// varyNames returns the field names of the Vary field in h,
+// canonicalized, sorted and without duplicates.
+func varyNames(h http.Header) []string {
+	names := headerList(h, "Vary")
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// variantKey returns the storage key of the response to req, among the
+// responses for the URL with the given key whose Vary field has the
+// field names names. It is key itself if names is empty.
+func variantKey(key string, names []string, req *http.Request) string {
+	var b strings.Builder
+	b.WriteString(key)
+	for _, name := range names {
+		b.WriteString(" " + name + "=")
+		b.WriteString(strconv.Quote(normalizeField(req.Header.Values(name))))
+	}
+	return b.String()
+}
+
+// RoundTrip implements the [http.RoundTripper] interface.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch req.Method {
+	case "", "GET":
+	case "HEAD", "OPTIONS", "TRACE":
+		return t.transport().RoundTrip(req)
+	default:
+		res, err := t.transport().RoundTrip(req)
+		if err == nil && res.StatusCode >= 200 && res.StatusCode < 400 {
+			t.invalidate(req, res)
+		}
+		return res, err
+	}
+	for _, k := range []string{"Range", "If-None-Match", "If-Modified-Since", "If-Match", "If-Unmodified-Since", "If-Range"} {
+		if req.Header.Get(k) != "" {
+			return t.transport().RoundTrip(req)
+		}
+	}
+	reqCC := parseCacheControl(req.Header)
+	if reqCC.has("no-store") {
+		return t.transport().RoundTrip(req)
+	}
+	if len(reqCC) == 0 && req.Header.Get("Pragma") == "no-cache" {
+		reqCC["no-cache"] = ""
+	}
+
+	key := cacheKey(req.URL)
+	e := t.load(key, req)
+	if e != nil {
+		now := t.timeNow()
+		resCC := parseCacheControl(e.header)
+		age := e.age(now)
+		lifetime := e.freshnessLifetime(resCC, t.Shared)
+		switch {
+		case t.fresh(reqCC, resCC, age, lifetime):
+			closeBody(req)
+			return e.response(req, now), nil
+		case t.staleWhileRevalidate(reqCC, resCC, age, lifetime):
+			closeBody(req)
+			// Build the response first, as a 304 Not Modified
+			// response to the revalidation updates e.
+			res := e.response(req, now)
+			t.revalidateInBackground(key, req, e)
+			return res, nil
+		}
+		if !e.hasValidator() {
+			e = nil
+		}
+	}
+	if reqCC.has("only-if-cached") {
+		closeBody(req)
+		return gatewayTimeout(req), nil
+	}
+	if e == nil {
+		return t.fetch(key, req)
+	}
+
+	res, err := t.revalidate(key, req, e)
+	if err == nil && !isServerError(res.StatusCode) {
+		return res, nil
+	}
+	now := t.timeNow()
+	resCC := parseCacheControl(e.header)
+	if t.staleIfError(reqCC, resCC, e.age(now), e.freshnessLifetime(resCC, t.Shared)) {
+		if res != nil {
+			res.Body.Close()
+		}
+		return e.response(req, now), nil
+	}
+	return res, err
+}
+
+// fresh reports whether a stored response with the given age and
+// freshness lifetime may be used for a request without validation
+// (RFC 9111, section 4.2).
+func (t *Transport) fresh(reqCC, resCC directives, age, lifetime time.Duration) bool {
+	if reqCC.has("no-cache") || resCC.has("no-cache") {
+		return false
+	}
+	if maxAge, ok := reqCC.seconds("max-age"); ok && age > maxAge {
+		return false
+	}
+	if minFresh, ok := reqCC.seconds("min-fresh"); ok {
+		lifetime -= minFresh
+	}
+	if age < lifetime {
+		return true
+	}
+	// The client may accept a stale response.
+	if !reqCC.has("max-stale") || t.mustRevalidate(resCC) {
+		return false
+	}
+	if reqCC["max-stale"] == "" {
+		return true
+	}
+	maxStale, _ := reqCC.seconds("max-stale")
+	return age-lifetime <= maxStale
+}
+
+// mustRevalidate reports whether a stale response must not be used
+// without validation, whatever the request allows.
+func (t *Transport) mustRevalidate(resCC directives) bool {
+	return resCC.has("must-revalidate") ||
+		t.Shared && (resCC.has("proxy-revalidate") || resCC.has("s-maxage"))
+}
+
+// staleWhileRevalidate reports whether a stale response may be used
+// while it is revalidated in the background (RFC 5861, section 3).
+func (t *Transport) staleWhileRevalidate(reqCC, resCC directives, age, lifetime time.Duration) bool {
+	window, ok := resCC.seconds("stale-while-revalidate")
+	if !ok || reqCC.has("no-cache") || resCC.has("no-cache") || t.mustRevalidate(resCC) {
+		return false
+	}
+	if reqCC.has("max-age") || reqCC.has("min-fresh") {
+		return false // the client asked for a fresher response
+	}
+	return age-lifetime <= window
+}
+
+// staleIfError reports whether a stale response may be used when
+// revalidation fails (RFC 5861, section 4).
+func (t *Transport) staleIfError(reqCC, resCC directives, age, lifetime time.Duration) bool {
+	if t.mustRevalidate(resCC) || resCC.has("no-cache") {
+		return false
+	}
+	for _, cc := range []directives{reqCC, resCC} {
+		if window, ok := cc.seconds("stale-if-error"); ok && age-lifetime <= window {
+			return true
+		}
+	}
+	return false
+}
+
+func isServerError(code int) bool {
+	switch code {
+	case http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// load returns the stored response for req, or nil. key is the
+// storage key of its URL.
+func (t *Transport) load(key string, req *http.Request) *entry {
+	s := t.getStorage()
+	data, ok := s.Get(key)
+	if !ok {
+		return nil
+	}
+	if v, ok := decodeVariants(data); ok {
+		key = variantKey(key, v.names, req)
+		if !slices.Contains(v.keys, key) {
+			return nil
+		}
+		if data, ok = s.Get(key); !ok {
+			return nil
+		}
+	}
+	e, err := decodeEntry(data)
+	if err != nil {
+		s.Delete(key)
+		return nil
+	}
+	if !e.matches(req) {
+		return nil
+	}
+	return e
+}
+
+// fetch sends req and arranges for the response to be stored.
+func (t *Transport) fetch(key string, req *http.Request) (*http.Response, error) {
+	requestTime := t.timeNow()
+	res, err := t.transport().RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	t.storeResponse(key, req, res, requestTime, t.timeNow())
+	return res, nil
+}
+
+// revalidate sends a conditional request for the stored response e.
+// If the server responds with 304 Not Modified, the stored response
+// is updated and returned. Otherwise, the new response is returned,
+// and is stored if it can be.
+func (t *Transport) revalidate(key string, req *http.Request, e *entry) (*http.Response, error) {
+	creq := req.Clone(req.Context())
+	if etag := e.header.Get("Etag"); etag != "" {
+		creq.Header.Set("If-None-Match", etag)
+	}
+	if lm := e.header.Get("Last-Modified"); lm != "" {
+		creq.Header.Set("If-Modified-Since", lm)
+	}
+	requestTime := t.timeNow()
+	res, err := t.transport().RoundTrip(creq)
+	if err != nil {
+		return nil, err
+	}
+	responseTime := t.timeNow()
+	if res.StatusCode != http.StatusNotModified {
+		t.storeResponse(key, req, res, requestTime, responseTime)
+		return res, nil
+	}
+	io.Copy(io.Discard, io.LimitReader(res.Body, 4<<10))
+	res.Body.Close()
+	e.update(res.Header, requestTime, responseTime)
+	if t.storable(req, e.statusCode, e.header) {
+		t.store(key, req, e)
+	} else {
+		t.remove(key, req)
+	}
+	return e.response(req, t.timeNow()), nil
+}
+
+// revalidateInBackground revalidates the stored response e for req,
+// unless it is already being revalidated.
+func (t *Transport) revalidateInBackground(key string, req *http.Request, e *entry) {
+	vkey := variantKey(key, varyNames(e.header), req)
+	t.mu.Lock()
+	if t.revalidations[vkey] {
+		t.mu.Unlock()
+		return
+	}
+	if t.revalidations == nil {
+		t.revalidations = make(map[string]bool)
+	}
+	t.revalidations[vkey] = true
+	t.mu.Unlock()
+
+	req = req.Clone(context.WithoutCancel(req.Context()))
+	req.Body = nil
+	go func() {
+		defer func() {
+			t.mu.Lock()
+			delete(t.revalidations, vkey)
+			t.mu.Unlock()
+		}()
+		res, err := t.revalidate(key, req, e)
+		if err != nil {
+			return
+		}
+		// Read the body, so that a new response is stored.
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+	}()
+}
+
+// storable reports whether a response to req may be stored
+// (RFC 9111, section 3).
+func (t *Transport) storable(req *http.Request, code int, h http.Header) bool {
+	if code < 200 || code == http.StatusPartialContent || code == http.StatusNotModified {
+		return false
+	}
+	resCC := parseCacheControl(h)
+	reqCC := parseCacheControl(req.Header)
+	if resCC.has("no-store") || reqCC.has("no-store") {
+		return false
+	}
+	if t.Shared {
+		if resCC.has("private") {
+			return false
+		}
+		if req.Header.Get("Authorization") != "" &&
+			!resCC.has("must-revalidate") && !resCC.has("public") && !resCC.has("s-maxage") {
+			return false
+		}
+	}
+	for _, name := range headerList(h, "Vary") {
+		if name == "*" {
+			return false
+		}
+	}
+	return h.Get("Expires") != "" ||
+		resCC.has("max-age") ||
+		t.Shared && resCC.has("s-maxage") ||
+		!t.Shared && resCC.has("private") ||
+		resCC.has("public") ||
+		heuristicallyCacheable(code)
+}
+
+// storeResponse arranges for res to be stored once its body has been
+// read completely, if it can be stored. Otherwise, it removes any
+// stored response that res replaces.
+func (t *Transport) storeResponse(key string, req *http.Request, res *http.Response, requestTime, responseTime time.Time) {
+	maxBody := t.MaxBodySize
+	if maxBody <= 0 {
+		maxBody = defaultMaxBodySize
+	}
+	if !t.storable(req, res.StatusCode, res.Header) || res.ContentLength > maxBody {
+		if res.StatusCode < 500 {
+			t.remove(key, req)
+		}
+		return
+	}
+	res.Body = &cachingBody{
+		ReadCloser: res.Body,
+		max:        maxBody,
+		store: func(body []byte) {
+			t.store(key, req, newEntry(req, res, body, requestTime, responseTime))
+		},
+	}
+}
+
+// store stores e, the response to req, for the URL with the given
+// storage key. A response with a Vary field is stored as one of the
+// variants for the URL, replacing only the one for the same values of
+// the request fields it names.
+func (t *Transport) store(key string, req *http.Request, e *entry) {
+	s := t.getStorage()
+	t.variantsMu.Lock()
+	defer t.variantsMu.Unlock()
+	v := t.loadVariants(key)
+	names := varyNames(e.header)
+	if len(names) == 0 {
+		t.deleteVariants(v)
+		s.Set(key, e.encode())
+		return
+	}
+	if v == nil || !slices.Equal(v.names, names) {
+		// The responses vary on other fields now.
+		t.deleteVariants(v)
+		v = &variants{names: names}
+	}
+	vkey := variantKey(key, names, req)
+	if i := slices.Index(v.keys, vkey); i >= 0 {
+		v.keys = slices.Delete(v.keys, i, i+1)
+	} else if len(v.keys) >= maxVariants {
+		s.Delete(v.keys[0])
+		v.keys = v.keys[1:]
+	}
+	v.keys = append(v.keys, vkey)
+	s.Set(vkey, e.encode())
+	s.Set(key, v.encode())
+}
+
+// remove removes the response stored for req, if any, for the URL
+// with the given storage key.
+func (t *Transport) remove(key string, req *http.Request) {
+	s := t.getStorage()
+	t.variantsMu.Lock()
+	defer t.variantsMu.Unlock()
+	v := t.loadVariants(key)
+	if v == nil {
+		s.Delete(key)
+		return
+	}
+	vkey := variantKey(key, v.names, req)
+	i := slices.Index(v.keys, vkey)
+	if i < 0 {
+		return
+	}
+	s.Delete(vkey)
+	v.keys = slices.Delete(v.keys, i, i+1)
+	if len(v.keys) == 0 {
+		s.Delete(key)
+	} else {
+		s.Set(key, v.encode())
+	}
+}
+
+// removeAll removes all the responses stored for the URL with the
+// given storage key.
+func (t *Transport) removeAll(key string) {
+	t.variantsMu.Lock()
+	defer t.variantsMu.Unlock()
+	t.deleteVariants(t.loadVariants(key))
+	t.getStorage().Delete(key)
+}
+
+// loadVariants returns the variants index stored for the URL with the
+// given storage key, or nil if there is none.
+func (t *Transport) loadVariants(key string) *variants {
+	data, ok := t.getStorage().Get(key)
+	if !ok {
+		return nil
+	}
+	v, _ := decodeVariants(data)
+	return v
+}
+
+// deleteVariants removes the responses listed in v, which may be nil.
+func (t *Transport) deleteVariants(v *variants) {
+	if v == nil {
+		return
+	}
+	for _, k := range v.keys {
+		t.getStorage().Delete(k)
+	}
+}
+
+// A cachingBody is a response body that keeps a copy of what is read
+// from it, and stores the response when it has been read completely.
+type cachingBody struct {
+	io.ReadCloser
+	buf   bytes.Buffer
+	max   int64
+	store func(body []byte)
+	done  bool
+}
+
+func (b *cachingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if b.done {
+		return n, err
+	}
+	if int64(b.buf.Len()+n) > b.max {
+		b.done = true
+		b.buf = bytes.Buffer{}
+		return n, err
+	}
+	b.buf.Write(p[:n])
+	if err == io.EOF {
+		b.done = true
+		b.store(b.buf.Bytes())
+	} else if err != nil {
+		b.done = true
+	}
+	return n, err
+}
+
+// invalidate removes the responses stored for the URLs affected by a
+// successful unsafe request (RFC 9111, section 4.4).
+func (t *Transport) invalidate(req *http.Request, res *http.Response) {
+	t.removeAll(cacheKey(req.URL))
+	for _, k := range []string{"Location", "Content-Location"} {
+		v := res.Header.Get(k)
+		if v == "" {
+			continue
+		}
+		u, err := req.URL.Parse(v)
+		if err != nil || u.Scheme != req.URL.Scheme || u.Host != req.URL.Host {
+			continue
+		}
+		t.removeAll(cacheKey(u))
+	}
+}
+
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
+
+// gatewayTimeout returns the response to an only-if-cached request
+// that cannot be satisfied from the cache (RFC 9111, section 5.2.1.7).
+func gatewayTimeout(req *http.Request) *http.Response {
+	const body = "httpcache: no stored response for only-if-cached request\n"
+	return &http.Response{
+		Status:        "504 Gateway Timeout",
+		StatusCode:    http.StatusGatewayTimeout,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"text/plain; charset=utf-8"}},
+		Body:          io.NopCloser(bytes.NewReader([]byte(body))),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,548 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package httpcache
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// A fakeClock is the clock of a test cache and origin server.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// An origin is a RoundTripper that serves requests with a handler,
+// which is called with the current count of requests.
+type origin struct {
+	clock    *fakeClock
+	handler  func(n int, w http.ResponseWriter, r *http.Request)
+	requests atomic.Int32
+	err      error // if set, returned by RoundTrip
+}
+
+func (o *origin) RoundTrip(req *http.Request) (*http.Response, error) {
+	n := int(o.requests.Add(1))
+	if o.err != nil {
+		return nil, o.err
+	}
+	rec := httptest.NewRecorder()
+	rec.Header().Set("Date", o.clock.Now().UTC().Format(http.TimeFormat))
+	o.handler(n, rec, req)
+	res := rec.Result()
+	res.Request = req
+	return res, nil
+}
+
+// newTestTransport returns a cache in front of an origin with the
+// given handler.
+func newTestTransport(handler func(n int, w http.ResponseWriter, r *http.Request)) (*Transport, *origin, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}
+	o := &origin{clock: clock, handler: handler}
+	return &Transport{Transport: o, now: clock.Now}, o, clock
+}
+
+// get sends a GET request with the given header fields, as pairs of
+// names and values, and returns the response and its body.
+func get(t *testing.T, tr http.RoundTripper, header ...string) (*http.Response, string) {
+	t.Helper()
+	return do(t, tr, "GET", "http://example.com/x", header...)
+}
+
+func do(t *testing.T, tr http.RoundTripper, method, url string, header ...string) (*http.Response, string) {
+	t.Helper()
+	req, _ := http.NewRequest(method, url, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Add(header[i], header[i+1])
+	}
+	res, err := tr.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, url, err)
+	}
+	b, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res, string(b)
+}
+
+func wantRequests(t *testing.T, o *origin, want int) {
+	t.Helper()
+	if n := int(o.requests.Load()); n != want {
+		t.Errorf("origin got %d requests; want %d", n, want)
+	}
+}
+
+func TestMaxAge(t *testing.T) {
+	tr, o, clock := newTestTransport(func(n int, w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		fmt.Fprint(w, "response ", n)
+	})
+	if _, body := get(t, tr); body != "response 1" {
+		t.Fatalf("body = %q", body)
+	}
+	clock.Advance(10 * time.Second)
+	res, body := get(t, tr)
+	if body != "response 1" {
+		t.Errorf("cached body = %q; want %q", body, "response 1")
+	}
+	if age := res.Header.Get("Age"); age != "10" {
+		t.Errorf("Age = %q; want 10", age)
+	}
+	wantRequests(t, o, 1)
+
+	clock.Advance(50 * time.Second)
+	if _, body := get(t, tr); body != "response 2" {
+		t.Errorf("body after expiry = %q; want %q", body, "response 2")
+	}
+	wantRequests(t, o, 2)
+}
+
+func TestAgeAndExpires(t *testing.T) {
+	tr, o, clock := newTestTransport(func(n int, w http.ResponseWriter, r *http.Request) {
+		// The response has already spent 30s of its 60s in other caches.
+		w.Header().Set("Age", "30")
+		date, _ := http.ParseTime(w.Header().Get("Date"))
+		w.Header().Set("Expires", date.Add(60*time.Second).Format(http.TimeFormat))
+		fmt.Fprint(w, n)
+	})
+	get(t, tr)
+	clock.Advance(20 * time.Second)
+	get(t, tr)
+	wantRequests(t, o, 1)
+	clock.Advance(15 * time.Second)
+	get(t, tr)
+	wantRequests(t, o, 2)
+}
+
+func TestHeuristicFreshness(t *testing.T) {
+	tr, o, clock := newTestTransport(func(n int, w http.ResponseWriter, r *http.Request) {
+		date, _ := http.ParseTime(w.Header().Get("Date"))
+		w.Header().Set("Last-Modified", date.Add(-50*time.Hour).Format(http.TimeFormat))
+		fmt.Fprint(w, n)
+	})
+	get(t, tr)
+	clock.Advance(4 * time.Hour)
+	get(t, tr)
+	wantRequests(t, o, 1)
+	clock.Advance(2 * time.Hour)
+	get(t, tr) // revalidated with If-Modified-Since, and replaced
+	wantRequests(t, o, 2)
+}
+
+func TestRevalidation(t *testing.T) {
+	var gotINM, gotIMS string
+	tr, o, _ := newTestTransport(func(n int, w http.ResponseWriter, r *http.Request) {
+		gotINM, gotIMS = r.Header.Get("If-None-Match"), r.Header.Get("If-Modified-Since")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Etag", `"v1"`)
+		w.Header().Set("Last-Modified", "Mon, 01 Jan 2024 00:00:00 GMT")
+		w.Header().Set("X-Request", fmt.Sprint(n))
+		if gotINM == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, "body")
+	})
+	get(t, tr)
+	if gotINM != "" || gotIMS != "" {
+		t.Errorf("first request has validators %q, %q", gotINM, gotIMS)
+	}
+	res, body := get(t, tr)
+	if gotINM != `"v1"` || gotIMS != "Mon, 01 Jan 2024 00:00:00 GMT" {
+		t.Errorf("revalidation sent If-None-Match %q, If-Modified-Since %q", gotINM, gotIMS)
+	}
+	if res.StatusCode != 200 || body != "body" {
+		t.Errorf("revalidated response = %d %q; want 200 %q", res.StatusCode, body, "body")
+	}
+	if v := res.Header.Get("X-Request"); v != "2" {
+		t.Errorf("X-Request = %q; want the 304's 2", v)
+	}
+	if v := res.Header.Get("Content-Type"); v != "text/plain" {
+		t.Errorf("Content-Type = %q; want the stored text/plain", v)
+	}
+	wantRequests(t, o, 2)
+
+	// The request's own conditional request is passed through.
+	res, _ = get(t, tr, "If-None-Match", `"v1"`)
+	if res.StatusCode != http.StatusNotModified {
+		t.Errorf("conditional request got %d; want 304", res.StatusCode)
+	}
+}
+
+func TestVary(t *testing.T) {
+	tr, o, _ := newTestTransport(func(n int, w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "Accept-Language, Accept")
+		fmt.Fprint(w, r.Header.Get("Accept-Language"), n)
+	})
+	get(t, tr, "Accept-Language", "en, fr")
+	if _, body := get(t, tr, "Accept-Language", "en,fr"); body != "en, fr1" {
+		t.Errorf("body = %q; want cached response", body)
+	}
+	wantRequests(t, o, 1)
+	if _, body := get(t, tr, "Accept-Language", "de"); body != "de2" {
+		t.Errorf("body = %q; want new response", body)
+	}
+	if _, body := get(t, tr, "Accept-Language", "de", "Accept", "text/html"); body != "de3" {
+		t.Errorf("body = %q; want new response", body)
+	}
+	wantRequests(t, o, 3)
+}
+
+func TestVaryVariants(t *testing.T) {
+	tr, o, _ := newTestTransport(func(n int, w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "Accept-Language")
+		fmt.Fprint(w, r.Header.Get("Accept-Language"), n)
+	})
+	get(t, tr, "Accept-Language", "en")
+	get(t, tr, "Accept-Language", "fr")
+	for range 3 {
+		for _, tt := range []struct{ lang, body string }{{"en", "en1"}, {"fr", "fr2"}} {
+			if _, body := get(t, tr, "Accept-Language", tt.lang); body != tt.body {
+				t.Errorf("Accept-Language %s: body = %q; want cached %q", tt.lang, body, tt.body)
+			}
+		}
+	}
+	wantRequests(t, o, 2)
+
+	// An unsafe request invalidates all the variants.
+	do(t, tr, "POST", "http://example.com/x")
+	if _, body := get(t, tr, "Accept-Language", "en"); body != "en4" {
+		t.Errorf("body = %q; want new response", body)
+	}
+	if _, body := get(t, tr, "Accept-Language", "fr"); body != "fr5" {
+		t.Errorf("body = %q; want new response", body)
+	}
+	wantRequests(t, o, 5)
+}
+
+func TestVaryStar(t *testing.T) {
+	tr, o, _ := newTestTransport(func(n int, w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "*")
+	})
+	get(t, tr)
+	get(t, tr)
+	wantRequests(t, o, 2)
+}
+
+func TestNotStored(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		shared bool
+		resCC  string
+		status int
+		header []string // request header
+	}{
+		{name: "no-store", resCC: "no-store, max-age=60"},
+		{name: "request no-store", resCC: "max-age=60", header: []string{"Cache-Control", "no-store"}},
+		{name: "private shared", shared: true, resCC: "private, max-age=60"},
+		{name: "authorization shared", shared: true, resCC: "max-age=60", header: []string{"Authorization", "Basic x"}},
+		{name: "no explicit freshness", status: http.StatusFound},
+		{name: "partial content", resCC: "max-age=60", status: http.StatusPartialContent},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			tr, o, _ := newTestTransport(func(n int, w http.ResponseWriter, r *http.Request) {
+				if tt.resCC != "" {
+					w.Header().Set("Cache-Control", tt.resCC)
+				}
+				w.WriteHeader(max(tt.status, 200))
+			})
+			tr.Shared = tt.shared
+			get(t, tr, tt.header...)
+			get(t, tr, tt.header...)
+			wantRequests(t, o, 2)
+		})
+	}
+}
+
+func TestStored(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		shared bool
+		resCC  string
+		status int
+		header []string // request header
+	}{
+		{name: "private", resCC: "private, max-age=60"},
+		{name: "authorization private", resCC: "max-age=60", header: []string{"Authorization", "Basic x"}},
+		{name: "authorization public", shared: true, resCC: "public, max-age=60", header: []string{"Authorization", "Basic x"}},
+		{name: "s-maxage", shared: true, resCC: "s-maxage=60, max-age=0"},
+		{name: "explicit freshness", resCC: "max-age=60", status: http.StatusFound},
+		{name: "404", resCC: "max-age=60", status: http.StatusNotFound},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			tr, o, _ := newTestTransport(func(n int, w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Cache-Control", tt.resCC)
+				w.WriteHeader(max(tt.status, 200))
+			})
+			tr.Shared = tt.shared
+			get(t, tr, tt.header...)
+			get(t, tr, tt.header...)
+			wantRequests(t, o, 1)
+		})
+	}
+}
+
+func TestRequestDirectives(t *testing.T) {
+	for _, tt := range []struct {
+		resCC    string
+		age      time.Duration
+		reqCC    string
+		requests int
+	}{
+		{"max-age=60", 30 * time.Second, "max-age=40", 1},
+		{"max-age=60", 30 * time.Second, "max-age=20", 2},
+		{"max-age=60", 30 * time.Second, "min-fresh=20", 1},
+		{"max-age=60", 30 * time.Second, "min-fresh=40", 2},
+		{"max-age=60", 30 * time.Second, "no-cache", 2},
+		{"max-age=60", 90 * time.Second, "max-stale", 1},
+		{"max-age=60", 90 * time.Second, "max-stale=40", 1},
+		{"max-age=60", 90 * time.Second, "max-stale=20", 2},
+		{"max-age=60, must-revalidate", 90 * time.Second, "max-stale", 2},
+		{"max-age=60", time.Second, "max-age=0", 2},
+	} {
+		tr, o, clock := newTestTransport(func(n int, w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", tt.resCC)
+		})
+		get(t, tr)
+		clock.Advance(tt.age)
+		get(t, tr, "Cache-Control", tt.reqCC)
+		if n := int(o.requests.Load()); n != tt.requests {
+			t.Errorf("response %q at age %v, request %q: origin got %d requests; want %d",
+				tt.resCC, tt.age, tt.reqCC, n, tt.requests)
+		}
+	}
+}
+
+func TestPragmaNoCache(t *testing.T) {
+	tr, o, _ := newTestTransport(func(n int, w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+	})
+	get(t, tr)
+	get(t, tr, "Pragma", "no-cache")
+	wantRequests(t, o, 2)
+}
+
+func TestOnlyIfCached(t *testing.T) {
+	tr, o, _ := newTestTransport(func(n int, w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		io.WriteString(w, "cached")
+	})
+	if res, _ := get(t, tr, "Cache-Control", "only-if-cached"); res.StatusCode != http.StatusGatewayTimeout {
+		t.Errorf("status = %d; want 504", res.StatusCode)
+	}
+	wantRequests(t, o, 0)
+	get(t, tr)
+	if _, body := get(t, tr, "Cache-Control", "only-if-cached"); body != "cached" {
+		t.Errorf("body = %q; want cached response", body)
+	}
+	wantRequests(t, o, 1)
+}
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	revalidated := make(chan struct{}, 1)
+	tr, o, clock := newTestTransport(func(n int, w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=10, stale-while-revalidate=60")
+		fmt.Fprint(w, n)
+		if n > 1 {
+			revalidated <- struct{}{}
+		}
+	})
+	get(t, tr)
+	clock.Advance(30 * time.Second)
+	if _, body := get(t, tr); body != "1" {
+		t.Errorf("body = %q; want stale response", body)
+	}
+	<-revalidated
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		_, body := get(t, tr)
+		if body == "2" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("revalidated response was not stored")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	wantRequests(t, o, 2)
+
+	// Beyond the window, the request waits for a new response.
+	clock.Advance(100 * time.Second)
+	if _, body := get(t, tr); body != "3" {
+		t.Errorf("body = %q; want new response", body)
+	}
+}
+
+func TestStaleWhileRevalidateNotModified(t *testing.T) {
+	revalidated := make(chan struct{}, 1)
+	tr, o, clock := newTestTransport(func(n int, w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=10, stale-while-revalidate=60")
+		w.Header().Set("Etag", `"x"`)
+		w.Header().Set("X-Version", fmt.Sprint(n))
+		if n > 1 {
+			if r.Header.Get("If-None-Match") != `"x"` {
+				t.Errorf("If-None-Match = %q; want %q", r.Header.Get("If-None-Match"), `"x"`)
+			}
+			w.WriteHeader(http.StatusNotModified)
+			revalidated <- struct{}{}
+			return
+		}
+		fmt.Fprint(w, "body")
+	})
+	get(t, tr)
+	clock.Advance(30 * time.Second)
+	res, body := get(t, tr)
+	if body != "body" || res.Header.Get("X-Version") != "1" {
+		t.Errorf("response = %q, version %q; want stale response", body, res.Header.Get("X-Version"))
+	}
+	<-revalidated
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		res, body := get(t, tr)
+		if body != "body" {
+			t.Fatalf("body = %q; want %q", body, "body")
+		}
+		if res.Header.Get("X-Version") == "2" {
+			if age := res.Header.Get("Age"); age != "0" {
+				t.Errorf("Age = %q; want 0", age)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("updated response was not stored")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	wantRequests(t, o, 2)
+}
+
+func TestStaleIfError(t *testing.T) {
+	fail := false
+	tr, o, clock := newTestTransport(func(n int, w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=10, stale-if-error=60")
+		w.Header().Set("Etag", `"x"`)
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, n)
+	})
+	get(t, tr)
+	clock.Advance(30 * time.Second)
+	fail = true
+	if res, body := get(t, tr); res.StatusCode != 200 || body != "1" {
+		t.Errorf("response = %d %q; want stale response", res.StatusCode, body)
+	}
+	o.err = errors.New("connection refused")
+	if res, body := get(t, tr); res.StatusCode != 200 || body != "1" {
+		t.Errorf("response = %d %q; want stale response", res.StatusCode, body)
+	}
+	clock.Advance(60 * time.Second)
+	req, _ := http.NewRequest("GET", "http://example.com/x", nil)
+	if _, err := tr.RoundTrip(req); err == nil {
+		t.Errorf("RoundTrip beyond stale-if-error window succeeded")
+	}
+}
+
+func TestInvalidation(t *testing.T) {
+	tr, o, _ := newTestTransport(func(n int, w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			w.Header().Set("Location", "/y")
+			w.Header().Set("Content-Location", "http://other.example/x")
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=60")
+	})
+	for _, u := range []string{"http://example.com/x", "http://example.com/y", "http://other.example/x"} {
+		do(t, tr, "GET", u)
+	}
+	wantRequests(t, o, 3)
+	do(t, tr, "POST", "http://example.com/x")
+	wantRequests(t, o, 4)
+	for _, u := range []string{"http://example.com/x", "http://example.com/y", "http://other.example/x"} {
+		do(t, tr, "GET", u)
+	}
+	// The other origin's response was not invalidated.
+	wantRequests(t, o, 6)
+}
+
+func TestIncompleteBodyNotStored(t *testing.T) {
+	tr, o, _ := newTestTransport(func(n int, w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		io.WriteString(w, strings.Repeat("x", 100))
+	})
+	req, _ := http.NewRequest("GET", "http://example.com/x", nil)
+	res, err := tr.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.ReadFull(res.Body, make([]byte, 10))
+	res.Body.Close()
+	get(t, tr)
+	get(t, tr)
+	wantRequests(t, o, 2)
+
+	tr, o, _ = newTestTransport(tr.Transport.(*origin).handler)
+	tr.MaxBodySize = 50
+	get(t, tr)
+	get(t, tr)
+	wantRequests(t, o, 2)
+}
+
+func TestParseCacheControl(t *testing.T) {
+	h := http.Header{"Cache-Control": {`Max-Age=60, no-cache="Set-Cookie, X-Foo"`, `private, max-age=5, s-maxage="10"`, "x=\"a\\\"b\""}}
+	d := parseCacheControl(h)
+	want := directives{"max-age": "60", "no-cache": "Set-Cookie, X-Foo", "private": "", "s-maxage": "10", "x": `a"b`}
+	if len(d) != len(want) {
+		t.Errorf("parseCacheControl = %q; want %q", d, want)
+	}
+	for k, v := range want {
+		if d[k] != v || !d.has(k) {
+			t.Errorf("parseCacheControl[%q] = %q; want %q", k, d[k], v)
+		}
+	}
+	for _, tt := range []struct {
+		in   string
+		want time.Duration
+	}{
+		{"0", 0},
+		{"120", 120 * time.Second},
+		{"-1", 0},
+		{"1.5", 0},
+		{"99999999999999999999", maxDeltaSeconds * time.Second},
+	} {
+		if got := parseDeltaSeconds(tt.in); got != tt.want {
+			t.Errorf("parseDeltaSeconds(%q) = %v; want %v", tt.in, got, tt.want)
+		}
+	}
+}
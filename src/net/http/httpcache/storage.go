@@ -0,0 +1,159 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package httpcache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// A Storage stores cache entries, which are opaque byte slices, by key.
+//
+// A Storage may discard entries at any time, for example to bound
+// its size. Storage errors are not reported: an entry that cannot be
+// read is treated as missing, and one that cannot be written is not
+// cached. Implementations must be safe for concurrent use.
+type Storage interface {
+	// Get returns the entry stored under key, and whether there is one.
+	// The caller must not modify the returned slice.
+	Get(key string) ([]byte, bool)
+
+	// Set stores value under key, replacing any existing entry.
+	// Set must not modify or retain value after it returns,
+	// except as the stored entry.
+	Set(key string, value []byte)
+
+	// Delete removes the entry stored under key, if any.
+	Delete(key string)
+}
+
+// A MemoryStorage is a [Storage] that keeps entries in memory,
+// discarding the least recently used ones when it is full.
+type MemoryStorage struct {
+	mu       sync.Mutex
+	maxBytes int64
+	size     int64
+	lru      list.List // of *memoryEntry, most recently used first
+	entries  map[string]*list.Element
+}
+
+type memoryEntry struct {
+	key   string
+	value []byte
+}
+
+// NewMemoryStorage returns a MemoryStorage that holds at most maxBytes
+// of keys and entries.
+func NewMemoryStorage(maxBytes int64) *MemoryStorage {
+	return &MemoryStorage{
+		maxBytes: maxBytes,
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Get implements [Storage].
+func (s *MemoryStorage) Get(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	el, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	s.lru.MoveToFront(el)
+	return el.Value.(*memoryEntry).value, true
+}
+
+// Set implements [Storage]. Entries larger than the storage are not
+// stored.
+func (s *MemoryStorage) Set(key string, value []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delete(key)
+	n := int64(len(key) + len(value))
+	if n > s.maxBytes {
+		return
+	}
+	s.entries[key] = s.lru.PushFront(&memoryEntry{key, value})
+	s.size += n
+	for s.size > s.maxBytes {
+		s.delete(s.lru.Back().Value.(*memoryEntry).key)
+	}
+}
+
+// Delete implements [Storage].
+func (s *MemoryStorage) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delete(key)
+}
+
+func (s *MemoryStorage) delete(key string) {
+	el, ok := s.entries[key]
+	if !ok {
+		return
+	}
+	e := s.lru.Remove(el).(*memoryEntry)
+	delete(s.entries, key)
+	s.size -= int64(len(e.key) + len(e.value))
+}
+
+// A DiskStorage is a [Storage] that keeps each entry in a file in a
+// directory. It does not limit the size of the directory.
+type DiskStorage struct {
+	dir string
+}
+
+// NewDiskStorage returns a DiskStorage that keeps entries in dir,
+// creating it if necessary. The directory should not be used for
+// anything else.
+func NewDiskStorage(dir string) (*DiskStorage, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &DiskStorage{dir: dir}, nil
+}
+
+// path returns the name of the file for key.
+func (s *DiskStorage) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:]))
+}
+
+// Get implements [Storage].
+func (s *DiskStorage) Get(key string) ([]byte, bool) {
+	data, err := os.ReadFile(s.path(key))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// Set implements [Storage]. The entry is written to a temporary file
+// which is then renamed, so that readers never see a partial entry.
+func (s *DiskStorage) Set(key string, value []byte) {
+	f, err := os.CreateTemp(s.dir, "tmp-*")
+	if err != nil {
+		return
+	}
+	_, err = f.Write(value)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), s.path(key))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+}
+
+// Delete implements [Storage].
+func (s *DiskStorage) Delete(key string) {
+	os.Remove(s.path(key))
+}
@@ -0,0 +1,125 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package httpcache
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testStorage(t *testing.T, s Storage) {
+	if _, ok := s.Get("a"); ok {
+		t.Errorf("Get on empty storage succeeded")
+	}
+	s.Set("a", []byte("1"))
+	s.Set("b", []byte("2"))
+	s.Set("a", []byte("3"))
+	if v, ok := s.Get("a"); !ok || string(v) != "3" {
+		t.Errorf("Get(a) = %q, %v; want 3, true", v, ok)
+	}
+	if v, ok := s.Get("b"); !ok || string(v) != "2" {
+		t.Errorf("Get(b) = %q, %v; want 2, true", v, ok)
+	}
+	s.Delete("a")
+	s.Delete("missing")
+	if _, ok := s.Get("a"); ok {
+		t.Errorf("Get after Delete succeeded")
+	}
+}
+
+func TestMemoryStorage(t *testing.T) {
+	testStorage(t, NewMemoryStorage(1<<10))
+
+	s := NewMemoryStorage(10)
+	s.Set("a", []byte("1234")) // 5 bytes
+	s.Set("b", []byte("1234"))
+	s.Get("a")
+	s.Set("c", []byte("1234")) // evicts b, the least recently used
+	if _, ok := s.Get("b"); ok {
+		t.Errorf("b was not evicted")
+	}
+	if _, ok := s.Get("a"); !ok {
+		t.Errorf("a was evicted")
+	}
+	s.Set("d", bytes.Repeat([]byte("x"), 20))
+	if _, ok := s.Get("d"); ok {
+		t.Errorf("entry larger than the storage was stored")
+	}
+	if s.size != 10 {
+		t.Errorf("size = %d; want 10", s.size)
+	}
+}
+
+func TestDiskStorage(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewDiskStorage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStorage(t, s)
+
+	// Entries persist.
+	s2, err := NewDiskStorage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := s2.Get("b"); !ok || string(v) != "2" {
+		t.Errorf("Get(b) from new storage = %q, %v; want 2, true", v, ok)
+	}
+	files, _ := os.ReadDir(dir)
+	if len(files) != 1 {
+		t.Errorf("storage directory has %d files; want 1", len(files))
+	}
+}
+
+func TestDiskStorageTransport(t *testing.T) {
+	s, err := NewDiskStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr, o, _ := newTestTransport(func(n int, w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte("line\r\n\r\nbody"))
+	})
+	tr.Storage = s
+	get(t, tr)
+	if _, body := get(t, tr); body != "line\r\n\r\nbody" {
+		t.Errorf("body = %q", body)
+	}
+	wantRequests(t, o, 1)
+}
+
+func TestEntryEncoding(t *testing.T) {
+	e := &entry{
+		requestTime:  time.Unix(100, 5),
+		responseTime: time.Unix(101, 6),
+		vary:         http.Header{"Accept-Language": {"en", "fr"}},
+		statusCode:   404,
+		status:       "404 Not Found",
+		header:       http.Header{"Cache-Control": {"max-age=60"}, "X-Multi": {"a", "b"}},
+		body:         []byte("\r\nnot found\r\n"),
+	}
+	got, err := decodeEntry(e.encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.requestTime.Equal(e.requestTime) || !got.responseTime.Equal(e.responseTime) {
+		t.Errorf("times = %v, %v; want %v, %v", got.requestTime, got.responseTime, e.requestTime, e.responseTime)
+	}
+	got.requestTime, got.responseTime = e.requestTime, e.responseTime
+	if !reflect.DeepEqual(got, e) {
+		t.Errorf("decoded %+v; want %+v", got, e)
+	}
+
+	for _, data := range []string{"", "GOHTTPCACHE/1\r\n", "GOHTTPCACHE/1\r\nx y\r\n\r\n200 OK\r\n\r\n", "OTHER\r\n"} {
+		if _, err := decodeEntry([]byte(data)); err == nil {
+			t.Errorf("decodeEntry(%q) succeeded", data)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package httpsig
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+)
+
+// Signature algorithms, from the HTTP Signature Algorithms registry
+// (RFC 9421, section 6.2).
+const (
+	AlgRSAPSSSHA512    = "rsa-pss-sha512"
+	AlgRSAv15SHA256    = "rsa-v1_5-sha256"
+	AlgHMACSHA256      = "hmac-sha256"
+	AlgECDSAP256SHA256 = "ecdsa-p256-sha256"
+	AlgECDSAP384SHA384 = "ecdsa-p384-sha384"
+	AlgEd25519         = "ed25519"
+)
+
+var errKeyType = errors.New("httpsig: key type does not match algorithm")
+
+// publicKey returns the public key of key, if it is a private key.
+func publicKey(key any) any {
+	if s, ok := key.(crypto.Signer); ok {
+		return s.Public()
+	}
+	return key
+}
+
+// defaultAlgorithm returns the algorithm used with key when none is
+// specified: HMAC-SHA256 for a []byte, RSASSA-PSS with SHA-512 for an
+// RSA key, ECDSA with the key's curve, or Ed25519.
+func defaultAlgorithm(key any) (string, error) {
+	switch k := publicKey(key).(type) {
+	case []byte:
+		return AlgHMACSHA256, nil
+	case *rsa.PublicKey:
+		return AlgRSAPSSSHA512, nil
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return AlgECDSAP256SHA256, nil
+		case elliptic.P384():
+			return AlgECDSAP384SHA384, nil
+		}
+		return "", errors.New("httpsig: unsupported ECDSA curve")
+	case ed25519.PublicKey:
+		return AlgEd25519, nil
+	}
+	return "", errors.New("httpsig: unsupported key type")
+}
+
+// ecdsaParams returns the curve and hash of an ECDSA algorithm.
+func ecdsaParams(alg string) (elliptic.Curve, crypto.Hash) {
+	if alg == AlgECDSAP256SHA256 {
+		return elliptic.P256(), crypto.SHA256
+	}
+	return elliptic.P384(), crypto.SHA384
+}
+
+func digest(h crypto.Hash, data []byte) []byte {
+	switch h {
+	case crypto.SHA256:
+		sum := sha256.Sum256(data)
+		return sum[:]
+	case crypto.SHA384:
+		sum := sha512.Sum384(data)
+		return sum[:]
+	}
+	sum := sha512.Sum512(data)
+	return sum[:]
+}
+
+// pssOptions are the RSASSA-PSS parameters of rsa-pss-sha512
+// (RFC 9421, section 3.3.1).
+var pssOptions = &rsa.PSSOptions{SaltLength: 64, Hash: crypto.SHA512}
+
+// sign signs the signature base with key using alg.
+func sign(alg string, key any, base []byte) ([]byte, error) {
+	if alg == AlgHMACSHA256 {
+		secret, ok := key.([]byte)
+		if !ok {
+			return nil, errKeyType
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(base)
+		return mac.Sum(nil), nil
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errKeyType
+	}
+	switch alg {
+	case AlgRSAPSSSHA512, AlgRSAv15SHA256:
+		if _, ok := signer.Public().(*rsa.PublicKey); !ok {
+			return nil, errKeyType
+		}
+		if alg == AlgRSAPSSSHA512 {
+			return signer.Sign(rand.Reader, digest(crypto.SHA512, base), pssOptions)
+		}
+		return signer.Sign(rand.Reader, digest(crypto.SHA256, base), crypto.SHA256)
+	case AlgECDSAP256SHA256, AlgECDSAP384SHA384:
+		curve, h := ecdsaParams(alg)
+		if pub, ok := signer.Public().(*ecdsa.PublicKey); !ok || pub.Curve != curve {
+			return nil, errKeyType
+		}
+		der, err := signer.Sign(rand.Reader, digest(h, base), h)
+		if err != nil {
+			return nil, err
+		}
+		// The signature is the concatenation of r and s, rather
+		// than their ASN.1 encoding (RFC 9421, section 3.3.4).
+		var rs struct{ R, S *big.Int }
+		if rest, err := asn1.Unmarshal(der, &rs); err != nil || len(rest) > 0 {
+			return nil, errors.New("httpsig: malformed ECDSA signature from signer")
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		sig := make([]byte, 2*size)
+		rs.R.FillBytes(sig[:size])
+		rs.S.FillBytes(sig[size:])
+		return sig, nil
+	case AlgEd25519:
+		if _, ok := signer.Public().(ed25519.PublicKey); !ok {
+			return nil, errKeyType
+		}
+		return signer.Sign(rand.Reader, base, crypto.Hash(0))
+	}
+	return nil, errors.New("httpsig: unsupported algorithm " + alg)
+}
+
+var errVerification = errors.New("httpsig: invalid signature")
+
+// verify verifies the signature of the signature base with key using
+// alg. The key may be a public key, a private key, or a []byte for
+// HMAC.
+func verify(alg string, key any, base, sig []byte) error {
+	key = publicKey(key)
+	ok := false
+	switch alg {
+	case AlgHMACSHA256:
+		secret, isBytes := key.([]byte)
+		if !isBytes {
+			return errKeyType
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(base)
+		ok = hmac.Equal(mac.Sum(nil), sig)
+	case AlgRSAPSSSHA512:
+		pub, isRSA := key.(*rsa.PublicKey)
+		if !isRSA {
+			return errKeyType
+		}
+		ok = rsa.VerifyPSS(pub, crypto.SHA512, digest(crypto.SHA512, base), sig, pssOptions) == nil
+	case AlgRSAv15SHA256:
+		pub, isRSA := key.(*rsa.PublicKey)
+		if !isRSA {
+			return errKeyType
+		}
+		ok = rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest(crypto.SHA256, base), sig) == nil
+	case AlgECDSAP256SHA256, AlgECDSAP384SHA384:
+		curve, h := ecdsaParams(alg)
+		pub, isECDSA := key.(*ecdsa.PublicKey)
+		if !isECDSA || pub.Curve != curve {
+			return errKeyType
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return errVerification
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		ok = ecdsa.Verify(pub, digest(h, base), r, s)
+	case AlgEd25519:
+		pub, isEd25519 := key.(ed25519.PublicKey)
+		if !isEd25519 {
+			return errKeyType
+		}
+		ok = ed25519.Verify(pub, base, sig)
+	default:
+		return errors.New("httpsig: unsupported algorithm " + alg)
+	}
+	if !ok {
+		return errVerification
+	}
+	return nil
+}
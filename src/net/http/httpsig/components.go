@@ -0,0 +1,346 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package httpsig
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/internal/ascii"
+	"net/http/internal/httpsfv"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// A component is a parsed component identifier (RFC 9421, section 2).
+type component struct {
+	id   string // serialized identifier, such as `"@query-param";name="a"`
+	name string // component name, such as "@query-param"
+
+	req bool   // ;req: the field or derived component of the request
+	bs  bool   // ;bs: the field values wrapped as byte sequences
+	key string // ;key: a member of a dictionary field, if hasKey
+	// qname is the ;name parameter of @query-param.
+	qname  string
+	hasKey bool
+}
+
+// parseComponent parses a component identifier given by the user,
+// either as a serialized identifier such as `"@query-param";name="a"`,
+// or with an unquoted name such as `content-type` or
+// `@query-param;name="a"`.
+func parseComponent(s string) (component, error) {
+	if !strings.HasPrefix(s, `"`) {
+		name, params, _ := strings.Cut(s, ";")
+		name, _ = ascii.ToLower(name)
+		s = strconv.Quote(name)
+		if params != "" {
+			s += ";" + params
+		}
+	}
+	var (
+		c   component
+		err error
+	)
+	ok := httpsfv.ParseItem(s, func(bareItem, param string) {
+		c, err = parseComponentItem(bareItem, param)
+	})
+	if !ok {
+		return component{}, errors.New("httpsig: malformed component identifier " + s)
+	}
+	return c, err
+}
+
+// parseComponentItem parses a component identifier from the bare item
+// and parameters of a member of a covered components list.
+func parseComponentItem(bareItem, param string) (component, error) {
+	name, ok := httpsfv.ParseString(bareItem)
+	if !ok || name == "" {
+		return component{}, errors.New("httpsig: malformed component identifier " + bareItem + param)
+	}
+	if lower, _ := ascii.ToLower(name); lower != name {
+		return component{}, errors.New("httpsig: component name " + bareItem + " is not lowercase")
+	}
+	c := component{id: bareItem + param, name: name}
+	hasName := false
+	var perr error
+	ok = httpsfv.ParseParameter(param, func(key, val string) {
+		switch key {
+		case "req":
+			c.req = val == "?1"
+		case "bs":
+			c.bs = val == "?1"
+		case "key":
+			c.key, ok = httpsfv.ParseString(val)
+			c.hasKey = true
+		case "name":
+			c.qname, ok = httpsfv.ParseString(val)
+			hasName = true
+		default:
+			// The sf and tr parameters are registered, but not
+			// supported; an unregistered parameter is an error.
+			perr = errors.New("httpsig: unsupported component parameter " + key)
+		}
+		if !ok {
+			perr = errors.New("httpsig: malformed component identifier " + c.id)
+		}
+	})
+	if !ok || perr != nil {
+		if perr == nil {
+			perr = errors.New("httpsig: malformed component identifier " + c.id)
+		}
+		return component{}, perr
+	}
+	switch {
+	case name == "@signature-params":
+		return component{}, errors.New("httpsig: @signature-params cannot be covered")
+	case name == "@query-param" && !hasName:
+		return component{}, errors.New("httpsig: @query-param requires a name parameter")
+	case name != "@query-param" && hasName:
+		return component{}, errors.New("httpsig: name parameter is only valid with @query-param")
+	case name[0] == '@' && (c.bs || c.hasKey):
+		return component{}, errors.New("httpsig: field parameter on derived component " + c.id)
+	case c.bs && c.hasKey:
+		return component{}, errors.New("httpsig: bs and key parameters are incompatible")
+	}
+	return c, nil
+}
+
+// A message is a request or response from which component values are
+// taken.
+type message struct {
+	req *http.Request  // the request, or the request of res
+	res *http.Response // nil for a request
+}
+
+// request returns the request from which the value of c is taken.
+func (m message) request(c component) (*http.Request, error) {
+	if m.req == nil || (c.req && m.res == nil) {
+		if c.req {
+			return nil, errors.New("httpsig: req parameter used without a request to respond to")
+		}
+		return nil, errors.New("httpsig: no request for component " + c.id)
+	}
+	return m.req, nil
+}
+
+// values returns the values of component c in m. Most components have
+// one value; @query-param has one for each occurrence of the parameter.
+func (m message) values(c component) ([]string, error) {
+	if c.name[0] == '@' {
+		return m.derived(c)
+	}
+	h := m.header(c)
+	if h == nil {
+		return nil, errors.New("httpsig: no message for component " + c.id)
+	}
+	vals := h.Values(c.name)
+	if len(vals) == 0 && c.name == "host" && !c.req && m.res == nil {
+		// The Host header of a request is held in Request.Host.
+		if host := m.req.Host; host != "" {
+			vals = []string{host}
+		} else if m.req.URL != nil {
+			vals = []string{m.req.URL.Host}
+		}
+	}
+	if len(vals) == 0 {
+		return nil, errors.New("httpsig: covered field " + c.name + " is not present")
+	}
+	for i, v := range vals {
+		vals[i] = textproto.TrimString(v)
+		if c.bs {
+			vals[i] = ":" + base64.StdEncoding.EncodeToString([]byte(vals[i])) + ":"
+		}
+	}
+	v := strings.Join(vals, ", ")
+	if !c.hasKey {
+		return []string{v}, nil
+	}
+	var member string
+	found := false
+	ok := httpsfv.ParseDictionary(v, func(key, val, param string) {
+		if key == c.key {
+			member, found = val+param, true
+		}
+	})
+	if !ok {
+		return nil, errors.New("httpsig: field " + c.name + " is not a dictionary")
+	}
+	if !found {
+		return nil, errors.New("httpsig: key " + strconv.Quote(c.key) + " not present in field " + c.name)
+	}
+	return []string{member}, nil
+}
+
+// header returns the header fields from which the value of field
+// component c is taken.
+func (m message) header(c component) http.Header {
+	if m.res != nil && !c.req {
+		return m.res.Header
+	}
+	if c.req && m.res == nil || m.req == nil {
+		return nil
+	}
+	return m.req.Header
+}
+
+func (m message) derived(c component) ([]string, error) {
+	if c.name == "@status" {
+		if m.res == nil || c.req {
+			return nil, errors.New("httpsig: @status is only valid for responses")
+		}
+		return []string{strconv.Itoa(m.res.StatusCode)}, nil
+	}
+	r, err := m.request(c)
+	if err != nil {
+		return nil, err
+	}
+	switch c.name {
+	case "@method":
+		if r.Method == "" {
+			return []string{"GET"}, nil
+		}
+		return []string{r.Method}, nil
+	case "@target-uri":
+		return []string{targetURI(r)}, nil
+	case "@authority":
+		return []string{authority(r)}, nil
+	case "@scheme":
+		return []string{scheme(r)}, nil
+	case "@request-target":
+		return []string{requestTarget(r)}, nil
+	case "@path":
+		p := r.URL.EscapedPath()
+		if p == "" {
+			p = "/"
+		}
+		return []string{p}, nil
+	case "@query":
+		return []string{"?" + r.URL.RawQuery}, nil
+	case "@query-param":
+		var vals []string
+		for pair := range strings.SplitSeq(r.URL.RawQuery, "&") {
+			k, v, _ := strings.Cut(pair, "=")
+			k, err1 := url.QueryUnescape(k)
+			v, err2 := url.QueryUnescape(v)
+			if err1 != nil || err2 != nil {
+				return nil, errors.New("httpsig: malformed query")
+			}
+			if encodeQueryComponent(k) == c.qname {
+				vals = append(vals, encodeQueryComponent(v))
+			}
+		}
+		if len(vals) == 0 {
+			return nil, errors.New("httpsig: query parameter " + strconv.Quote(c.qname) + " is not present")
+		}
+		return vals, nil
+	}
+	return nil, errors.New("httpsig: unknown derived component " + c.name)
+}
+
+// isServerRequest reports whether r was received by a server, rather
+// than being sent by a client.
+func isServerRequest(r *http.Request) bool {
+	return r.RequestURI != ""
+}
+
+func scheme(r *http.Request) string {
+	if !isServerRequest(r) && r.URL.Scheme != "" {
+		s, _ := ascii.ToLower(r.URL.Scheme)
+		return s
+	}
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// authority returns the normalized authority of r: its host, in
+// lowercase, with the port removed if it is the scheme's default.
+func authority(r *http.Request) string {
+	host := r.Host
+	if host == "" {
+		host = r.URL.Host
+	}
+	host, _ = ascii.ToLower(host)
+	switch scheme(r) {
+	case "http":
+		host = strings.TrimSuffix(host, ":80")
+	case "https":
+		host = strings.TrimSuffix(host, ":443")
+	}
+	return host
+}
+
+func requestTarget(r *http.Request) string {
+	if isServerRequest(r) {
+		return r.RequestURI
+	}
+	return r.URL.RequestURI()
+}
+
+func targetURI(r *http.Request) string {
+	if !isServerRequest(r) {
+		return r.URL.String()
+	}
+	if !strings.HasPrefix(r.RequestURI, "/") && r.RequestURI != "*" {
+		// The request target is in absolute form.
+		return r.RequestURI
+	}
+	return scheme(r) + "://" + authority(r) + r.RequestURI
+}
+
+// encodeQueryComponent percent-encodes a decoded query parameter name
+// or value as RFC 9421, section 2.2.8 requires: every byte apart from
+// ASCII letters, digits, and "*-._" is encoded, including spaces.
+func encodeQueryComponent(s string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			c == '*', c == '-', c == '.', c == '_':
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&15])
+		}
+	}
+	return b.String()
+}
+
+// signatureBase returns the signature base of m for the covered
+// components and the serialized signature parameters
+// (RFC 9421, section 2.5).
+func signatureBase(m message, comps []component, params string) ([]byte, error) {
+	var b strings.Builder
+	seen := make(map[string]bool)
+	for _, c := range comps {
+		if seen[c.id] {
+			return nil, errors.New("httpsig: component " + c.id + " is covered more than once")
+		}
+		seen[c.id] = true
+		vals, err := m.values(c)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range vals {
+			if strings.ContainsAny(v, "\r\n") {
+				return nil, errors.New("httpsig: value of component " + c.id + " contains a newline")
+			}
+			b.WriteString(c.id)
+			b.WriteString(": ")
+			b.WriteString(v)
+			b.WriteByte('\n')
+		}
+	}
+	b.WriteString(`"@signature-params": `)
+	b.WriteString(params)
+	return []byte(b.String()), nil
+}
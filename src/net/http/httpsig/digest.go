@@ -0,0 +1,101 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package httpsig
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/internal/httpsfv"
+)
+
+// ErrDigestMismatch is returned by [VerifyContentDigest] when content
+// does not match its digest.
+var ErrDigestMismatch = errors.New("httpsig: content does not match Content-Digest")
+
+// contentDigest returns the digest of body with the named algorithm
+// from the Hash Algorithms for HTTP Digest Fields registry, or false if
+// the algorithm is not supported.
+func contentDigest(alg string, body []byte) ([]byte, bool) {
+	switch alg {
+	case "sha-256":
+		sum := sha256.Sum256(body)
+		return sum[:], true
+	case "sha-512":
+		sum := sha512.Sum512(body)
+		return sum[:], true
+	}
+	return nil, false
+}
+
+// ContentDigest returns the value of a Content-Digest field for body,
+// using alg, which is "sha-256" or "sha-512".
+func ContentDigest(alg string, body []byte) (string, error) {
+	sum, ok := contentDigest(alg, body)
+	if !ok {
+		return "", errors.New("httpsig: unsupported digest algorithm " + alg)
+	}
+	return alg + "=:" + base64.StdEncoding.EncodeToString(sum) + ":", nil
+}
+
+// VerifyContentDigest checks body against the value of a Content-Digest
+// field. Every digest in the field with a supported algorithm must
+// match, and there must be at least one.
+func VerifyContentDigest(value string, body []byte) error {
+	checked := false
+	mismatch := false
+	ok := httpsfv.ParseDictionary(value, func(key, val, param string) {
+		want, ok := contentDigest(key, body)
+		if !ok {
+			return
+		}
+		got, ok := parseByteSequence(val)
+		checked = true
+		if !ok || subtle.ConstantTimeCompare(got, want) != 1 {
+			mismatch = true
+		}
+	})
+	switch {
+	case !ok:
+		return errors.New("httpsig: malformed Content-Digest field")
+	case !checked:
+		return errors.New("httpsig: Content-Digest field has no supported algorithm")
+	case mismatch:
+		return ErrDigestMismatch
+	}
+	return nil
+}
+
+// SetRequestContentDigest reads the body of r and sets its
+// Content-Digest header field, using alg, which is "sha-256" or
+// "sha-512". It replaces the body with a copy, and sets r.GetBody to
+// return further copies.
+func SetRequestContentDigest(r *http.Request, alg string) error {
+	var body []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			return err
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+		r.ContentLength = int64(len(body))
+	}
+	v, err := ContentDigest(alg, body)
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Content-Digest", v)
+	return nil
+}
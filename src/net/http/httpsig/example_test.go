@@ -0,0 +1,70 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package httpsig_test
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httpsig"
+	"net/http/httptest"
+	"strings"
+)
+
+func Example() {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// The server accepts requests signed with the client's key over
+	// their method, target URI, and content.
+	v := &httpsig.Verifier{
+		Key: func(keyID string) (any, string, error) {
+			if keyID != "client-1" {
+				return nil, "", errors.New("unknown key")
+			}
+			return pub, httpsig.AlgEd25519, nil
+		},
+		RequiredComponents: []string{"@method", "@target-uri", "content-digest"},
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sig, err := v.VerifyRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := httpsig.VerifyContentDigest(r.Header.Get("Content-Digest"), body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintf(w, "hello, %s", sig.KeyID)
+	}))
+	defer ts.Close()
+
+	req, err := http.NewRequest("POST", ts.URL+"/hello", strings.NewReader("body"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := httpsig.SetRequestContentDigest(req, "sha-256"); err != nil {
+		log.Fatal(err)
+	}
+	s := &httpsig.Signer{KeyID: "client-1", Key: priv}
+	if err := s.SignRequest(req); err != nil {
+		log.Fatal(err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+	fmt.Println(string(body))
+	// Output: hello, client-1
+}
@@ -0,0 +1,399 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package httpsig implements HTTP Message Signatures, as defined in
+// RFC 9421, and the Content-Digest field, as defined in RFC 9530.
+//
+// A [Signer] signs selected components of a request or response, such
+// as its method, target URI, and header fields, and adds the signature
+// in the Signature-Input and Signature header fields. A [Verifier]
+// checks such a signature.
+//
+// A signature does not cover the content of a message. To protect the
+// content too, add a Content-Digest field with [SetRequestContentDigest]
+// or [ContentDigest] and cover it in the signature, and have the
+// recipient check it with [VerifyContentDigest].
+package httpsig
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/internal/httpsfv"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoSignature is returned by [Verifier.VerifyRequest] and
+// [Verifier.VerifyResponse] when a message has no signature to verify.
+var ErrNoSignature = errors.New("httpsig: message is not signed")
+
+// A Signer signs HTTP messages.
+type Signer struct {
+	// Label is the label of the signature in the Signature-Input
+	// and Signature fields. If empty, it is "sig1".
+	Label string
+
+	// KeyID identifies the key to the verifier. If non-empty, it
+	// is sent as the keyid parameter of the signature.
+	KeyID string
+
+	// Key is the signing key: a crypto.Signer holding an RSA,
+	// ECDSA (P-256 or P-384), or Ed25519 private key, or a []byte
+	// holding an HMAC secret.
+	Key any
+
+	// Algorithm is the signature algorithm, such as AlgEd25519.
+	// If non-empty, it is sent as the alg parameter of the
+	// signature. If empty, the algorithm is chosen from the type
+	// of Key, and the verifier is expected to know it from the key.
+	Algorithm string
+
+	// Components lists the components covered by the signature.
+	// Each is the name of a header field, such as "content-type",
+	// or of a derived component, such as "@method", optionally
+	// followed by parameters, as in `@query-param;name="id"`.
+	//
+	// If nil, requests are signed over "@method" and "@target-uri",
+	// and responses over "@status", and in both cases over the
+	// "content-digest" field if it is present.
+	Components []string
+
+	// Expires, if positive, is how long after its creation the
+	// signature expires.
+	Expires time.Duration
+
+	// Tag, if non-empty, is sent as the tag parameter of the
+	// signature, identifying the application or profile it is for.
+	Tag string
+
+	now func() time.Time // for testing
+}
+
+// SignRequest signs r, adding the signature to its header. A covered
+// component that is not present in r, such as a missing header field,
+// is an error.
+func (s *Signer) SignRequest(r *http.Request) error {
+	return s.sign(message{req: r}, r.Header)
+}
+
+// SignResponse signs res, adding the signature to its header.
+// Components with the req parameter, such as `@method;req`, are taken
+// from res.Request.
+func (s *Signer) SignResponse(res *http.Response) error {
+	return s.sign(message{req: res.Request, res: res}, res.Header)
+}
+
+func (s *Signer) sign(m message, h http.Header) error {
+	label := s.Label
+	if label == "" {
+		label = "sig1"
+	}
+	if !isKey(label) {
+		return errors.New("httpsig: invalid signature label " + strconv.Quote(label))
+	}
+	names := s.Components
+	if names == nil {
+		if m.res == nil {
+			names = []string{"@method", "@target-uri"}
+		} else {
+			names = []string{"@status"}
+		}
+		if h.Get("Content-Digest") != "" {
+			names = append(names, "content-digest")
+		}
+	}
+	comps := make([]component, len(names))
+	ids := make([]string, len(names))
+	for i, name := range names {
+		c, err := parseComponent(name)
+		if err != nil {
+			return err
+		}
+		comps[i], ids[i] = c, c.id
+	}
+	alg := s.Algorithm
+	if alg == "" {
+		var err error
+		if alg, err = defaultAlgorithm(s.Key); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	created := now().Unix()
+	params := "(" + strings.Join(ids, " ") + ");created=" + strconv.FormatInt(created, 10)
+	if s.Expires > 0 {
+		params += ";expires=" + strconv.FormatInt(created+int64((s.Expires+time.Second-1)/time.Second), 10)
+	}
+	if s.KeyID != "" {
+		params += ";keyid=" + strconv.Quote(s.KeyID)
+	}
+	if s.Algorithm != "" {
+		params += ";alg=" + strconv.Quote(s.Algorithm)
+	}
+	if s.Tag != "" {
+		params += ";tag=" + strconv.Quote(s.Tag)
+	}
+	if _, ok := httpsfv.ParseString(strconv.Quote(s.KeyID + s.Tag)); !ok {
+		return errors.New("httpsig: key ID or tag contains characters that cannot be sent")
+	}
+
+	base, err := signatureBase(m, comps, params)
+	if err != nil {
+		return err
+	}
+	sig, err := sign(alg, s.Key, base)
+	if err != nil {
+		return err
+	}
+	h.Add("Signature-Input", label+"="+params)
+	h.Add("Signature", label+"=:"+base64.StdEncoding.EncodeToString(sig)+":")
+	return nil
+}
+
+// parseByteSequence parses and decodes a structured field byte
+// sequence.
+func parseByteSequence(s string) ([]byte, bool) {
+	b64, ok := httpsfv.ParseByteSequence(s)
+	if !ok {
+		return nil, false
+	}
+	b, err := base64.StdEncoding.DecodeString(string(b64))
+	return b, err == nil
+}
+
+// isKey reports whether s is a valid structured field dictionary key.
+func isKey(s string) bool {
+	if s == "" || !('a' <= s[0] && s[0] <= 'z' || s[0] == '*') {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		if !('a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("_-.*", c) >= 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// A Verifier verifies the signatures of HTTP messages.
+type Verifier struct {
+	// Key returns the key with which to verify a signature made
+	// with the given key ID, and the algorithm used with that key.
+	// The key ID is empty if the signature has no keyid parameter.
+	//
+	// The key is an *rsa.PublicKey, *ecdsa.PublicKey, or
+	// ed25519.PublicKey, or a []byte holding an HMAC secret.
+	// If the algorithm is empty, it is taken from the alg parameter
+	// of the signature, if any, or otherwise chosen from the type
+	// of the key as by [Signer]. A signature whose alg parameter
+	// does not match the algorithm is rejected.
+	Key func(keyID string) (key any, alg string, err error)
+
+	// Label, if non-empty, is the label of the signature to
+	// verify. Otherwise, the message is accepted if any of its
+	// signatures is valid.
+	Label string
+
+	// RequiredComponents lists components, named as in
+	// Signer.Components, that an accepted signature must cover.
+	RequiredComponents []string
+
+	// MaxAge, if positive, is the maximum age of an accepted
+	// signature. Signatures without a created parameter, or created
+	// more than a minute in the future, are then rejected; the
+	// minute allows for clock skew between signer and verifier.
+	MaxAge time.Duration
+
+	now func() time.Time // for testing
+}
+
+// maxClockSkew is how far in the future the creation time of a
+// signature may be when [Verifier.MaxAge] is set.
+const maxClockSkew = time.Minute
+
+// A Signature describes a verified signature.
+type Signature struct {
+	Label      string
+	KeyID      string
+	Algorithm  string
+	Components []string // covered component identifiers, as serialized
+	Created    time.Time
+	Expires    time.Time // zero if the signature does not expire
+	Nonce      string
+	Tag        string
+}
+
+// VerifyRequest verifies a signature of r.
+func (v *Verifier) VerifyRequest(r *http.Request) (*Signature, error) {
+	return v.verify(message{req: r}, r.Header)
+}
+
+// VerifyResponse verifies a signature of res. Components with the req
+// parameter are taken from res.Request.
+func (v *Verifier) VerifyResponse(res *http.Response) (*Signature, error) {
+	return v.verify(message{req: res.Request, res: res}, res.Header)
+}
+
+func (v *Verifier) verify(m message, h http.Header) (*Signature, error) {
+	type input struct{ label, inner, params string }
+	var inputs []input
+	ok := httpsfv.ParseDictionary(strings.Join(h.Values("Signature-Input"), ", "), func(key, val, param string) {
+		if v.Label == "" || key == v.Label {
+			inputs = append(inputs, input{key, val, param})
+		}
+	})
+	if !ok {
+		return nil, errors.New("httpsig: malformed Signature-Input field")
+	}
+	sigs := make(map[string]string)
+	ok = httpsfv.ParseDictionary(strings.Join(h.Values("Signature"), ", "), func(key, val, param string) {
+		sigs[key] = val
+	})
+	if !ok {
+		return nil, errors.New("httpsig: malformed Signature field")
+	}
+	if len(inputs) == 0 {
+		return nil, ErrNoSignature
+	}
+	var firstErr error
+	for _, in := range inputs {
+		sig, err := v.verifyOne(m, in.label, in.inner, in.params, sigs[in.label])
+		if err == nil {
+			return sig, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return nil, firstErr
+}
+
+// verifyOne verifies the signature with the given label, serialized
+// covered components and parameters, and serialized signature value.
+func (v *Verifier) verifyOne(m message, label, inner, params, value string) (*Signature, error) {
+	if value == "" {
+		return nil, errors.New("httpsig: no Signature for label " + label)
+	}
+	sigBytes, ok := parseByteSequence(value)
+	if !ok {
+		return nil, errors.New("httpsig: malformed signature " + label)
+	}
+	sig := &Signature{Label: label}
+	var (
+		comps []component
+		err   error
+	)
+	ok = httpsfv.ParseBareInnerList(inner, func(bareItem, param string) {
+		c, cerr := parseComponentItem(bareItem, param)
+		if cerr != nil && err == nil {
+			err = cerr
+		}
+		comps = append(comps, c)
+		sig.Components = append(sig.Components, c.id)
+	})
+	if !ok {
+		return nil, errors.New("httpsig: malformed covered components of signature " + label)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var alg string
+	ok = httpsfv.ParseParameter(params, func(key, val string) {
+		var pok bool
+		switch key {
+		case "created", "expires":
+			var n int64
+			if n, pok = httpsfv.ParseInteger(val); pok {
+				if key == "created" {
+					sig.Created = time.Unix(n, 0)
+				} else {
+					sig.Expires = time.Unix(n, 0)
+				}
+			}
+		case "keyid":
+			sig.KeyID, pok = httpsfv.ParseString(val)
+		case "alg":
+			alg, pok = httpsfv.ParseString(val)
+		case "nonce":
+			sig.Nonce, pok = httpsfv.ParseString(val)
+		case "tag":
+			sig.Tag, pok = httpsfv.ParseString(val)
+		default:
+			pok = true
+		}
+		if !pok && err == nil {
+			err = errors.New("httpsig: malformed parameter " + key + " of signature " + label)
+		}
+	})
+	if !ok {
+		return nil, errors.New("httpsig: malformed parameters of signature " + label)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range v.RequiredComponents {
+		c, err := parseComponent(name)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(sig.Components, c.id) {
+			return nil, errors.New("httpsig: signature " + label + " does not cover " + c.id)
+		}
+	}
+	now := time.Now
+	if v.now != nil {
+		now = v.now
+	}
+	t := now()
+	if !sig.Expires.IsZero() && t.After(sig.Expires) {
+		return nil, errors.New("httpsig: signature " + label + " has expired")
+	}
+	if v.MaxAge > 0 {
+		if sig.Created.IsZero() {
+			return nil, errors.New("httpsig: signature " + label + " has no creation time")
+		}
+		if t.Sub(sig.Created) > v.MaxAge {
+			return nil, errors.New("httpsig: signature " + label + " is too old")
+		}
+		if sig.Created.After(t.Add(maxClockSkew)) {
+			return nil, errors.New("httpsig: signature " + label + " was created in the future")
+		}
+	}
+
+	if v.Key == nil {
+		return nil, errors.New("httpsig: Verifier has no Key function")
+	}
+	key, keyAlg, err := v.Key(sig.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case keyAlg == "" && alg != "":
+		keyAlg = alg
+	case keyAlg == "":
+		if keyAlg, err = defaultAlgorithm(key); err != nil {
+			return nil, err
+		}
+	case alg != "" && alg != keyAlg:
+		return nil, errors.New("httpsig: signature " + label + " uses algorithm " + alg + " rather than " + keyAlg)
+	}
+	sig.Algorithm = keyAlg
+
+	base, err := signatureBase(m, comps, inner+params)
+	if err != nil {
+		return nil, err
+	}
+	if err := verify(keyAlg, key, base, sigBytes); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
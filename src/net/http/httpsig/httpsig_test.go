@@ -0,0 +1,444 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package httpsig
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test vectors from RFC 9421, Appendix B.
+
+const testKeyEd25519 = "MC4CAQAwBQYDK2VwBCIEIJ+DYvh6SEqVTm50DFtMDoQikTmiCqirVv9mWG9qfSnF"
+
+const testSharedSecret = "uzvJfB4u3N0Jy4T7NZ75MDVcr8zSTInedJtkgcu46YW4XByzNJjxBdtjUkdJPBtbmHhIDi6pcl8jsasjlTMtDQ=="
+
+var testCreated = time.Unix(1618884473, 0)
+
+func testRequest(t *testing.T) *http.Request {
+	t.Helper()
+	r, err := http.NewRequest("POST", "http://example.com/foo?param=Value&Pet=dog", strings.NewReader(`{"hello": "world"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Header.Set("Date", "Tue, 20 Apr 2021 02:07:55 GMT")
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Content-Length", "18")
+	return r
+}
+
+func testEd25519Key(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	der, _ := base64.StdEncoding.DecodeString(testKeyEd25519)
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key.(ed25519.PrivateKey)
+}
+
+func fixedTime(t time.Time) func() time.Time {
+	return func() time.Time { return t }
+}
+
+func TestRFC9421Ed25519(t *testing.T) {
+	key := testEd25519Key(t)
+	const (
+		input = `sig-b26=("date" "@method" "@path" "@authority" "content-type" "content-length");created=1618884473;keyid="test-key-ed25519"`
+		sig   = `sig-b26=:wqcAqbmYJ2ji2glfAMaRy4gruYYnx2nEFN2HN6jrnDnQCK1u02Gb04v9EDgwUPiu4A0w6vuQv5lIp5WPpBKRCw==:`
+	)
+
+	// Ed25519 signatures are deterministic, so signing reproduces the
+	// example exactly.
+	r := testRequest(t)
+	s := &Signer{
+		Label:      "sig-b26",
+		KeyID:      "test-key-ed25519",
+		Key:        key,
+		Components: []string{"date", "@method", "@path", "@authority", "content-type", "content-length"},
+		now:        fixedTime(testCreated),
+	}
+	if err := s.SignRequest(r); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Header.Get("Signature-Input"); got != input {
+		t.Errorf("Signature-Input = %s\nwant %s", got, input)
+	}
+	if got := r.Header.Get("Signature"); got != sig {
+		t.Errorf("Signature = %s\nwant %s", got, sig)
+	}
+
+	r = testRequest(t)
+	r.Header.Set("Signature-Input", input)
+	r.Header.Set("Signature", sig)
+	v := &Verifier{
+		Key: func(keyID string) (any, string, error) {
+			if keyID != "test-key-ed25519" {
+				return nil, "", errors.New("unknown key")
+			}
+			return key.Public(), "", nil
+		},
+		now: fixedTime(testCreated.Add(time.Minute)),
+	}
+	got, err := v.VerifyRequest(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Label != "sig-b26" || got.KeyID != "test-key-ed25519" || got.Algorithm != AlgEd25519 || !got.Created.Equal(testCreated) {
+		t.Errorf("VerifyRequest = %+v", got)
+	}
+	if want := []string{`"date"`, `"@method"`, `"@path"`, `"@authority"`, `"content-type"`, `"content-length"`}; !slices.Equal(got.Components, want) {
+		t.Errorf("Components = %q; want %q", got.Components, want)
+	}
+
+	r.Method = "PUT"
+	if _, err := v.VerifyRequest(r); err == nil {
+		t.Errorf("VerifyRequest of modified request succeeded")
+	}
+}
+
+func TestRFC9421HMAC(t *testing.T) {
+	secret, _ := base64.StdEncoding.DecodeString(testSharedSecret)
+	r := testRequest(t)
+	r.Header.Set("Signature-Input", `sig-b25=("date" "@authority" "content-type");created=1618884473;keyid="test-shared-secret"`)
+	r.Header.Set("Signature", `sig-b25=:pxcQw6G3AjtMBQjwo8XzkZf/bws5LelbaMk5rGIGtE8=:`)
+	v := &Verifier{
+		Key: func(keyID string) (any, string, error) {
+			return secret, AlgHMACSHA256, nil
+		},
+	}
+	if _, err := v.VerifyRequest(r); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSignVerifyAlgorithms(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p256, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	p384, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	for _, tt := range []struct {
+		alg    string
+		key    crypto.Signer
+		secret []byte
+	}{
+		{AlgRSAPSSSHA512, rsaKey, nil},
+		{AlgRSAv15SHA256, rsaKey, nil},
+		{AlgECDSAP256SHA256, p256, nil},
+		{AlgECDSAP384SHA384, p384, nil},
+		{AlgEd25519, testEd25519Key(t), nil},
+		{AlgHMACSHA256, nil, []byte("secret")},
+	} {
+		var signKey, verifyKey any = tt.key, nil
+		if tt.secret != nil {
+			signKey, verifyKey = tt.secret, tt.secret
+		} else {
+			verifyKey = tt.key.Public()
+		}
+		r := testRequest(t)
+		s := &Signer{KeyID: "k", Key: signKey, Algorithm: tt.alg}
+		if err := s.SignRequest(r); err != nil {
+			t.Errorf("%s: SignRequest: %v", tt.alg, err)
+			continue
+		}
+		if !strings.Contains(r.Header.Get("Signature-Input"), `;alg="`+tt.alg+`"`) {
+			t.Errorf("%s: Signature-Input = %q; want alg parameter", tt.alg, r.Header.Get("Signature-Input"))
+		}
+		v := &Verifier{Key: func(string) (any, string, error) { return verifyKey, "", nil }}
+		sig, err := v.VerifyRequest(r)
+		if err != nil {
+			t.Errorf("%s: VerifyRequest: %v", tt.alg, err)
+			continue
+		}
+		if sig.Algorithm != tt.alg {
+			t.Errorf("%s: verified with %s", tt.alg, sig.Algorithm)
+		}
+
+		// A key registered for another algorithm is rejected.
+		other := AlgHMACSHA256
+		if tt.alg == other {
+			other = AlgEd25519
+		}
+		v.Key = func(string) (any, string, error) { return verifyKey, other, nil }
+		if _, err := v.VerifyRequest(r); err == nil {
+			t.Errorf("%s: VerifyRequest with algorithm %s succeeded", tt.alg, other)
+		}
+	}
+}
+
+func TestVerifyPolicy(t *testing.T) {
+	key := testEd25519Key(t)
+	sign := func(s *Signer) *http.Request {
+		t.Helper()
+		r := testRequest(t)
+		s.Key = key
+		s.now = fixedTime(testCreated)
+		if err := s.SignRequest(r); err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+	keyFunc := func(string) (any, string, error) { return key.Public(), "", nil }
+	for _, tt := range []struct {
+		name string
+		s    *Signer
+		v    *Verifier
+		ok   bool
+	}{{
+		name: "default",
+		s:    &Signer{},
+		v:    &Verifier{},
+		ok:   true,
+	}, {
+		name: "required components covered",
+		s:    &Signer{Components: []string{"@method", "@target-uri", "Content-Type"}},
+		v:    &Verifier{RequiredComponents: []string{"@method", "content-type"}},
+		ok:   true,
+	}, {
+		name: "required component missing",
+		s:    &Signer{},
+		v:    &Verifier{RequiredComponents: []string{"content-type"}},
+	}, {
+		name: "not expired",
+		s:    &Signer{Expires: time.Minute},
+		v:    &Verifier{now: fixedTime(testCreated.Add(time.Minute))},
+		ok:   true,
+	}, {
+		name: "expired",
+		s:    &Signer{Expires: time.Minute},
+		v:    &Verifier{now: fixedTime(testCreated.Add(time.Minute + time.Second))},
+	}, {
+		name: "max age",
+		s:    &Signer{},
+		v:    &Verifier{MaxAge: time.Hour, now: fixedTime(testCreated.Add(2 * time.Hour))},
+	}, {
+		name: "created within clock skew",
+		s:    &Signer{},
+		v:    &Verifier{MaxAge: time.Hour, now: fixedTime(testCreated.Add(-maxClockSkew))},
+		ok:   true,
+	}, {
+		name: "created in the future",
+		s:    &Signer{},
+		v:    &Verifier{MaxAge: time.Hour, now: fixedTime(testCreated.Add(-maxClockSkew - time.Second))},
+	}, {
+		name: "label",
+		s:    &Signer{Label: "a"},
+		v:    &Verifier{Label: "a"},
+		ok:   true,
+	}, {
+		name: "other label",
+		s:    &Signer{Label: "a"},
+		v:    &Verifier{Label: "b"},
+	}} {
+		r := sign(tt.s)
+		tt.v.Key = keyFunc
+		if tt.v.now == nil {
+			tt.v.now = fixedTime(testCreated)
+		}
+		_, err := tt.v.VerifyRequest(r)
+		if ok := err == nil; ok != tt.ok {
+			t.Errorf("%s: VerifyRequest error = %v; want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+
+	if _, err := (&Verifier{Key: keyFunc}).VerifyRequest(testRequest(t)); err != ErrNoSignature {
+		t.Errorf("unsigned request: error = %v; want %v", err, ErrNoSignature)
+	}
+}
+
+func TestSignResponse(t *testing.T) {
+	key := testEd25519Key(t)
+	req := testRequest(t)
+	res := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{"Content-Type": {"text/plain"}},
+		Request:    req,
+	}
+	s := &Signer{
+		Key:        key,
+		Components: []string{"@status", "content-type", "@method;req", `"@query-param";req;name="Pet"`},
+	}
+	if err := s.SignResponse(res); err != nil {
+		t.Fatal(err)
+	}
+	v := &Verifier{Key: func(string) (any, string, error) { return key.Public(), "", nil }}
+	if _, err := v.VerifyResponse(res); err != nil {
+		t.Fatal(err)
+	}
+	req.URL.RawQuery = "Pet=cat"
+	if _, err := v.VerifyResponse(res); err == nil {
+		t.Errorf("VerifyResponse with modified request succeeded")
+	}
+
+	if err := (&Signer{Key: key, Components: []string{"@status"}}).SignRequest(testRequest(t)); err == nil {
+		t.Errorf("SignRequest covering @status succeeded")
+	}
+}
+
+// TestServer tests signing a request with a client and verifying it
+// with a server, which sees a different representation of the request.
+func TestServer(t *testing.T) {
+	key := testEd25519Key(t)
+	v := &Verifier{
+		Key:                func(string) (any, string, error) { return key.Public(), "", nil },
+		RequiredComponents: []string{"@target-uri", "content-digest"},
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := v.VerifyRequest(r); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := VerifyContentDigest(r.Header.Get("Content-Digest"), body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+	}))
+	defer ts.Close()
+
+	r, _ := http.NewRequest("POST", ts.URL+"/a%2Fb/c?q=1&q=two+words", strings.NewReader("body"))
+	if err := SetRequestContentDigest(r, "sha-256"); err != nil {
+		t.Fatal(err)
+	}
+	s := &Signer{Key: key, Components: []string{"@method", "@target-uri", "@authority", "@request-target", "@path", "@query", `@query-param;name="q"`, "content-digest", "host"}}
+	if err := s.SignRequest(r); err != nil {
+		t.Fatal(err)
+	}
+	res, err := ts.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if res.StatusCode != 200 {
+		t.Errorf("status = %d: %s", res.StatusCode, msg)
+	}
+}
+
+func TestDerivedComponents(t *testing.T) {
+	r, _ := http.NewRequest("POST", "https://WWW.Example.com:443/path/%7Ea?param=value&foo=bar&baz=batman&qux=&a+b=c%20d", nil)
+	for _, tt := range []struct {
+		comp string
+		want []string
+	}{
+		{"@method", []string{"POST"}},
+		{"@authority", []string{"www.example.com"}},
+		{"@scheme", []string{"https"}},
+		{"@path", []string{"/path/%7Ea"}},
+		{"@query", []string{"?param=value&foo=bar&baz=batman&qux=&a+b=c%20d"}},
+		{"@request-target", []string{"/path/%7Ea?param=value&foo=bar&baz=batman&qux=&a+b=c%20d"}},
+		{`@query-param;name="baz"`, []string{"batman"}},
+		{`@query-param;name="qux"`, []string{""}},
+		{`@query-param;name="a%20b"`, []string{"c%20d"}},
+	} {
+		c, err := parseComponent(tt.comp)
+		if err != nil {
+			t.Errorf("parseComponent(%q): %v", tt.comp, err)
+			continue
+		}
+		got, err := message{req: r}.values(c)
+		if err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("%s = %q, %v; want %q", tt.comp, got, err, tt.want)
+		}
+	}
+}
+
+func TestFieldComponents(t *testing.T) {
+	r, _ := http.NewRequest("GET", "http://example.com/", nil)
+	r.Header["Example-Dict"] = []string{" a=1,    b=2;x=1;y=2,   c=(a   b   c)"}
+	r.Header["Cache-Control"] = []string{"max-age=60", "   must-revalidate"}
+	r.Header["Example-Header"] = []string{"value, with, lots", "of, commas"}
+	for _, tt := range []struct {
+		comp string
+		want string
+	}{
+		{"cache-control", "max-age=60, must-revalidate"},
+		{`example-dict;key="a"`, "1"},
+		{`example-dict;key="b"`, "2;x=1;y=2"},
+		{"example-header;bs", ":dmFsdWUsIHdpdGgsIGxvdHM=:, :b2YsIGNvbW1hcw==:"},
+		{"host", "example.com"},
+	} {
+		c, err := parseComponent(tt.comp)
+		if err != nil {
+			t.Errorf("parseComponent(%q): %v", tt.comp, err)
+			continue
+		}
+		got, err := message{req: r}.values(c)
+		if err != nil || len(got) != 1 || got[0] != tt.want {
+			t.Errorf("%s = %q, %v; want %q", tt.comp, got, err, tt.want)
+		}
+	}
+
+	for _, comp := range []string{
+		"missing",
+		`example-dict;key="z"`,
+		"@unknown",
+		"@signature-params",
+		"@query-param",
+		"@method;bs",
+		"example-dict;sf",
+		`"Upper"`,
+	} {
+		c, err := parseComponent(comp)
+		if err == nil {
+			_, err = message{req: r}.values(c)
+		}
+		if err == nil {
+			t.Errorf("component %s: no error", comp)
+		}
+	}
+}
+
+func TestContentDigest(t *testing.T) {
+	// From RFC 9530, Appendix B.
+	body := []byte(`{"hello": "world"}`)
+	for _, tt := range []struct {
+		alg, want string
+	}{
+		{"sha-256", "sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:"},
+		{"sha-512", "sha-512=:WZDPaVn/7XgHaAy8pmojAkGWoRx2UFChF41A2svX+TaPm+AbwAgBWnrIiYllu7BNNyealdVLvRwEmTHWXvJwew==:"},
+	} {
+		got, err := ContentDigest(tt.alg, body)
+		if err != nil || got != tt.want {
+			t.Errorf("ContentDigest(%q) = %q, %v; want %q", tt.alg, got, err, tt.want)
+		}
+		if err := VerifyContentDigest(tt.want, body); err != nil {
+			t.Errorf("VerifyContentDigest(%q): %v", tt.want, err)
+		}
+	}
+	if _, err := ContentDigest("md5", body); err == nil {
+		t.Errorf("ContentDigest(md5) succeeded")
+	}
+	for _, tt := range []struct {
+		value string
+		err   error
+	}{
+		{"sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:, unixsum=:MTIz:", nil},
+		{"sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPA=:", ErrDigestMismatch},
+		{"sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:, sha-512=:AAAA:", ErrDigestMismatch},
+		{"unixsum=:MTIz:", errors.New("")},
+		{"sha-256", errors.New("")},
+	} {
+		err := VerifyContentDigest(tt.value, body)
+		if (err == nil) != (tt.err == nil) || tt.err == ErrDigestMismatch && err != ErrDigestMismatch {
+			t.Errorf("VerifyContentDigest(%q) = %v; want %v", tt.value, err, tt.err)
+		}
+	}
+}
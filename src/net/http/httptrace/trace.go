@@ -160,6 +160,12 @@ type ClientTrace struct {
 	// request and any body. It may be called multiple times
 	// in the case of retried requests.
 	WroteRequest func(WroteRequestInfo)
+
+	// Retrying is called by a RoundTripper that retries failed
+	// requests, such as http.RetryTransport, before it waits to
+	// send the request again. The hooks above are called again
+	// for each attempt.
+	Retrying func(RetryInfo)
 }
 
 // WroteRequestInfo contains information provided to the WroteRequest
@@ -169,6 +175,23 @@ type WroteRequestInfo struct {
 	Err error
 }
 
+// RetryInfo contains information provided to the Retrying hook.
+type RetryInfo struct {
+	// Attempt is the number of the attempt about to be made,
+	// counting the original request as attempt 1.
+	Attempt int
+
+	// Err is the error returned by the previous attempt, if any.
+	Err error
+
+	// StatusCode is the status code of the response to the
+	// previous attempt, or zero if there was no response.
+	StatusCode int
+
+	// Delay is how long the request waits before it is sent again.
+	Delay time.Duration
+}
+
 // compose modifies t such that it respects the previously-registered hooks in old,
 // subject to the composition policy requested in t.Compose.
 func (t *ClientTrace) compose(old *ClientTrace) {
@@ -191,6 +214,7 @@ func (t *ClientTrace) compose(old *ClientTrace) {
 	t.WroteHeaders = compose0to0(t.WroteHeaders, old.WroteHeaders)
 	t.Wait100Continue = compose0to0(t.Wait100Continue, old.Wait100Continue)
 	t.WroteRequest = compose1to0(t.WroteRequest, old.WroteRequest)
+	t.Retrying = compose1to0(t.Retrying, old.Retrying)
 }
 
 func compose0to0[F func()](f1, f2 F) F {
@@ -5,6 +5,7 @@
 package httputil_test
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log"
@@ -126,3 +127,21 @@ func ExampleReverseProxy() {
 	// Output:
 	// this call was relayed by the reverse proxy
 }
+
+func ExampleNewUpstreamProxy() {
+	var targets []*url.URL
+	for _, s := range []string{"http://10.0.0.1:8080", "http://10.0.0.2:8080", "http://10.0.0.3:8080"} {
+		u, err := url.Parse(s)
+		if err != nil {
+			log.Fatal(err)
+		}
+		targets = append(targets, u)
+	}
+
+	pool := httputil.NewUpstreamPool(targets...)
+	pool.Balancer = httputil.ConsistentHash("X-Session-ID")
+	pool.HealthCheckPath = "/healthz"
+	go pool.RunHealthChecks(context.Background())
+
+	log.Fatal(http.ListenAndServe(":8000", httputil.NewUpstreamProxy(pool)))
+}
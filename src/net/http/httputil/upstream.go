@@ -0,0 +1,495 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package httputil
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrNoUpstream is returned by [UpstreamPool.RoundTrip] when every
+// upstream in the pool is unhealthy or ejected.
+var ErrNoUpstream = errors.New("httputil: no available upstream")
+
+// An UpstreamError records a failed attempt to send a request to an
+// upstream. [UpstreamPool.RoundTrip] returns it, wrapping the error of
+// the last attempt, so a [ReverseProxy.ErrorHandler] can tell which
+// upstream failed.
+type UpstreamError struct {
+	Upstream *Upstream
+	Err      error
+}
+
+func (e *UpstreamError) Error() string {
+	return "httputil: upstream " + e.Upstream.URL.Host + ": " + e.Err.Error()
+}
+
+func (e *UpstreamError) Unwrap() error { return e.Err }
+
+// An Upstream is a backend server in an [UpstreamPool].
+type Upstream struct {
+	// URL is the target of requests sent to the upstream, as with
+	// [ProxyRequest.SetURL]. It must not be modified.
+	URL *url.URL
+
+	active atomic.Int64
+
+	mu           sync.Mutex
+	unhealthy    bool      // the last active health check failed
+	fails        int       // consecutive failed requests
+	ejectedUntil time.Time // zero if not ejected
+}
+
+// ActiveRequests returns the number of requests in flight to u,
+// including those whose response body is still being read.
+func (u *Upstream) ActiveRequests() int {
+	return int(u.active.Load())
+}
+
+// Available reports whether u may be picked for a request: it passed
+// its most recent health check, and it is not ejected.
+func (u *Upstream) Available() bool {
+	return u.available(time.Now())
+}
+
+func (u *Upstream) available(now time.Time) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return !u.unhealthy && !now.Before(u.ejectedUntil)
+}
+
+// A LoadBalancer picks the upstream for a request.
+//
+// Pick is called with the upstreams that are available and have not
+// yet been tried for the request, in the order they were given to
+// [NewUpstreamPool]; the slice is never empty. Pick must return one of
+// them, and must be safe for concurrent use.
+type LoadBalancer interface {
+	Pick(r *http.Request, upstreams []*Upstream) *Upstream
+}
+
+// RoundRobin returns a [LoadBalancer] that picks upstreams in turn.
+func RoundRobin() LoadBalancer {
+	return new(roundRobin)
+}
+
+type roundRobin struct {
+	next atomic.Uint64
+}
+
+func (b *roundRobin) Pick(r *http.Request, upstreams []*Upstream) *Upstream {
+	return upstreams[(b.next.Add(1)-1)%uint64(len(upstreams))]
+}
+
+// LeastRequests returns a [LoadBalancer] that picks the upstream with
+// the fewest requests in flight. Ties are broken in turn.
+func LeastRequests() LoadBalancer {
+	return new(leastRequests)
+}
+
+type leastRequests struct {
+	next atomic.Uint64
+}
+
+func (b *leastRequests) Pick(r *http.Request, upstreams []*Upstream) *Upstream {
+	start := int((b.next.Add(1) - 1) % uint64(len(upstreams)))
+	var best *Upstream
+	for i := range upstreams {
+		u := upstreams[(start+i)%len(upstreams)]
+		if best == nil || u.ActiveRequests() < best.ActiveRequests() {
+			best = u
+		}
+	}
+	return best
+}
+
+// ConsistentHash returns a [LoadBalancer] that sends requests with the
+// same value of the named header to the same upstream, so long as it
+// is available. When an upstream becomes unavailable, only the
+// requests that were sent to it move to other upstreams.
+// Requests without the header are distributed in turn.
+//
+// Upstreams are chosen by rendezvous hashing of the header value and
+// the upstream URL, so the choice is the same in every process that
+// uses the same upstream URLs.
+func ConsistentHash(header string) LoadBalancer {
+	return &consistentHash{header: http.CanonicalHeaderKey(header)}
+}
+
+type consistentHash struct {
+	header   string
+	fallback roundRobin
+}
+
+func (b *consistentHash) Pick(r *http.Request, upstreams []*Upstream) *Upstream {
+	key := r.Header.Get(b.header)
+	if key == "" {
+		return b.fallback.Pick(r, upstreams)
+	}
+	var (
+		best      *Upstream
+		bestScore uint64
+	)
+	for _, u := range upstreams {
+		h := fnv.New64a()
+		io.WriteString(h, key)
+		h.Write([]byte{0})
+		io.WriteString(h, u.URL.String())
+		if score := mix64(h.Sum64()); best == nil || score > bestScore {
+			best, bestScore = u, score
+		}
+	}
+	return best
+}
+
+// mix64 is the finalizer of SplitMix64. It spreads the bits of an
+// FNV hash, whose high bits depend poorly on the last bytes hashed.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// An UpstreamPool is an [http.RoundTripper] that balances requests
+// across a set of upstream servers. Use it as the Transport of a
+// [ReverseProxy], or see [NewUpstreamProxy].
+//
+// RoundTrip rewrites the URL of each request to target the upstream it
+// picks, as [ProxyRequest.SetURL] does; the request's Host field is
+// left as is.
+//
+// Upstreams that fail are ejected from the pool for a while (passive
+// health checking). A request fails if the upstream cannot be reached,
+// or if it responds with 502 Bad Gateway, 503 Service Unavailable, or
+// 504 Gateway Timeout. When an upstream cannot be reached, idempotent
+// requests are retried on another upstream; other errors are returned
+// as an [*UpstreamError], which a ReverseProxy passes to its
+// ErrorHandler.
+//
+// Upstreams may also be checked periodically by
+// [UpstreamPool.RunHealthChecks] (active health checking).
+//
+// The fields of an UpstreamPool must not be modified after it is
+// first used.
+type UpstreamPool struct {
+	// Balancer picks the upstream for each request.
+	// If nil, RoundRobin is used.
+	Balancer LoadBalancer
+
+	// Transport sends requests to the upstreams, including
+	// health checks. If nil, http.DefaultTransport is used.
+	Transport http.RoundTripper
+
+	// MaxAttempts is the maximum number of upstreams an idempotent
+	// request is sent to. If zero, it is 2. A request is
+	// idempotent if its method is GET, HEAD, OPTIONS, TRACE, PUT,
+	// or DELETE, or if it has an Idempotency-Key or
+	// X-Idempotency-Key header. A request with a body is only
+	// retried if its GetBody field is set.
+	MaxAttempts int
+
+	// MaxFails is the number of consecutive failed requests after
+	// which an upstream is ejected. If zero, it is 1.
+	MaxFails int
+
+	// EjectDuration is how long an upstream is ejected for.
+	// If zero, it is 10 seconds.
+	EjectDuration time.Duration
+
+	// HealthCheckPath is the path requested by active health
+	// checks, relative to each upstream's URL. An upstream is
+	// healthy if it responds with a 2xx or 3xx status.
+	// If empty, "/" is used.
+	HealthCheckPath string
+
+	// HealthCheckInterval is the time between active health
+	// checks. If zero, it is 10 seconds.
+	HealthCheckInterval time.Duration
+
+	// HealthCheckTimeout limits the time of each health check.
+	// If zero, it is the HealthCheckInterval.
+	HealthCheckTimeout time.Duration
+
+	upstreams  []*Upstream
+	roundRobin roundRobin // used if Balancer is nil
+}
+
+// NewUpstreamPool returns a pool of upstreams with the given target
+// URLs. Requests are routed as by [ProxyRequest.SetURL].
+func NewUpstreamPool(targets ...*url.URL) *UpstreamPool {
+	p := &UpstreamPool{}
+	for _, t := range targets {
+		p.upstreams = append(p.upstreams, &Upstream{URL: t})
+	}
+	return p
+}
+
+// Upstreams returns the upstreams in the pool.
+func (p *UpstreamPool) Upstreams() []*Upstream {
+	return p.upstreams
+}
+
+// NewUpstreamProxy returns a new [ReverseProxy] that balances requests
+// across the upstreams in pool, which is used as its Transport.
+// Its Rewrite function sets the X-Forwarded headers, as
+// [ProxyRequest.SetXForwarded] does, and clears the outbound Host so
+// that it is taken from the upstream URL.
+func NewUpstreamProxy(pool *UpstreamPool) *ReverseProxy {
+	return &ReverseProxy{
+		Rewrite: func(r *ProxyRequest) {
+			r.Out.Host = ""
+			r.SetXForwarded()
+		},
+		Transport: pool,
+	}
+}
+
+func (p *UpstreamPool) transport() http.RoundTripper {
+	if p.Transport != nil {
+		return p.Transport
+	}
+	return http.DefaultTransport
+}
+
+// RoundTrip implements the [http.RoundTripper] interface.
+func (p *UpstreamPool) RoundTrip(req *http.Request) (*http.Response, error) {
+	attempts := 1
+	if isIdempotent(req) {
+		attempts = p.MaxAttempts
+		if attempts <= 0 {
+			attempts = 2
+		}
+	}
+	var (
+		tried []*Upstream
+		err   error
+	)
+	for i := 0; i < attempts; i++ {
+		u := p.pick(req, tried)
+		if u == nil {
+			break
+		}
+		tried = append(tried, u)
+		outreq := req.Clone(req.Context())
+		if i > 0 && req.Body != nil && req.Body != http.NoBody {
+			body, gerr := req.GetBody()
+			if gerr != nil {
+				break
+			}
+			outreq.Body = body
+		}
+		rewriteRequestURL(outreq, u.URL)
+
+		var res *http.Response
+		res, err = p.send(u, outreq)
+		if err == nil {
+			return res, nil
+		}
+		err = &UpstreamError{Upstream: u, Err: err}
+		if req.Context().Err() != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = ErrNoUpstream
+	}
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	return nil, err
+}
+
+// pick returns an available upstream that is not in tried,
+// or nil if there is none.
+func (p *UpstreamPool) pick(req *http.Request, tried []*Upstream) *Upstream {
+	now := time.Now()
+	var candidates []*Upstream
+	for _, u := range p.upstreams {
+		if u.available(now) && !containsUpstream(tried, u) {
+			candidates = append(candidates, u)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	if p.Balancer == nil {
+		return p.roundRobin.Pick(req, candidates)
+	}
+	return p.Balancer.Pick(req, candidates)
+}
+
+func containsUpstream(s []*Upstream, u *Upstream) bool {
+	for _, v := range s {
+		if v == u {
+			return true
+		}
+	}
+	return false
+}
+
+// send sends req to u, keeping track of the requests in flight and of
+// failures. The response status is treated as a failure, but its
+// response is still returned.
+func (p *UpstreamPool) send(u *Upstream, req *http.Request) (*http.Response, error) {
+	u.active.Add(1)
+	res, err := p.transport().RoundTrip(req)
+	if err != nil {
+		u.active.Add(-1)
+		if req.Context().Err() == nil {
+			p.recordResult(u, false)
+		}
+		return nil, err
+	}
+	switch res.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		p.recordResult(u, false)
+	default:
+		p.recordResult(u, true)
+	}
+	done := func() { u.active.Add(-1) }
+	if rwc, ok := res.Body.(io.ReadWriteCloser); ok {
+		res.Body = &upstreamReadWriteBody{upstreamBody{ReadCloser: rwc, done: done}, rwc}
+	} else {
+		res.Body = &upstreamBody{ReadCloser: res.Body, done: done}
+	}
+	return res, nil
+}
+
+// recordResult records the success or failure of a request to u,
+// ejecting it after too many consecutive failures.
+func (p *UpstreamPool) recordResult(u *Upstream, ok bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if ok {
+		u.fails = 0
+		return
+	}
+	u.fails++
+	maxFails := p.MaxFails
+	if maxFails <= 0 {
+		maxFails = 1
+	}
+	if u.fails >= maxFails {
+		d := p.EjectDuration
+		if d <= 0 {
+			d = 10 * time.Second
+		}
+		u.ejectedUntil = time.Now().Add(d)
+		u.fails = 0
+	}
+}
+
+// An upstreamBody is a response body that calls done once when it
+// is closed.
+type upstreamBody struct {
+	io.ReadCloser
+	once sync.Once
+	done func()
+}
+
+func (b *upstreamBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.done)
+	return err
+}
+
+// An upstreamReadWriteBody is an upstreamBody for a 101 Switching
+// Protocols response, whose body is writable.
+type upstreamReadWriteBody struct {
+	upstreamBody
+	w io.Writer
+}
+
+func (b *upstreamReadWriteBody) Write(p []byte) (int, error) {
+	return b.w.Write(p)
+}
+
+// isIdempotent reports whether req may be sent again after a failure.
+func isIdempotent(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case "", "GET", "HEAD", "OPTIONS", "TRACE", "PUT", "DELETE":
+		return true
+	}
+	if _, ok := req.Header["Idempotency-Key"]; ok {
+		return true
+	}
+	if _, ok := req.Header["X-Idempotency-Key"]; ok {
+		return true
+	}
+	return false
+}
+
+// RunHealthChecks checks the health of every upstream in the pool,
+// then again every HealthCheckInterval, until ctx is done. An upstream
+// that fails a check is not picked for requests until it passes one.
+func (p *UpstreamPool) RunHealthChecks(ctx context.Context) {
+	interval := p.HealthCheckInterval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		var wg sync.WaitGroup
+		for _, u := range p.upstreams {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				healthy := p.checkHealth(ctx, u, interval)
+				if ctx.Err() != nil {
+					return
+				}
+				u.mu.Lock()
+				u.unhealthy = !healthy
+				u.mu.Unlock()
+			}()
+		}
+		wg.Wait()
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// checkHealth sends a health check request to u.
+func (p *UpstreamPool) checkHealth(ctx context.Context, u *Upstream, interval time.Duration) bool {
+	timeout := p.HealthCheckTimeout
+	if timeout <= 0 {
+		timeout = interval
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	path := p.HealthCheckPath
+	if path == "" {
+		path = "/"
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", path, nil)
+	if err != nil {
+		return false
+	}
+	rewriteRequestURL(req, u.URL)
+	res, err := p.transport().RoundTrip(req)
+	if err != nil {
+		return false
+	}
+	io.Copy(io.Discard, io.LimitReader(res.Body, 4096))
+	res.Body.Close()
+	return res.StatusCode >= 200 && res.StatusCode < 400
+}
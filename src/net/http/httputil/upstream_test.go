@@ -0,0 +1,362 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package httputil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newUpstreams starts n backends that respond with their index, and
+// returns their URLs.
+func newUpstreams(t *testing.T, n int, h func(i int, w http.ResponseWriter, r *http.Request)) []*url.URL {
+	var urls []*url.URL
+	for i := range n {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if h != nil {
+				h(i, w, r)
+				return
+			}
+			fmt.Fprint(w, i)
+		}))
+		t.Cleanup(ts.Close)
+		u, _ := url.Parse(ts.URL)
+		urls = append(urls, u)
+	}
+	return urls
+}
+
+func get(t *testing.T, url string, header ...string) (string, int) {
+	t.Helper()
+	req, _ := http.NewRequest("GET", url, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	b, _ := io.ReadAll(res.Body)
+	return string(b), res.StatusCode
+}
+
+func TestUpstreamPoolRoundRobin(t *testing.T) {
+	pool := NewUpstreamPool(newUpstreams(t, 3, nil)...)
+	proxy := httptest.NewServer(NewUpstreamProxy(pool))
+	defer proxy.Close()
+
+	var got []string
+	for range 6 {
+		body, _ := get(t, proxy.URL)
+		got = append(got, body)
+	}
+	if s := strings.Join(got, ","); s != "0,1,2,0,1,2" {
+		t.Errorf("upstreams = %s; want 0,1,2,0,1,2", s)
+	}
+}
+
+func TestUpstreamPoolRewrite(t *testing.T) {
+	var gotURL, gotHost, gotXFF string
+	urls := newUpstreams(t, 1, func(_ int, w http.ResponseWriter, r *http.Request) {
+		gotURL, gotHost, gotXFF = r.URL.String(), r.Host, r.Header.Get("X-Forwarded-For")
+	})
+	urls[0].Path = "/base"
+	urls[0].RawQuery = "a=1"
+	proxy := httptest.NewServer(NewUpstreamProxy(NewUpstreamPool(urls...)))
+	defer proxy.Close()
+
+	get(t, proxy.URL+"/dir?b=2")
+	if want := "/base/dir?a=1&b=2"; gotURL != want {
+		t.Errorf("upstream URL = %q; want %q", gotURL, want)
+	}
+	if gotHost != urls[0].Host {
+		t.Errorf("upstream Host = %q; want %q", gotHost, urls[0].Host)
+	}
+	if gotXFF == "" {
+		t.Errorf("X-Forwarded-For not set")
+	}
+}
+
+func TestUpstreamPoolLeastRequests(t *testing.T) {
+	pool := NewUpstreamPool(newUpstreams(t, 3, nil)...)
+	pool.Balancer = LeastRequests()
+	u := pool.Upstreams()
+	u[0].active.Store(2)
+	u[2].active.Store(1)
+	req, _ := http.NewRequest("GET", "/", nil)
+	for range 3 {
+		if got := pool.pick(req, nil); got != u[1] {
+			t.Fatalf("picked %v; want %v", got.URL, u[1].URL)
+		}
+	}
+	u[1].active.Store(3)
+	if got := pool.pick(req, nil); got != u[2] {
+		t.Errorf("picked %v; want %v", got.URL, u[2].URL)
+	}
+	if got := pool.pick(req, []*Upstream{u[2]}); got != u[0] {
+		t.Errorf("picked %v excluding %v; want %v", got.URL, u[2].URL, u[0].URL)
+	}
+}
+
+func TestUpstreamPoolConsistentHash(t *testing.T) {
+	var urls []*url.URL
+	for i := range 5 {
+		urls = append(urls, &url.URL{Scheme: "http", Host: fmt.Sprintf("backend%d:80", i)})
+	}
+	pool := NewUpstreamPool(urls...)
+	pool.Balancer = ConsistentHash("x-user")
+	ups := pool.Upstreams()
+
+	pick := func(key string, tried ...*Upstream) *Upstream {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.Header.Set("X-User", key)
+		return pool.pick(req, tried)
+	}
+	counts := make(map[*Upstream]int)
+	first := make(map[string]*Upstream)
+	for i := range 1000 {
+		key := fmt.Sprint("user", i)
+		u := pick(key)
+		if pick(key) != u {
+			t.Fatalf("key %q picked different upstreams", key)
+		}
+		first[key] = u
+		counts[u]++
+	}
+	for _, u := range ups {
+		if counts[u] < 100 {
+			t.Errorf("upstream %v picked %d times of 1000", u.URL, counts[u])
+		}
+	}
+
+	// Excluding an upstream moves only its keys.
+	for key, u := range first {
+		got := pick(key, ups[0])
+		if u != ups[0] && got != u {
+			t.Errorf("key %q moved from %v to %v", key, u.URL, got.URL)
+		}
+		if got == ups[0] {
+			t.Errorf("key %q picked excluded upstream", key)
+		}
+	}
+}
+
+func TestUpstreamPoolRetry(t *testing.T) {
+	urls := newUpstreams(t, 2, nil)
+	dead := httptest.NewServer(http.NotFoundHandler())
+	deadURL, _ := url.Parse(dead.URL)
+	dead.Close()
+
+	pool := NewUpstreamPool(deadURL, urls[0], urls[1])
+	proxy := httptest.NewServer(NewUpstreamProxy(pool))
+	defer proxy.Close()
+
+	body, code := get(t, proxy.URL)
+	if code != 200 || (body != "0" && body != "1") {
+		t.Errorf("GET = %d %q; want 200 from a live upstream", code, body)
+	}
+	if pool.Upstreams()[0].Available() {
+		t.Errorf("dead upstream was not ejected")
+	}
+
+	// The ejected upstream is skipped.
+	for range 4 {
+		if _, code := get(t, proxy.URL); code != 200 {
+			t.Errorf("GET = %d; want 200", code)
+		}
+	}
+}
+
+func TestUpstreamPoolNoRetry(t *testing.T) {
+	var requests atomic.Int32
+	urls := newUpstreams(t, 1, func(_ int, w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	})
+	dead := httptest.NewServer(http.NotFoundHandler())
+	deadURL, _ := url.Parse(dead.URL)
+	dead.Close()
+
+	pool := NewUpstreamPool(deadURL, urls[0])
+	var handlerErr error
+	proxy := NewUpstreamProxy(pool)
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		handlerErr = err
+		w.WriteHeader(http.StatusBadGateway)
+	}
+	ts := httptest.NewServer(proxy)
+	defer ts.Close()
+
+	res, err := http.Post(ts.URL, "text/plain", strings.NewReader("body"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusBadGateway {
+		t.Errorf("POST status = %d; want 502", res.StatusCode)
+	}
+	var ue *UpstreamError
+	if !errors.As(handlerErr, &ue) || ue.Upstream != pool.Upstreams()[0] {
+		t.Errorf("ErrorHandler got %v; want UpstreamError for %v", handlerErr, deadURL)
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("POST was retried")
+	}
+
+	// With the dead upstream ejected, requests go to the other one.
+	res, err = http.Post(ts.URL, "text/plain", strings.NewReader("body"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != 200 || requests.Load() != 1 {
+		t.Errorf("POST status = %d, requests = %d; want 200, 1", res.StatusCode, requests.Load())
+	}
+}
+
+func TestUpstreamPoolPassiveEjection(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	urls := newUpstreams(t, 2, func(i int, w http.ResponseWriter, r *http.Request) {
+		if i == 0 && failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, i)
+	})
+	pool := NewUpstreamPool(urls...)
+	pool.MaxFails = 2
+	pool.EjectDuration = time.Hour
+	proxy := httptest.NewServer(NewUpstreamProxy(pool))
+	defer proxy.Close()
+
+	u0 := pool.Upstreams()[0]
+	get(t, proxy.URL) // 503 from upstream 0
+	get(t, proxy.URL)
+	if !u0.Available() {
+		t.Fatalf("upstream ejected after one failure")
+	}
+	if _, code := get(t, proxy.URL); code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d; want 503", code)
+	}
+	if u0.Available() {
+		t.Fatalf("upstream not ejected after two failures")
+	}
+	for range 3 {
+		if body, _ := get(t, proxy.URL); body != "1" {
+			t.Errorf("got response from upstream %q; want 1", body)
+		}
+	}
+
+	// When the ejection ends, the upstream is used again.
+	u0.mu.Lock()
+	u0.ejectedUntil = time.Time{}
+	u0.mu.Unlock()
+	failing.Store(false)
+	seen := make(map[string]bool)
+	for range 2 {
+		body, _ := get(t, proxy.URL)
+		seen[body] = true
+	}
+	if !seen["0"] {
+		t.Errorf("upstream not used after ejection ended")
+	}
+}
+
+func TestUpstreamPoolNoUpstream(t *testing.T) {
+	pool := NewUpstreamPool(newUpstreams(t, 1, nil)...)
+	u := pool.Upstreams()[0]
+	u.mu.Lock()
+	u.unhealthy = true
+	u.mu.Unlock()
+	req, _ := http.NewRequest("GET", "/", nil)
+	if _, err := pool.RoundTrip(req); err != ErrNoUpstream {
+		t.Errorf("RoundTrip error = %v; want %v", err, ErrNoUpstream)
+	}
+}
+
+func TestUpstreamPoolActiveRequests(t *testing.T) {
+	release := make(chan struct{})
+	urls := newUpstreams(t, 1, func(_ int, w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+		w.(http.Flusher).Flush()
+		<-release
+	})
+	pool := NewUpstreamPool(urls...)
+	u := pool.Upstreams()[0]
+	req, _ := http.NewRequest("GET", "/", nil)
+	res, err := pool.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := u.ActiveRequests(); n != 1 {
+		t.Errorf("ActiveRequests with body open = %d; want 1", n)
+	}
+	close(release)
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+	res.Body.Close()
+	if n := u.ActiveRequests(); n != 0 {
+		t.Errorf("ActiveRequests after close = %d; want 0", n)
+	}
+}
+
+func TestUpstreamPoolHealthChecks(t *testing.T) {
+	var healthy atomic.Bool
+	var checks atomic.Int32
+	urls := newUpstreams(t, 2, func(i int, w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/base/healthz" {
+			checks.Add(1)
+			if i == 0 && !healthy.Load() {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+			return
+		}
+		fmt.Fprint(w, i)
+	})
+	for _, u := range urls {
+		u.Path = "/base"
+	}
+	pool := NewUpstreamPool(urls...)
+	pool.HealthCheckPath = "/healthz"
+	pool.HealthCheckInterval = time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		pool.RunHealthChecks(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	u0 := pool.Upstreams()[0]
+	waitFor := func(avail bool) {
+		t.Helper()
+		deadline := time.Now().Add(10 * time.Second)
+		for u0.Available() != avail {
+			if time.Now().After(deadline) {
+				t.Fatalf("upstream never became available=%v", avail)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	waitFor(false)
+	if checks.Load() == 0 {
+		t.Fatal("no health checks were sent")
+	}
+	healthy.Store(true)
+	waitFor(true)
+}
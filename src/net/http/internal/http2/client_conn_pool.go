@@ -260,6 +260,24 @@ func (p *clientConnPool) closeIdleConnections() {
 	}
 }
 
+// connStates returns the state of each connection in the pool,
+// by address.
+func (p *clientConnPool) connStates() map[string][]ClientConnState {
+	p.mu.Lock()
+	conns := make(map[string][]*ClientConn, len(p.conns))
+	for addr, vv := range p.conns {
+		conns[addr] = append([]*ClientConn(nil), vv...)
+	}
+	p.mu.Unlock()
+	states := make(map[string][]ClientConnState, len(conns))
+	for addr, vv := range conns {
+		for _, cc := range vv {
+			states[addr] = append(states[addr], cc.State())
+		}
+	}
+	return states
+}
+
 func filterOutClientConn(in []*ClientConn, exclude *ClientConn) []*ClientConn {
 	out := in[:0]
 	for _, v := range in {
@@ -23,6 +23,7 @@ type Config struct {
 	WriteByteTimeout              time.Duration
 	PermitProhibitedCipherSuites  bool
 	CountError                    func(errType string)
+	EnableConnectProtocol         bool
 }
 
 func configFromServer(h1 ServerConfig, h2 *Server) Config {
@@ -144,4 +145,7 @@ func fillNetHTTPConfig(conf *Config, h2 Config) {
 	if h2.CountError != nil {
 		conf.CountError = h2.CountError
 	}
+	if h2.EnableConnectProtocol {
+		conf.EnableConnectProtocol = true
+	}
 }
@@ -236,6 +236,19 @@ func (t *Transport) AddConn(scheme, authority string, c net.Conn) error {
 	return err
 }
 
+// ConnStates returns the state of each connection in the connection
+// pool of t, by address. It returns nil if t uses a ConnPool other
+// than the default.
+func (t *Transport) ConnStates() map[string][]ClientConnState {
+	switch p := t.connPool().(type) {
+	case noDialClientConnPool:
+		return p.connStates()
+	case *clientConnPool:
+		return p.connStates()
+	}
+	return nil
+}
+
 // unencryptedTransport is a Transport with a RoundTrip method that
 // always permits http:// URLs.
 type unencryptedTransport Transport
@@ -0,0 +1,142 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"fmt"
+	"math"
+)
+
+// RequestLimits limits the size of requests handled by a [Server], or
+// by the handlers registered for a [ServeMux] pattern.
+//
+// In each field, zero means that the limit is inherited: a limit set
+// for a pattern with [ServeMux.SetLimits] overrides the corresponding
+// limit of [Server.RequestLimits], and other limits are kept. A
+// negative value means there is no limit.
+type RequestLimits struct {
+	// MaxBodyBytes limits the size of the request body. A request
+	// whose Content-Length exceeds the limit is rejected with 413
+	// Content Too Large before it reaches the handler. Otherwise,
+	// reading beyond the limit from the body returns a
+	// [*MaxBytesError], as with [MaxBytesReader].
+	MaxBodyBytes int64
+
+	// MaxHeaders limits the number of header field lines in the
+	// request. A request with more is rejected with 431 Request
+	// Header Fields Too Large.
+	MaxHeaders int
+
+	// MaxMultipartParts limits the number of parts that
+	// [Request.ParseMultipartForm], [Request.FormValue], and the
+	// [multipart.Reader] returned by [Request.MultipartReader]
+	// read from a multipart body. Reading more fails with
+	// [multipart.ErrMessageTooLarge].
+	MaxMultipartParts int
+
+	// MaxMultipartPartBytes limits the size of the content of each
+	// part of a multipart body, such as an uploaded file, in the
+	// same way.
+	MaxMultipartPartBytes int64
+}
+
+// merge returns the limits of l overridden by those set in o.
+func (l *RequestLimits) merge(o *RequestLimits) *RequestLimits {
+	m := new(RequestLimits)
+	if l != nil {
+		*m = *l
+	}
+	if o.MaxBodyBytes != 0 {
+		m.MaxBodyBytes = o.MaxBodyBytes
+	}
+	if o.MaxHeaders != 0 {
+		m.MaxHeaders = o.MaxHeaders
+	}
+	if o.MaxMultipartParts != 0 {
+		m.MaxMultipartParts = o.MaxMultipartParts
+	}
+	if o.MaxMultipartPartBytes != 0 {
+		m.MaxMultipartPartBytes = o.MaxMultipartPartBytes
+	}
+	return m
+}
+
+// applyLimits applies l to r, replacing any limits applied before.
+// If r exceeds a limit that can be checked before it is handled, it
+// responds with an error and returns false.
+func (r *Request) applyLimits(w ResponseWriter, l *RequestLimits) bool {
+	if l.MaxHeaders > 0 {
+		n := 0
+		for _, vv := range r.Header {
+			n += len(vv)
+		}
+		if n > l.MaxHeaders {
+			Error(w, StatusText(StatusRequestHeaderFieldsTooLarge), StatusRequestHeaderFieldsTooLarge)
+			return false
+		}
+	}
+	if max := l.MaxBodyBytes; max != 0 {
+		if max > 0 && r.ContentLength > max {
+			w.Header().Set("Connection", "close")
+			Error(w, StatusText(StatusRequestEntityTooLarge), StatusRequestEntityTooLarge)
+			return false
+		}
+		switch {
+		case r.limitBody != nil && r.Body == r.limitBody:
+			r.limitBody.setLimit(max)
+		case max > 0 && r.Body != nil && r.Body != NoBody:
+			r.limitBody = &maxBytesReader{w: w, r: r.Body, i: max, n: max}
+			r.Body = r.limitBody
+		}
+	}
+	r.limits = l
+	return true
+}
+
+// setLimit changes the limit of l to n, counting the bytes already
+// read against it. If n is negative, there is no limit.
+func (l *maxBytesReader) setLimit(n int64) {
+	read := l.i - l.n
+	if n < 0 {
+		n = math.MaxInt64
+	}
+	l.i = n
+	l.n = max(0, n-read)
+}
+
+// SetLimits sets the limits on the size of requests matched by pattern,
+// which overrides the limits set by [Server.RequestLimits]. The pattern
+// need not be registered yet, but must be written exactly as it is
+// registered. SetLimits panics if the pattern is invalid.
+//
+// Limits set with SetLimits are ignored if the GODEBUG setting
+// httpmuxgo121 is 1.
+func (mux *ServeMux) SetLimits(pattern string, limits RequestLimits) {
+	pat, err := parsePattern(pattern)
+	if err != nil {
+		panic(fmt.Errorf("parsing %q: %w", pattern, err))
+	}
+	mux.mu.Lock()
+	defer mux.mu.Unlock()
+	if mux.limits == nil {
+		mux.limits = make(map[string]*RequestLimits)
+	}
+	mux.limits[pat.String()] = &limits
+}
+
+// requestLimits returns the limits that apply to r, which has been
+// matched against the patterns of mux, or nil if there are none.
+func (mux *ServeMux) requestLimits(r *Request) *RequestLimits {
+	if use121 {
+		return r.limits
+	}
+	mux.mu.RLock()
+	l := mux.limits[r.Pattern]
+	mux.mu.RUnlock()
+	if l == nil {
+		return r.limits
+	}
+	return r.limits.merge(l)
+}
@@ -0,0 +1,195 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	. "net/http"
+	"strings"
+	"testing"
+)
+
+func TestRequestLimitsBody(t *testing.T) { run(t, testRequestLimitsBody) }
+func testRequestLimitsBody(t *testing.T, mode testMode) {
+	mux := NewServeMux()
+	handler := func(w ResponseWriter, r *Request) {
+		n, err := io.Copy(io.Discard, r.Body)
+		var maxErr *MaxBytesError
+		if errors.As(err, &maxErr) {
+			w.WriteHeader(StatusRequestEntityTooLarge)
+			return
+		}
+		fmt.Fprint(w, n)
+	}
+	mux.HandleFunc("/", handler)
+	mux.HandleFunc("/small", handler)
+	mux.HandleFunc("/large", handler)
+	mux.HandleFunc("/unlimited", handler)
+	mux.SetLimits("/small", RequestLimits{MaxBodyBytes: 5})
+	mux.SetLimits("/large", RequestLimits{MaxBodyBytes: 20})
+	mux.SetLimits("/unlimited", RequestLimits{MaxBodyBytes: -1})
+	cst := newClientServerTest(t, mode, mux, func(s *Server) {
+		s.RequestLimits = &RequestLimits{MaxBodyBytes: 10}
+	})
+
+	for _, tt := range []struct {
+		path    string
+		size    int
+		chunked bool
+		want    int
+	}{
+		{"/", 10, false, 200},
+		{"/", 11, false, StatusRequestEntityTooLarge},
+		{"/", 11, true, StatusRequestEntityTooLarge},
+		{"/small", 5, false, 200},
+		{"/small", 6, false, StatusRequestEntityTooLarge},
+		{"/small", 6, true, StatusRequestEntityTooLarge},
+		{"/large", 20, false, 200},
+		{"/large", 20, true, 200},
+		{"/large", 21, true, StatusRequestEntityTooLarge},
+		{"/unlimited", 1000, false, 200},
+		{"/unlimited", 1000, true, 200},
+	} {
+		var body io.Reader = strings.NewReader(strings.Repeat("a", tt.size))
+		if tt.chunked {
+			// Hide the length, so the body is sent chunked.
+			body = io.MultiReader(body)
+		}
+		req, _ := NewRequest("POST", cst.ts.URL+tt.path, body)
+		res, err := cst.c.Do(req)
+		if err != nil {
+			t.Errorf("POST %s with %d bytes: %v", tt.path, tt.size, err)
+			continue
+		}
+		got, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		if res.StatusCode != tt.want {
+			t.Errorf("POST %s with %d bytes (chunked=%v): status %d; want %d", tt.path, tt.size, tt.chunked, res.StatusCode, tt.want)
+		} else if tt.want == 200 && string(got) != fmt.Sprint(tt.size) {
+			t.Errorf("POST %s with %d bytes: handler read %s", tt.path, tt.size, got)
+		}
+	}
+}
+
+func TestRequestLimitsHeaders(t *testing.T) { run(t, testRequestLimitsHeaders) }
+func testRequestLimitsHeaders(t *testing.T, mode testMode) {
+	mux := NewServeMux()
+	mux.HandleFunc("/", func(w ResponseWriter, r *Request) {})
+	mux.HandleFunc("/strict", func(w ResponseWriter, r *Request) {})
+	mux.SetLimits("/strict", RequestLimits{MaxHeaders: 3})
+	cst := newClientServerTest(t, mode, mux)
+
+	for _, tt := range []struct {
+		path    string
+		headers int
+		want    int
+	}{
+		{"/", 10, 200},
+		{"/strict", 0, 200},
+		{"/strict", 10, StatusRequestHeaderFieldsTooLarge},
+	} {
+		req, _ := NewRequest("GET", cst.ts.URL+tt.path, nil)
+		for i := range tt.headers {
+			req.Header.Add(fmt.Sprintf("X-Header-%d", i), "v")
+		}
+		res, err := cst.c.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != tt.want {
+			t.Errorf("GET %s with %d extra headers: status %d; want %d", tt.path, tt.headers, res.StatusCode, tt.want)
+		}
+	}
+}
+
+func TestRequestLimitsMultipart(t *testing.T) { run(t, testRequestLimitsMultipart) }
+func testRequestLimitsMultipart(t *testing.T, mode testMode) {
+	mux := NewServeMux()
+	mux.HandleFunc("/form", func(w ResponseWriter, r *Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			if errors.Is(err, multipart.ErrMessageTooLarge) {
+				w.WriteHeader(StatusRequestEntityTooLarge)
+			} else {
+				w.WriteHeader(StatusBadRequest)
+			}
+			return
+		}
+		fmt.Fprint(w, len(r.MultipartForm.File))
+	})
+	mux.HandleFunc("/stream", func(w ResponseWriter, r *Request) {
+		mr, err := r.MultipartReader()
+		if err != nil {
+			w.WriteHeader(StatusBadRequest)
+			return
+		}
+		n := 0
+		for {
+			p, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err == nil {
+				_, err = io.Copy(io.Discard, p)
+			}
+			if err != nil {
+				w.WriteHeader(StatusRequestEntityTooLarge)
+				return
+			}
+			n++
+		}
+		fmt.Fprint(w, n)
+	})
+	mux.SetLimits("/stream", RequestLimits{MaxMultipartParts: 3})
+	cst := newClientServerTest(t, mode, mux, func(s *Server) {
+		s.RequestLimits = &RequestLimits{MaxMultipartParts: 2, MaxMultipartPartBytes: 100}
+	})
+
+	for _, tt := range []struct {
+		path  string
+		parts int
+		size  int
+		want  int
+	}{
+		{"/form", 2, 100, 200},
+		{"/form", 3, 10, StatusRequestEntityTooLarge},
+		{"/form", 1, 101, StatusRequestEntityTooLarge},
+		{"/stream", 3, 100, 200},
+		{"/stream", 4, 10, StatusRequestEntityTooLarge},
+		{"/stream", 1, 101, StatusRequestEntityTooLarge},
+	} {
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		for i := range tt.parts {
+			fw, _ := mw.CreateFormFile(fmt.Sprintf("f%d", i), "file")
+			fw.Write(bytes.Repeat([]byte{'a'}, tt.size))
+		}
+		mw.Close()
+		res, err := cst.c.Post(cst.ts.URL+tt.path, mw.FormDataContentType(), &buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		if res.StatusCode != tt.want {
+			t.Errorf("POST %s with %d parts of %d bytes: status %d; want %d", tt.path, tt.parts, tt.size, res.StatusCode, tt.want)
+		} else if tt.want == 200 && string(got) != fmt.Sprint(tt.parts) {
+			t.Errorf("POST %s with %d parts: handler read %s", tt.path, tt.parts, got)
+		}
+	}
+}
+
+func TestServeMuxSetLimitsInvalidPattern(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("SetLimits with invalid pattern did not panic")
+		}
+	}()
+	NewServeMux().SetLimits("GET", RequestLimits{})
+}
@@ -0,0 +1,282 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RateLimiter limits the rate of requests by key, such as by client
+// address, with a token bucket for each key.
+//
+// Each bucket holds up to limit tokens, and refills at a rate of limit
+// tokens per window. Each request takes a token; a request that finds
+// its bucket empty is rejected with 429 Too Many Requests and a
+// Retry-After header giving the time until a token is available.
+//
+// All responses carry RateLimit-Policy and RateLimit header fields, as
+// described by the IETF draft "RateLimit header fields for HTTP"
+// (draft-ietf-httpapi-ratelimit-headers), giving the limit and window,
+// the requests remaining, and the time until the bucket is full.
+type RateLimiter struct {
+	limit  int
+	window time.Duration
+	key    atomic.Pointer[func(*Request) string]
+	deny   atomic.Pointer[Handler]
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time // when tokens was computed
+}
+
+// NewRateLimiter returns a new [RateLimiter] that allows bursts of up
+// to limit requests per key, and limit requests per window on average.
+// It panics if limit or window is not positive.
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	if limit <= 0 || window <= 0 {
+		panic("http: non-positive limit or window in NewRateLimiter")
+	}
+	return &RateLimiter{
+		limit:   limit,
+		window:  window,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// SetKeyFunc sets the function that returns the key by which a request
+// is limited. By default, requests are limited by the IP address of
+// the client, from [Request.RemoteAddr].
+//
+// SetKeyFunc can be called concurrently with other methods
+// or request handling, and applies to future requests.
+func (l *RateLimiter) SetKeyFunc(f func(*Request) string) {
+	if f == nil {
+		l.key.Store(nil)
+		return
+	}
+	l.key.Store(&f)
+}
+
+// SetDenyHandler sets a handler to invoke when a request is rejected.
+// The Retry-After and RateLimit header fields are set before it is
+// called. The default handler responds with a 429 Too Many Requests
+// status.
+//
+// SetDenyHandler can be called concurrently with other methods
+// or request handling, and applies to future requests.
+func (l *RateLimiter) SetDenyHandler(h Handler) {
+	if h == nil {
+		l.deny.Store(nil)
+		return
+	}
+	l.deny.Store(&h)
+}
+
+// Handler returns a handler that applies the rate limit to requests
+// before passing them to h.
+func (l *RateLimiter) Handler(h Handler) Handler {
+	return HandlerFunc(func(w ResponseWriter, r *Request) {
+		key := remoteIP(r)
+		if f := l.key.Load(); f != nil {
+			key = (*f)(r)
+		}
+		ok, remaining, reset, retryAfter := l.take(key, time.Now())
+
+		hdr := w.Header()
+		hdr.Set("RateLimit-Policy", `"default";q=`+strconv.Itoa(l.limit)+";w="+ceilSeconds(l.window))
+		hdr.Set("RateLimit", `"default";r=`+strconv.Itoa(remaining)+";t="+ceilSeconds(reset))
+		if !ok {
+			hdr.Set("Retry-After", ceilSeconds(retryAfter))
+			if deny := l.deny.Load(); deny != nil {
+				(*deny).ServeHTTP(w, r)
+				return
+			}
+			Error(w, StatusText(StatusTooManyRequests), StatusTooManyRequests)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// take takes a token from the bucket for key. It returns whether there
+// was one, the number of tokens remaining, the time until the bucket
+// is full, and, if there was no token, the time until there is one.
+func (l *RateLimiter) take(key string, now time.Time) (ok bool, remaining int, reset, retryAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+	rate := float64(l.limit) / l.window.Seconds() // tokens per second
+	b := l.buckets[key]
+	if b == nil {
+		b = &tokenBucket{tokens: float64(l.limit), last: now}
+		l.buckets[key] = b
+	}
+	b.refill(now, rate, l.limit)
+	if b.tokens >= 1 {
+		b.tokens--
+		ok = true
+	} else {
+		retryAfter = time.Duration((1 - b.tokens) / rate * float64(time.Second))
+	}
+	reset = time.Duration((float64(l.limit) - b.tokens) / rate * float64(time.Second))
+	return ok, int(b.tokens), reset, retryAfter
+}
+
+func (b *tokenBucket) refill(now time.Time, rate float64, limit int) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = min(float64(limit), b.tokens+elapsed.Seconds()*rate)
+		b.last = now
+	}
+}
+
+// sweep removes the buckets that have refilled completely, at most
+// once per window. The caller holds l.mu.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.window {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= l.window {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// remoteIP returns the IP address of the client that sent r.
+func remoteIP(r *Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ceilSeconds formats d as a whole number of seconds, rounding up.
+func ceilSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64((max(0, d)+time.Second-1)/time.Second), 10)
+}
+
+// ConcurrencyLimiter limits the number of requests handled at once,
+// in total and per connection.
+//
+// A request that would exceed a limit is rejected immediately with
+// 503 Service Unavailable, rather than waiting, so that an overloaded
+// server sheds load instead of accumulating goroutines.
+//
+// The limit per connection applies to the concurrent streams of an
+// HTTP/2 connection. Unlike [HTTP2Config.MaxConcurrentStreams], which
+// is enforced by the client, it rejects the requests of clients that
+// open more streams than they are permitted to. It has no effect on
+// HTTP/1 connections, which carry one request at a time.
+type ConcurrencyLimiter struct {
+	limit    int
+	inFlight atomic.Int64
+	perConn  atomic.Int64
+	deny     atomic.Pointer[Handler]
+
+	mu    sync.Mutex
+	conns map[*conn]int64 // requests in flight per connection
+}
+
+// NewConcurrencyLimiter returns a new [ConcurrencyLimiter] that allows
+// at most limit requests in flight. If limit is not positive, there is no
+// limit on the total, but there may be one per connection.
+func NewConcurrencyLimiter(limit int) *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{limit: limit}
+}
+
+// SetMaxPerConn sets the maximum number of requests in flight on each
+// connection. If n is not positive, there is no limit per connection,
+// which is the default.
+//
+// SetMaxPerConn can be called concurrently with other methods
+// or request handling, and applies to future requests.
+func (l *ConcurrencyLimiter) SetMaxPerConn(n int) {
+	l.perConn.Store(int64(n))
+}
+
+// SetDenyHandler sets a handler to invoke when a request is rejected.
+// The default handler responds with a 503 Service Unavailable status
+// and a Retry-After header of one second.
+//
+// SetDenyHandler can be called concurrently with other methods
+// or request handling, and applies to future requests.
+func (l *ConcurrencyLimiter) SetDenyHandler(h Handler) {
+	if h == nil {
+		l.deny.Store(nil)
+		return
+	}
+	l.deny.Store(&h)
+}
+
+// Handler returns a handler that applies the limits to requests before
+// passing them to h.
+func (l *ConcurrencyLimiter) Handler(h Handler) Handler {
+	return HandlerFunc(func(w ResponseWriter, r *Request) {
+		if !l.acquire(r) {
+			if deny := l.deny.Load(); deny != nil {
+				(*deny).ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("Retry-After", "1")
+			Error(w, StatusText(StatusServiceUnavailable), StatusServiceUnavailable)
+			return
+		}
+		defer l.release(r)
+		h.ServeHTTP(w, r)
+	})
+}
+
+// acquire reports whether r may be handled, and if so counts it as in
+// flight.
+func (l *ConcurrencyLimiter) acquire(r *Request) bool {
+	if n := l.inFlight.Add(1); l.limit > 0 && n > int64(l.limit) {
+		l.inFlight.Add(-1)
+		return false
+	}
+	perConn := l.perConn.Load()
+	c, _ := r.Context().Value(connContextKey).(*conn)
+	if perConn <= 0 || c == nil {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conns[c] >= perConn {
+		l.inFlight.Add(-1)
+		return false
+	}
+	if l.conns == nil {
+		l.conns = make(map[*conn]int64)
+	}
+	l.conns[c]++
+	return true
+}
+
+func (l *ConcurrencyLimiter) release(r *Request) {
+	l.inFlight.Add(-1)
+	c, _ := r.Context().Value(connContextKey).(*conn)
+	if c == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if n, ok := l.conns[c]; ok {
+		if n <= 1 {
+			delete(l.conns, c)
+		} else {
+			l.conns[c] = n - 1
+		}
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http_test
+
+import (
+	"io"
+	. "net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	l := NewRateLimiter(4, time.Hour)
+	h := l.Handler(HandlerFunc(func(w ResponseWriter, r *Request) {
+		io.WriteString(w, "ok")
+	}))
+	do := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	for i := range 4 {
+		rec := do("192.0.2.1:1234")
+		if rec.Code != 200 {
+			t.Fatalf("request %d: status %d; want 200", i, rec.Code)
+		}
+		if got, want := rec.Header().Get("RateLimit-Policy"), `"default";q=4;w=3600`; got != want {
+			t.Errorf("RateLimit-Policy = %q; want %q", got, want)
+		}
+		if got, want := rec.Header().Get("RateLimit"), `"default";r=`+strconv.Itoa(3-i)+";t="; !strings.HasPrefix(got, want) {
+			t.Errorf("request %d: RateLimit = %q; want prefix %q", i, got, want)
+		}
+	}
+	rec := do("192.0.2.1:5678")
+	if rec.Code != StatusTooManyRequests {
+		t.Fatalf("status %d; want 429", rec.Code)
+	}
+	if ra, err := strconv.Atoi(rec.Header().Get("Retry-After")); err != nil || ra < 1 || ra > 900 {
+		t.Errorf("Retry-After = %q; want between 1 and 900", rec.Header().Get("Retry-After"))
+	}
+	if got := rec.Header().Get("RateLimit"); !strings.HasPrefix(got, `"default";r=0;t=`) {
+		t.Errorf("RateLimit = %q; want r=0", got)
+	}
+
+	// Other clients have their own buckets.
+	if rec := do("192.0.2.2:1234"); rec.Code != 200 {
+		t.Errorf("other client: status %d; want 200", rec.Code)
+	}
+}
+
+func TestRateLimiterRefill(t *testing.T) {
+	l := NewRateLimiter(1, 50*time.Millisecond)
+	h := l.Handler(HandlerFunc(func(w ResponseWriter, r *Request) {}))
+	do := func() int {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		return rec.Code
+	}
+	if code := do(); code != 200 {
+		t.Fatalf("status %d; want 200", code)
+	}
+	if code := do(); code != StatusTooManyRequests {
+		t.Fatalf("status %d; want 429", code)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if code := do(); code != 200 {
+		t.Errorf("after refill: status %d; want 200", code)
+	}
+}
+
+func TestRateLimiterKeyAndDeny(t *testing.T) {
+	l := NewRateLimiter(1, time.Hour)
+	l.SetKeyFunc(func(r *Request) string { return r.Header.Get("X-Api-Key") })
+	l.SetDenyHandler(HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.WriteHeader(StatusTeapot)
+	}))
+	h := l.Handler(HandlerFunc(func(w ResponseWriter, r *Request) {}))
+	do := func(key string) int {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Api-Key", key)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	for _, tt := range []struct {
+		key  string
+		want int
+	}{
+		{"a", 200},
+		{"b", 200},
+		{"a", StatusTeapot},
+		{"b", StatusTeapot},
+	} {
+		if code := do(tt.key); code != tt.want {
+			t.Errorf("key %q: status %d; want %d", tt.key, code, tt.want)
+		}
+	}
+}
+
+func TestConcurrencyLimiter(t *testing.T) { run(t, testConcurrencyLimiter) }
+func testConcurrencyLimiter(t *testing.T, mode testMode) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	l := NewConcurrencyLimiter(1)
+	cst := newClientServerTest(t, mode, l.Handler(HandlerFunc(func(w ResponseWriter, r *Request) {
+		if r.URL.Path == "/block" {
+			close(started)
+			<-release
+		}
+	})))
+
+	done := make(chan error)
+	go func() {
+		res, err := cst.c.Get(cst.ts.URL + "/block")
+		if err == nil {
+			res.Body.Close()
+		}
+		done <- err
+	}()
+	<-started
+	res, err := cst.c.Get(cst.ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != StatusServiceUnavailable || res.Header.Get("Retry-After") != "1" {
+		t.Errorf("response = %d with Retry-After %q; want 503 with 1", res.StatusCode, res.Header.Get("Retry-After"))
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	res, err = cst.c.Get(cst.ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != 200 {
+		t.Errorf("after release: status %d; want 200", res.StatusCode)
+	}
+}
+
+func TestConcurrencyLimiterPerConn(t *testing.T) {
+	run(t, testConcurrencyLimiterPerConn, []testMode{http2Mode})
+}
+func testConcurrencyLimiterPerConn(t *testing.T, mode testMode) {
+	var (
+		startedOnce sync.Once
+		started     = make(chan struct{})
+		release     = make(chan struct{})
+	)
+	l := NewConcurrencyLimiter(0)
+	l.SetMaxPerConn(1)
+	cst := newClientServerTest(t, mode, l.Handler(HandlerFunc(func(w ResponseWriter, r *Request) {
+		if r.URL.Path == "/block" {
+			startedOnce.Do(func() { close(started) })
+			<-release
+		}
+	})))
+
+	done := make(chan error)
+	go func() {
+		res, err := cst.c.Get(cst.ts.URL + "/block")
+		if err == nil {
+			res.Body.Close()
+		}
+		done <- err
+	}()
+	<-started
+
+	// A second stream on the same connection is rejected.
+	res, err := cst.c.Get(cst.ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != StatusServiceUnavailable {
+		t.Errorf("second stream: status %d; want 503", res.StatusCode)
+	}
+
+	// A request on another connection is not.
+	tr := cst.tr.Clone()
+	defer tr.CloseIdleConnections()
+	res, err = (&Client{Transport: tr}).Get(cst.ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != 200 {
+		t.Errorf("other connection: status %d; want 200", res.StatusCode)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -336,6 +336,10 @@ type Request struct {
 	pat         *pattern          // the pattern that matched
 	matches     []string          // values for the matching wildcards in pat
 	otherValues map[string]string // for calls to SetPathValue that don't match a wildcard
+
+	// The following fields are for requests with RequestLimits.
+	limits    *RequestLimits  // the limits that apply to the request
+	limitBody *maxBytesReader // the Body installed to apply limits.MaxBodyBytes
 }
 
 // Context returns the request's context. To change the context, use
@@ -521,7 +525,12 @@ func (r *Request) multipartReader(allowMixed bool) (*multipart.Reader, error) {
 	if !ok {
 		return nil, ErrMissingBoundary
 	}
-	return multipart.NewReader(r.Body, boundary), nil
+	mr := multipart.NewReader(r.Body, boundary)
+	if l := r.limits; l != nil {
+		mr.SetMaxParts(l.MaxMultipartParts)
+		mr.SetMaxPartSize(l.MaxMultipartPartBytes)
+	}
+	return mr, nil
 }
 
 // isH2Upgrade reports whether r represents the http2 "client preface"
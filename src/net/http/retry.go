@@ -0,0 +1,195 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http/httptrace"
+	"strconv"
+	"time"
+)
+
+// RetryTransport is a [RoundTripper] that retries requests that fail
+// with an error or with a response indicating a transient failure.
+//
+// Only idempotent requests are retried: those with the methods GET,
+// HEAD, OPTIONS, TRACE, PUT, or DELETE, and those with an
+// Idempotency-Key or X-Idempotency-Key header. A request with a body
+// is only retried if its GetBody field is set; GetBody is called to
+// rewind the body for each retry.
+//
+// Between attempts, RetryTransport waits for an exponentially
+// increasing, jittered delay. When a response with status 429 Too Many
+// Requests or 503 Service Unavailable has a Retry-After header, it
+// waits at least as long as the header requests.
+//
+// Before each retry, the Retrying hook of the request's
+// [httptrace.ClientTrace] is called, if any.
+type RetryTransport struct {
+	// Transport sends each attempt.
+	// If nil, DefaultTransport is used.
+	Transport RoundTripper
+
+	// MaxRetries is the maximum number of times a request is
+	// retried. If zero, it is 3. If negative, requests are not
+	// retried.
+	MaxRetries int
+
+	// MinBackoff is the delay before the first retry, before
+	// jitter is applied. It doubles for each retry after that.
+	// If zero, it is 100 milliseconds.
+	MinBackoff time.Duration
+
+	// MaxBackoff bounds the delay between attempts, apart from
+	// delays requested by Retry-After. If zero, it is 10 seconds.
+	MaxBackoff time.Duration
+
+	// MaxRetryAfter is the longest Retry-After delay that is
+	// honored. A response asking for a longer delay is returned
+	// without retrying. If zero, it is one minute.
+	MaxRetryAfter time.Duration
+
+	// ShouldRetry reports whether an attempt that returned res and
+	// err should be retried. It is only called for requests that
+	// can be retried. If nil, attempts are retried if they fail
+	// with an error other than a context error, or with the status
+	// 429 Too Many Requests, 502 Bad Gateway, 503 Service
+	// Unavailable, or 504 Gateway Timeout.
+	ShouldRetry func(res *Response, err error) bool
+}
+
+// RoundTrip implements the [RoundTripper] interface.
+func (t *RetryTransport) RoundTrip(req *Request) (*Response, error) {
+	rt := t.Transport
+	if rt == nil {
+		rt = DefaultTransport
+	}
+	maxRetries := t.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = 3
+	}
+	if maxRetries < 0 || !req.isRetryable() {
+		return rt.RoundTrip(req)
+	}
+	ctx := req.Context()
+	trace := httptrace.ContextClientTrace(ctx)
+	for attempt := 1; ; attempt++ {
+		r := req
+		if attempt > 1 && req.Body != nil && req.Body != NoBody {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r = new(Request)
+			*r = *req
+			r.Body = body
+		}
+		res, err := rt.RoundTrip(r)
+		if attempt > maxRetries || ctx.Err() != nil || !t.shouldRetry(res, err) {
+			return res, err
+		}
+
+		delay := t.backoff(attempt)
+		if res != nil && (res.StatusCode == StatusTooManyRequests || res.StatusCode == StatusServiceUnavailable) {
+			if d, ok := parseRetryAfter(res.Header.Get("Retry-After"), time.Now()); ok {
+				if d > t.maxRetryAfter() {
+					return res, err
+				}
+				delay = max(delay, d)
+			}
+		}
+		info := httptrace.RetryInfo{Attempt: attempt + 1, Err: err, Delay: delay}
+		if res != nil {
+			info.StatusCode = res.StatusCode
+			// Read a little of the body, so the connection can be reused.
+			io.CopyN(io.Discard, res.Body, 4<<10)
+			res.Body.Close()
+		}
+		if trace != nil && trace.Retrying != nil {
+			trace.Retrying(info)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, context.Cause(ctx)
+		}
+	}
+}
+
+func (t *RetryTransport) shouldRetry(res *Response, err error) bool {
+	if t.ShouldRetry != nil {
+		return t.ShouldRetry(res, err)
+	}
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch res.StatusCode {
+	case StatusTooManyRequests, StatusBadGateway, StatusServiceUnavailable, StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff returns the delay before the given attempt is retried:
+// a random duration between half and all of MinBackoff doubled for
+// each earlier attempt, capped at MaxBackoff.
+func (t *RetryTransport) backoff(attempt int) time.Duration {
+	minBackoff := t.MinBackoff
+	if minBackoff <= 0 {
+		minBackoff = 100 * time.Millisecond
+	}
+	maxBackoff := t.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = 10 * time.Second
+	}
+	d := minBackoff
+	for i := 1; i < attempt && d < maxBackoff; i++ {
+		d *= 2
+	}
+	d = min(d, maxBackoff)
+	return d/2 + rand.N(d/2+1)
+}
+
+func (t *RetryTransport) maxRetryAfter() time.Duration {
+	if t.MaxRetryAfter > 0 {
+		return t.MaxRetryAfter
+	}
+	return time.Minute
+}
+
+// isRetryable reports whether r may be sent again by a RetryTransport.
+func (r *Request) isRetryable() bool {
+	if r.Body != nil && r.Body != NoBody && r.GetBody == nil {
+		return false
+	}
+	switch valueOrDefault(r.Method, "GET") {
+	case "GET", "HEAD", "OPTIONS", "TRACE", "PUT", "DELETE":
+		return true
+	}
+	return r.Header.has("Idempotency-Key") || r.Header.has("X-Idempotency-Key")
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which is
+// either a number of seconds or an HTTP date, and returns the delay it
+// requests from now.
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.ParseUint(v, 10, 32); err == nil {
+		return time.Duration(secs) * time.Second, true
+	}
+	t, err := ParseTime(v)
+	if err != nil {
+		return 0, false
+	}
+	return max(0, t.Sub(now)), true
+}
@@ -0,0 +1,272 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	. "net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryTransportStatus(t *testing.T) { run(t, testRetryTransportStatus) }
+func testRetryTransportStatus(t *testing.T, mode testMode) {
+	var requests atomic.Int32
+	cst := newClientServerTest(t, mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		if requests.Add(1) < 3 {
+			w.WriteHeader(StatusServiceUnavailable)
+			io.WriteString(w, "unavailable")
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	rt := &RetryTransport{Transport: cst.tr, MinBackoff: time.Millisecond}
+	c := &Client{Transport: rt}
+
+	var (
+		mu      sync.Mutex
+		retries []httptrace.RetryInfo
+	)
+	trace := &httptrace.ClientTrace{
+		Retrying: func(info httptrace.RetryInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			retries = append(retries, info)
+		},
+	}
+	req, _ := NewRequestWithContext(httptrace.WithClientTrace(context.Background(), trace), "GET", cst.ts.URL, nil)
+	res, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if res.StatusCode != 200 || string(body) != "ok" {
+		t.Errorf("response = %d %q; want 200 %q", res.StatusCode, body, "ok")
+	}
+	if len(retries) != 2 {
+		t.Fatalf("Retrying called %d times; want 2", len(retries))
+	}
+	for i, info := range retries {
+		if info.Attempt != i+2 || info.StatusCode != StatusServiceUnavailable || info.Err != nil {
+			t.Errorf("retry %d: %+v; want attempt %d with status 503", i, info, i+2)
+		}
+		if info.Delay <= 0 {
+			t.Errorf("retry %d: Delay = %v; want positive", i, info.Delay)
+		}
+	}
+}
+
+func TestRetryTransportMaxRetries(t *testing.T) { run(t, testRetryTransportMaxRetries) }
+func testRetryTransportMaxRetries(t *testing.T, mode testMode) {
+	var requests atomic.Int32
+	cst := newClientServerTest(t, mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		requests.Add(1)
+		w.WriteHeader(StatusBadGateway)
+	}))
+	c := &Client{Transport: &RetryTransport{Transport: cst.tr, MinBackoff: time.Millisecond, MaxRetries: 2}}
+	res, err := c.Get(cst.ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != StatusBadGateway {
+		t.Errorf("status = %d; want 502", res.StatusCode)
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("server got %d requests; want 3", n)
+	}
+
+	requests.Store(0)
+	c.Transport = &RetryTransport{Transport: cst.tr, MaxRetries: -1}
+	res, err = c.Get(cst.ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if n := requests.Load(); n != 1 {
+		t.Errorf("with retries disabled, server got %d requests; want 1", n)
+	}
+}
+
+func TestRetryTransportBody(t *testing.T) { run(t, testRetryTransportBody) }
+func testRetryTransportBody(t *testing.T, mode testMode) {
+	var (
+		mu     sync.Mutex
+		bodies []string
+	)
+	cst := newClientServerTest(t, mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, r.Method+" "+string(b))
+		mu.Unlock()
+		w.WriteHeader(StatusServiceUnavailable)
+	}))
+	c := &Client{Transport: &RetryTransport{Transport: cst.tr, MinBackoff: time.Millisecond, MaxRetries: 1}}
+
+	send := func(method string, body io.Reader, header ...string) {
+		t.Helper()
+		req, _ := NewRequest(method, cst.ts.URL, body)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		res, err := c.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+	send("PUT", strings.NewReader("put"))
+	send("POST", strings.NewReader("post"))
+	send("POST", strings.NewReader("keyed"), "Idempotency-Key", "123")
+	// A body that cannot be rewound is not retried.
+	send("PUT", io.MultiReader(strings.NewReader("once")))
+
+	want := []string{"PUT put", "PUT put", "POST post", "POST keyed", "POST keyed", "PUT once"}
+	if got := strings.Join(bodies, ","); got != strings.Join(want, ",") {
+		t.Errorf("server got requests %q; want %q", bodies, want)
+	}
+}
+
+func TestRetryTransportRetryAfter(t *testing.T) { run(t, testRetryTransportRetryAfter) }
+func testRetryTransportRetryAfter(t *testing.T, mode testMode) {
+	var requests atomic.Int32
+	cst := newClientServerTest(t, mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", r.URL.Query().Get("after"))
+			w.WriteHeader(StatusTooManyRequests)
+		}
+	}))
+	var delay time.Duration
+	trace := &httptrace.ClientTrace{
+		Retrying: func(info httptrace.RetryInfo) { delay = info.Delay },
+	}
+	c := &Client{Transport: &RetryTransport{
+		Transport:     cst.tr,
+		MinBackoff:    time.Millisecond,
+		MaxRetryAfter: 10 * time.Second,
+	}}
+	get := func(after string) *Response {
+		requests.Store(0)
+		ctx := httptrace.WithClientTrace(context.Background(), trace)
+		req, _ := NewRequestWithContext(ctx, "GET", cst.ts.URL+"?after="+after, nil)
+		res, err := c.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		return res
+	}
+
+	start := time.Now()
+	if res := get("1"); res.StatusCode != 200 {
+		t.Errorf("status = %d; want 200", res.StatusCode)
+	}
+	if delay != time.Second {
+		t.Errorf("Retrying delay = %v; want 1s", delay)
+	}
+	if d := time.Since(start); d < time.Second {
+		t.Errorf("retried after %v; want at least 1s", d)
+	}
+
+	// A longer delay than MaxRetryAfter is not honored.
+	if res := get("3600"); res.StatusCode != StatusTooManyRequests {
+		t.Errorf("status = %d; want 429", res.StatusCode)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("server got %d requests; want 1", n)
+	}
+}
+
+func TestRetryTransportError(t *testing.T) {
+	ts := httptest.NewServer(NotFoundHandler())
+	url := ts.URL
+	ts.Close()
+
+	var attempts []int
+	trace := &httptrace.ClientTrace{
+		Retrying: func(info httptrace.RetryInfo) {
+			if info.Err == nil {
+				t.Errorf("RetryInfo.Err is nil")
+			}
+			attempts = append(attempts, info.Attempt)
+		},
+	}
+	req, _ := NewRequestWithContext(httptrace.WithClientTrace(context.Background(), trace), "GET", url, nil)
+	rt := &RetryTransport{MinBackoff: time.Millisecond, MaxRetries: 2}
+	if _, err := rt.RoundTrip(req); err == nil {
+		t.Fatal("RoundTrip succeeded")
+	}
+	if len(attempts) != 2 || attempts[0] != 2 || attempts[1] != 3 {
+		t.Errorf("retried attempts %v; want [2 3]", attempts)
+	}
+}
+
+func TestRetryTransportCancel(t *testing.T) {
+	ts := httptest.NewServer(HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.WriteHeader(StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	trace := &httptrace.ClientTrace{
+		Retrying: func(httptrace.RetryInfo) { cancel() },
+	}
+	req, _ := NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), "GET", ts.URL, nil)
+	rt := &RetryTransport{Transport: ts.Client().Transport, MinBackoff: time.Hour}
+	if _, err := rt.RoundTrip(req); !errors.Is(err, context.Canceled) {
+		t.Errorf("RoundTrip error = %v; want %v", err, context.Canceled)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, tt := range []struct {
+		in   string
+		want time.Duration
+		ok   bool
+	}{
+		{"", 0, false},
+		{"0", 0, true},
+		{"120", 2 * time.Minute, true},
+		{"-1", 0, false},
+		{"1.5", 0, false},
+		{"Fri, 02 Jan 2026 03:05:05 GMT", time.Minute, true},
+		{"Fri, 02 Jan 2026 03:00:00 GMT", 0, true},
+		{"soon", 0, false},
+	} {
+		got, ok := ExportParseRetryAfter(tt.in, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseRetryAfter(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	rt := &RetryTransport{MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	for _, tt := range []struct {
+		attempt int
+		lo, hi  time.Duration
+	}{
+		{1, 50 * time.Millisecond, 100 * time.Millisecond},
+		{2, 100 * time.Millisecond, 200 * time.Millisecond},
+		{3, 200 * time.Millisecond, 400 * time.Millisecond},
+		{10, 500 * time.Millisecond, time.Second},
+		{100, 500 * time.Millisecond, time.Second},
+	} {
+		for range 100 {
+			if d := ExportRetryBackoff(rt, tt.attempt); d < tt.lo || d > tt.hi {
+				t.Fatalf("backoff(%d) = %v; want in [%v, %v]", tt.attempt, d, tt.lo, tt.hi)
+			}
+		}
+	}
+}
@@ -249,6 +249,10 @@ var (
 	// address the connection arrived on.
 	// The associated value will be of type net.Addr.
 	LocalAddrContextKey = &contextKey{"local-addr"}
+
+	// connContextKey is a context key whose value is the *conn
+	// a request arrived on, including HTTP/2 requests.
+	connContextKey = &contextKey{"http-conn"}
 )
 
 // A conn represents the server side of an HTTP connection.
@@ -1900,6 +1904,7 @@ func (c *conn) serve(ctx context.Context) {
 		c.remoteAddr = ra.String()
 	}
 	ctx = context.WithValue(ctx, LocalAddrContextKey, c.rwc.LocalAddr())
+	ctx = context.WithValue(ctx, connContextKey, c)
 	var inFlightResponse *response
 	defer func() {
 		if err := recover(); err != nil && err != ErrAbortHandler {
@@ -2577,7 +2582,8 @@ type ServeMux struct {
 	mu     sync.RWMutex
 	tree   routingNode
 	index  routingIndex
-	mux121 serveMux121 // used only when GODEBUG=httpmuxgo121=1
+	mux121 serveMux121               // used only when GODEBUG=httpmuxgo121=1
+	limits map[string]*RequestLimits // by pattern, set by SetLimits
 }
 
 // NewServeMux allocates and returns a new [ServeMux].
@@ -2826,6 +2832,9 @@ func (mux *ServeMux) ServeHTTP(w ResponseWriter, r *Request) {
 	} else {
 		h, r.Pattern, r.pat, r.matches = mux.findHandler(r)
 	}
+	if l := mux.requestLimits(r); l != nil && !r.applyLimits(w, l) {
+		return
+	}
 	h.ServeHTTP(w, r)
 }
 
@@ -3022,6 +3031,15 @@ type Server struct {
 	// If zero, DefaultMaxHeaderBytes is used.
 	MaxHeaderBytes int
 
+	// RequestLimits optionally limits the size of the body and
+	// header of requests, and of the parts of multipart bodies.
+	// The limits may be overridden for requests matching a
+	// ServeMux pattern with [ServeMux.SetLimits]. If Handler is not
+	// a ServeMux, the limits are applied before Handler is called,
+	// so that a request whose Content-Length exceeds MaxBodyBytes
+	// is rejected even if a ServeMux it reaches would allow it.
+	RequestLimits *RequestLimits
+
 	// TLSNextProto optionally specifies a function to take over
 	// ownership of the provided TLS connection when an ALPN
 	// protocol upgrade has occurred. The map key is the protocol
@@ -3320,6 +3338,15 @@ func (sh serverHandler) ServeHTTP(rw ResponseWriter, req *Request) {
 	if !sh.srv.DisableGeneralOptionsHandler && req.RequestURI == "*" && req.Method == "OPTIONS" {
 		handler = globalOptionsHandler{}
 	}
+	if l := sh.srv.RequestLimits; l != nil {
+		if _, ok := handler.(*ServeMux); ok {
+			// Let the ServeMux apply the limits, after
+			// overriding them with those of the route.
+			req.limits = l
+		} else if !req.applyLimits(rw, l) {
+			return
+		}
+	}
 
 	handler.ServeHTTP(rw, req)
 }
@@ -0,0 +1,171 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sse
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// ErrEventTooLarge is returned by a [Decoder] when an event or a line
+// exceeds the maximum size.
+var ErrEventTooLarge = errors.New("sse: event too large")
+
+// defaultMaxEventSize is the default maximum size of an event.
+const defaultMaxEventSize = 1 << 20
+
+// A Decoder reads events from an event stream.
+type Decoder struct {
+	r      io.Reader
+	buf    []byte // unparsed input is buf[start:]
+	start  int
+	skipLF bool // the last line ended with CR; skip a following LF
+	began  bool // the byte order mark has been checked
+	max    int
+	lastID string
+	retry  time.Duration
+	err    error
+}
+
+// NewDecoder returns a decoder that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: r, max: defaultMaxEventSize}
+}
+
+// SetMaxEventSize sets the maximum size in bytes of an event's data
+// and of any line of the stream. Decode returns [ErrEventTooLarge] if
+// it is exceeded. The default is 1MiB.
+func (d *Decoder) SetMaxEventSize(n int) {
+	d.max = n
+}
+
+// Retry returns the reconnection time most recently sent by the server
+// in a retry field, or zero if none was.
+func (d *Decoder) Retry() time.Duration {
+	return d.retry
+}
+
+// Decode reads the next event. At the end of the stream it returns
+// io.EOF, discarding any incomplete event.
+func (d *Decoder) Decode() (Event, error) {
+	if d.err != nil {
+		return Event{}, d.err
+	}
+	ev, err := d.decode()
+	if err != nil {
+		d.err = err
+	}
+	return ev, err
+}
+
+func (d *Decoder) decode() (Event, error) {
+	var (
+		typ     string
+		data    []byte
+		hasData bool
+	)
+	for {
+		line, err := d.readLine()
+		if err != nil {
+			return Event{}, err
+		}
+		if len(line) == 0 {
+			if !hasData {
+				typ = ""
+				continue
+			}
+			return Event{ID: d.lastID, Type: typ, Data: string(data)}, nil
+		}
+		if line[0] == ':' {
+			continue // comment
+		}
+		name, value, _ := bytes.Cut(line, []byte(":"))
+		value, _ = bytes.CutPrefix(value, []byte(" "))
+		switch string(name) {
+		case "event":
+			typ = string(value)
+		case "data":
+			if hasData {
+				data = append(data, '\n')
+			}
+			data = append(data, value...)
+			hasData = true
+			if len(data) > d.max {
+				return Event{}, ErrEventTooLarge
+			}
+		case "id":
+			if bytes.IndexByte(value, 0) < 0 {
+				d.lastID = string(value)
+			}
+		case "retry":
+			if ms, err := strconv.ParseUint(string(value), 10, 63); err == nil {
+				d.retry = time.Duration(min(ms, maxRetryMillis)) * time.Millisecond
+			}
+		}
+	}
+}
+
+// bom is the UTF-8 byte order mark.
+var bom = []byte("\xef\xbb\xbf")
+
+// maxRetryMillis bounds reconnection times so that they fit
+// in a time.Duration.
+const maxRetryMillis = math.MaxInt64 / uint64(time.Millisecond)
+
+// readLine returns the next line of the stream, without its end of
+// line. Lines end with CRLF, LF, or CR. The line is valid until the
+// next call.
+func (d *Decoder) readLine() ([]byte, error) {
+	for {
+		b := d.buf[d.start:]
+		if d.skipLF && len(b) > 0 {
+			if b[0] == '\n' {
+				d.start++
+				b = b[1:]
+			}
+			d.skipLF = false
+		}
+		if !d.began && (len(b) >= len(bom) || !bytes.HasPrefix(bom, b)) {
+			// The stream may start with a byte order mark.
+			d.began = true
+			if bytes.HasPrefix(b, bom) {
+				d.start += len(bom)
+				b = b[len(bom):]
+			}
+		}
+		if d.began {
+			if i := bytes.IndexAny(b, "\r\n"); i >= 0 {
+				d.start += i + 1
+				d.skipLF = b[i] == '\r'
+				return b[:i], nil
+			}
+		}
+		if len(b) > d.max {
+			return nil, ErrEventTooLarge
+		}
+
+		// Read more input.
+		if d.start > 0 {
+			d.buf = d.buf[:copy(d.buf, b)]
+			d.start = 0
+		}
+		if cap(d.buf)-len(d.buf) < 512 {
+			d.buf = append(d.buf, make([]byte, max(4096, len(d.buf)))...)[:len(d.buf)]
+		}
+		n, err := d.r.Read(d.buf[len(d.buf):cap(d.buf)])
+		d.buf = d.buf[:len(d.buf)+n]
+		if n == 0 && err != nil {
+			if err == io.EOF && !d.began {
+				d.began = true
+				continue
+			}
+			return nil, err
+		}
+	}
+}
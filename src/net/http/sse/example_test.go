@@ -0,0 +1,57 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sse_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/sse"
+	"strconv"
+	"time"
+)
+
+func ExampleNewResponseEncoder() {
+	http.HandleFunc("/progress", func(w http.ResponseWriter, r *http.Request) {
+		e, err := sse.NewResponseEncoder(w)
+		if err != nil {
+			return
+		}
+		defer e.StartHeartbeat(15 * time.Second)()
+
+		// Resume after the last event the client received.
+		start, _ := strconv.Atoi(sse.LastEventID(r))
+		for i := start + 1; i <= 100; i++ {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(time.Second):
+			}
+			err := e.Encode(sse.Event{
+				ID:   strconv.Itoa(i),
+				Type: "progress",
+				Data: strconv.Itoa(i) + "%",
+			})
+			if err != nil {
+				return
+			}
+		}
+	})
+}
+
+func ExampleStream() {
+	req, err := http.NewRequest("GET", "https://example.com/progress", nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	s := &sse.Stream{Request: req}
+	for ev, err := range s.Events(context.Background()) {
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(ev.Type, ev.Data)
+	}
+}
@@ -0,0 +1,227 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package sse implements server-sent events, the text/event-stream
+// format defined in the HTML Living Standard.
+//
+// Servers send events with an [Encoder], usually created by
+// [NewResponseEncoder] in an HTTP handler. Clients parse event streams
+// with a [Decoder], or receive events with a [Stream], which
+// reconnects when the connection is lost and resumes from the last
+// event ID it received.
+package sse
+
+import (
+	"errors"
+	"io"
+	"iter"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// An Event is a server-sent event.
+type Event struct {
+	// ID is the event ID. When decoding, it is the most recent ID
+	// received on the stream, since IDs apply to all the events
+	// that follow them. IDs may not contain newlines or NUL.
+	ID string
+
+	// Type is the event type. An empty type is equivalent to
+	// "message". Types may not contain newlines.
+	Type string
+
+	// Data is the payload of the event. It may contain newlines,
+	// which are sent as separate data fields.
+	Data string
+}
+
+// LastEventID returns the ID of the last event a reconnecting client
+// received, from the request's Last-Event-ID header. A server can use
+// it to resume the stream after that event.
+func LastEventID(r *http.Request) string {
+	return r.Header.Get("Last-Event-ID")
+}
+
+// An Encoder writes events to an output stream.
+// It is safe for concurrent use.
+type Encoder struct {
+	mu        sync.Mutex
+	w         io.Writer
+	flush     func() error // nil if w is not flushed
+	buf       []byte
+	err       error
+	lastWrite time.Time
+}
+
+// NewEncoder returns an encoder that writes to w. If w has a Flush
+// method, such as an [http.Flusher], the encoder calls it after each
+// event it writes.
+func NewEncoder(w io.Writer) *Encoder {
+	e := &Encoder{w: w, lastWrite: time.Now()}
+	switch f := w.(type) {
+	case interface{ FlushError() error }:
+		e.flush = f.FlushError
+	case interface{ Flush() error }:
+		e.flush = f.Flush
+	case http.Flusher:
+		e.flush = func() error {
+			f.Flush()
+			return nil
+		}
+	}
+	return e
+}
+
+// NewResponseEncoder starts an event stream in response to an HTTP
+// request. It sets the Content-Type and Cache-Control headers of the
+// response, sends them with status 200, and returns an encoder that
+// flushes each event to the client.
+func NewResponseEncoder(w http.ResponseWriter) (*Encoder, error) {
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+	if err := rc.Flush(); err != nil {
+		return nil, err
+	}
+	return &Encoder{w: w, flush: rc.Flush, lastWrite: time.Now()}, nil
+}
+
+// Encode writes an event.
+func (e *Encoder) Encode(ev Event) error {
+	if strings.ContainsAny(ev.ID, "\r\n\x00") {
+		return errors.New("sse: invalid event ID " + strconv.Quote(ev.ID))
+	}
+	if strings.ContainsAny(ev.Type, "\r\n") {
+		return errors.New("sse: invalid event type " + strconv.Quote(ev.Type))
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	b := e.buf[:0]
+	if ev.ID != "" {
+		b = appendField(b, "id", ev.ID)
+	}
+	if ev.Type != "" {
+		b = appendField(b, "event", ev.Type)
+	}
+	for line := range lines(ev.Data) {
+		b = appendField(b, "data", line)
+	}
+	b = append(b, '\n')
+	return e.write(b)
+}
+
+// Retry asks clients to wait for d before reconnecting after they
+// lose the connection.
+func (e *Encoder) Retry(d time.Duration) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	b := appendField(e.buf[:0], "retry", strconv.FormatInt(d.Milliseconds(), 10))
+	return e.write(append(b, '\n'))
+}
+
+// Comment writes a comment, which clients ignore. Comments can keep
+// idle connections from being closed by proxies; see also
+// [Encoder.StartHeartbeat].
+func (e *Encoder) Comment(text string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	b := e.buf[:0]
+	for line := range lines(text) {
+		b = append(b, ':')
+		if line != "" {
+			b = append(b, ' ')
+			b = append(b, line...)
+		}
+		b = append(b, '\n')
+	}
+	return e.write(append(b, '\n'))
+}
+
+// StartHeartbeat starts a goroutine that writes an empty comment
+// whenever nothing has been written for the given interval. It
+// returns a function that stops the goroutine and waits for it to
+// exit; an HTTP handler must call it before returning.
+func (e *Encoder) StartHeartbeat(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		t := time.NewTimer(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-t.C:
+			}
+			e.mu.Lock()
+			idle := time.Since(e.lastWrite)
+			if idle >= interval {
+				e.write([]byte(":\n\n"))
+				idle = 0
+			}
+			err := e.err
+			e.mu.Unlock()
+			if err != nil {
+				return
+			}
+			t.Reset(interval - idle)
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+		<-exited
+	}
+}
+
+// write writes b and flushes it. The caller holds e.mu.
+func (e *Encoder) write(b []byte) error {
+	if cap(b) <= 64<<10 {
+		e.buf = b
+	}
+	if e.err != nil {
+		return e.err
+	}
+	_, err := e.w.Write(b)
+	if err == nil && e.flush != nil {
+		err = e.flush()
+	}
+	e.lastWrite = time.Now()
+	e.err = err
+	return err
+}
+
+func appendField(b []byte, name, value string) []byte {
+	b = append(b, name...)
+	b = append(b, ": "...)
+	b = append(b, value...)
+	return append(b, '\n')
+}
+
+// lines yields the lines of s, which may end with CRLF, LF or CR.
+// An empty string has a single empty line.
+func lines(s string) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for {
+			i := strings.IndexAny(s, "\r\n")
+			if i < 0 {
+				yield(s)
+				return
+			}
+			if !yield(s[:i]) {
+				return
+			}
+			if s[i] == '\r' && i+1 < len(s) && s[i+1] == '\n' {
+				i++
+			}
+			s = s[i+1:]
+		}
+	}
+}
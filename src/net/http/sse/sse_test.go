@@ -0,0 +1,325 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sse
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestEncoder(t *testing.T) {
+	var b strings.Builder
+	e := NewEncoder(&b)
+	e.Encode(Event{Data: "hello"})
+	e.Encode(Event{ID: "42", Type: "update", Data: "line 1\nline 2\r\nline 3\n"})
+	e.Encode(Event{})
+	e.Retry(1500 * time.Millisecond)
+	e.Comment("keep\nalive")
+	want := "data: hello\n\n" +
+		"id: 42\nevent: update\ndata: line 1\ndata: line 2\ndata: line 3\ndata: \n\n" +
+		"data: \n\n" +
+		"retry: 1500\n\n" +
+		": keep\n: alive\n\n"
+	if got := b.String(); got != want {
+		t.Errorf("encoded:\n%q\nwant:\n%q", got, want)
+	}
+
+	for _, ev := range []Event{
+		{ID: "a\nb"},
+		{ID: "a\x00b"},
+		{Type: "a\rb"},
+	} {
+		if err := e.Encode(ev); err == nil {
+			t.Errorf("Encode(%+v) succeeded; want error", ev)
+		}
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		in    string
+		want  []Event
+		retry time.Duration
+	}{{
+		name: "simple",
+		in:   "data: hello\n\ndata: world\n\n",
+		want: []Event{{Data: "hello"}, {Data: "world"}},
+	}, {
+		name: "line endings",
+		in:   "data: a\r\ndata: b\rdata: c\n\r\n",
+		want: []Event{{Data: "a\nb\nc"}},
+	}, {
+		name: "byte order mark",
+		in:   "\xef\xbb\xbfdata: x\n\n",
+		want: []Event{{Data: "x"}},
+	}, {
+		name: "fields",
+		in:   ": comment\nevent: add\nid: 1\ndata:no space\ndata:  two spaces\nunknown: x\n\n",
+		want: []Event{{ID: "1", Type: "add", Data: "no space\n two spaces"}},
+	}, {
+		name: "field without colon",
+		in:   "data\n\ndata\ndata\n\n",
+		want: []Event{{Data: ""}, {Data: "\n"}},
+	}, {
+		name: "id persists",
+		in:   "id: 1\ndata: a\n\ndata: b\n\nid\ndata: c\n\nid: x\x00y\ndata: d\n\n",
+		want: []Event{{ID: "1", Data: "a"}, {ID: "1", Data: "b"}, {Data: "c"}, {Data: "d"}},
+	}, {
+		name: "event without data",
+		in:   "event: ignored\n\ndata: x\n\n",
+		want: []Event{{Data: "x"}},
+	}, {
+		name: "incomplete event",
+		in:   "data: x\n\ndata: y\n",
+		want: []Event{{Data: "x"}},
+	}, {
+		name:  "retry",
+		in:    "retry: 2500\n\nretry: 1x\n\nretry: -1\n\n",
+		retry: 2500 * time.Millisecond,
+	}} {
+		for _, oneByte := range []bool{false, true} {
+			var r io.Reader = strings.NewReader(tt.in)
+			if oneByte {
+				r = iotest.OneByteReader(r)
+			}
+			d := NewDecoder(r)
+			var got []Event
+			for {
+				ev, err := d.Decode()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("%s: %v", tt.name, err)
+				}
+				got = append(got, ev)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("%s (one byte reads: %v): got %+v; want %+v", tt.name, oneByte, got, tt.want)
+			}
+			if d.Retry() != tt.retry {
+				t.Errorf("%s: Retry = %v; want %v", tt.name, d.Retry(), tt.retry)
+			}
+		}
+	}
+}
+
+func TestDecoderMaxEventSize(t *testing.T) {
+	for _, in := range []string{
+		"data: " + strings.Repeat("x", 200) + "\n\n",
+		strings.Repeat("data: xxxxxxxx\n", 20) + "\n",
+	} {
+		d := NewDecoder(strings.NewReader(in))
+		d.SetMaxEventSize(100)
+		if _, err := d.Decode(); err != ErrEventTooLarge {
+			t.Errorf("Decode: %v; want %v", err, ErrEventTooLarge)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	events := []Event{
+		{Data: "one"},
+		{ID: "2", Type: "custom", Data: "two\nlines"},
+		{ID: "3", Data: ""},
+		{ID: "3", Data: "\n\n"},
+	}
+	r, w := io.Pipe()
+	go func() {
+		e := NewEncoder(w)
+		for _, ev := range events {
+			e.Encode(ev)
+		}
+		w.Close()
+	}()
+	d := NewDecoder(r)
+	for _, want := range events {
+		got, err := d.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("decoded %+v; want %+v", got, want)
+		}
+	}
+}
+
+// lockedBuffer is a strings.Builder safe for concurrent use.
+type lockedBuffer struct {
+	mu sync.Mutex
+	b  strings.Builder
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.String()
+}
+
+func TestHeartbeat(t *testing.T) {
+	var b lockedBuffer
+	e := NewEncoder(&b)
+	stop := e.StartHeartbeat(time.Millisecond)
+	deadline := time.Now().Add(10 * time.Second)
+	for !strings.Contains(b.String(), ":\n\n") {
+		if time.Now().After(deadline) {
+			t.Fatal("no heartbeat was written")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	stop()
+	stop()
+	n := len(b.String())
+	time.Sleep(10 * time.Millisecond)
+	if len(b.String()) != n {
+		t.Errorf("heartbeat written after stop")
+	}
+}
+
+func TestStream(t *testing.T) {
+	for _, http2 := range []bool{false, true} {
+		var requests atomic.Int32
+		ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Accept") != "text/event-stream" {
+				t.Errorf("Accept = %q", r.Header.Get("Accept"))
+			}
+			switch n := requests.Add(1); n {
+			case 1:
+				if id := LastEventID(r); id != "" {
+					t.Errorf("first request has Last-Event-ID %q", id)
+				}
+				e, err := NewResponseEncoder(w)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				e.Retry(time.Millisecond)
+				e.Encode(Event{ID: "1", Data: "a"})
+				e.Encode(Event{ID: "2", Data: "b"})
+			case 2:
+				if id := LastEventID(r); id != "2" {
+					t.Errorf("second request has Last-Event-ID %q; want %q", id, "2")
+				}
+				e, _ := NewResponseEncoder(w)
+				e.Encode(Event{ID: "3", Type: "last", Data: "c"})
+			default:
+				w.WriteHeader(http.StatusNoContent)
+			}
+		}))
+		ts.EnableHTTP2 = http2
+		ts.StartTLS()
+		defer ts.Close()
+
+		req, _ := http.NewRequest("GET", ts.URL, nil)
+		s := &Stream{Client: ts.Client(), Request: req, RetryDelay: time.Hour}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		var got []Event
+		for ev, err := range s.Events(ctx) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, ev)
+		}
+		want := []Event{{ID: "1", Data: "a"}, {ID: "2", Data: "b"}, {ID: "3", Type: "last", Data: "c"}}
+		if !slices.Equal(got, want) {
+			t.Errorf("HTTP2=%v: events %+v; want %+v", http2, got, want)
+		}
+		if s.LastEventID() != "3" {
+			t.Errorf("LastEventID = %q; want %q", s.LastEventID(), "3")
+		}
+	}
+}
+
+func TestStreamErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		handler  http.HandlerFunc
+		requests int32
+	}{{
+		name: "status",
+		handler: func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "nope", http.StatusInternalServerError)
+		},
+		requests: 1,
+	}, {
+		name: "content type",
+		handler: func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			io.WriteString(w, "data: x\n\n")
+		},
+		requests: 1,
+	}, {
+		name: "max retries",
+		handler: func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		},
+		requests: 3,
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				tt.handler(w, r)
+			}))
+			defer ts.Close()
+			req, _ := http.NewRequest("GET", ts.URL, nil)
+			s := &Stream{Request: req, RetryDelay: time.Millisecond, MaxRetries: 2}
+			var errs []error
+			for _, err := range s.Events(context.Background()) {
+				errs = append(errs, err)
+			}
+			if len(errs) != 1 || errs[0] == nil {
+				t.Errorf("Events yielded %v; want one error", errs)
+			}
+			if n := requests.Load(); n != tt.requests {
+				t.Errorf("server got %d requests; want %d", n, tt.requests)
+			}
+		})
+	}
+}
+
+func TestStreamCancel(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e, _ := NewResponseEncoder(w)
+		e.Encode(Event{Data: "x"})
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+	req, _ := http.NewRequest("GET", ts.URL, nil)
+	s := &Stream{Request: req}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var err error
+	for ev, e := range s.Events(ctx) {
+		if e != nil {
+			err = e
+			break
+		}
+		if ev.Data == "x" {
+			cancel()
+		}
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Events ended with %v; want %v", err, context.Canceled)
+	}
+}
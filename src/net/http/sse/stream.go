@@ -0,0 +1,190 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sse
+
+import (
+	"context"
+	"errors"
+	"io"
+	"iter"
+	"mime"
+	"net/http"
+	"time"
+)
+
+// defaultRetryDelay is the reconnection time used until the server
+// sets one.
+const defaultRetryDelay = 3 * time.Second
+
+// A Stream receives events from a server, reconnecting when the
+// connection is lost, as an EventSource does in a browser.
+//
+// When it reconnects, a Stream sends the ID of the last event it
+// received in the Last-Event-ID header, and waits for the
+// reconnection time set by the server, or RetryDelay, before each
+// attempt. A response with a status other than 200 OK or a content
+// type other than text/event-stream ends the stream, except that
+// 204 No Content ends it without an error.
+type Stream struct {
+	// Client is the HTTP client used to connect to the server.
+	// If nil, http.DefaultClient is used. The client's Timeout
+	// limits the lifetime of each connection, and should usually
+	// be zero.
+	Client *http.Client
+
+	// Request is the request sent to the server. It is cloned
+	// for each connection. If it has a body, its GetBody field
+	// must be set.
+	Request *http.Request
+
+	// RetryDelay is the initial reconnection time.
+	// If zero, it is three seconds.
+	RetryDelay time.Duration
+
+	// MaxRetries is the number of consecutive failed attempts to
+	// connect after which the stream ends. If zero, the stream
+	// keeps trying to reconnect until its context is done.
+	MaxRetries int
+
+	// MaxEventSize is the maximum size of an event.
+	// If zero, the Decoder's default is used.
+	MaxEventSize int
+
+	lastID string
+}
+
+// LastEventID returns the ID of the last event received.
+// It must not be called concurrently with Events.
+func (s *Stream) LastEventID() string {
+	return s.lastID
+}
+
+// Events connects to the server and returns an iterator over the
+// events it sends. Iteration ends when ctx is done, when the stream
+// fails permanently, or when the loop is broken; errors are yielded
+// as the final element.
+func (s *Stream) Events(ctx context.Context) iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		delay := s.RetryDelay
+		if delay <= 0 {
+			delay = defaultRetryDelay
+		}
+		failures := 0
+		for {
+			resp, err := s.connect(ctx)
+			if err == nil {
+				failures = 0
+				if resp == nil {
+					return // 204 No Content
+				}
+				d := NewDecoder(resp.Body)
+				d.lastID = s.lastID
+				if s.MaxEventSize > 0 {
+					d.SetMaxEventSize(s.MaxEventSize)
+				}
+				for {
+					var ev Event
+					ev, err = d.Decode()
+					if err != nil {
+						break
+					}
+					s.lastID = ev.ID
+					if !yield(ev, nil) {
+						resp.Body.Close()
+						return
+					}
+				}
+				resp.Body.Close()
+				s.lastID = d.lastID
+				if d.retry > 0 {
+					delay = d.retry
+				}
+				if err == ErrEventTooLarge {
+					yield(Event{}, err)
+					return
+				}
+			} else {
+				if pe, ok := err.(*permanentError); ok {
+					yield(Event{}, pe.err)
+					return
+				}
+				failures++
+				if s.MaxRetries > 0 && failures > s.MaxRetries {
+					yield(Event{}, err)
+					return
+				}
+			}
+			if ctx.Err() != nil {
+				yield(Event{}, ctx.Err())
+				return
+			}
+
+			t := time.NewTimer(delay)
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				t.Stop()
+				yield(Event{}, ctx.Err())
+				return
+			}
+		}
+	}
+}
+
+// A permanentError is a failure to connect that is not retried.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+
+// connect sends the request. It returns a nil response if the server
+// responded with 204 No Content.
+func (s *Stream) connect(ctx context.Context) (*http.Response, error) {
+	if s.Request == nil {
+		return nil, &permanentError{errors.New("sse: nil Stream.Request")}
+	}
+	req := s.Request.Clone(ctx)
+	if s.Request.Body != nil && s.Request.Body != http.NoBody {
+		if s.Request.GetBody == nil {
+			return nil, &permanentError{errors.New("sse: request with a body has no GetBody")}
+		}
+		body, err := s.Request.GetBody()
+		if err != nil {
+			return nil, &permanentError{err}
+		}
+		req.Body = body
+	}
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+	req.Header.Set("Cache-Control", "no-cache")
+	if s.lastID != "" {
+		req.Header.Set("Last-Event-ID", s.lastID)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNoContent:
+		resp.Body.Close()
+		return nil, nil
+	case resp.StatusCode != http.StatusOK:
+		resp.Body.Close()
+		return nil, &permanentError{errors.New("sse: unexpected response status " + resp.Status)}
+	}
+	if mt, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mt != "text/event-stream" {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+		return nil, &permanentError{errors.New("sse: unexpected content type " + resp.Header.Get("Content-Type"))}
+	}
+	return resp, nil
+}
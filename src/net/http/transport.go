@@ -293,6 +293,8 @@ type Transport struct {
 	h3transport        dialClientConner // non-nil if http3 wired up
 	tlsNextProtoWasNil bool             // whether TLSNextProto was nil when the Once fired
 
+	stats transportStats // for Stats
+
 	// ForceAttemptHTTP2 controls whether HTTP/2 is enabled when a non-zero
 	// Dial, DialTLS, or DialContext func or TLSClientConfig is provided.
 	// By default, use of any those fields conservatively disables HTTP/2.
@@ -585,9 +587,9 @@ func (t *Transport) alternateRoundTripper(req *Request) RoundTripper {
 	return altProto[req.URL.Scheme]
 }
 
-func validateHeaders(hdrs Header) string {
+func validateHeaders(hdrs Header, extendedConnect bool) string {
 	for k, vv := range hdrs {
-		if !httpguts.ValidHeaderFieldName(k) {
+		if !httpguts.ValidHeaderFieldName(k) && (k != ":protocol" || !extendedConnect) {
 			return fmt.Sprintf("field name %q", k)
 		}
 		for _, v := range vv {
@@ -617,15 +619,20 @@ func (t *Transport) roundTrip(req *Request) (_ *Response, err error) {
 	}
 	scheme := req.URL.Scheme
 	isHTTP := scheme == "http" || scheme == "https"
+	// An extended CONNECT request (RFC 8441) names the protocol to
+	// run over the stream in a ":protocol" pseudo-header.
+	// It is only supported over HTTP/2.
+	_, isExtendedConnect := req.Header[":protocol"]
+	isExtendedConnect = isExtendedConnect && req.Method == "CONNECT"
 	if isHTTP {
 		// Validate the outgoing headers.
-		if err := validateHeaders(req.Header); err != "" {
+		if err := validateHeaders(req.Header, isExtendedConnect); err != "" {
 			req.closeBody()
 			return nil, fmt.Errorf("net/http: invalid header %s", err)
 		}
 
 		// Validate the outgoing trailers too.
-		if err := validateHeaders(req.Trailer); err != "" {
+		if err := validateHeaders(req.Trailer, false); err != "" {
 			req.closeBody()
 			return nil, fmt.Errorf("net/http: invalid trailer %s", err)
 		}
@@ -635,10 +642,18 @@ func (t *Transport) roundTrip(req *Request) (_ *Response, err error) {
 	req = setupRewindBody(req)
 
 	if altRT := t.alternateRoundTripper(req); altRT != nil {
-		if resp, err := altRT.RoundTrip(req); err != ErrSkipAltProtocol {
+		var key connectMethodKey
+		if isHTTP {
+			key = connectMethodKey{scheme: scheme, addr: canonicalAddr(req.URL)}
+			t.stats.add(key, hostCounts{inFlight: 1})
+		}
+		resp, err := altRT.RoundTrip(req)
+		if isHTTP {
+			t.stats.add(key, hostCounts{inFlight: -1})
+		}
+		if err != ErrSkipAltProtocol {
 			return resp, err
 		}
-		var err error
 		req, err = rewindBody(req)
 		if err != nil {
 			return nil, err
@@ -705,8 +720,11 @@ func (t *Transport) roundTrip(req *Request) (_ *Response, err error) {
 		// host (for http or https), the http proxy, or the http proxy
 		// pre-CONNECTed to https server. In any case, we'll be ready
 		// to send it requests.
+		key := cm.key()
+		t.stats.add(key, hostCounts{inFlight: 1})
 		pconn, err := t.getConn(treq, cm)
 		if err != nil {
+			t.stats.add(key, hostCounts{inFlight: -1})
 			req.closeBody()
 			return nil, err
 		}
@@ -715,9 +733,15 @@ func (t *Transport) roundTrip(req *Request) (_ *Response, err error) {
 		if pconn.alt != nil {
 			// HTTP/2 path.
 			resp, err = pconn.alt.RoundTrip(req)
+		} else if isExtendedConnect {
+			t.stats.add(key, hostCounts{inFlight: -1})
+			t.putOrCloseIdleConn(pconn)
+			req.closeBody()
+			return nil, errExtendedConnectHTTP1
 		} else {
 			resp, err = pconn.roundTrip(treq)
 		}
+		t.stats.add(key, hostCounts{inFlight: -1})
 		if err == nil {
 			if pconn.alt != nil {
 				// HTTP/2 requests are not cancelable with CancelRequest,
@@ -776,6 +800,8 @@ func awaitLegacyCancel(ctx context.Context, cancel context.CancelCauseFunc, req
 	}
 }
 
+var errExtendedConnectHTTP1 = errors.New("net/http: extended CONNECT requires HTTP/2")
+
 var errCannotRewind = errors.New("net/http: cannot rewind body after connection loss")
 
 type readTrackingBody struct {
@@ -1340,7 +1366,8 @@ func (t *Transport) removeIdleConnLocked(pconn *persistConn) bool {
 
 var zeroDialer net.Dialer
 
-func (t *Transport) dial(ctx context.Context, network, addr string) (net.Conn, error) {
+func (t *Transport) dial(ctx context.Context, network, addr string) (_ net.Conn, err error) {
+	defer t.stats.recordDial(time.Now(), &err)
 	if t.DialContext != nil {
 		c, err := t.DialContext(ctx, network, addr)
 		if c == nil && err == nil {
@@ -1536,6 +1563,7 @@ func (q *wantConnQueue) all(f func(*wantConn)) {
 }
 
 func (t *Transport) customDialTLS(ctx context.Context, network, addr string) (conn net.Conn, err error) {
+	defer t.stats.recordDial(time.Now(), &err)
 	if t.DialTLSContext != nil {
 		conn, err = t.DialTLSContext(ctx, network, addr)
 	} else {
@@ -1581,6 +1609,9 @@ func (t *Transport) getConn(treq *transportRequest, cm connectMethod) (_ *persis
 		}
 	}()
 
+	t.stats.add(w.key, hostCounts{waiting: 1})
+	defer t.stats.add(w.key, hostCounts{waiting: -1})
+
 	// Queue for idle connection.
 	if delivered := t.queueForIdleConn(w); !delivered {
 		t.queueForDial(w)
@@ -1683,7 +1714,14 @@ func (t *Transport) dialConnFor(w *wantConn) {
 	}
 
 	const isClientConn = false
+	t.stats.add(w.key, hostCounts{dialing: 1})
 	pc, err := t.dialConn(ctx, w.cm, isClientConn, nil)
+	if err == nil && pc.alt == nil {
+		pc.counted = true
+		t.stats.add(w.key, hostCounts{dialing: -1, conns: 1})
+	} else {
+		t.stats.add(w.key, hostCounts{dialing: -1})
+	}
 	delivered := w.tryDeliver(pc, err, time.Time{})
 	if err == nil && (!delivered || pc.alt != nil) {
 		// pconn was not passed to w,
@@ -1767,6 +1805,7 @@ func (pconn *persistConn) addTLS(ctx context.Context, name string, trace *httptr
 			errc <- tlsHandshakeTimeoutError{}
 		})
 	}
+	start := time.Now()
 	go func() {
 		if trace != nil && trace.TLSHandshakeStart != nil {
 			trace.TLSHandshakeStart()
@@ -1777,7 +1816,9 @@ func (pconn *persistConn) addTLS(ctx context.Context, name string, trace *httptr
 		}
 		errc <- err
 	}()
-	if err := <-errc; err != nil {
+	err := <-errc
+	pconn.t.stats.recordTLSHandshake(start, err)
+	if err != nil {
 		plainConn.Close()
 		if err == (tlsHandshakeTimeoutError{}) {
 			// Now that we have closed the connection,
@@ -1858,7 +1899,10 @@ func (t *Transport) dialConn(ctx context.Context, cm connectMethod, isClientConn
 			if trace != nil && trace.TLSHandshakeStart != nil {
 				trace.TLSHandshakeStart()
 			}
-			if err := tc.HandshakeContext(ctx); err != nil {
+			start := time.Now()
+			err := tc.HandshakeContext(ctx)
+			t.stats.recordTLSHandshake(start, err)
+			if err != nil {
 				go pconn.conn.Close()
 				if trace != nil && trace.TLSHandshakeDone != nil {
 					trace.TLSHandshakeDone(tls.ConnectionState{}, err)
@@ -2209,6 +2253,7 @@ type persistConn struct {
 	reused               bool   // whether conn has had successful request/response and is being reused.
 	reserved             bool   // ClientConn only: concurrency slot reserved
 	inFlight             bool   // ClientConn only: request is in flight
+	counted              bool   // whether counted in t.stats
 	internalStateHook    func() // ClientConn state hook
 
 	// mutateHeaderFunc is an optional func to modify extra
@@ -2726,6 +2771,20 @@ func (b *readWriteCloserBody) CloseWrite() error {
 	return fmt.Errorf("CloseWrite: %w", ErrNotSupported)
 }
 
+func (b *readWriteCloserBody) SetReadDeadline(t time.Time) error {
+	if d, ok := b.ReadWriteCloser.(interface{ SetReadDeadline(time.Time) error }); ok {
+		return d.SetReadDeadline(t)
+	}
+	return fmt.Errorf("SetReadDeadline: %w", ErrNotSupported)
+}
+
+func (b *readWriteCloserBody) SetWriteDeadline(t time.Time) error {
+	if d, ok := b.ReadWriteCloser.(interface{ SetWriteDeadline(time.Time) error }); ok {
+		return d.SetWriteDeadline(t)
+	}
+	return fmt.Errorf("SetWriteDeadline: %w", ErrNotSupported)
+}
+
 // nothingWrittenError wraps a write errors which ended up writing zero bytes.
 type nothingWrittenError struct {
 	error
@@ -3085,6 +3144,9 @@ func (pc *persistConn) closeLocked(err error) {
 	if pc.closed == nil {
 		pc.closed = err
 		pc.t.decConnsPerHost(pc.cacheKey)
+		if pc.counted {
+			pc.t.stats.add(pc.cacheKey, hostCounts{conns: -1})
+		}
 		// Close HTTP/1 (pc.alt == nil) connection.
 		// HTTP/2 closes its connection itself.
 		// Close HTTP/3 connection if it implements io.Closer.
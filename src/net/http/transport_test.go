@@ -7766,3 +7766,40 @@ func testIssue61474(t *testing.T, mode testMode) {
 		})
 	}
 }
+
+func TestTransportExtendedConnect(t *testing.T) {
+	run(t, testTransportExtendedConnect, []testMode{http1Mode, http2Mode})
+}
+func testTransportExtendedConnect(t *testing.T, mode testMode) {
+	cst := newClientServerTest(t, mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		if r.Method != "CONNECT" || r.Header.Get(":protocol") != "echo" {
+			t.Errorf("request method %q, :protocol %q; want CONNECT, echo", r.Method, r.Header.Get(":protocol"))
+		}
+		w.WriteHeader(200)
+		io.Copy(w, r.Body)
+	}), func(s *Server) {
+		s.HTTP2 = &HTTP2Config{EnableConnectProtocol: true}
+	})
+
+	req, _ := NewRequest("CONNECT", cst.ts.URL, strings.NewReader("hello"))
+	req.Header.Set(":protocol", "echo")
+	res, err := cst.c.Do(req)
+	if mode == http1Mode {
+		if err == nil {
+			res.Body.Close()
+			t.Fatal("extended CONNECT over HTTP/1 succeeded; want error")
+		}
+		return
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "hello" {
+		t.Errorf("response body = %q; want %q", body, "hello")
+	}
+}
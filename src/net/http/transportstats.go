@@ -0,0 +1,227 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"cmp"
+	"net/http/internal/http2"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TransportStats is a snapshot of the connections of a [Transport],
+// and of counters of its connection setup. It is returned by
+// [Transport.Stats].
+//
+// TransportStats may be encoded as JSON, for example to publish it
+// with the expvar package.
+type TransportStats struct {
+	// Hosts describes the connections and requests for each
+	// destination, ordered by Scheme, Addr, and Proxy.
+	Hosts []TransportHostStats
+
+	// Dials is the number of connections dialed, and DialErrors
+	// the number of those that failed. DialTime is the total time
+	// spent dialing; it includes TLS handshakes performed by
+	// Transport.DialTLSContext or DialTLS.
+	Dials      int64
+	DialErrors int64
+	DialTime   time.Duration
+
+	// TLSHandshakes is the number of TLS handshakes performed by
+	// the Transport, and TLSHandshakeErrors the number of those
+	// that failed, including by timing out. TLSHandshakeTime is
+	// the total time spent in them.
+	TLSHandshakes      int64
+	TLSHandshakeErrors int64
+	TLSHandshakeTime   time.Duration
+}
+
+// TransportHostStats describes the connections and requests of a
+// [Transport] for one destination.
+//
+// The counts of connections do not include connections created by
+// [Transport.NewClientConn], which are not pooled.
+type TransportHostStats struct {
+	// Scheme and Addr are the scheme and host:port of the
+	// destination. Proxy is the URL of the proxy used to reach it,
+	// if any.
+	Scheme string
+	Addr   string
+	Proxy  string `json:",omitempty"`
+
+	// IdleConns and ActiveConns are the numbers of HTTP/1
+	// connections in the idle pool and in use.
+	IdleConns   int
+	ActiveConns int
+
+	// DialingConns is the number of connections being dialed.
+	DialingConns int
+
+	// WaitingRequests is the number of requests waiting for a
+	// connection. A persistently high count, with ActiveConns at
+	// Transport.MaxConnsPerHost, indicates pool exhaustion.
+	WaitingRequests int
+
+	// InFlightRequests is the number of requests that have been
+	// started and have not yet received response headers or
+	// failed, including those waiting for a connection.
+	InFlightRequests int
+
+	// HTTP2Conns is the number of HTTP/2 connections, and
+	// HTTP2ActiveStreams and HTTP2MaxConcurrentStreams the total
+	// of their active streams and of the concurrent streams their
+	// peers allow.
+	HTTP2Conns                int
+	HTTP2ActiveStreams        int
+	HTTP2MaxConcurrentStreams int
+}
+
+// transportStats holds the counters reported by Transport.Stats.
+type transportStats struct {
+	dials, dialErrors, dialNanos atomic.Int64
+	tlsHandshakes, tlsErrors     atomic.Int64
+	tlsNanos                     atomic.Int64
+
+	mu    sync.Mutex
+	hosts map[connectMethodKey]*hostCounts
+}
+
+// hostCounts are the counts kept for each destination.
+type hostCounts struct {
+	conns    int // HTTP/1 connections in the pool, idle or active
+	dialing  int
+	waiting  int
+	inFlight int
+}
+
+// add adds d to the counts for key.
+func (s *transportStats) add(key connectMethodKey, d hostCounts) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.hosts[key]
+	if c == nil {
+		if s.hosts == nil {
+			s.hosts = make(map[connectMethodKey]*hostCounts)
+		}
+		c = new(hostCounts)
+		s.hosts[key] = c
+	}
+	c.conns += d.conns
+	c.dialing += d.dialing
+	c.waiting += d.waiting
+	c.inFlight += d.inFlight
+	if *c == (hostCounts{}) {
+		delete(s.hosts, key)
+	}
+}
+
+// recordDial records a dial that began at start and returned *err.
+func (s *transportStats) recordDial(start time.Time, err *error) {
+	s.dials.Add(1)
+	s.dialNanos.Add(int64(time.Since(start)))
+	if *err != nil {
+		s.dialErrors.Add(1)
+	}
+}
+
+// recordTLSHandshake records a TLS handshake that began at start and
+// returned err.
+func (s *transportStats) recordTLSHandshake(start time.Time, err error) {
+	s.tlsHandshakes.Add(1)
+	s.tlsNanos.Add(int64(time.Since(start)))
+	if err != nil {
+		s.tlsErrors.Add(1)
+	}
+}
+
+// Stats returns a snapshot of the connections of t and of counters of
+// its connection setup.
+//
+// The counts of HTTP/2 connections and streams are only available
+// when t uses its built-in HTTP/2 implementation.
+func (t *Transport) Stats() TransportStats {
+	st := TransportStats{
+		Dials:              t.stats.dials.Load(),
+		DialErrors:         t.stats.dialErrors.Load(),
+		DialTime:           time.Duration(t.stats.dialNanos.Load()),
+		TLSHandshakes:      t.stats.tlsHandshakes.Load(),
+		TLSHandshakeErrors: t.stats.tlsErrors.Load(),
+		TLSHandshakeTime:   time.Duration(t.stats.tlsNanos.Load()),
+	}
+	hosts := make(map[connectMethodKey]*TransportHostStats)
+	host := func(key connectMethodKey) *TransportHostStats {
+		key.onlyH1 = false
+		h := hosts[key]
+		if h == nil {
+			h = &TransportHostStats{Scheme: key.scheme, Addr: key.addr, Proxy: key.proxy}
+			hosts[key] = h
+		}
+		return h
+	}
+
+	t.stats.mu.Lock()
+	for key, c := range t.stats.hosts {
+		h := host(key)
+		h.ActiveConns += c.conns
+		h.DialingConns += c.dialing
+		h.WaitingRequests += c.waiting
+		h.InFlightRequests += c.inFlight
+	}
+	t.stats.mu.Unlock()
+
+	t.idleMu.Lock()
+	for key, pconns := range t.idleConn {
+		for _, pc := range pconns {
+			if pc.alt == nil {
+				h := host(key)
+				h.IdleConns++
+				h.ActiveConns--
+			}
+		}
+	}
+	t.idleMu.Unlock()
+
+	t.nextProtoOnce.Do(t.onceSetNextProtoDefaults)
+	if t2, ok := t.h2transport.(*http2.Transport); ok {
+		for addr, states := range t2.ConnStates() {
+			// The HTTP/2 pool is keyed by address alone. Attribute
+			// its connections to an existing entry for the address
+			// if there is one, preferring https.
+			key := connectMethodKey{scheme: "https", addr: addr}
+			if _, ok := hosts[key]; !ok {
+				if _, ok := hosts[connectMethodKey{scheme: "http", addr: addr}]; ok {
+					key.scheme = "http"
+				}
+			}
+			h := host(key)
+			for _, cs := range states {
+				if cs.Closed {
+					continue
+				}
+				h.HTTP2Conns++
+				h.HTTP2ActiveStreams += cs.StreamsActive
+				h.HTTP2MaxConcurrentStreams += int(cs.MaxConcurrentStreams)
+			}
+		}
+	}
+
+	for _, h := range hosts {
+		h.ActiveConns = max(0, h.ActiveConns)
+		if *h != (TransportHostStats{Scheme: h.Scheme, Addr: h.Addr, Proxy: h.Proxy}) {
+			st.Hosts = append(st.Hosts, *h)
+		}
+	}
+	slices.SortFunc(st.Hosts, func(a, b TransportHostStats) int {
+		return cmp.Or(
+			cmp.Compare(a.Scheme, b.Scheme),
+			cmp.Compare(a.Addr, b.Addr),
+			cmp.Compare(a.Proxy, b.Proxy),
+		)
+	})
+	return st
+}
@@ -0,0 +1,155 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http_test
+
+import (
+	"encoding/json"
+	"io"
+	. "net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// hostStats returns the stats for the destination of cst.
+func hostStats(t *testing.T, cst *clientServerTest) (TransportStats, TransportHostStats) {
+	t.Helper()
+	st := cst.tr.Stats()
+	u, _ := url.Parse(cst.ts.URL)
+	for _, h := range st.Hosts {
+		if h.Addr == u.Host {
+			return st, h
+		}
+	}
+	return st, TransportHostStats{}
+}
+
+func TestTransportStatsHTTP1(t *testing.T) {
+	run(t, testTransportStatsHTTP1, []testMode{http1Mode, https1Mode})
+}
+func testTransportStatsHTTP1(t *testing.T, mode testMode) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+	cst := newClientServerTest(t, mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		started <- struct{}{}
+		<-release
+	}))
+	cst.tr.MaxConnsPerHost = 1
+
+	errc := make(chan error, 2)
+	for range 2 {
+		go func() {
+			res, err := cst.c.Get(cst.ts.URL)
+			if err == nil {
+				io.Copy(io.Discard, res.Body)
+				res.Body.Close()
+			}
+			errc <- err
+		}()
+	}
+	<-started
+	waitCondition(t, 10*time.Millisecond, func(d time.Duration) bool {
+		_, h := hostStats(t, cst)
+		want := TransportHostStats{
+			ActiveConns:      1,
+			WaitingRequests:  1,
+			InFlightRequests: 2,
+		}
+		h.Scheme, h.Addr, h.Proxy = "", "", ""
+		if h != want {
+			if d > 0 {
+				t.Logf("stats while blocked = %+v; want %+v", h, want)
+			}
+			return false
+		}
+		return true
+	})
+
+	close(release)
+	for range 2 {
+		if err := <-errc; err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitCondition(t, 10*time.Millisecond, func(d time.Duration) bool {
+		_, h := hostStats(t, cst)
+		if h.IdleConns != 1 || h.ActiveConns != 0 || h.InFlightRequests != 0 || h.WaitingRequests != 0 {
+			if d > 0 {
+				t.Logf("stats when idle = %+v; want 1 idle connection", h)
+			}
+			return false
+		}
+		return true
+	})
+
+	st, _ := hostStats(t, cst)
+	if st.Dials != 1 || st.DialErrors != 0 || st.DialTime <= 0 {
+		t.Errorf("Dials, DialErrors, DialTime = %v, %v, %v; want 1, 0, positive", st.Dials, st.DialErrors, st.DialTime)
+	}
+	if wantTLS := int64(0); mode == https1Mode {
+		wantTLS = 1
+		if st.TLSHandshakes != wantTLS || st.TLSHandshakeTime <= 0 {
+			t.Errorf("TLSHandshakes, TLSHandshakeTime = %v, %v; want 1, positive", st.TLSHandshakes, st.TLSHandshakeTime)
+		}
+	} else if st.TLSHandshakes != 0 {
+		t.Errorf("TLSHandshakes = %v; want 0", st.TLSHandshakes)
+	}
+
+	cst.tr.CloseIdleConnections()
+	if _, h := hostStats(t, cst); h != (TransportHostStats{}) {
+		t.Errorf("after CloseIdleConnections, stats = %+v; want none", h)
+	}
+}
+
+func TestTransportStatsHTTP2(t *testing.T) { run(t, testTransportStatsHTTP2, []testMode{http2Mode}) }
+func testTransportStatsHTTP2(t *testing.T, mode testMode) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	cst := newClientServerTest(t, mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		started <- struct{}{}
+		<-release
+	}))
+
+	errc := make(chan error, 2)
+	for range 2 {
+		go func() {
+			res, err := cst.c.Get(cst.ts.URL)
+			if err == nil {
+				res.Body.Close()
+			}
+			errc <- err
+		}()
+		<-started
+	}
+	_, h := hostStats(t, cst)
+	if h.HTTP2Conns != 1 || h.HTTP2ActiveStreams != 2 || h.HTTP2MaxConcurrentStreams == 0 || h.InFlightRequests != 2 {
+		t.Errorf("stats = %+v; want 1 HTTP/2 connection with 2 active streams and 2 requests in flight", h)
+	}
+	close(release)
+	for range 2 {
+		if err := <-errc; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestTransportStatsDialError(t *testing.T) {
+	tr := &Transport{}
+	defer tr.CloseIdleConnections()
+	c := &Client{Transport: tr}
+	if _, err := c.Get("http://127.0.0.1:1/"); err == nil {
+		t.Fatal("Get succeeded")
+	}
+	st := tr.Stats()
+	if st.Dials != 1 || st.DialErrors != 1 {
+		t.Errorf("Dials, DialErrors = %v, %v; want 1, 1", st.Dials, st.DialErrors)
+	}
+	if len(st.Hosts) != 0 {
+		t.Errorf("Hosts = %+v; want none", st.Hosts)
+	}
+	if _, err := json.Marshal(st); err != nil {
+		t.Errorf("json.Marshal: %v", err)
+	}
+}
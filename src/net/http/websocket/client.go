@@ -0,0 +1,244 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrBadHandshake is returned by [Dial] when the server does not
+// accept the opening handshake.
+var ErrBadHandshake = errors.New("websocket: bad handshake")
+
+// DialOptions configure the client side of the opening handshake.
+type DialOptions struct {
+	// Client is the HTTP client used for the opening handshake.
+	// If nil, http.DefaultClient is used. The client's Timeout
+	// must be zero, since it would also limit the lifetime of the
+	// connection.
+	Client *http.Client
+
+	// Header specifies additional headers to send with the
+	// request, such as Origin or Authorization.
+	Header http.Header
+
+	// Subprotocols lists the subprotocols to offer to the server.
+	Subprotocols []string
+
+	// EnableCompression offers the permessage-deflate extension.
+	EnableCompression bool
+
+	// HTTP2 selects extended CONNECT over HTTP/2, as defined in
+	// RFC 8441, instead of an HTTP/1.1 upgrade. The client's
+	// transport must use HTTP/2 to reach the server, and the
+	// server must support extended CONNECT.
+	HTTP2 bool
+}
+
+// Dial opens a WebSocket connection to the server at urlStr, whose
+// scheme is "ws" or "wss" ("http" and "https" are also accepted).
+//
+// The context applies to the opening handshake only; once Dial
+// returns, canceling it has no effect on the connection.
+//
+// Dial returns the server's response to the handshake. If the
+// handshake fails, Dial returns an error wrapping [ErrBadHandshake]
+// along with the response, whose Body holds up to 1024 bytes of the
+// response body.
+func Dial(ctx context.Context, urlStr string, opts *DialOptions) (*Conn, *http.Response, error) {
+	if opts == nil {
+		opts = &DialOptions{}
+	}
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	case "http", "https":
+	default:
+		return nil, nil, errors.New("websocket: unsupported URL scheme " + u.Scheme)
+	}
+	if u.Fragment != "" {
+		return nil, nil, errors.New("websocket: URL has a fragment")
+	}
+	client := opts.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	// The request outlives ctx: it carries the connection.
+	reqCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(ctx, cancel)
+	fail := func(err error) (*Conn, *http.Response, error) {
+		stop()
+		cancel()
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return nil, nil, err
+	}
+
+	var (
+		req  *http.Request
+		pw   *io.PipeWriter
+		key  string
+		body io.Reader
+	)
+	if opts.HTTP2 {
+		var pr *io.PipeReader
+		pr, pw = io.Pipe()
+		body = pr
+	}
+	req, err = http.NewRequestWithContext(reqCtx, http.MethodGet, u.String(), body)
+	if err != nil {
+		return fail(err)
+	}
+	for k, vv := range opts.Header {
+		req.Header[k] = slices.Clone(vv)
+	}
+	if opts.HTTP2 {
+		req.Method = http.MethodConnect
+		req.Header.Set(":protocol", "websocket")
+	} else {
+		var b [16]byte
+		rand.Read(b[:])
+		key = base64.StdEncoding.EncodeToString(b[:])
+		req.Header.Set("Upgrade", "websocket")
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Sec-WebSocket-Key", key)
+	}
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if len(opts.Subprotocols) > 0 {
+		req.Header.Set("Sec-WebSocket-Protocol", strings.Join(opts.Subprotocols, ", "))
+	}
+	if opts.EnableCompression {
+		req.Header.Set("Sec-WebSocket-Extensions", deflateOffer)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if pw != nil {
+			pw.Close()
+		}
+		return fail(err)
+	}
+	badHandshake := func(msg string) (*Conn, *http.Response, error) {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(b))
+		if pw != nil {
+			pw.Close()
+		}
+		stop()
+		cancel()
+		return nil, resp, errors.Join(ErrBadHandshake, errors.New("websocket: "+msg))
+	}
+	if opts.HTTP2 {
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return badHandshake("unexpected status " + resp.Status)
+		}
+	} else {
+		if resp.StatusCode != http.StatusSwitchingProtocols {
+			return badHandshake("unexpected status " + resp.Status)
+		}
+		if !headerContainsToken(resp.Header, "Upgrade", "websocket") ||
+			!headerContainsToken(resp.Header, "Connection", "upgrade") {
+			return badHandshake("server did not upgrade to websocket")
+		}
+		if resp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+			return badHandshake("invalid Sec-WebSocket-Accept")
+		}
+	}
+	subprotocol := resp.Header.Get("Sec-WebSocket-Protocol")
+	if subprotocol != "" && !slices.Contains(opts.Subprotocols, subprotocol) {
+		return badHandshake("server selected a subprotocol that was not offered")
+	}
+	exts := parseExtensions(resp.Header)
+	if len(exts) > 0 && !opts.EnableCompression {
+		return badHandshake("server accepted an extension that was not offered")
+	}
+	params, compress, err := checkDeflateResponse(exts)
+	if err != nil {
+		return badHandshake(err.Error())
+	}
+	if !stop() {
+		// ctx was canceled during the handshake.
+		resp.Body.Close()
+		return fail(ctx.Err())
+	}
+
+	s := &clientStream{body: resp.Body, pw: pw, cancel: cancel}
+	if rwc, ok := resp.Body.(io.ReadWriteCloser); ok && pw == nil {
+		s.rwc = rwc
+	} else if pw == nil {
+		cancel()
+		resp.Body.Close()
+		return nil, resp, errors.New("websocket: response body is not writable")
+	}
+	c := newConn(s, bufio.NewReader(s), true)
+	c.subprotocol = subprotocol
+	c.compress = compress
+	c.deflate = params
+	return c, resp, nil
+}
+
+// A clientStream is the stream of a client connection: the
+// upgraded connection for HTTP/1.1, or the request and response
+// bodies of an extended CONNECT request.
+type clientStream struct {
+	body   io.ReadCloser
+	rwc    io.ReadWriteCloser // HTTP/1.1
+	pw     *io.PipeWriter     // HTTP/2
+	cancel context.CancelFunc
+}
+
+func (s *clientStream) Read(p []byte) (int, error) {
+	return s.body.Read(p)
+}
+
+func (s *clientStream) Write(p []byte) (int, error) {
+	if s.rwc != nil {
+		return s.rwc.Write(p)
+	}
+	return s.pw.Write(p)
+}
+
+func (s *clientStream) Close() error {
+	if s.pw != nil {
+		s.pw.Close()
+	}
+	err := s.body.Close()
+	s.cancel()
+	return err
+}
+
+func (s *clientStream) SetReadDeadline(t time.Time) error {
+	if d, ok := s.rwc.(deadliner); ok {
+		return d.SetReadDeadline(t)
+	}
+	return errDeadline
+}
+
+func (s *clientStream) SetWriteDeadline(t time.Time) error {
+	if d, ok := s.rwc.(deadliner); ok {
+		return d.SetWriteDeadline(t)
+	}
+	return errDeadline
+}
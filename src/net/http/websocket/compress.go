@@ -0,0 +1,166 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"compress/flate"
+	"errors"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"sync"
+)
+
+// The permessage-deflate extension is defined in RFC 7692.
+//
+// This implementation compresses each message it sends independently
+// of the previous ones ("no context takeover"), which keeps the memory
+// held by idle connections small. It decompresses messages from peers
+// that do use context takeover by keeping the last 32KiB of the
+// messages it received as a dictionary.
+const extPermessageDeflate = "permessage-deflate"
+
+// deflateTail is appended to the payload of a compressed message
+// before decompressing it: the empty stored block that the sender
+// removed (RFC 7692 Section 7.2.2), followed by an empty final block
+// so that the decompressor reports io.EOF.
+var deflateTail = []byte{0x00, 0x00, 0xff, 0xff, 0x01, 0x00, 0x00, 0xff, 0xff}
+
+// maxWindow is the size of the LZ77 window, 2^15 bytes.
+const maxWindow = 1 << 15
+
+var flateWriterPool sync.Pool // of *flate.Writer
+
+func getFlateWriter() *flate.Writer {
+	if fw, ok := flateWriterPool.Get().(*flate.Writer); ok {
+		return fw
+	}
+	fw, _ := flate.NewWriter(nil, flate.BestSpeed)
+	return fw
+}
+
+// An extension is an element of a Sec-WebSocket-Extensions header.
+type extension struct {
+	name   string
+	params []extensionParam
+}
+
+type extensionParam struct {
+	name, value string
+}
+
+// parseExtensions parses the Sec-WebSocket-Extensions headers in h.
+// Malformed elements are skipped.
+func parseExtensions(h http.Header) []extension {
+	var exts []extension
+	for _, v := range h.Values("Sec-WebSocket-Extensions") {
+		for elem := range strings.SplitSeq(v, ",") {
+			parts := strings.Split(elem, ";")
+			ext := extension{name: textproto.TrimString(parts[0])}
+			if !isToken(ext.name) {
+				continue
+			}
+			ok := true
+			for _, p := range parts[1:] {
+				name, value, _ := strings.Cut(p, "=")
+				name = textproto.TrimString(name)
+				value = textproto.TrimString(value)
+				if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+					value = value[1 : len(value)-1]
+				}
+				if !isToken(name) {
+					ok = false
+					break
+				}
+				ext.params = append(ext.params, extensionParam{name, value})
+			}
+			if ok {
+				exts = append(exts, ext)
+			}
+		}
+	}
+	return exts
+}
+
+// deflateParams are the negotiated parameters of permessage-deflate.
+type deflateParams struct {
+	// peerNoContextTakeover reports whether the peer compresses each
+	// message independently, so that no dictionary needs to be kept.
+	peerNoContextTakeover bool
+}
+
+// acceptDeflate picks the first permessage-deflate offer in exts
+// that the server can accept, returning the parameters and the
+// extension to include in the response.
+func acceptDeflate(exts []extension) (deflateParams, string, bool) {
+offers:
+	for _, ext := range exts {
+		if ext.name != extPermessageDeflate {
+			continue
+		}
+		var p deflateParams
+		resp := extPermessageDeflate + "; server_no_context_takeover"
+		for _, param := range ext.params {
+			switch param.name {
+			case "server_no_context_takeover":
+			case "client_no_context_takeover":
+				p.peerNoContextTakeover = true
+				resp += "; client_no_context_takeover"
+			case "server_max_window_bits":
+				// Our compressor uses a full-size window.
+				if param.value != "15" {
+					continue offers
+				}
+			case "client_max_window_bits":
+				// A hint that the client supports the parameter;
+				// the decompressor accepts any window size.
+			default:
+				continue offers
+			}
+		}
+		return p, resp, true
+	}
+	return deflateParams{}, "", false
+}
+
+// deflateOffer is the permessage-deflate offer sent by clients.
+const deflateOffer = extPermessageDeflate + "; client_no_context_takeover"
+
+// checkDeflateResponse checks the extensions accepted by a server in
+// response to deflateOffer.
+func checkDeflateResponse(exts []extension) (deflateParams, bool, error) {
+	var p deflateParams
+	switch {
+	case len(exts) == 0:
+		return p, false, nil
+	case len(exts) > 1 || exts[0].name != extPermessageDeflate:
+		return p, false, errors.New("websocket: server accepted an extension that was not offered")
+	}
+	for _, param := range exts[0].params {
+		switch param.name {
+		case "server_no_context_takeover":
+			p.peerNoContextTakeover = true
+		case "client_no_context_takeover", "server_max_window_bits":
+		default:
+			// client_max_window_bits was not offered, and
+			// must not appear in the response.
+			return p, false, errors.New("websocket: invalid permessage-deflate parameter " + param.name)
+		}
+	}
+	return p, true, nil
+}
+
+// isToken reports whether s is a token as defined by RFC 7230.
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range []byte(s) {
+		if c <= ' ' || c >= 0x7f || strings.IndexByte(`()<>@,;:\"/[]?={}`, c) >= 0 {
+			return false
+		}
+	}
+	return true
+}
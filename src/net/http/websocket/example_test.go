@@ -0,0 +1,56 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket_test
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/http/websocket"
+)
+
+func ExampleAccept() {
+	http.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+		c, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+			EnableCompression: true,
+		})
+		if err != nil {
+			return
+		}
+		defer c.CloseNow()
+		for {
+			typ, msg, err := c.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := c.WriteMessage(typ, msg); err != nil {
+				return
+			}
+		}
+	})
+
+	srv := &http.Server{
+		Addr: ":8080",
+		// Permit WebSocket connections over HTTP/2.
+		HTTP2: &http.HTTP2Config{EnableConnectProtocol: true},
+	}
+	log.Fatal(srv.ListenAndServeTLS("cert.pem", "key.pem"))
+}
+
+func ExampleDial() {
+	c, _, err := websocket.Dial(context.Background(), "wss://example.com/echo", nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := c.WriteMessage(websocket.TextMessage, []byte("hello")); err != nil {
+		log.Fatal(err)
+	}
+	_, msg, err := c.ReadMessage()
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("received %s", msg)
+	c.Close(websocket.StatusNormalClosure, "")
+}
@@ -0,0 +1,163 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+)
+
+// Frame opcodes, from RFC 6455 Section 5.2.
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xa
+)
+
+// Bits of the first two bytes of a frame header.
+const (
+	finBit  = 1 << 7
+	rsv1Bit = 1 << 6
+	rsv2Bit = 1 << 5
+	rsv3Bit = 1 << 4
+	maskBit = 1 << 7
+)
+
+// maxControlPayload is the largest payload of a control frame.
+const maxControlPayload = 125
+
+// maxFrameHeader is the size of the largest frame header.
+const maxFrameHeader = 2 + 8 + 4
+
+// isControl reports whether op is the opcode of a control frame.
+func isControl(op byte) bool { return op&0x8 != 0 }
+
+// A frameHeader is the decoded header of a frame.
+type frameHeader struct {
+	fin     bool
+	rsv1    bool // set on the first frame of a compressed message
+	opcode  byte
+	masked  bool
+	length  int64
+	maskKey [4]byte
+}
+
+// A protocolError is a violation of the WebSocket protocol by the peer.
+// The connection is failed with StatusProtocolError.
+type protocolError string
+
+func (e protocolError) Error() string { return "websocket: protocol error: " + string(e) }
+
+// appendFrameHeader appends the wire form of h to b.
+func appendFrameHeader(b []byte, h frameHeader) []byte {
+	b0 := h.opcode
+	if h.fin {
+		b0 |= finBit
+	}
+	if h.rsv1 {
+		b0 |= rsv1Bit
+	}
+	var b1 byte
+	if h.masked {
+		b1 = maskBit
+	}
+	switch {
+	case h.length <= 125:
+		b = append(b, b0, b1|byte(h.length))
+	case h.length <= 0xffff:
+		b = append(b, b0, b1|126)
+		b = binary.BigEndian.AppendUint16(b, uint16(h.length))
+	default:
+		b = append(b, b0, b1|127)
+		b = binary.BigEndian.AppendUint64(b, uint64(h.length))
+	}
+	if h.masked {
+		b = append(b, h.maskKey[:]...)
+	}
+	return b
+}
+
+// readFrameHeader reads a frame header from r and checks the rules
+// that apply to every frame regardless of the state of the
+// connection.
+func readFrameHeader(r *bufio.Reader) (frameHeader, error) {
+	var h frameHeader
+	var b [8]byte
+	if _, err := io.ReadFull(r, b[:2]); err != nil {
+		return h, err
+	}
+	h.fin = b[0]&finBit != 0
+	h.rsv1 = b[0]&rsv1Bit != 0
+	h.opcode = b[0] & 0xf
+	h.masked = b[1]&maskBit != 0
+	if b[0]&(rsv2Bit|rsv3Bit) != 0 {
+		return h, protocolError("reserved bits set")
+	}
+	switch h.opcode {
+	case opContinuation, opText, opBinary, opClose, opPing, opPong:
+	default:
+		return h, protocolError("unknown opcode")
+	}
+
+	switch n := b[1] &^ maskBit; n {
+	case 126:
+		if _, err := io.ReadFull(r, b[:2]); err != nil {
+			return h, unexpectedEOF(err)
+		}
+		h.length = int64(binary.BigEndian.Uint16(b[:2]))
+	case 127:
+		if _, err := io.ReadFull(r, b[:8]); err != nil {
+			return h, unexpectedEOF(err)
+		}
+		v := binary.BigEndian.Uint64(b[:8])
+		if v>>63 != 0 {
+			return h, protocolError("invalid payload length")
+		}
+		h.length = int64(v)
+	default:
+		h.length = int64(n)
+	}
+	if h.masked {
+		if _, err := io.ReadFull(r, h.maskKey[:]); err != nil {
+			return h, unexpectedEOF(err)
+		}
+	}
+
+	if isControl(h.opcode) {
+		if !h.fin {
+			return h, protocolError("fragmented control frame")
+		}
+		if h.length > maxControlPayload {
+			return h, protocolError("control frame too long")
+		}
+		if h.rsv1 {
+			return h, protocolError("compressed control frame")
+		}
+	}
+	return h, nil
+}
+
+// unexpectedEOF converts io.EOF in the middle of a frame to
+// io.ErrUnexpectedEOF.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// maskBytes applies the masking key to b, which starts at offset pos
+// of a payload. It returns the offset following b.
+func maskBytes(key [4]byte, pos int, b []byte) int {
+	for i := range b {
+		b[i] ^= key[pos&3]
+		pos++
+	}
+	return pos & 3
+}
@@ -0,0 +1,217 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/internal/ascii"
+	"net/textproto"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// keyGUID is the GUID used to compute Sec-WebSocket-Accept.
+const keyGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// acceptKey returns the Sec-WebSocket-Accept value for a
+// Sec-WebSocket-Key.
+func acceptKey(key string) string {
+	h := sha1.Sum([]byte(key + keyGUID))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+// AcceptOptions configure the server side of the opening handshake.
+type AcceptOptions struct {
+	// Subprotocols lists the subprotocols supported by the server,
+	// in order of preference. The first one that the client
+	// offers is selected.
+	Subprotocols []string
+
+	// CheckOrigin reports whether the request may be accepted
+	// based on its Origin header. If nil, requests are accepted
+	// if they have no Origin header or if the host of the origin
+	// matches the Host of the request, which protects against
+	// cross-site WebSocket hijacking by browsers.
+	CheckOrigin func(r *http.Request) bool
+
+	// EnableCompression enables the permessage-deflate extension
+	// if the client offers it.
+	EnableCompression bool
+}
+
+// Accept completes the opening handshake for a WebSocket request and
+// returns the connection.
+//
+// For HTTP/1.1 requests, Accept hijacks the connection. For HTTP/2
+// requests, which use extended CONNECT, the connection is carried by
+// the request's stream, and the handler must not return until it is
+// done with the connection.
+//
+// If the request is not a valid WebSocket request, Accept replies
+// with an HTTP error and returns an error.
+func Accept(w http.ResponseWriter, r *http.Request, opts *AcceptOptions) (*Conn, error) {
+	if opts == nil {
+		opts = &AcceptOptions{}
+	}
+	fail := func(code int, msg string) (*Conn, error) {
+		if code == http.StatusUpgradeRequired {
+			w.Header().Set("Sec-WebSocket-Version", "13")
+		}
+		http.Error(w, http.StatusText(code), code)
+		return nil, errors.New("websocket: " + msg)
+	}
+
+	extendedConnect := r.ProtoMajor >= 2
+	if extendedConnect {
+		if r.Method != http.MethodConnect || r.Header.Get(":protocol") != "websocket" {
+			return fail(http.StatusBadRequest, "not an extended CONNECT request for websocket")
+		}
+	} else {
+		if r.Method != http.MethodGet {
+			return fail(http.StatusMethodNotAllowed, "request method is not GET")
+		}
+		if !headerContainsToken(r.Header, "Connection", "upgrade") ||
+			!headerContainsToken(r.Header, "Upgrade", "websocket") {
+			return fail(http.StatusUpgradeRequired, "request is not a websocket upgrade")
+		}
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		return fail(http.StatusUpgradeRequired, "unsupported version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !extendedConnect {
+		if b, err := base64.StdEncoding.DecodeString(key); err != nil || len(b) != 16 {
+			return fail(http.StatusBadRequest, "invalid Sec-WebSocket-Key")
+		}
+	}
+	checkOrigin := opts.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = sameOrigin
+	}
+	if !checkOrigin(r) {
+		return fail(http.StatusForbidden, "origin not allowed")
+	}
+
+	h := w.Header()
+	subprotocol := selectSubprotocol(r.Header, opts.Subprotocols)
+	if subprotocol != "" {
+		h.Set("Sec-WebSocket-Protocol", subprotocol)
+	}
+	var params deflateParams
+	compress := false
+	if opts.EnableCompression {
+		var ext string
+		params, ext, compress = acceptDeflate(parseExtensions(r.Header))
+		if compress {
+			h.Set("Sec-WebSocket-Extensions", ext)
+		}
+	}
+
+	var c *Conn
+	if extendedConnect {
+		rc := http.NewResponseController(w)
+		w.WriteHeader(http.StatusOK)
+		if err := rc.Flush(); err != nil {
+			return nil, err
+		}
+		s := &serverStream{w: w, body: r.Body, rc: rc}
+		c = newConn(s, bufio.NewReader(r.Body), false)
+		c.flush = rc.Flush
+	} else {
+		h.Set("Upgrade", "websocket")
+		h.Set("Connection", "Upgrade")
+		h.Set("Sec-WebSocket-Accept", acceptKey(key))
+		netConn, brw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			return fail(http.StatusInternalServerError, "hijacking connection: "+err.Error())
+		}
+		// Clear any deadlines set by the Server's timeouts.
+		netConn.SetDeadline(time.Time{})
+		brw.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+		h.Write(brw)
+		brw.WriteString("\r\n")
+		if err := brw.Flush(); err != nil {
+			netConn.Close()
+			return nil, err
+		}
+		c = newConn(netConn, brw.Reader, false)
+	}
+	c.subprotocol = subprotocol
+	c.compress = compress
+	c.deflate = params
+	return c, nil
+}
+
+// A serverStream is the stream of an extended CONNECT request.
+type serverStream struct {
+	w    io.Writer
+	body io.ReadCloser
+	rc   *http.ResponseController
+}
+
+func (s *serverStream) Read(p []byte) (int, error)  { return s.body.Read(p) }
+func (s *serverStream) Write(p []byte) (int, error) { return s.w.Write(p) }
+func (s *serverStream) Close() error                { return s.body.Close() }
+
+func (s *serverStream) SetReadDeadline(t time.Time) error  { return s.rc.SetReadDeadline(t) }
+func (s *serverStream) SetWriteDeadline(t time.Time) error { return s.rc.SetWriteDeadline(t) }
+
+// sameOrigin reports whether the request has no Origin header or
+// an origin whose host matches the request's Host.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return ascii.EqualFold(u.Host, r.Host)
+}
+
+// selectSubprotocol returns the first of the supported subprotocols
+// that is offered in h.
+func selectSubprotocol(h http.Header, supported []string) string {
+	offered := headerTokens(h, "Sec-WebSocket-Protocol")
+	for _, p := range supported {
+		if slices.Contains(offered, p) {
+			return p
+		}
+	}
+	return ""
+}
+
+// headerTokens returns the comma-separated elements of the header
+// field name.
+func headerTokens(h http.Header, name string) []string {
+	var tokens []string
+	for _, v := range h.Values(name) {
+		for t := range strings.SplitSeq(v, ",") {
+			if t = textproto.TrimString(t); t != "" {
+				tokens = append(tokens, t)
+			}
+		}
+	}
+	return tokens
+}
+
+// headerContainsToken reports whether the header field name contains
+// token, compared case-insensitively.
+func headerContainsToken(h http.Header, name, token string) bool {
+	for _, t := range headerTokens(h, name) {
+		if ascii.EqualFold(t, token) {
+			return true
+		}
+	}
+	return false
+}
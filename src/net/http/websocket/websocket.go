@@ -0,0 +1,863 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package websocket implements the WebSocket protocol defined in RFC 6455.
+//
+// Servers upgrade HTTP requests to WebSocket connections with [Accept],
+// and clients open connections with [Dial]. Connections are
+// established with an HTTP/1.1 upgrade or, over HTTP/2, with the
+// extended CONNECT method defined in RFC 8441. HTTP/2 servers must
+// enable extended CONNECT with [net/http.HTTP2Config.EnableConnectProtocol].
+//
+// Messages may be compressed with the permessage-deflate extension
+// defined in RFC 7692 when it is enabled by both peers.
+//
+// A [Conn] supports one concurrent reader and any number of
+// concurrent writers. Control frames are handled while reading:
+// pings are answered, pongs are reported to [Conn.Ping], and a close
+// frame from the peer completes the closing handshake. Applications
+// should therefore keep reading from a connection for as long as it
+// is open.
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// A MessageType is the type of a data message.
+type MessageType int
+
+// Message types.
+const (
+	TextMessage   MessageType = opText   // UTF-8 encoded text
+	BinaryMessage MessageType = opBinary // binary data
+)
+
+func (t MessageType) String() string {
+	switch t {
+	case TextMessage:
+		return "text"
+	case BinaryMessage:
+		return "binary"
+	}
+	return "MessageType(" + strconv.Itoa(int(t)) + ")"
+}
+
+// A StatusCode is the status code of a close frame,
+// as defined in RFC 6455 Section 7.4.
+type StatusCode int
+
+// Status codes. StatusNoStatusReceived and StatusAbnormalClosure are
+// never sent: they describe close frames without a status code and
+// connections that ended without a close frame.
+const (
+	StatusNormalClosure           StatusCode = 1000
+	StatusGoingAway               StatusCode = 1001
+	StatusProtocolError           StatusCode = 1002
+	StatusUnsupportedData         StatusCode = 1003
+	StatusNoStatusReceived        StatusCode = 1005
+	StatusAbnormalClosure         StatusCode = 1006
+	StatusInvalidFramePayloadData StatusCode = 1007
+	StatusPolicyViolation         StatusCode = 1008
+	StatusMessageTooBig           StatusCode = 1009
+	StatusMandatoryExtension      StatusCode = 1010
+	StatusInternalError           StatusCode = 1011
+)
+
+// sendable reports whether code may be sent in a close frame.
+func (code StatusCode) sendable() bool {
+	switch {
+	case 1000 <= code && code <= 1003, 1007 <= code && code <= 1011:
+		return true
+	case 3000 <= code && code <= 4999:
+		// Registered and private use codes.
+		return true
+	}
+	return false
+}
+
+// A CloseError is returned by reads from a [Conn] once the peer has
+// closed the connection. Code is StatusAbnormalClosure if the
+// connection ended without a close frame.
+type CloseError struct {
+	Code   StatusCode
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	s := "websocket: closed with status " + strconv.Itoa(int(e.Code))
+	if e.Reason != "" {
+		s += ": " + e.Reason
+	}
+	return s
+}
+
+var (
+	// ErrClosed is returned by operations on a connection after
+	// it has been closed.
+	ErrClosed = errors.New("websocket: use of closed connection")
+
+	// ErrReadLimit is returned when a message exceeds the limit
+	// set with [Conn.SetReadLimit].
+	ErrReadLimit = errors.New("websocket: message exceeds read limit")
+
+	errInvalidUTF8 = errors.New("websocket: invalid UTF-8 in text message")
+)
+
+const (
+	// defaultReadLimit is the default maximum size of a message.
+	defaultReadLimit = 32 << 20
+
+	// fragmentSize is the payload size of the frames of messages
+	// written with a message writer.
+	fragmentSize = 16 << 10
+
+	// closeTimeout is how long Close waits for the peer's close frame.
+	closeTimeout = 5 * time.Second
+)
+
+// A deadliner is a connection that supports deadlines.
+type deadliner interface {
+	SetReadDeadline(time.Time) error
+	SetWriteDeadline(time.Time) error
+}
+
+// A Conn is a WebSocket connection.
+type Conn struct {
+	rwc         io.ReadWriteCloser
+	br          *bufio.Reader
+	flush       func() error // called after each frame is written, if non-nil
+	deadline    deadliner    // nil if deadlines are not supported
+	client      bool         // frames are masked by the client
+	subprotocol string
+	compress    bool // permessage-deflate was negotiated
+	deflate     deflateParams
+
+	readLimit atomic.Int64
+
+	frameMu  sync.Mutex // serializes frame writes
+	writeBuf []byte     // guarded by frameMu
+	writeErr error      // guarded by frameMu; ErrClosed once a close frame is sent
+
+	msgSem chan struct{} // held by the writer of the current message
+
+	readSem  chan struct{} // held while reading
+	readErr  error         // guarded by readSem
+	rmsg     messageState  // guarded by readSem
+	fr       io.ReadCloser // flate reader, guarded by readSem
+	dict     []byte        // recent decompressed data, guarded by readSem
+	readDone chan struct{} // closed when readErr is set
+
+	pingMu sync.Mutex
+	pings  map[string]chan struct{}
+
+	closeOnce sync.Once
+	closeErr  error
+	closed    chan struct{}
+}
+
+// newConn returns a connection over rwc, reading from br.
+func newConn(rwc io.ReadWriteCloser, br *bufio.Reader, client bool) *Conn {
+	c := &Conn{
+		rwc:      rwc,
+		br:       br,
+		client:   client,
+		msgSem:   make(chan struct{}, 1),
+		readSem:  make(chan struct{}, 1),
+		readDone: make(chan struct{}),
+		pings:    make(map[string]chan struct{}),
+		closed:   make(chan struct{}),
+	}
+	if d, ok := rwc.(deadliner); ok {
+		c.deadline = d
+	}
+	c.readLimit.Store(defaultReadLimit)
+	return c
+}
+
+// Subprotocol returns the subprotocol negotiated during the opening
+// handshake, or "" if none was.
+func (c *Conn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// SetReadLimit sets the maximum size in bytes of a message read from
+// the peer. The size of compressed messages is measured after
+// decompression. When a message exceeds the limit, the connection is
+// closed with StatusMessageTooBig and reads return [ErrReadLimit].
+// The default limit is 32MiB.
+func (c *Conn) SetReadLimit(n int64) {
+	c.readLimit.Store(n)
+}
+
+// SetReadDeadline sets the deadline for reads from the underlying
+// connection. A read that times out leaves the connection in an
+// unknown state; all later reads fail.
+// It returns an error wrapping [errors.ErrUnsupported] if the
+// underlying connection does not support deadlines.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	if c.deadline == nil {
+		return errDeadline
+	}
+	return c.deadline.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the deadline for writes to the underlying
+// connection. A write that times out leaves the connection in an
+// unknown state; all later writes fail.
+// It returns an error wrapping [errors.ErrUnsupported] if the
+// underlying connection does not support deadlines.
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	if c.deadline == nil {
+		return errDeadline
+	}
+	return c.deadline.SetWriteDeadline(t)
+}
+
+var errDeadline = errors.Join(errors.New("websocket: deadlines not supported"), errors.ErrUnsupported)
+
+// writeFrame writes a single frame.
+func (c *Conn) writeFrame(opcode byte, fin, rsv1 bool, payload []byte) error {
+	c.frameMu.Lock()
+	defer c.frameMu.Unlock()
+	if c.writeErr != nil {
+		return c.writeErr
+	}
+	h := frameHeader{
+		fin:    fin,
+		rsv1:   rsv1,
+		opcode: opcode,
+		masked: c.client,
+		length: int64(len(payload)),
+	}
+	if c.client {
+		rand.Read(h.maskKey[:])
+	}
+	b := appendFrameHeader(c.writeBuf[:0], h)
+	start := len(b)
+	b = append(b, payload...)
+	if c.client {
+		maskBytes(h.maskKey, 0, b[start:])
+	}
+	if cap(b) <= maxFrameHeader+fragmentSize {
+		c.writeBuf = b
+	}
+	_, err := c.rwc.Write(b)
+	if err == nil && c.flush != nil {
+		err = c.flush()
+	}
+	if err != nil {
+		c.writeErr = err
+		return err
+	}
+	if opcode == opClose {
+		c.writeErr = ErrClosed
+	}
+	return nil
+}
+
+// writeClose sends a close frame. StatusNoStatusReceived sends a
+// close frame without a status code.
+func (c *Conn) writeClose(code StatusCode, reason string) error {
+	var b []byte
+	if code != StatusNoStatusReceived {
+		b = binary.BigEndian.AppendUint16(make([]byte, 0, 2+len(reason)), uint16(code))
+		b = append(b, reason...)
+	}
+	return c.writeFrame(opClose, true, false, b)
+}
+
+// NextWriter returns a writer for a new message of type typ. The
+// message is sent in fragments as data is written, and is completed
+// by closing the writer. Only one message can be written at a time:
+// NextWriter blocks until the writer of the previous message is
+// closed. Control frames may still be sent while a message is being
+// written.
+func (c *Conn) NextWriter(typ MessageType) (io.WriteCloser, error) {
+	if typ != TextMessage && typ != BinaryMessage {
+		return nil, errors.New("websocket: invalid message type " + typ.String())
+	}
+	select {
+	case c.msgSem <- struct{}{}:
+	case <-c.closed:
+		return nil, ErrClosed
+	}
+	w := &messageWriter{c: c, opcode: byte(typ), compress: c.compress}
+	if c.compress {
+		w.fw = getFlateWriter()
+		w.fw.Reset(flateSink{w})
+	}
+	return w, nil
+}
+
+// WriteMessage writes a message of type typ with payload data.
+func (c *Conn) WriteMessage(typ MessageType, data []byte) error {
+	if c.compress {
+		w, err := c.NextWriter(typ)
+		if err != nil {
+			return err
+		}
+		w.Write(data)
+		return w.Close()
+	}
+	if typ != TextMessage && typ != BinaryMessage {
+		return errors.New("websocket: invalid message type " + typ.String())
+	}
+	select {
+	case c.msgSem <- struct{}{}:
+	case <-c.closed:
+		return ErrClosed
+	}
+	defer func() { <-c.msgSem }()
+	return c.writeFrame(byte(typ), true, false, data)
+}
+
+// A messageWriter writes the frames of a message.
+type messageWriter struct {
+	c        *Conn
+	opcode   byte // opcode of the next frame
+	compress bool
+	fw       *flate.Writer // compressor, if compress is set
+	buf      []byte        // payload not yet sent
+	closed   bool
+	err      error
+}
+
+// flateSink receives the output of a message's compressor.
+type flateSink struct {
+	w *messageWriter
+}
+
+func (s flateSink) Write(p []byte) (int, error) {
+	return s.w.buffer(p)
+}
+
+func (w *messageWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errors.New("websocket: write to closed message writer")
+	}
+	if w.err != nil {
+		return 0, w.err
+	}
+	if w.fw != nil {
+		n, err := w.fw.Write(p)
+		if err == nil {
+			err = w.err
+		}
+		return n, err
+	}
+	return w.buffer(p)
+}
+
+// buffer adds payload data to the message, sending any complete
+// fragments.
+func (w *messageWriter) buffer(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	// Keep back the last four bytes of compressed data: if they are
+	// the end of the final flush, they are removed from the message.
+	reserve := 0
+	if w.fw != nil {
+		reserve = 4
+	}
+	for len(w.buf)-reserve > fragmentSize {
+		if err := w.writeFrame(false, w.buf[:fragmentSize]); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[:copy(w.buf, w.buf[fragmentSize:])]
+	}
+	return len(p), nil
+}
+
+func (w *messageWriter) writeFrame(fin bool, payload []byte) error {
+	rsv1 := w.compress && w.opcode != opContinuation
+	err := w.c.writeFrame(w.opcode, fin, rsv1, payload)
+	w.opcode = opContinuation
+	if err != nil {
+		w.err = err
+	}
+	return err
+}
+
+// Close sends the final frame of the message.
+func (w *messageWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	defer func() { <-w.c.msgSem }()
+	if w.fw != nil {
+		err := w.fw.Flush()
+		w.fw.Reset(io.Discard)
+		flateWriterPool.Put(w.fw)
+		w.fw = nil
+		if err != nil && w.err == nil {
+			w.err = err
+		}
+		if w.err == nil {
+			// RFC 7692 Section 7.2.1: remove the empty block
+			// that ends the flushed data.
+			w.buf = bytes.TrimSuffix(w.buf, deflateTail[:4])
+		}
+	}
+	if w.err != nil {
+		return w.err
+	}
+	return w.writeFrame(true, w.buf)
+}
+
+// Ping sends a ping to the peer and waits for the matching pong.
+// Pongs are received by the goroutine reading from the connection,
+// so Ping does not return until a reader is running.
+func (c *Conn) Ping(ctx context.Context) error {
+	var key [8]byte
+	rand.Read(key[:])
+	ch := make(chan struct{})
+	c.pingMu.Lock()
+	c.pings[string(key[:])] = ch
+	c.pingMu.Unlock()
+	defer func() {
+		c.pingMu.Lock()
+		delete(c.pings, string(key[:]))
+		c.pingMu.Unlock()
+	}()
+
+	if err := c.writeFrame(opPing, true, false, key[:]); err != nil {
+		return err
+	}
+	select {
+	case <-ch:
+		return nil
+	case <-c.readDone:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close performs the closing handshake: it sends a close frame with
+// the given status code and reason, waits for the peer's close frame,
+// and closes the underlying connection. Messages received while
+// waiting are discarded. If the peer does not respond within five
+// seconds, the connection is closed anyway.
+//
+// The reason must be at most 123 bytes long.
+func (c *Conn) Close(code StatusCode, reason string) error {
+	if !code.sendable() {
+		return errors.New("websocket: invalid close status code " + strconv.Itoa(int(code)))
+	}
+	if len(reason) > maxControlPayload-2 {
+		return errors.New("websocket: close reason too long")
+	}
+	err := c.writeClose(code, reason)
+	if err != nil && err != ErrClosed {
+		c.CloseNow()
+		return err
+	}
+	t := time.AfterFunc(closeTimeout, func() { c.CloseNow() })
+	defer t.Stop()
+	select {
+	case c.readSem <- struct{}{}:
+		// No other goroutine is reading: read until the peer's
+		// close frame arrives.
+		for c.readErr == nil {
+			if _, err := c.nextReaderLocked(); err == nil {
+				io.Copy(io.Discard, lockedReader{c})
+			}
+		}
+		<-c.readSem
+	case <-c.readDone:
+	}
+	c.CloseNow()
+	return nil
+}
+
+// CloseNow closes the underlying connection without a closing
+// handshake.
+func (c *Conn) CloseNow() error {
+	c.closeOnce.Do(func() {
+		c.closeErr = c.rwc.Close()
+		close(c.closed)
+	})
+	return c.closeErr
+}
+
+// A messageState is the state of the message being read.
+type messageState struct {
+	gen        uint64 // incremented for each message
+	typ        byte
+	active     bool  // the message has not been read to its end
+	final      bool  // the current frame is the last of the message
+	remaining  int64 // payload bytes left in the current frame
+	masked     bool
+	maskKey    [4]byte
+	maskPos    int
+	compressed bool
+	n          int64 // bytes of the message read so far
+	utf8       utf8Validator
+}
+
+// NextReader waits for the next data message from the peer and
+// returns its type and a reader for its payload. The reader is valid
+// until the next call to NextReader; any part of the message that
+// was not read is discarded.
+//
+// Once the peer closes the connection, NextReader returns a
+// [*CloseError]. All errors are permanent.
+func (c *Conn) NextReader() (MessageType, io.Reader, error) {
+	c.readSem <- struct{}{}
+	defer func() { <-c.readSem }()
+	typ, err := c.nextReaderLocked()
+	if err != nil {
+		return 0, nil, err
+	}
+	return typ, &messageReader{c: c, gen: c.rmsg.gen}, nil
+}
+
+// ReadMessage reads the next data message from the peer.
+func (c *Conn) ReadMessage() (MessageType, []byte, error) {
+	typ, r, err := c.NextReader()
+	if err != nil {
+		return 0, nil, err
+	}
+	b, err := io.ReadAll(r)
+	return typ, b, err
+}
+
+func (c *Conn) nextReaderLocked() (MessageType, error) {
+	if c.readErr != nil {
+		return 0, c.readErr
+	}
+	// Discard the rest of the previous message.
+	if c.rmsg.active {
+		if _, err := io.Copy(io.Discard, lockedReader{c}); err != nil {
+			return 0, err
+		}
+	}
+	h, err := c.nextDataFrame()
+	if err != nil {
+		return 0, err
+	}
+	if h.opcode == opContinuation {
+		return 0, c.fail(StatusProtocolError, protocolError("unexpected continuation frame"))
+	}
+	m := &c.rmsg
+	*m = messageState{
+		gen:        m.gen + 1,
+		typ:        h.opcode,
+		active:     true,
+		compressed: h.rsv1,
+	}
+	if err := c.startFrame(h); err != nil {
+		return 0, err
+	}
+	if m.compressed {
+		src := io.MultiReader(payloadReader{c}, bytes.NewReader(deflateTail))
+		if c.fr == nil {
+			c.fr = flate.NewReader(src)
+		}
+		c.fr.(flate.Resetter).Reset(src, c.dict)
+	}
+	return MessageType(h.opcode), nil
+}
+
+// startFrame starts reading the payload of the data frame h.
+func (c *Conn) startFrame(h frameHeader) error {
+	m := &c.rmsg
+	if !m.compressed && m.n+h.length > c.readLimit.Load() {
+		return c.fail(StatusMessageTooBig, ErrReadLimit)
+	}
+	m.final = h.fin
+	m.remaining = h.length
+	m.masked = h.masked
+	m.maskKey = h.maskKey
+	m.maskPos = 0
+	return nil
+}
+
+// nextDataFrame reads frames until it reads the header of a data
+// frame, handling control frames.
+func (c *Conn) nextDataFrame() (frameHeader, error) {
+	for {
+		h, err := readFrameHeader(c.br)
+		if err != nil {
+			return h, c.readFailed(err)
+		}
+		if h.masked == c.client {
+			if c.client {
+				return h, c.fail(StatusProtocolError, protocolError("masked frame from server"))
+			}
+			return h, c.fail(StatusProtocolError, protocolError("unmasked frame from client"))
+		}
+		if h.rsv1 && !c.compress {
+			return h, c.fail(StatusProtocolError, protocolError("reserved bits set"))
+		}
+		if !isControl(h.opcode) {
+			return h, nil
+		}
+		if err := c.handleControl(h); err != nil {
+			return h, err
+		}
+	}
+}
+
+// handleControl reads the payload of the control frame h and acts
+// on it.
+func (c *Conn) handleControl(h frameHeader) error {
+	var buf [maxControlPayload]byte
+	payload := buf[:h.length]
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return c.readFailed(unexpectedEOF(err))
+	}
+	if h.masked {
+		maskBytes(h.maskKey, 0, payload)
+	}
+	switch h.opcode {
+	case opPing:
+		c.writeFrame(opPong, true, false, payload)
+	case opPong:
+		c.pingMu.Lock()
+		if ch, ok := c.pings[string(payload)]; ok {
+			close(ch)
+			delete(c.pings, string(payload))
+		}
+		c.pingMu.Unlock()
+	case opClose:
+		ce := &CloseError{Code: StatusNoStatusReceived}
+		switch {
+		case len(payload) == 1:
+			return c.fail(StatusProtocolError, protocolError("invalid close frame"))
+		case len(payload) >= 2:
+			ce.Code = StatusCode(binary.BigEndian.Uint16(payload))
+			ce.Reason = string(payload[2:])
+			if !ce.Code.sendable() {
+				return c.fail(StatusProtocolError, protocolError("invalid close status code"))
+			}
+			if !utf8.ValidString(ce.Reason) {
+				return c.fail(StatusInvalidFramePayloadData, errInvalidUTF8)
+			}
+		}
+		// Echo the status code (RFC 6455 Section 5.5.1). If we
+		// sent a close frame first, this fails with ErrClosed.
+		c.writeClose(ce.Code, "")
+		c.setReadErr(ce)
+		c.CloseNow()
+		return ce
+	}
+	return nil
+}
+
+// setReadErr records the error that ends reading.
+func (c *Conn) setReadErr(err error) {
+	if c.readErr == nil {
+		c.readErr = err
+		close(c.readDone)
+	}
+}
+
+// readFailed records a failure to read from the underlying connection.
+func (c *Conn) readFailed(err error) error {
+	if c.readErr != nil {
+		return c.readErr
+	}
+	select {
+	case <-c.closed:
+		err = ErrClosed
+	default:
+		if err == io.EOF {
+			err = &CloseError{Code: StatusAbnormalClosure}
+		}
+	}
+	c.setReadErr(err)
+	return err
+}
+
+// fail fails the connection because the peer sent invalid data: it
+// sends a close frame with code and closes the connection.
+func (c *Conn) fail(code StatusCode, err error) error {
+	if c.readErr != nil {
+		return c.readErr
+	}
+	c.setReadErr(err)
+	c.writeClose(code, "")
+	c.CloseNow()
+	return err
+}
+
+// readPayload reads the payload of the frames of the current message.
+func (c *Conn) readPayload(p []byte) (int, error) {
+	m := &c.rmsg
+	for m.remaining == 0 {
+		if m.final {
+			return 0, io.EOF
+		}
+		h, err := c.nextDataFrame()
+		if err != nil {
+			return 0, err
+		}
+		if h.opcode != opContinuation {
+			return 0, c.fail(StatusProtocolError, protocolError("expected continuation frame"))
+		}
+		if h.rsv1 {
+			return 0, c.fail(StatusProtocolError, protocolError("reserved bits set"))
+		}
+		if err := c.startFrame(h); err != nil {
+			return 0, err
+		}
+	}
+	if int64(len(p)) > m.remaining {
+		p = p[:m.remaining]
+	}
+	n, err := c.br.Read(p)
+	m.remaining -= int64(n)
+	if m.masked {
+		m.maskPos = maskBytes(m.maskKey, m.maskPos, p[:n])
+	}
+	if err != nil {
+		return n, c.readFailed(unexpectedEOF(err))
+	}
+	return n, nil
+}
+
+// payloadReader reads the raw payload of the current message.
+// The caller holds readSem.
+type payloadReader struct {
+	c *Conn
+}
+
+func (r payloadReader) Read(p []byte) (int, error) {
+	return r.c.readPayload(p)
+}
+
+// A messageReader reads a message returned by NextReader.
+type messageReader struct {
+	c   *Conn
+	gen uint64
+}
+
+func (r *messageReader) Read(p []byte) (int, error) {
+	c := r.c
+	c.readSem <- struct{}{}
+	defer func() { <-c.readSem }()
+	if c.rmsg.gen != r.gen {
+		return 0, io.EOF
+	}
+	return c.readLocked(p)
+}
+
+// lockedReader reads the current message. The caller holds readSem.
+type lockedReader struct {
+	c *Conn
+}
+
+func (r lockedReader) Read(p []byte) (int, error) {
+	return r.c.readLocked(p)
+}
+
+// readLocked reads the payload of the current message, after
+// decompressing it.
+func (c *Conn) readLocked(p []byte) (int, error) {
+	m := &c.rmsg
+	if !m.active {
+		return 0, io.EOF
+	}
+	if c.readErr != nil {
+		return 0, c.readErr
+	}
+	var n int
+	var err error
+	if m.compressed {
+		n, err = c.fr.Read(p)
+		if c.readErr != nil {
+			return 0, c.readErr
+		}
+		if err != nil && err != io.EOF {
+			return 0, c.fail(StatusInvalidFramePayloadData, err)
+		}
+		if !c.deflate.peerNoContextTakeover {
+			c.keepDict(p[:n])
+		}
+	} else {
+		n, err = c.readPayload(p)
+	}
+	m.n += int64(n)
+	if m.n > c.readLimit.Load() {
+		return 0, c.fail(StatusMessageTooBig, ErrReadLimit)
+	}
+	if m.typ == opText && !m.utf8.write(p[:n]) {
+		return 0, c.fail(StatusInvalidFramePayloadData, errInvalidUTF8)
+	}
+	if err == io.EOF {
+		if m.typ == opText && !m.utf8.done() {
+			return 0, c.fail(StatusInvalidFramePayloadData, errInvalidUTF8)
+		}
+		m.active = false
+	}
+	return n, err
+}
+
+// keepDict adds decompressed data to the dictionary used to
+// decompress the next message.
+func (c *Conn) keepDict(b []byte) {
+	c.dict = append(c.dict, b...)
+	if len(c.dict) > 2*maxWindow {
+		c.dict = c.dict[:copy(c.dict, c.dict[len(c.dict)-maxWindow:])]
+	}
+}
+
+// A utf8Validator checks that text arriving in pieces is valid UTF-8.
+type utf8Validator struct {
+	buf [utf8.UTFMax]byte // incomplete rune at the end of the last write
+	n   int
+}
+
+// write reports whether p continues a valid UTF-8 sequence.
+func (v *utf8Validator) write(p []byte) bool {
+	if v.n > 0 {
+		k := copy(v.buf[v.n:], p)
+		b := v.buf[:v.n+k]
+		if !utf8.FullRune(b) {
+			v.n += k
+			return true
+		}
+		r, size := utf8.DecodeRune(b)
+		if r == utf8.RuneError && size == 1 {
+			return false
+		}
+		p = p[size-v.n:]
+		v.n = 0
+	}
+	for len(p) > 0 {
+		if p[0] < utf8.RuneSelf {
+			p = p[1:]
+			continue
+		}
+		if !utf8.FullRune(p) {
+			v.n = copy(v.buf[:], p)
+			return true
+		}
+		r, size := utf8.DecodeRune(p)
+		if r == utf8.RuneError && size == 1 {
+			return false
+		}
+		p = p[size:]
+	}
+	return true
+}
+
+// done reports whether the text ended on a rune boundary.
+func (v *utf8Validator) done() bool {
+	return v.n == 0
+}
@@ -0,0 +1,535 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFrameHeader(t *testing.T) {
+	for _, h := range []frameHeader{
+		{fin: true, opcode: opText, length: 0},
+		{fin: true, opcode: opBinary, length: 125},
+		{fin: false, opcode: opText, rsv1: true, length: 126},
+		{fin: true, opcode: opContinuation, length: 0xffff},
+		{fin: true, opcode: opBinary, length: 0x10000, masked: true, maskKey: [4]byte{1, 2, 3, 4}},
+		{fin: true, opcode: opPing, length: 4, masked: true, maskKey: [4]byte{5, 6, 7, 8}},
+	} {
+		b := appendFrameHeader(nil, h)
+		got, err := readFrameHeader(bufio.NewReader(bytes.NewReader(b)))
+		if err != nil {
+			t.Errorf("readFrameHeader(%+v): %v", h, err)
+			continue
+		}
+		if got != h {
+			t.Errorf("round trip of %+v = %+v", h, got)
+		}
+	}
+}
+
+func TestReadFrameHeaderErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		b    []byte
+	}{
+		{"rsv2", []byte{finBit | rsv2Bit | opText, 0}},
+		{"unknown opcode", []byte{finBit | 0x3, 0}},
+		{"fragmented control", []byte{opPing, 0}},
+		{"long control", []byte{finBit | opPing, 126, 0, 126}},
+		{"compressed control", []byte{finBit | rsv1Bit | opPong, 0}},
+		{"length msb", []byte{finBit | opBinary, 127, 0x80, 0, 0, 0, 0, 0, 0, 0}},
+	} {
+		_, err := readFrameHeader(bufio.NewReader(bytes.NewReader(tt.b)))
+		if _, ok := err.(protocolError); !ok {
+			t.Errorf("%s: readFrameHeader = %v; want protocol error", tt.name, err)
+		}
+	}
+	_, err := readFrameHeader(bufio.NewReader(bytes.NewReader([]byte{finBit | opBinary, 126, 0})))
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("truncated header: %v; want %v", err, io.ErrUnexpectedEOF)
+	}
+}
+
+func TestUTF8Validator(t *testing.T) {
+	for _, tt := range []struct {
+		chunks []string
+		ok     bool
+	}{
+		{[]string{"hello, ", "世界"}, true},
+		{[]string{"\xe4", "\xb8", "\x96"}, true},
+		{[]string{"a\xf0\x9f", "\x98\x80b"}, true},
+		{[]string{"\xe4\xb8"}, false},
+		{[]string{"\xff"}, false},
+		{[]string{"\xed", "\xa0\x80"}, false}, // surrogate
+		{[]string{"ok\xe4", "x"}, false},
+	} {
+		var v utf8Validator
+		ok := true
+		for _, c := range tt.chunks {
+			ok = ok && v.write([]byte(c))
+		}
+		ok = ok && v.done()
+		if ok != tt.ok {
+			t.Errorf("validating %q = %v; want %v", tt.chunks, ok, tt.ok)
+		}
+	}
+}
+
+func TestAcceptDeflate(t *testing.T) {
+	for _, tt := range []struct {
+		offer      string
+		resp       string
+		noTakeover bool
+	}{
+		{"permessage-deflate", "permessage-deflate; server_no_context_takeover", false},
+		{"permessage-deflate; client_max_window_bits", "permessage-deflate; server_no_context_takeover", false},
+		{"permessage-deflate; client_no_context_takeover", "permessage-deflate; server_no_context_takeover; client_no_context_takeover", true},
+		{"permessage-deflate; server_max_window_bits=10, permessage-deflate", "permessage-deflate; server_no_context_takeover", false},
+		{`permessage-deflate; server_max_window_bits="15"`, "permessage-deflate; server_no_context_takeover", false},
+		{"x-webkit-deflate-frame", "", false},
+		{"permessage-deflate; unknown", "", false},
+	} {
+		h := http.Header{"Sec-Websocket-Extensions": {tt.offer}}
+		p, resp, ok := acceptDeflate(parseExtensions(h))
+		if resp != tt.resp || ok != (tt.resp != "") || p.peerNoContextTakeover != tt.noTakeover {
+			t.Errorf("acceptDeflate(%q) = %+v, %q, %v; want %q", tt.offer, p, resp, ok, tt.resp)
+		}
+	}
+}
+
+// echoHandler accepts WebSocket connections and echoes the messages
+// it receives until the connection is closed.
+func echoHandler(t *testing.T, opts *AcceptOptions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, err := Accept(w, r, opts)
+		if err != nil {
+			return
+		}
+		defer c.CloseNow()
+		for {
+			typ, r, err := c.NextReader()
+			if err != nil {
+				return
+			}
+			w, err := c.NextWriter(typ)
+			if err != nil {
+				return
+			}
+			if _, err := io.Copy(w, r); err != nil {
+				return
+			}
+			if err := w.Close(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func newServer(t *testing.T, h http.Handler, http2 bool) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewUnstartedServer(h)
+	if http2 {
+		ts.EnableHTTP2 = true
+		ts.Config.HTTP2 = &http.HTTP2Config{EnableConnectProtocol: true}
+		ts.StartTLS()
+	} else {
+		ts.Start()
+	}
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func dial(t *testing.T, ts *httptest.Server, opts *DialOptions) *Conn {
+	t.Helper()
+	if opts == nil {
+		opts = &DialOptions{}
+	}
+	opts.Client = ts.Client()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c, resp, err := Dial(ctx, "ws"+strings.TrimPrefix(ts.URL, "http"), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.HTTP2 && resp.ProtoMajor != 2 {
+		t.Errorf("handshake used %s; want HTTP/2", resp.Proto)
+	}
+	t.Cleanup(func() { c.CloseNow() })
+	return c
+}
+
+func TestEcho(t *testing.T) {
+	run := func(t *testing.T, http2, compress bool) {
+		ts := newServer(t, echoHandler(t, &AcceptOptions{
+			Subprotocols:      []string{"chat.v2", "chat.v1"},
+			EnableCompression: true,
+		}), http2)
+		c := dial(t, ts, &DialOptions{
+			HTTP2:             http2,
+			Subprotocols:      []string{"chat.v1", "chat.v2"},
+			EnableCompression: compress,
+		})
+		if got := c.Subprotocol(); got != "chat.v2" {
+			t.Errorf("Subprotocol = %q; want %q", got, "chat.v2")
+		}
+		if c.compress != compress {
+			t.Errorf("compression negotiated = %v; want %v", c.compress, compress)
+		}
+
+		large := bytes.Repeat([]byte("0123456789abcdef"), 3*fragmentSize/16+7)
+		for _, msg := range []struct {
+			typ  MessageType
+			data []byte
+		}{
+			{TextMessage, []byte("hello, world")},
+			{BinaryMessage, []byte{0, 1, 2, 0xff}},
+			{TextMessage, nil},
+			{BinaryMessage, large},
+			{TextMessage, []byte("hello again, world")},
+		} {
+			if err := c.WriteMessage(msg.typ, msg.data); err != nil {
+				t.Fatal(err)
+			}
+			typ, data, err := c.ReadMessage()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if typ != msg.typ || !bytes.Equal(data, msg.data) {
+				t.Fatalf("echo of %v message of %d bytes = %v message of %d bytes", msg.typ, len(msg.data), typ, len(data))
+			}
+		}
+
+		// A message written in pieces is fragmented.
+		w, err := c.NextWriter(TextMessage)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for range 3 * fragmentSize / 8 {
+			io.WriteString(w, "fragment")
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		_, data, err := c.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := strings.Repeat("fragment", 3*fragmentSize/8); string(data) != want {
+			t.Errorf("echo of fragmented message has %d bytes; want %d", len(data), len(want))
+		}
+
+		if err := c.Close(StatusNormalClosure, ""); err != nil {
+			t.Errorf("Close: %v", err)
+		}
+	}
+	for _, http2 := range []bool{false, true} {
+		for _, compress := range []bool{false, true} {
+			name := "HTTP1"
+			if http2 {
+				name = "HTTP2"
+			}
+			if compress {
+				name += "/compressed"
+			}
+			t.Run(name, func(t *testing.T) { run(t, http2, compress) })
+		}
+	}
+}
+
+func TestPing(t *testing.T) {
+	for _, http2 := range []bool{false, true} {
+		ts := newServer(t, echoHandler(t, nil), http2)
+		c := dial(t, ts, &DialOptions{HTTP2: http2})
+		go c.ReadMessage()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := c.Ping(ctx); err != nil {
+			t.Errorf("Ping (HTTP2=%v): %v", http2, err)
+		}
+		cancel()
+	}
+}
+
+func TestCloseHandshake(t *testing.T) {
+	done := make(chan error, 1)
+	ts := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := Accept(w, r, nil)
+		if err != nil {
+			done <- err
+			return
+		}
+		_, _, err = c.ReadMessage()
+		done <- err
+	}), false)
+	c := dial(t, ts, nil)
+	if err := c.Close(StatusGoingAway, "bye"); err != nil {
+		t.Fatal(err)
+	}
+	err := <-done
+	var ce *CloseError
+	if !errors.As(err, &ce) || ce.Code != StatusGoingAway || ce.Reason != "bye" {
+		t.Errorf("server read %v; want close with status %d", err, StatusGoingAway)
+	}
+	if _, _, err := c.ReadMessage(); err == nil {
+		t.Errorf("ReadMessage after Close succeeded")
+	}
+	if err := c.WriteMessage(TextMessage, []byte("x")); err == nil {
+		t.Errorf("WriteMessage after Close succeeded")
+	}
+}
+
+func TestAcceptErrors(t *testing.T) {
+	ts := newServer(t, echoHandler(t, nil), false)
+
+	// A cross-origin request is refused.
+	_, resp, err := Dial(context.Background(), "ws"+strings.TrimPrefix(ts.URL, "http"), &DialOptions{
+		Header: http.Header{"Origin": {"https://attacker.example"}},
+	})
+	if !errors.Is(err, ErrBadHandshake) || resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("cross-origin Dial: %v; want %v with status 403", err, ErrBadHandshake)
+	}
+
+	// A plain request is refused.
+	resp, err = http.Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUpgradeRequired || resp.Header.Get("Sec-WebSocket-Version") != "13" {
+		t.Errorf("plain GET: status %d, version %q", resp.StatusCode, resp.Header.Get("Sec-WebSocket-Version"))
+	}
+}
+
+func TestDialHTTP2Unsupported(t *testing.T) {
+	// Extended CONNECT is not enabled on the server.
+	ts := httptest.NewUnstartedServer(echoHandler(t, nil))
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+	defer ts.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, _, err := Dial(ctx, "wss"+strings.TrimPrefix(ts.URL, "https"), &DialOptions{
+		Client: ts.Client(),
+		HTTP2:  true,
+	})
+	if err == nil {
+		t.Fatal("Dial succeeded; want error")
+	}
+}
+
+// pipeConn returns a server connection and the client end of its
+// underlying transport.
+func pipeConn(t *testing.T) (*Conn, net.Conn) {
+	c1, c2 := net.Pipe()
+	c := newConn(c1, bufio.NewReader(c1), false)
+	t.Cleanup(func() {
+		c.CloseNow()
+		c2.Close()
+	})
+	return c, c2
+}
+
+// writeRawFrame writes a masked frame, as a client would.
+func writeRawFrame(w io.Writer, b0 byte, payload []byte) {
+	h := frameHeader{
+		fin:     b0&finBit != 0,
+		rsv1:    b0&rsv1Bit != 0,
+		opcode:  b0 & 0xf,
+		masked:  true,
+		length:  int64(len(payload)),
+		maskKey: [4]byte{0x12, 0x34, 0x56, 0x78},
+	}
+	b := appendFrameHeader(nil, h)
+	p := bytes.Clone(payload)
+	maskBytes(h.maskKey, 0, p)
+	w.Write(append(b, p...))
+}
+
+// readCloseCode reads frames from r until a close frame and returns
+// its status code.
+func readCloseCode(t *testing.T, r io.Reader) StatusCode {
+	t.Helper()
+	br := bufio.NewReader(r)
+	for {
+		h, err := readFrameHeader(br)
+		if err != nil {
+			t.Fatal(err)
+		}
+		payload := make([]byte, h.length)
+		if _, err := io.ReadFull(br, payload); err != nil {
+			t.Fatal(err)
+		}
+		if h.opcode == opClose {
+			if len(payload) < 2 {
+				return StatusNoStatusReceived
+			}
+			return StatusCode(int(payload[0])<<8 | int(payload[1]))
+		}
+	}
+}
+
+func TestProtocolErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		write func(w io.Writer)
+		code  StatusCode
+		err   error
+	}{{
+		name: "unmasked",
+		write: func(w io.Writer) {
+			w.Write(appendFrameHeader(nil, frameHeader{fin: true, opcode: opText}))
+		},
+		code: StatusProtocolError,
+	}, {
+		name: "continuation first",
+		write: func(w io.Writer) {
+			writeRawFrame(w, finBit|opContinuation, []byte("x"))
+		},
+		code: StatusProtocolError,
+	}, {
+		name: "interleaved messages",
+		write: func(w io.Writer) {
+			writeRawFrame(w, opText, []byte("a"))
+			writeRawFrame(w, finBit|opText, []byte("b"))
+		},
+		code: StatusProtocolError,
+	}, {
+		name: "uncompressed connection",
+		write: func(w io.Writer) {
+			writeRawFrame(w, finBit|rsv1Bit|opText, []byte("x"))
+		},
+		code: StatusProtocolError,
+	}, {
+		name: "invalid UTF-8",
+		write: func(w io.Writer) {
+			writeRawFrame(w, opText, []byte("ok\xe4"))
+			writeRawFrame(w, finBit|opContinuation, []byte("x"))
+		},
+		code: StatusInvalidFramePayloadData,
+		err:  errInvalidUTF8,
+	}, {
+		name: "invalid close code",
+		write: func(w io.Writer) {
+			writeRawFrame(w, finBit|opClose, []byte{0x03, 0xed}) // 1005
+		},
+		code: StatusProtocolError,
+	}, {
+		name: "read limit",
+		write: func(w io.Writer) {
+			writeRawFrame(w, finBit|opBinary, make([]byte, 101))
+		},
+		code: StatusMessageTooBig,
+		err:  ErrReadLimit,
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			c, peer := pipeConn(t)
+			c.SetReadLimit(100)
+			peer.SetDeadline(time.Now().Add(10 * time.Second))
+			go tt.write(peer)
+			errc := make(chan error, 1)
+			go func() {
+				_, _, err := c.ReadMessage()
+				errc <- err
+			}()
+			if code := readCloseCode(t, peer); code != tt.code {
+				t.Errorf("close status %d; want %d", code, tt.code)
+			}
+			err := <-errc
+			if err == nil {
+				t.Fatal("ReadMessage succeeded")
+			}
+			if tt.err != nil && err != tt.err {
+				t.Errorf("ReadMessage: %v; want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestControlFrames(t *testing.T) {
+	c, peer := pipeConn(t)
+	peer.SetDeadline(time.Now().Add(10 * time.Second))
+	go func() {
+		// A ping between the fragments of a message.
+		writeRawFrame(peer, opText, []byte("hel"))
+		writeRawFrame(peer, finBit|opPing, []byte("are you there?"))
+		writeRawFrame(peer, finBit|opContinuation, []byte("lo"))
+	}()
+	msgc := make(chan string, 1)
+	go func() {
+		_, b, err := c.ReadMessage()
+		if err != nil {
+			t.Error(err)
+		}
+		msgc <- string(b)
+	}()
+
+	br := bufio.NewReader(peer)
+	h, err := readFrameHeader(br)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := make([]byte, h.length)
+	io.ReadFull(br, payload)
+	if h.opcode != opPong || string(payload) != "are you there?" || h.masked {
+		t.Errorf("reply to ping: opcode %d, masked %v, payload %q", h.opcode, h.masked, payload)
+	}
+	if msg := <-msgc; msg != "hello" {
+		t.Errorf("message = %q; want %q", msg, "hello")
+	}
+
+	// The peer starts the closing handshake.
+	go writeRawFrame(peer, finBit|opClose, []byte{0x03, 0xe8, 'o', 'k'})
+	errc := make(chan error, 1)
+	go func() {
+		_, _, err := c.ReadMessage()
+		errc <- err
+	}()
+	if code := readCloseCode(t, br); code != StatusNormalClosure {
+		t.Errorf("close reply has status %d; want %d", code, StatusNormalClosure)
+	}
+	var ce *CloseError
+	if err := <-errc; !errors.As(err, &ce) || ce.Code != StatusNormalClosure || ce.Reason != "ok" {
+		t.Errorf("ReadMessage: %v; want close with status %d", err, StatusNormalClosure)
+	}
+}
+
+func TestCompressionContextTakeover(t *testing.T) {
+	// The peer compresses messages with a shared window: the second
+	// message refers to the first.
+	c, peer := pipeConn(t)
+	c.compress = true
+	peer.SetDeadline(time.Now().Add(10 * time.Second))
+
+	var buf bytes.Buffer
+	fw := getFlateWriter()
+	fw.Reset(&buf)
+	msgs := []string{"a message that repeats", "a message that repeats"}
+	go func() {
+		for _, m := range msgs {
+			buf.Reset()
+			io.WriteString(fw, m)
+			fw.Flush()
+			writeRawFrame(peer, finBit|rsv1Bit|opText, bytes.TrimSuffix(buf.Bytes(), deflateTail[:4]))
+		}
+	}()
+	for _, m := range msgs {
+		_, b, err := c.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != m {
+			t.Errorf("message = %q; want %q", b, m)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"context"
+	"internal/strconv"
+	"iter"
+)
+
+// An InterfaceEventType is the kind of change reported by an
+// [InterfaceEvent].
+type InterfaceEventType int
+
+const (
+	// InterfaceUp reports that an interface came up, or that
+	// an interface that is up was added.
+	InterfaceUp InterfaceEventType = iota + 1
+
+	// InterfaceDown reports that an interface went down, or that
+	// an interface that was up was removed.
+	InterfaceDown
+
+	// InterfaceAddrAdded reports that a unicast address was
+	// assigned to an interface.
+	InterfaceAddrAdded
+
+	// InterfaceAddrRemoved reports that a unicast address was
+	// removed from an interface.
+	InterfaceAddrRemoved
+)
+
+var interfaceEventTypes = [...]string{
+	InterfaceUp:          "up",
+	InterfaceDown:        "down",
+	InterfaceAddrAdded:   "addr added",
+	InterfaceAddrRemoved: "addr removed",
+}
+
+func (t InterfaceEventType) String() string {
+	if t > 0 && int(t) < len(interfaceEventTypes) {
+		return interfaceEventTypes[t]
+	}
+	return "InterfaceEventType(" + strconv.Itoa(int(t)) + ")"
+}
+
+// An InterfaceEvent reports a change to a network interface.
+type InterfaceEvent struct {
+	Type InterfaceEventType
+
+	// Interface describes the interface after the change.
+	// For address events on an interface that has not been
+	// seen otherwise, only the Index field is set.
+	Interface Interface
+
+	// Addr is the address that was added or removed, for
+	// InterfaceAddrAdded and InterfaceAddrRemoved events.
+	// It is an *IPNet, as in the results of [Interface.Addrs].
+	Addr Addr
+}
+
+func (ev InterfaceEvent) String() string {
+	name := ev.Interface.Name
+	if name == "" {
+		name = strconv.Itoa(ev.Interface.Index)
+	}
+	s := name + " " + ev.Type.String()
+	if ev.Addr != nil {
+		s += " " + ev.Addr.String()
+	}
+	return s
+}
+
+// WatchInterfaces returns an iterator over changes to the system's
+// network interfaces: interfaces going up and down, and unicast
+// addresses being added and removed.
+//
+// Iteration begins with an InterfaceUp event for each interface that
+// is up and an InterfaceAddrAdded event for each address, describing
+// the state when the iteration started. Events for later changes
+// follow. If the system drops notifications, for instance because
+// the caller does not keep up with them, the state is read again and
+// the differences are reported as events, so that the sequence of
+// events always describes the current state.
+//
+// Iteration stops when ctx is done. If watching fails, the iterator
+// yields the error and stops.
+//
+// WatchInterfaces is supported on Linux only. On other platforms the
+// iterator yields an error wrapping [errors.ErrUnsupported].
+func WatchInterfaces(ctx context.Context) iter.Seq2[InterfaceEvent, error] {
+	return func(yield func(InterfaceEvent, error) bool) {
+		err := watchInterfaces(ctx, func(ev InterfaceEvent) bool {
+			return yield(ev, nil)
+		})
+		if err != nil && ctx.Err() == nil {
+			yield(InterfaceEvent{}, &OpError{Op: "route", Net: "ip+net", Source: nil, Addr: nil, Err: err})
+		}
+	}
+}
@@ -0,0 +1,310 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"syscall"
+	"unsafe"
+)
+
+// Multicast groups of NETLINK_ROUTE; see linux/rtnetlink.h.
+const (
+	_RTMGRP_LINK        = 0x1
+	_RTMGRP_IPV4_IFADDR = 0x10
+	_RTMGRP_IPV6_IFADDR = 0x100
+)
+
+// A routeSource supplies the messages from which interface events
+// are derived. It is implemented by a netlink socket, and by fakes
+// in tests.
+type routeSource interface {
+	// dump returns RTM_NEWLINK and RTM_NEWADDR messages describing
+	// all interfaces and addresses.
+	dump() ([]syscall.NetlinkMessage, error)
+
+	// read returns the next batch of notifications. It returns
+	// syscall.ENOBUFS if notifications were dropped.
+	read() ([]syscall.NetlinkMessage, error)
+
+	// close unblocks any read in progress and releases the source.
+	// It may be called more than once, and concurrently with read.
+	close() error
+}
+
+func watchInterfaces(ctx context.Context, yield func(InterfaceEvent) bool) error {
+	src, err := newNetlinkRouteSource()
+	if err != nil {
+		return err
+	}
+	return watchRoute(ctx, src, yield)
+}
+
+// watchRoute yields the events derived from the messages of src
+// until ctx is done, yield returns false, or src fails.
+func watchRoute(ctx context.Context, src routeSource, yield func(InterfaceEvent) bool) error {
+	defer src.close()
+	stop := context.AfterFunc(ctx, func() { src.close() })
+	defer stop()
+
+	var w interfaceWatcher
+	resync := true
+	for {
+		var events []InterfaceEvent
+		if resync {
+			msgs, err := src.dump()
+			if err != nil {
+				return err
+			}
+			events = w.sync(msgs)
+			resync = false
+		} else {
+			msgs, err := src.read()
+			if errors.Is(err, syscall.ENOBUFS) {
+				// Notifications were lost; the dump will
+				// tell what changed in the meantime.
+				resync = true
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			events = w.update(msgs, nil)
+		}
+		for _, ev := range events {
+			if !yield(ev) {
+				return nil
+			}
+		}
+	}
+}
+
+// An ifaddrKey identifies an address of an interface.
+type ifaddrKey struct {
+	index int
+	addr  string
+}
+
+// An interfaceWatcher tracks the state of interfaces as described
+// by netlink messages, and reports changes to it as events.
+type interfaceWatcher struct {
+	links map[int]Interface
+	addrs map[ifaddrKey]Addr
+}
+
+// update applies msgs to the state and appends the resulting
+// events to events.
+func (w *interfaceWatcher) update(msgs []syscall.NetlinkMessage, events []InterfaceEvent) []InterfaceEvent {
+	if w.links == nil {
+		w.links = make(map[int]Interface)
+		w.addrs = make(map[ifaddrKey]Addr)
+	}
+	for i := range msgs {
+		m := &msgs[i]
+		switch m.Header.Type {
+		case syscall.RTM_NEWLINK, syscall.RTM_DELLINK:
+			if len(m.Data) < syscall.SizeofIfInfomsg {
+				continue
+			}
+			ifim := (*syscall.IfInfomsg)(unsafe.Pointer(&m.Data[0]))
+			attrs, err := syscall.ParseNetlinkRouteAttr(m)
+			if err != nil {
+				continue
+			}
+			ifi := *newLink(ifim, attrs)
+			// The attributes refer to the message buffer,
+			// which may be reused.
+			ifi.HardwareAddr = slices.Clone(ifi.HardwareAddr)
+			prev, ok := w.links[ifi.Index]
+			if ifi.Name == "" {
+				ifi.Name = prev.Name
+			}
+			wasUp := ok && prev.Flags&FlagUp != 0
+			if m.Header.Type == syscall.RTM_DELLINK {
+				events = w.removeAddrs(ifi, events)
+				delete(w.links, ifi.Index)
+				ifi.Flags &^= FlagUp | FlagRunning
+			} else {
+				w.links[ifi.Index] = ifi
+			}
+			switch isUp := ifi.Flags&FlagUp != 0; {
+			case isUp && !wasUp:
+				events = append(events, InterfaceEvent{Type: InterfaceUp, Interface: ifi})
+			case !isUp && wasUp:
+				events = append(events, InterfaceEvent{Type: InterfaceDown, Interface: ifi})
+			}
+		case syscall.RTM_NEWADDR, syscall.RTM_DELADDR:
+			if len(m.Data) < syscall.SizeofIfAddrmsg {
+				continue
+			}
+			ifam := (*syscall.IfAddrmsg)(unsafe.Pointer(&m.Data[0]))
+			attrs, err := syscall.ParseNetlinkRouteAttr(m)
+			if err != nil {
+				continue
+			}
+			ifa := newAddr(ifam, attrs)
+			if ifa == nil {
+				continue
+			}
+			index := int(ifam.Index)
+			key := ifaddrKey{index, ifa.String()}
+			_, had := w.addrs[key]
+			ifi, ok := w.links[index]
+			if !ok {
+				ifi = Interface{Index: index}
+			}
+			if m.Header.Type == syscall.RTM_NEWADDR {
+				if !had {
+					// The kernel also sends RTM_NEWADDR
+					// when an address's properties change.
+					w.addrs[key] = ifa
+					events = append(events, InterfaceEvent{Type: InterfaceAddrAdded, Interface: ifi, Addr: ifa})
+				}
+			} else if had {
+				delete(w.addrs, key)
+				events = append(events, InterfaceEvent{Type: InterfaceAddrRemoved, Interface: ifi, Addr: ifa})
+			}
+		}
+	}
+	return events
+}
+
+// removeAddrs removes the remaining addresses of a removed interface,
+// appending the resulting events to events.
+func (w *interfaceWatcher) removeAddrs(ifi Interface, events []InterfaceEvent) []InterfaceEvent {
+	for _, key := range w.sortedAddrs() {
+		if key.index == ifi.Index {
+			events = append(events, InterfaceEvent{Type: InterfaceAddrRemoved, Interface: ifi, Addr: w.addrs[key]})
+			delete(w.addrs, key)
+		}
+	}
+	return events
+}
+
+// sync replaces the state with the one described by msgs, the
+// result of a dump, and returns the differences as events.
+func (w *interfaceWatcher) sync(msgs []syscall.NetlinkMessage) []InterfaceEvent {
+	var next interfaceWatcher
+	next.update(msgs, nil)
+
+	var events []InterfaceEvent
+	for _, key := range w.sortedAddrs() {
+		if _, ok := next.addrs[key]; !ok {
+			ifi, ok := next.links[key.index]
+			if !ok {
+				ifi = w.links[key.index]
+			}
+			events = append(events, InterfaceEvent{Type: InterfaceAddrRemoved, Interface: ifi, Addr: w.addrs[key]})
+		}
+	}
+	for _, index := range w.sortedLinks() {
+		prev := w.links[index]
+		ifi, ok := next.links[index]
+		if !ok {
+			ifi = prev
+			ifi.Flags &^= FlagUp | FlagRunning
+		}
+		if prev.Flags&FlagUp != 0 && ifi.Flags&FlagUp == 0 {
+			events = append(events, InterfaceEvent{Type: InterfaceDown, Interface: ifi})
+		}
+	}
+	for _, index := range next.sortedLinks() {
+		ifi := next.links[index]
+		prev, ok := w.links[index]
+		if ifi.Flags&FlagUp != 0 && (!ok || prev.Flags&FlagUp == 0) {
+			events = append(events, InterfaceEvent{Type: InterfaceUp, Interface: ifi})
+		}
+	}
+	for _, key := range next.sortedAddrs() {
+		if _, ok := w.addrs[key]; !ok {
+			ifi, ok := next.links[key.index]
+			if !ok {
+				ifi = Interface{Index: key.index}
+			}
+			events = append(events, InterfaceEvent{Type: InterfaceAddrAdded, Interface: ifi, Addr: next.addrs[key]})
+		}
+	}
+	*w = next
+	return events
+}
+
+func (w *interfaceWatcher) sortedLinks() []int {
+	indexes := make([]int, 0, len(w.links))
+	for index := range w.links {
+		indexes = append(indexes, index)
+	}
+	slices.Sort(indexes)
+	return indexes
+}
+
+func (w *interfaceWatcher) sortedAddrs() []ifaddrKey {
+	keys := make([]ifaddrKey, 0, len(w.addrs))
+	for key := range w.addrs {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b ifaddrKey) int {
+		return cmp.Or(cmp.Compare(a.index, b.index), cmp.Compare(a.addr, b.addr))
+	})
+	return keys
+}
+
+// A netlinkRouteSource is a routeSource backed by a NETLINK_ROUTE
+// socket subscribed to link and address notifications.
+type netlinkRouteSource struct {
+	c   *NetlinkConn
+	buf []byte
+}
+
+func newNetlinkRouteSource() (*netlinkRouteSource, error) {
+	sl := &sysListener{network: "netlink:route"}
+	laddr := &NetlinkAddr{Groups: _RTMGRP_LINK | _RTMGRP_IPV4_IFADDR | _RTMGRP_IPV6_IFADDR}
+	c, err := sl.listenNetlink(context.Background(), laddr)
+	if err != nil {
+		return nil, err
+	}
+	return &netlinkRouteSource{c: c, buf: make([]byte, 32<<10)}, nil
+}
+
+func (s *netlinkRouteSource) dump() ([]syscall.NetlinkMessage, error) {
+	var msgs []syscall.NetlinkMessage
+	for _, typ := range []int{syscall.RTM_GETLINK, syscall.RTM_GETADDR} {
+		tab, err := syscall.NetlinkRIB(typ, syscall.AF_UNSPEC)
+		if err != nil {
+			return nil, os.NewSyscallError("netlinkrib", err)
+		}
+		m, err := syscall.ParseNetlinkMessage(tab)
+		if err != nil {
+			return nil, os.NewSyscallError("parsenetlinkmessage", err)
+		}
+		msgs = append(msgs, m...)
+	}
+	return msgs, nil
+}
+
+func (s *netlinkRouteSource) read() ([]syscall.NetlinkMessage, error) {
+	for {
+		n, from, err := s.c.readFrom(s.buf)
+		if err != nil {
+			return nil, err
+		}
+		if from == nil || from.Port != 0 {
+			// Not from the kernel.
+			continue
+		}
+		msgs, err := syscall.ParseNetlinkMessage(s.buf[:n])
+		if err != nil {
+			return nil, os.NewSyscallError("parsenetlinkmessage", err)
+		}
+		return msgs, nil
+	}
+}
+
+func (s *netlinkRouteSource) close() error {
+	return s.c.Close()
+}
@@ -0,0 +1,346 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"context"
+	"errors"
+	"internal/testenv"
+	"os"
+	"slices"
+	"syscall"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+// appendRouteAttr appends a netlink route attribute to b.
+func appendRouteAttr(b []byte, typ uint16, value []byte) []byte {
+	var attr [syscall.SizeofRtAttr]byte
+	*(*syscall.RtAttr)(unsafe.Pointer(&attr[0])) = syscall.RtAttr{Len: uint16(syscall.SizeofRtAttr + len(value)), Type: typ}
+	b = append(b, attr[:]...)
+	b = append(b, value...)
+	for len(b)%syscall.NLMSG_ALIGNTO != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// linkMessage returns a link message for the interface with the
+// given index, name and IFF_* flags.
+func linkMessage(typ uint16, index int, name string, flags uint32) syscall.NetlinkMessage {
+	var ifim [syscall.SizeofIfInfomsg]byte
+	*(*syscall.IfInfomsg)(unsafe.Pointer(&ifim[0])) = syscall.IfInfomsg{
+		Family: syscall.AF_UNSPEC,
+		Index:  int32(index),
+		Flags:  flags,
+		Change: flags,
+	}
+	data := ifim[:]
+	if name != "" {
+		data = appendRouteAttr(data, syscall.IFLA_IFNAME, append([]byte(name), 0))
+	}
+	return syscall.NetlinkMessage{Header: syscall.NlMsghdr{Type: typ}, Data: data}
+}
+
+// addrMessage returns an address message for the address ipnet
+// of the interface with the given index.
+func addrMessage(typ uint16, index int, ipnet *IPNet) syscall.NetlinkMessage {
+	family, ip := uint8(syscall.AF_INET6), ipnet.IP.To16()
+	if ip4 := ipnet.IP.To4(); ip4 != nil {
+		family, ip = syscall.AF_INET, ip4
+	}
+	ones, _ := ipnet.Mask.Size()
+	var ifam [syscall.SizeofIfAddrmsg]byte
+	*(*syscall.IfAddrmsg)(unsafe.Pointer(&ifam[0])) = syscall.IfAddrmsg{
+		Family:    family,
+		Prefixlen: uint8(ones),
+		Index:     uint32(index),
+	}
+	data := appendRouteAttr(ifam[:], syscall.IFA_LOCAL, ip)
+	data = appendRouteAttr(data, syscall.IFA_ADDRESS, ip)
+	return syscall.NetlinkMessage{Header: syscall.NlMsghdr{Type: typ}, Data: data}
+}
+
+// A fakeRouteSource is a routeSource whose messages are supplied by
+// the test.
+type fakeRouteSource struct {
+	dumps  chan []syscall.NetlinkMessage
+	reads  chan fakeRouteRead
+	closed chan struct{}
+}
+
+type fakeRouteRead struct {
+	msgs []syscall.NetlinkMessage
+	err  error
+}
+
+func newFakeRouteSource() *fakeRouteSource {
+	return &fakeRouteSource{
+		dumps:  make(chan []syscall.NetlinkMessage, 10),
+		reads:  make(chan fakeRouteRead, 10),
+		closed: make(chan struct{}),
+	}
+}
+
+func (s *fakeRouteSource) dump() ([]syscall.NetlinkMessage, error) {
+	select {
+	case msgs := <-s.dumps:
+		return msgs, nil
+	case <-s.closed:
+		return nil, ErrClosed
+	}
+}
+
+func (s *fakeRouteSource) read() ([]syscall.NetlinkMessage, error) {
+	select {
+	case r := <-s.reads:
+		return r.msgs, r.err
+	case <-s.closed:
+		return nil, ErrClosed
+	}
+}
+
+func (s *fakeRouteSource) close() error {
+	select {
+	case <-s.closed:
+	default:
+		close(s.closed)
+	}
+	return nil
+}
+
+func mustParseIPNet(s string) *IPNet {
+	ip, ipnet, err := ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	ipnet.IP = ip
+	return ipnet
+}
+
+func TestWatchRouteFake(t *testing.T) {
+	const (
+		up      = syscall.IFF_UP | syscall.IFF_RUNNING
+		loIndex = 1
+		ethIdx  = 2
+	)
+	lo4 := mustParseIPNet("127.0.0.1/8")
+	eth4 := mustParseIPNet("192.0.2.1/24")
+	eth6 := mustParseIPNet("2001:db8::1/64")
+
+	src := newFakeRouteSource()
+	src.dumps <- []syscall.NetlinkMessage{
+		linkMessage(syscall.RTM_NEWLINK, loIndex, "lo", up|syscall.IFF_LOOPBACK),
+		linkMessage(syscall.RTM_NEWLINK, ethIdx, "eth0", 0),
+		addrMessage(syscall.RTM_NEWADDR, loIndex, lo4),
+	}
+	src.reads <- fakeRouteRead{msgs: []syscall.NetlinkMessage{
+		linkMessage(syscall.RTM_NEWLINK, ethIdx, "eth0", up),
+		// A change that doesn't affect the up state.
+		linkMessage(syscall.RTM_NEWLINK, ethIdx, "eth0", syscall.IFF_UP),
+	}}
+	src.reads <- fakeRouteRead{msgs: []syscall.NetlinkMessage{
+		addrMessage(syscall.RTM_NEWADDR, ethIdx, eth4),
+		// An update of the same address.
+		addrMessage(syscall.RTM_NEWADDR, ethIdx, eth4),
+		addrMessage(syscall.RTM_NEWADDR, ethIdx, eth6),
+		addrMessage(syscall.RTM_DELADDR, ethIdx, eth6),
+	}}
+	// Lost notifications: in the meantime, eth0 went away.
+	src.reads <- fakeRouteRead{err: os.NewSyscallError("recvfrom", syscall.ENOBUFS)}
+	src.dumps <- []syscall.NetlinkMessage{
+		linkMessage(syscall.RTM_NEWLINK, loIndex, "lo", up|syscall.IFF_LOOPBACK),
+		addrMessage(syscall.RTM_NEWADDR, loIndex, lo4),
+	}
+	src.reads <- fakeRouteRead{msgs: []syscall.NetlinkMessage{
+		linkMessage(syscall.RTM_NEWLINK, ethIdx, "eth1", up),
+		addrMessage(syscall.RTM_NEWADDR, ethIdx, eth4),
+		linkMessage(syscall.RTM_DELLINK, ethIdx, "eth1", up),
+		linkMessage(syscall.RTM_NEWLINK, loIndex, "lo", syscall.IFF_LOOPBACK),
+	}}
+
+	want := []string{
+		"lo up",
+		"lo addr added 127.0.0.1/8",
+		"eth0 up",
+		"eth0 addr added 192.0.2.1/24",
+		"eth0 addr added 2001:db8::1/64",
+		"eth0 addr removed 2001:db8::1/64",
+		"eth0 addr removed 192.0.2.1/24",
+		"eth0 down",
+		"eth1 up",
+		"eth1 addr added 192.0.2.1/24",
+		"eth1 addr removed 192.0.2.1/24",
+		"eth1 down",
+		"lo down",
+	}
+	var got []string
+	err := watchRoute(context.Background(), src, func(ev InterfaceEvent) bool {
+		got = append(got, ev.String())
+		return len(got) < len(want)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got events:\n%q\nwant:\n%q", got, want)
+	}
+	select {
+	case <-src.closed:
+	default:
+		t.Error("source not closed after iteration stopped")
+	}
+}
+
+func TestWatchRouteFakeError(t *testing.T) {
+	src := newFakeRouteSource()
+	src.dumps <- nil
+	src.reads <- fakeRouteRead{err: os.NewSyscallError("recvfrom", syscall.EBADMSG)}
+	err := watchRoute(context.Background(), src, func(ev InterfaceEvent) bool {
+		t.Errorf("unexpected event %v", ev)
+		return true
+	})
+	if !errors.Is(err, syscall.EBADMSG) {
+		t.Errorf("watchRoute error = %v; want EBADMSG", err)
+	}
+}
+
+func TestWatchRouteFakeCancel(t *testing.T) {
+	src := newFakeRouteSource()
+	src.dumps <- nil
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- watchRoute(ctx, src, func(ev InterfaceEvent) bool { return true })
+	}()
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("watchRoute did not return after cancellation")
+	}
+}
+
+// routeRequest sends a request on a NETLINK_ROUTE connection and
+// waits for its acknowledgment.
+func routeRequest(c *NetlinkConn, m syscall.NetlinkMessage, flags uint16) error {
+	b := make([]byte, syscall.NLMSG_HDRLEN, syscall.NLMSG_HDRLEN+len(m.Data))
+	h := (*syscall.NlMsghdr)(unsafe.Pointer(&b[0]))
+	*h = m.Header
+	h.Len = uint32(syscall.NLMSG_HDRLEN + len(m.Data))
+	h.Flags = syscall.NLM_F_REQUEST | syscall.NLM_F_ACK | flags
+	h.Seq = 1
+	b = append(b, m.Data...)
+	if _, err := c.WriteTo(b, &NetlinkAddr{}); err != nil {
+		return err
+	}
+	buf := make([]byte, os.Getpagesize())
+	n, err := c.Read(buf)
+	if err != nil {
+		return err
+	}
+	msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+	if err != nil {
+		return err
+	}
+	for _, m := range msgs {
+		if m.Header.Type == syscall.NLMSG_ERROR && len(m.Data) >= 4 {
+			if errno := *(*int32)(unsafe.Pointer(&m.Data[0])); errno != 0 {
+				return syscall.Errno(-errno)
+			}
+			return nil
+		}
+	}
+	return errors.New("no acknowledgment")
+}
+
+func TestWatchInterfaces(t *testing.T) {
+	if os.Getenv("GO_NET_WATCH_INTERFACES_NETNS") != "1" {
+		// Run the test in a new network namespace, where it may
+		// change the loopback interface.
+		cmd := testenv.Command(t, testenv.Executable(t), "-test.run=^TestWatchInterfaces$", "-test.v")
+		cmd.Env = append(os.Environ(), "GO_NET_WATCH_INTERFACES_NETNS=1")
+		cmd.SysProcAttr = &syscall.SysProcAttr{
+			Cloneflags:  syscall.CLONE_NEWNET | syscall.CLONE_NEWUSER,
+			UidMappings: []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getuid(), Size: 1}},
+			GidMappings: []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getgid(), Size: 1}},
+		}
+		out, err := cmd.CombinedOutput()
+		if testenv.SyscallIsNotSupported(err) {
+			t.Skipf("skipping: could not start process with CLONE_NEWNET and CLONE_NEWUSER: %v", err)
+		}
+		t.Logf("running in a new network namespace:\n%s", out)
+		if err != nil {
+			t.Errorf("subprocess failed: %v", err)
+		}
+		return
+	}
+
+	lo, err := InterfaceByName("lo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := ListenNetlink("netlink:route", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	setUp := func(up bool) error {
+		m := linkMessage(syscall.RTM_NEWLINK, lo.Index, "", 0)
+		ifim := (*syscall.IfInfomsg)(unsafe.Pointer(&m.Data[0]))
+		ifim.Change = syscall.IFF_UP
+		if up {
+			ifim.Flags = syscall.IFF_UP
+		}
+		return routeRequest(c, m, 0)
+	}
+	if err := setUp(true); err != nil {
+		if testenv.SyscallIsNotSupported(err) {
+			t.Skipf("skipping: cannot configure loopback interface: %v", err)
+		}
+		t.Fatal(err)
+	}
+
+	testAddr := mustParseIPNet("192.0.2.1/24")
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	var steps []string
+	for ev, err := range WatchInterfaces(ctx) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Logf("event: %v", ev)
+		if ev.Interface.Index != lo.Index {
+			continue
+		}
+		var err error
+		switch {
+		case ev.Type == InterfaceUp:
+			// The initial state is reported; the watch is
+			// established. Add an address.
+			steps = append(steps, "up")
+			err = routeRequest(c, addrMessage(syscall.RTM_NEWADDR, lo.Index, testAddr), syscall.NLM_F_CREATE|syscall.NLM_F_EXCL)
+		case ev.Type == InterfaceAddrAdded && ev.Addr.String() == testAddr.String():
+			steps = append(steps, "added")
+			err = routeRequest(c, addrMessage(syscall.RTM_DELADDR, lo.Index, testAddr), 0)
+		case ev.Type == InterfaceAddrRemoved && ev.Addr.String() == testAddr.String():
+			steps = append(steps, "removed")
+			err = setUp(false)
+		case ev.Type == InterfaceDown:
+			steps = append(steps, "down")
+			cancel()
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ev.Interface.Name != "lo" {
+			t.Errorf("event %v: interface name = %q; want lo", ev, ev.Interface.Name)
+		}
+	}
+	if want := []string{"up", "added", "removed", "down"}; !slices.Equal(steps, want) {
+		t.Errorf("saw %q; want %q", steps, want)
+	}
+}
@@ -0,0 +1,16 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux
+
+package net
+
+import (
+	"context"
+	"errors"
+)
+
+func watchInterfaces(ctx context.Context, yield func(InterfaceEvent) bool) error {
+	return errors.ErrUnsupported
+}
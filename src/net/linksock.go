@@ -0,0 +1,357 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"context"
+	"internal/strconv"
+	"syscall"
+)
+
+// LinkAddr represents the address of a link-layer end point,
+// as used by packet sockets.
+type LinkAddr struct {
+	// Interface is the name of the network interface.
+	// When listening, an empty name means all interfaces.
+	Interface string
+
+	// Protocol is the EtherType of the frame, such as 0x0806
+	// for ARP, in host byte order.
+	Protocol uint16
+
+	// HardwareAddr is the hardware address of the sender of a
+	// received frame, or of the destination of a sent frame.
+	HardwareAddr HardwareAddr
+}
+
+// Network returns the address's network name, "packet".
+func (a *LinkAddr) Network() string { return "packet" }
+
+func (a *LinkAddr) String() string {
+	if a == nil {
+		return "<nil>"
+	}
+	s := a.Interface
+	if len(a.HardwareAddr) > 0 {
+		if s != "" {
+			s += "/"
+		}
+		s += a.HardwareAddr.String()
+	}
+	return s
+}
+
+func (a *LinkAddr) opAddr() Addr {
+	if a == nil {
+		return nil
+	}
+	return a
+}
+
+// NetlinkAddr represents the address of a netlink end point.
+type NetlinkAddr struct {
+	// Port is the netlink port ID. Port 0 is the kernel when
+	// sending, and lets the kernel choose one when listening.
+	Port uint32
+
+	// Groups is the bit mask of the multicast groups to join
+	// when listening, or to send to. Groups numbered above 32
+	// can be joined with [NetlinkConn.JoinGroup].
+	Groups uint32
+}
+
+// Network returns the address's network name, "netlink".
+func (a *NetlinkAddr) Network() string { return "netlink" }
+
+func (a *NetlinkAddr) String() string {
+	if a == nil {
+		return "<nil>"
+	}
+	s := strconv.FormatUint(uint64(a.Port), 10)
+	if a.Groups != 0 {
+		s += "/" + strconv.FormatUint(uint64(a.Groups), 10)
+	}
+	return s
+}
+
+func (a *NetlinkAddr) opAddr() Addr {
+	if a == nil {
+		return nil
+	}
+	return a
+}
+
+// netlinkProtocols maps the names accepted in "netlink:name"
+// networks to netlink protocol numbers.
+var netlinkProtocols = map[string]int{
+	"route":     0,  // NETLINK_ROUTE
+	"sockdiag":  4,  // NETLINK_SOCK_DIAG
+	"audit":     9,  // NETLINK_AUDIT
+	"netfilter": 12, // NETLINK_NETFILTER
+	"uevent":    15, // NETLINK_KOBJECT_UEVENT
+	"generic":   16, // NETLINK_GENERIC
+}
+
+// ethAll is ETH_P_ALL, the protocol of a packet socket
+// that receives frames of every EtherType.
+const ethAll = 0x0003
+
+// parseLinkProtocol parses the protocol part of a "packet:proto"
+// network: an EtherType in decimal or, with a 0x prefix, hexadecimal.
+func parseLinkProtocol(s string) (int, bool) {
+	n, err := strconv.ParseUint(s, 0, 16)
+	if err != nil {
+		return 0, false
+	}
+	return int(n), true
+}
+
+// parseNetlinkGroups parses the address of a netlink network,
+// a multicast group mask in decimal or, with a 0x prefix,
+// hexadecimal. An empty address joins no groups.
+func parseNetlinkGroups(s string) (uint32, error) {
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseUint(s, 0, 32)
+	if err != nil {
+		return 0, &AddrError{Err: "invalid netlink group mask", Addr: s}
+	}
+	return uint32(n), nil
+}
+
+// A BPFInstruction is a classic BPF instruction, as used by socket
+// filters. Its layout matches struct sock_filter.
+type BPFInstruction struct {
+	Op uint16 // operation code
+	Jt uint8  // jump offset if true
+	Jf uint8  // jump offset if false
+	K  uint32 // generic field
+}
+
+// LinkConn is the implementation of the [Conn] and [PacketConn]
+// interfaces for link-layer packet sockets.
+//
+// Packets read and written on a LinkConn include the link-layer
+// header, such as the Ethernet header.
+type LinkConn struct {
+	conn
+}
+
+// SyscallConn returns a raw network connection.
+// This implements the [syscall.Conn] interface.
+func (c *LinkConn) SyscallConn() (syscall.RawConn, error) {
+	if !c.ok() {
+		return nil, syscall.EINVAL
+	}
+	return newRawConn(c.fd), nil
+}
+
+// ReadFromLink acts like ReadFrom but returns a LinkAddr.
+func (c *LinkConn) ReadFromLink(b []byte) (int, *LinkAddr, error) {
+	if !c.ok() {
+		return 0, nil, syscall.EINVAL
+	}
+	n, addr, err := c.readFrom(b)
+	if err != nil {
+		err = &OpError{Op: "read", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return n, addr, err
+}
+
+// ReadFrom implements the [PacketConn] ReadFrom method.
+func (c *LinkConn) ReadFrom(b []byte) (int, Addr, error) {
+	n, addr, err := c.ReadFromLink(b)
+	if addr == nil {
+		return n, nil, err
+	}
+	return n, addr, err
+}
+
+// WriteToLink acts like [LinkConn.WriteTo] but takes a [LinkAddr].
+// The Interface and HardwareAddr fields of addr must be set; if
+// Protocol is zero, the protocol of the connection is used.
+func (c *LinkConn) WriteToLink(b []byte, addr *LinkAddr) (int, error) {
+	if !c.ok() {
+		return 0, syscall.EINVAL
+	}
+	n, err := c.writeTo(b, addr)
+	if err != nil {
+		err = &OpError{Op: "write", Net: c.fd.net, Source: c.fd.laddr, Addr: addr.opAddr(), Err: err}
+	}
+	return n, err
+}
+
+// WriteTo implements the [PacketConn] WriteTo method.
+func (c *LinkConn) WriteTo(b []byte, addr Addr) (int, error) {
+	if !c.ok() {
+		return 0, syscall.EINVAL
+	}
+	a, ok := addr.(*LinkAddr)
+	if !ok {
+		return 0, &OpError{Op: "write", Net: c.fd.net, Source: c.fd.laddr, Addr: addr, Err: syscall.EINVAL}
+	}
+	return c.WriteToLink(b, a)
+}
+
+// SetFilter attaches a classic BPF program to the connection.
+// Only packets accepted by the program are received.
+// A nil or empty program removes the filter.
+func (c *LinkConn) SetFilter(prog []BPFInstruction) error {
+	if !c.ok() {
+		return syscall.EINVAL
+	}
+	if err := setFilter(c.fd, prog); err != nil {
+		return &OpError{Op: "set", Net: c.fd.net, Source: nil, Addr: c.fd.laddr, Err: err}
+	}
+	return nil
+}
+
+func newLinkConn(fd *netFD) *LinkConn { return &LinkConn{conn{fd}} }
+
+// ListenLink acts like [ListenPacket] for packet sockets.
+//
+// The network must be "packet", which receives frames of all
+// protocols, or "packet:proto", where proto is an EtherType in decimal
+// or, with a 0x prefix, hexadecimal.
+//
+// If laddr is nil or its Interface field is empty, ListenLink
+// receives frames from all interfaces. The Protocol field of laddr
+// is ignored.
+//
+// Packet sockets are supported on Linux only, and usually require
+// the CAP_NET_RAW capability.
+func ListenLink(network string, laddr *LinkAddr) (*LinkConn, error) {
+	if laddr == nil {
+		laddr = &LinkAddr{}
+	}
+	sl := &sysListener{network: network, address: laddr.String()}
+	c, err := sl.listenLink(context.Background(), laddr)
+	if err != nil {
+		return nil, &OpError{Op: "listen", Net: network, Source: nil, Addr: laddr.opAddr(), Err: err}
+	}
+	return c, nil
+}
+
+// NetlinkConn is the implementation of the [Conn] and [PacketConn]
+// interfaces for netlink sockets.
+type NetlinkConn struct {
+	conn
+}
+
+// SyscallConn returns a raw network connection.
+// This implements the [syscall.Conn] interface.
+func (c *NetlinkConn) SyscallConn() (syscall.RawConn, error) {
+	if !c.ok() {
+		return nil, syscall.EINVAL
+	}
+	return newRawConn(c.fd), nil
+}
+
+// ReadFromNetlink acts like ReadFrom but returns a NetlinkAddr.
+func (c *NetlinkConn) ReadFromNetlink(b []byte) (int, *NetlinkAddr, error) {
+	if !c.ok() {
+		return 0, nil, syscall.EINVAL
+	}
+	n, addr, err := c.readFrom(b)
+	if err != nil {
+		err = &OpError{Op: "read", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return n, addr, err
+}
+
+// ReadFrom implements the [PacketConn] ReadFrom method.
+func (c *NetlinkConn) ReadFrom(b []byte) (int, Addr, error) {
+	n, addr, err := c.ReadFromNetlink(b)
+	if addr == nil {
+		return n, nil, err
+	}
+	return n, addr, err
+}
+
+// WriteToNetlink acts like [NetlinkConn.WriteTo] but takes a [NetlinkAddr].
+func (c *NetlinkConn) WriteToNetlink(b []byte, addr *NetlinkAddr) (int, error) {
+	if !c.ok() {
+		return 0, syscall.EINVAL
+	}
+	n, err := c.writeTo(b, addr)
+	if err != nil {
+		err = &OpError{Op: "write", Net: c.fd.net, Source: c.fd.laddr, Addr: addr.opAddr(), Err: err}
+	}
+	return n, err
+}
+
+// WriteTo implements the [PacketConn] WriteTo method.
+func (c *NetlinkConn) WriteTo(b []byte, addr Addr) (int, error) {
+	if !c.ok() {
+		return 0, syscall.EINVAL
+	}
+	a, ok := addr.(*NetlinkAddr)
+	if !ok {
+		return 0, &OpError{Op: "write", Net: c.fd.net, Source: c.fd.laddr, Addr: addr, Err: syscall.EINVAL}
+	}
+	return c.WriteToNetlink(b, a)
+}
+
+// JoinGroup joins the netlink multicast group with the given number.
+// Unlike the Groups field of [NetlinkAddr], it is not limited to the
+// first 32 groups.
+func (c *NetlinkConn) JoinGroup(group int) error {
+	if !c.ok() {
+		return syscall.EINVAL
+	}
+	if err := setNetlinkMembership(c.fd, group, true); err != nil {
+		return &OpError{Op: "set", Net: c.fd.net, Source: nil, Addr: c.fd.laddr, Err: err}
+	}
+	return nil
+}
+
+// LeaveGroup leaves the netlink multicast group with the given number.
+func (c *NetlinkConn) LeaveGroup(group int) error {
+	if !c.ok() {
+		return syscall.EINVAL
+	}
+	if err := setNetlinkMembership(c.fd, group, false); err != nil {
+		return &OpError{Op: "set", Net: c.fd.net, Source: nil, Addr: c.fd.laddr, Err: err}
+	}
+	return nil
+}
+
+// SetFilter attaches a classic BPF program to the connection.
+// Only messages accepted by the program are received.
+// A nil or empty program removes the filter.
+func (c *NetlinkConn) SetFilter(prog []BPFInstruction) error {
+	if !c.ok() {
+		return syscall.EINVAL
+	}
+	if err := setFilter(c.fd, prog); err != nil {
+		return &OpError{Op: "set", Net: c.fd.net, Source: nil, Addr: c.fd.laddr, Err: err}
+	}
+	return nil
+}
+
+func newNetlinkConn(fd *netFD) *NetlinkConn { return &NetlinkConn{conn{fd}} }
+
+// ListenNetlink acts like [ListenPacket] for netlink sockets.
+//
+// The network must be "netlink:proto", where proto is a netlink
+// protocol number or one of the names "route", "sockdiag", "audit",
+// "netfilter", "uevent" and "generic".
+//
+// If laddr is nil, the kernel chooses the port and no multicast
+// groups are joined.
+//
+// Netlink sockets are supported on Linux only.
+func ListenNetlink(network string, laddr *NetlinkAddr) (*NetlinkConn, error) {
+	if laddr == nil {
+		laddr = &NetlinkAddr{}
+	}
+	sl := &sysListener{network: network, address: laddr.String()}
+	c, err := sl.listenNetlink(context.Background(), laddr)
+	if err != nil {
+		return nil, &OpError{Op: "listen", Net: network, Source: nil, Addr: laddr.opAddr(), Err: err}
+	}
+	return c, nil
+}
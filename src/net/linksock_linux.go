@@ -0,0 +1,199 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"context"
+	"internal/goarch"
+	"runtime"
+	"syscall"
+)
+
+// These constants aren't in the syscall package, which is frozen.
+const (
+	_SOL_NETLINK             = 0x10e
+	_NETLINK_ADD_MEMBERSHIP  = 0x1
+	_NETLINK_DROP_MEMBERSHIP = 0x2
+)
+
+// htons converts a 16-bit value between host and network byte order.
+func htons(v uint16) uint16 {
+	if goarch.BigEndian {
+		return v
+	}
+	return v<<8 | v>>8
+}
+
+func sockaddrToLink(sa syscall.Sockaddr) *LinkAddr {
+	sll, ok := sa.(*syscall.SockaddrLinklayer)
+	if !ok {
+		return nil
+	}
+	a := &LinkAddr{Protocol: htons(sll.Protocol)}
+	if sll.Ifindex != 0 {
+		a.Interface = zoneCache.name(sll.Ifindex)
+	}
+	if sll.Halen > 0 {
+		a.HardwareAddr = make(HardwareAddr, min(int(sll.Halen), len(sll.Addr)))
+		copy(a.HardwareAddr, sll.Addr[:])
+	}
+	return a
+}
+
+func (a *LinkAddr) family() int { return syscall.AF_PACKET }
+
+func (a *LinkAddr) isWildcard() bool {
+	return a == nil || a.Interface == ""
+}
+
+func (a *LinkAddr) sockaddr(family int) (syscall.Sockaddr, error) {
+	if a == nil {
+		return nil, nil
+	}
+	sll := &syscall.SockaddrLinklayer{Protocol: htons(a.Protocol)}
+	if a.Interface != "" {
+		sll.Ifindex = zoneCache.index(a.Interface)
+		if sll.Ifindex == 0 {
+			return nil, &AddrError{Err: errNoSuchInterface.Error(), Addr: a.Interface}
+		}
+	}
+	if len(a.HardwareAddr) > len(sll.Addr) {
+		return nil, &AddrError{Err: "hardware address too long", Addr: a.HardwareAddr.String()}
+	}
+	sll.Halen = uint8(copy(sll.Addr[:], a.HardwareAddr))
+	return sll, nil
+}
+
+func (a *LinkAddr) toLocal(net string) sockaddr { return a }
+
+func sockaddrToNetlink(sa syscall.Sockaddr) *NetlinkAddr {
+	snl, ok := sa.(*syscall.SockaddrNetlink)
+	if !ok {
+		return nil
+	}
+	return &NetlinkAddr{Port: snl.Pid, Groups: snl.Groups}
+}
+
+func (a *NetlinkAddr) family() int { return syscall.AF_NETLINK }
+
+func (a *NetlinkAddr) isWildcard() bool {
+	return a == nil || a.Port == 0
+}
+
+func (a *NetlinkAddr) sockaddr(family int) (syscall.Sockaddr, error) {
+	if a == nil {
+		return nil, nil
+	}
+	return &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK, Pid: a.Port, Groups: a.Groups}, nil
+}
+
+func (a *NetlinkAddr) toLocal(net string) sockaddr { return a }
+
+func (c *LinkConn) readFrom(b []byte) (int, *LinkAddr, error) {
+	n, sa, err := c.fd.readFrom(b)
+	return n, sockaddrToLink(sa), err
+}
+
+func (c *LinkConn) writeTo(b []byte, addr *LinkAddr) (int, error) {
+	if addr == nil {
+		return 0, errMissingAddress
+	}
+	if addr.Protocol == 0 {
+		if la, ok := c.fd.laddr.(*LinkAddr); ok {
+			a := *addr
+			a.Protocol = la.Protocol
+			addr = &a
+		}
+	}
+	sa, err := addr.sockaddr(syscall.AF_PACKET)
+	if err != nil {
+		return 0, err
+	}
+	return c.fd.writeTo(b, sa)
+}
+
+func (sl *sysListener) listenLink(ctx context.Context, laddr *LinkAddr) (*LinkConn, error) {
+	afnet, proto, err := parseNetwork(ctx, sl.network, true)
+	if err != nil {
+		return nil, err
+	}
+	if afnet != "packet" {
+		return nil, UnknownNetworkError(sl.network)
+	}
+	la := *laddr
+	la.Protocol = uint16(proto)
+	fd, err := socket(ctx, sl.network, syscall.AF_PACKET, syscall.SOCK_RAW, int(htons(uint16(proto))), false, &la, nil, sl.ListenConfig.controlContext())
+	if err != nil {
+		return nil, err
+	}
+	if lsa, err := syscall.Getsockname(fd.pfd.Sysfd); err == nil {
+		fd.laddr = sockaddrToLink(lsa)
+	}
+	return newLinkConn(fd), nil
+}
+
+func (c *NetlinkConn) readFrom(b []byte) (int, *NetlinkAddr, error) {
+	n, sa, err := c.fd.readFrom(b)
+	return n, sockaddrToNetlink(sa), err
+}
+
+func (c *NetlinkConn) writeTo(b []byte, addr *NetlinkAddr) (int, error) {
+	if addr == nil {
+		return 0, errMissingAddress
+	}
+	sa, _ := addr.sockaddr(syscall.AF_NETLINK)
+	return c.fd.writeTo(b, sa)
+}
+
+func (sl *sysListener) listenNetlink(ctx context.Context, laddr *NetlinkAddr) (*NetlinkConn, error) {
+	afnet, proto, err := parseNetwork(ctx, sl.network, true)
+	if err != nil {
+		return nil, err
+	}
+	if afnet != "netlink" {
+		return nil, UnknownNetworkError(sl.network)
+	}
+	fd, err := socket(ctx, sl.network, syscall.AF_NETLINK, syscall.SOCK_RAW, proto, false, laddr, nil, sl.ListenConfig.controlContext())
+	if err != nil {
+		return nil, err
+	}
+	if lsa, err := syscall.Getsockname(fd.pfd.Sysfd); err == nil {
+		fd.laddr = sockaddrToNetlink(lsa)
+	}
+	return newNetlinkConn(fd), nil
+}
+
+func setNetlinkMembership(fd *netFD, group int, join bool) error {
+	opt := _NETLINK_ADD_MEMBERSHIP
+	if !join {
+		opt = _NETLINK_DROP_MEMBERSHIP
+	}
+	err := fd.pfd.SetsockoptInt(_SOL_NETLINK, opt, group)
+	runtime.KeepAlive(fd)
+	return wrapSyscallError("setsockopt", err)
+}
+
+func setFilter(fd *netFD, prog []BPFInstruction) error {
+	var err error
+	if cerr := fd.pfd.RawControl(func(s uintptr) {
+		if len(prog) == 0 {
+			err = syscall.DetachLsf(int(s))
+			if err == syscall.ENOENT {
+				// No filter was attached.
+				err = nil
+			}
+			return
+		}
+		filter := make([]syscall.SockFilter, len(prog))
+		for i, ins := range prog {
+			filter[i] = syscall.SockFilter{Code: ins.Op, Jt: ins.Jt, Jf: ins.Jf, K: ins.K}
+		}
+		err = syscall.AttachLsf(int(s), filter)
+	}); cerr != nil {
+		return cerr
+	}
+	runtime.KeepAlive(fd)
+	return wrapSyscallError("setsockopt", err)
+}
@@ -0,0 +1,213 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+// newRouteDumpRequest returns a netlink request that dumps
+// the links known to the kernel.
+func newRouteDumpRequest(seq uint32) []byte {
+	b := make([]byte, syscall.NLMSG_HDRLEN+syscall.SizeofIfInfomsg)
+	h := (*syscall.NlMsghdr)(unsafe.Pointer(&b[0]))
+	h.Len = uint32(len(b))
+	h.Type = syscall.RTM_GETLINK
+	h.Flags = syscall.NLM_F_DUMP | syscall.NLM_F_REQUEST
+	h.Seq = seq
+	b[syscall.NLMSG_HDRLEN] = syscall.AF_UNSPEC
+	return b
+}
+
+func TestNetlinkRouteDump(t *testing.T) {
+	c, err := ListenNetlink("netlink:route", nil)
+	if err != nil {
+		t.Skipf("netlink not available: %v", err)
+	}
+	defer c.Close()
+
+	la, ok := c.LocalAddr().(*NetlinkAddr)
+	if !ok || la.Port == 0 {
+		t.Fatalf("LocalAddr = %v; want a netlink address with a port", c.LocalAddr())
+	}
+
+	if _, err := c.WriteTo(newRouteDumpRequest(1), &NetlinkAddr{}); err != nil {
+		t.Fatal(err)
+	}
+	c.SetReadDeadline(time.Now().Add(10 * time.Second))
+	b := make([]byte, os.Getpagesize()*8)
+	links := 0
+	for done := false; !done; {
+		n, from, err := c.ReadFromNetlink(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if from.Port != 0 {
+			t.Errorf("message from port %d; want the kernel", from.Port)
+		}
+		msgs, err := syscall.ParseNetlinkMessage(b[:n])
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range msgs {
+			switch m.Header.Type {
+			case syscall.NLMSG_DONE:
+				done = true
+			case syscall.NLMSG_ERROR:
+				t.Fatal("netlink error reply")
+			case syscall.RTM_NEWLINK:
+				links++
+			}
+		}
+	}
+	if links == 0 {
+		t.Error("no links in dump")
+	}
+}
+
+func TestNetlinkGroups(t *testing.T) {
+	// Group 1 is RTNLGRP_LINK, for link changes.
+	c, err := ListenNetlink("netlink:0", &NetlinkAddr{Groups: 1})
+	if err != nil {
+		t.Skipf("netlink not available: %v", err)
+	}
+	defer c.Close()
+
+	if la := c.LocalAddr().(*NetlinkAddr); la.Groups != 1 {
+		t.Errorf("LocalAddr().Groups = %#x; want 0x1", la.Groups)
+	}
+	if err := c.JoinGroup(syscall.RTNLGRP_IPV4_IFADDR); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.LeaveGroup(syscall.RTNLGRP_IPV4_IFADDR); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestListenLink(t *testing.T) {
+	ifi := loopbackInterface()
+	if ifi == nil {
+		t.Skip("no loopback interface")
+	}
+	c, err := ListenLink("packet:0x0800", &LinkAddr{Interface: ifi.Name})
+	if errors.Is(err, syscall.EPERM) {
+		t.Skip("packet sockets require CAP_NET_RAW")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	la, ok := c.LocalAddr().(*LinkAddr)
+	if !ok {
+		t.Fatalf("LocalAddr = %T; want *LinkAddr", c.LocalAddr())
+	}
+	if la.Interface != ifi.Name || la.Protocol != 0x0800 {
+		t.Errorf("LocalAddr = %+v; want interface %s, protocol 0x0800", la, ifi.Name)
+	}
+
+	// Generate some IPv4 traffic on the loopback interface.
+	uc, err := ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer uc.Close()
+	if _, err := uc.WriteTo([]byte("link"), uc.LocalAddr()); err != nil {
+		t.Fatal(err)
+	}
+
+	c.SetReadDeadline(time.Now().Add(10 * time.Second))
+	b := make([]byte, 1500)
+	n, from, err := c.ReadFromLink(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from.Protocol != 0x0800 || from.Interface != ifi.Name {
+		t.Errorf("ReadFromLink address = %+v; want interface %s, protocol 0x0800", from, ifi.Name)
+	}
+	if n < 14+20 {
+		t.Errorf("ReadFromLink returned %d bytes; too short for an IPv4 frame", n)
+	}
+}
+
+func TestLinkSetFilter(t *testing.T) {
+	c, err := ListenLink("packet", nil)
+	if errors.Is(err, syscall.EPERM) {
+		t.Skip("packet sockets require CAP_NET_RAW")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// A program that rejects every packet.
+	prog := []BPFInstruction{{Op: 0x06, K: 0}} // ret #0
+	if err := c.SetFilter(prog); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetFilter(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetFilter(nil); err != nil {
+		t.Fatalf("removing an absent filter: %v", err)
+	}
+}
+
+func TestLinkNetworkParse(t *testing.T) {
+	for _, tt := range []struct {
+		network string
+		ok      bool
+	}{
+		{"packet", true},
+		{"packet:0x86dd", true},
+		{"packet:2054", true},
+		{"packet:0x10000", false},
+		{"packet:ip", false},
+		{"netlink:route", true},
+		{"netlink:16", true},
+		{"netlink", false},
+		{"netlink:bogus", false},
+	} {
+		var c PacketConn
+		var err error
+		if strings.HasPrefix(tt.network, "packet") {
+			c, err = ListenLink(tt.network, nil)
+			if errors.Is(err, syscall.EPERM) {
+				continue
+			}
+		} else {
+			c, err = ListenNetlink(tt.network, nil)
+		}
+		if err == nil {
+			c.Close()
+		}
+		if (err == nil) != tt.ok {
+			t.Errorf("listen on %q: err = %v; want ok = %v", tt.network, err, tt.ok)
+		}
+	}
+}
+
+func TestLinkAddrSockaddr(t *testing.T) {
+	ifi := loopbackInterface()
+	if ifi == nil {
+		t.Skip("no loopback interface")
+	}
+	sa, err := (&LinkAddr{Interface: ifi.Name, Protocol: 0x0800}).sockaddr(syscall.AF_PACKET)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sll := sa.(*syscall.SockaddrLinklayer); sll.Ifindex != ifi.Index {
+		t.Errorf("Ifindex = %d; want %d", sll.Ifindex, ifi.Index)
+	}
+	if _, err := (&LinkAddr{Interface: "nonexistent0"}).sockaddr(syscall.AF_PACKET); err == nil {
+		t.Errorf("sockaddr for a nonexistent interface succeeded")
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux
+
+package net
+
+import (
+	"context"
+	"errors"
+)
+
+func (c *LinkConn) readFrom(b []byte) (int, *LinkAddr, error) {
+	return 0, nil, errors.ErrUnsupported
+}
+
+func (c *LinkConn) writeTo(b []byte, addr *LinkAddr) (int, error) {
+	return 0, errors.ErrUnsupported
+}
+
+func (sl *sysListener) listenLink(ctx context.Context, laddr *LinkAddr) (*LinkConn, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (c *NetlinkConn) readFrom(b []byte) (int, *NetlinkAddr, error) {
+	return 0, nil, errors.ErrUnsupported
+}
+
+func (c *NetlinkConn) writeTo(b []byte, addr *NetlinkAddr) (int, error) {
+	return 0, errors.ErrUnsupported
+}
+
+func (sl *sysListener) listenNetlink(ctx context.Context, laddr *NetlinkAddr) (*NetlinkConn, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func setNetlinkMembership(fd *netFD, group int, join bool) error {
+	return errors.ErrUnsupported
+}
+
+func setFilter(fd *netFD, prog []BPFInstruction) error {
+	return errors.ErrUnsupported
+}
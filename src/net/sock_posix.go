@@ -79,6 +79,9 @@ func (fd *netFD) ctrlNetwork() string {
 	case "unix", "unixgram", "unixpacket":
 		return fd.net
 	}
+	if fd.family != syscall.AF_INET && fd.family != syscall.AF_INET6 {
+		return fd.net
+	}
 	switch fd.net[len(fd.net)-1] {
 	case '4', '6':
 		return fd.net
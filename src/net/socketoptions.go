@@ -0,0 +1,111 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"context"
+	"errors"
+	"internal/stringslite"
+	"syscall"
+	"time"
+)
+
+// SocketOptions specifies socket options to set on a network
+// connection after it is created and before it is bound or connected.
+//
+// Setting an option that is not supported by the operating system,
+// or that does not apply to the network, causes the Listen or Dial
+// call to fail with an error wrapping [errors.ErrUnsupported].
+// Errors reported by the operating system when setting an option
+// are returned as well.
+type SocketOptions struct {
+	// ReusePort sets SO_REUSEPORT, which allows multiple sockets
+	// to bind to the same address and port. On Linux, incoming
+	// connections and datagrams are distributed among the
+	// sockets, so that several listeners on one port can each
+	// run their own accept loop.
+	ReusePort bool
+
+	// FreeBind sets IP_FREEBIND (IPV6_FREEBIND for IPv6), which
+	// allows binding to an address that is not assigned to a
+	// local interface.
+	// It is supported on Linux only.
+	FreeBind bool
+
+	// FastOpen enables TCP Fast Open (RFC 7413).
+	// For listeners, it is the maximum length of the queue of
+	// connections that have not yet completed the handshake.
+	// For dialers, any positive value enables Fast Open.
+	// It is supported on Linux only.
+	FastOpen int
+
+	// DeferAccept sets TCP_DEFER_ACCEPT, which delays accepting
+	// a connection until data arrives or DeferAccept elapses.
+	// The duration is rounded up to the nearest second.
+	// It applies to TCP listeners and is supported on Linux only.
+	DeferAccept time.Duration
+
+	// Mark sets SO_MARK, which tags the packets sent on the
+	// socket for use in routing and packet filtering. Setting it
+	// usually requires the CAP_NET_ADMIN capability.
+	// It is supported on Linux only.
+	Mark uint32
+}
+
+// unsupportedSocketOptionError reports that a socket option is not
+// supported by the operating system or the network.
+type unsupportedSocketOptionError string
+
+func (e unsupportedSocketOptionError) Error() string {
+	return "socket option " + string(e) + " not supported"
+}
+
+func (e unsupportedSocketOptionError) Unwrap() error { return errors.ErrUnsupported }
+
+// control returns a function that sets the socket options and then
+// calls fn, if not nil. The mode is "dial" or "listen".
+func (so *SocketOptions) control(mode string, fn func(context.Context, string, string, syscall.RawConn) error) func(context.Context, string, string, syscall.RawConn) error {
+	if *so == (SocketOptions{}) {
+		return fn
+	}
+	opts := *so
+	return func(ctx context.Context, network, address string, c syscall.RawConn) error {
+		var err error
+		if cerr := c.Control(func(s uintptr) {
+			err = opts.set(s, network, mode)
+		}); cerr != nil {
+			return cerr
+		}
+		if err != nil {
+			return err
+		}
+		if fn != nil {
+			return fn(ctx, network, address, c)
+		}
+		return nil
+	}
+}
+
+// isStreamNetwork reports whether network, as passed to a Control
+// function, is a TCP network.
+func isStreamNetwork(network string) bool {
+	return stringslite.HasPrefix(network, "tcp")
+}
+
+// checkLinuxOnly returns an error if any of the options that are only
+// supported on Linux is set.
+func (so *SocketOptions) checkLinuxOnly() error {
+	switch {
+	case so.FreeBind:
+		return unsupportedSocketOptionError("FreeBind")
+	case so.FastOpen > 0:
+		return unsupportedSocketOptionError("FastOpen")
+	case so.DeferAccept > 0:
+		return unsupportedSocketOptionError("DeferAccept")
+	case so.Mark != 0:
+		return unsupportedSocketOptionError("Mark")
+	}
+	return nil
+}
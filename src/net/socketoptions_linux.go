@@ -0,0 +1,79 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// These constants aren't in the syscall package, which is frozen.
+const (
+	_IPV6_FREEBIND        = 0x4e
+	_TCP_FASTOPEN         = 0x17
+	_TCP_FASTOPEN_CONNECT = 0x1e
+)
+
+// soReusePort returns the value of SO_REUSEPORT, which is missing
+// from the syscall package on some architectures.
+func soReusePort() int {
+	switch runtime.GOARCH {
+	case "mips", "mipsle", "mips64", "mips64le":
+		return 0x200
+	}
+	return 0xf
+}
+
+func (so *SocketOptions) set(s uintptr, network, mode string) error {
+	fd := int(s)
+	stream := isStreamNetwork(network)
+	if so.ReusePort {
+		if err := syscall.SetsockoptInt(fd, syscall.SOL_SOCKET, soReusePort(), 1); err != nil {
+			return wrapSyscallError("setsockopt", err)
+		}
+	}
+	if so.FreeBind {
+		var err error
+		if network[len(network)-1] == '6' {
+			err = syscall.SetsockoptInt(fd, syscall.IPPROTO_IPV6, _IPV6_FREEBIND, 1)
+		} else {
+			err = syscall.SetsockoptInt(fd, syscall.IPPROTO_IP, syscall.IP_FREEBIND, 1)
+		}
+		if err != nil {
+			return wrapSyscallError("setsockopt", err)
+		}
+	}
+	if so.FastOpen > 0 {
+		if !stream {
+			return unsupportedSocketOptionError("FastOpen")
+		}
+		var err error
+		if mode == "listen" {
+			err = syscall.SetsockoptInt(fd, syscall.IPPROTO_TCP, _TCP_FASTOPEN, so.FastOpen)
+		} else {
+			err = syscall.SetsockoptInt(fd, syscall.IPPROTO_TCP, _TCP_FASTOPEN_CONNECT, 1)
+		}
+		if err != nil {
+			return wrapSyscallError("setsockopt", err)
+		}
+	}
+	if so.DeferAccept > 0 {
+		if !stream || mode != "listen" {
+			return unsupportedSocketOptionError("DeferAccept")
+		}
+		// The kernel expects seconds so round to next highest second.
+		secs := int(roundDurationUp(so.DeferAccept, time.Second))
+		if err := syscall.SetsockoptInt(fd, syscall.IPPROTO_TCP, syscall.TCP_DEFER_ACCEPT, secs); err != nil {
+			return wrapSyscallError("setsockopt", err)
+		}
+	}
+	if so.Mark != 0 {
+		if err := syscall.SetsockoptInt(fd, syscall.SOL_SOCKET, syscall.SO_MARK, int(so.Mark)); err != nil {
+			return wrapSyscallError("setsockopt", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"context"
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSocketOptionsReusePort(t *testing.T) {
+	if !testableNetwork("tcp4") {
+		t.Skip("tcp4 is not supported")
+	}
+	lc := &ListenConfig{SocketOptions: SocketOptions{ReusePort: true}}
+	ln1, err := lc.Listen(context.Background(), "tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln1.Close()
+	ln2, err := lc.Listen(context.Background(), "tcp4", ln1.Addr().String())
+	if err != nil {
+		t.Fatalf("second Listen on %v with ReusePort: %v", ln1.Addr(), err)
+	}
+	defer ln2.Close()
+
+	// Without ReusePort, binding the same address must fail.
+	ln3, err := (&ListenConfig{}).Listen(context.Background(), "tcp4", ln1.Addr().String())
+	if err == nil {
+		ln3.Close()
+		t.Fatalf("Listen on %v without ReusePort succeeded", ln1.Addr())
+	}
+}
+
+func TestSocketOptionsListen(t *testing.T) {
+	if !testableNetwork("tcp4") {
+		t.Skip("tcp4 is not supported")
+	}
+	lc := &ListenConfig{
+		SocketOptions: SocketOptions{
+			FreeBind:    true,
+			FastOpen:    16,
+			DeferAccept: 1500 * time.Millisecond,
+		},
+	}
+	var controlled bool
+	lc.Control = func(network, address string, c syscall.RawConn) error {
+		controlled = true
+		var secs int
+		var err error
+		if cerr := c.Control(func(s uintptr) {
+			secs, err = syscall.GetsockoptInt(int(s), syscall.IPPROTO_TCP, syscall.TCP_DEFER_ACCEPT)
+		}); cerr != nil {
+			return cerr
+		}
+		if err != nil {
+			return err
+		}
+		// The kernel converts the timeout to a number of SYN-ACK
+		// retransmissions, so it may report a larger value.
+		if secs < 2 {
+			t.Errorf("TCP_DEFER_ACCEPT = %d, want at least 2", secs)
+		}
+		return nil
+	}
+	ln, err := lc.Listen(context.Background(), "tcp4", "127.0.0.1:0")
+	if errors.Is(err, syscall.ENOPROTOOPT) {
+		t.Skipf("socket option not available: %v", err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln.Close()
+	if !controlled {
+		t.Error("Control was not called after setting socket options")
+	}
+}
+
+func TestSocketOptionsUnsupported(t *testing.T) {
+	if !testableNetwork("udp4") {
+		t.Skip("udp4 is not supported")
+	}
+	lc := &ListenConfig{SocketOptions: SocketOptions{DeferAccept: time.Second}}
+	c, err := lc.ListenPacket(context.Background(), "udp4", "127.0.0.1:0")
+	if err == nil {
+		c.Close()
+		t.Fatal("ListenPacket with DeferAccept succeeded")
+	}
+	if !errors.Is(err, errors.ErrUnsupported) {
+		t.Fatalf("ListenPacket with DeferAccept: got %v, want error wrapping ErrUnsupported", err)
+	}
+
+	d := &Dialer{SocketOptions: SocketOptions{DeferAccept: time.Second}}
+	ln := newLocalListener(t, "tcp4")
+	defer ln.Close()
+	conn, err := d.Dial("tcp4", ln.Addr().String())
+	if err == nil {
+		conn.Close()
+		t.Fatal("Dial with DeferAccept succeeded")
+	}
+	if !errors.Is(err, errors.ErrUnsupported) {
+		t.Fatalf("Dial with DeferAccept: got %v, want error wrapping ErrUnsupported", err)
+	}
+}
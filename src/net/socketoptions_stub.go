@@ -0,0 +1,14 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build js || plan9 || solaris || wasip1 || windows
+
+package net
+
+func (so *SocketOptions) set(s uintptr, network, mode string) error {
+	if so.ReusePort {
+		return unsupportedSocketOptionError("ReusePort")
+	}
+	return so.checkLinuxOnly()
+}
@@ -0,0 +1,21 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build aix || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package net
+
+import "syscall"
+
+func (so *SocketOptions) set(s uintptr, network, mode string) error {
+	if err := so.checkLinuxOnly(); err != nil {
+		return err
+	}
+	if so.ReusePort {
+		if err := syscall.SetsockoptInt(int(s), syscall.SOL_SOCKET, syscall.SO_REUSEPORT, 1); err != nil {
+			return wrapSyscallError("setsockopt", err)
+		}
+	}
+	return nil
+}
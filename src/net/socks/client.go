@@ -0,0 +1,505 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package socks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	noDeadline   = time.Time{}
+	aLongTimeAgo = time.Unix(1, 0)
+)
+
+// A Dialer holds SOCKS-specific options.
+type Dialer struct {
+	proxyNetwork string // network between a proxy server and a client
+	proxyAddress string // proxy server address
+
+	// ProxyDial specifies the optional dial function for
+	// establishing the transport connection to the proxy server.
+	ProxyDial func(context.Context, string, string) (net.Conn, error)
+
+	// AuthMethods specifies the list of request authentication
+	// methods.
+	// If empty, SOCKS client requests only AuthMethodNotRequired.
+	AuthMethods []AuthMethod
+
+	// Authenticate specifies the optional authentication
+	// function. It must be non-nil when AuthMethods is not empty.
+	// It must return an error when the authentication is failed.
+	Authenticate func(context.Context, io.ReadWriter, AuthMethod) error
+}
+
+// NewDialer returns a new Dialer that dials through the provided
+// proxy server's network and address.
+func NewDialer(network, address string) *Dialer {
+	return &Dialer{proxyNetwork: network, proxyAddress: address}
+}
+
+// Dial connects to the address on the named network through the
+// proxy server.
+//
+// See [Dialer.DialContext] for a description of the network and
+// address parameters.
+func (d *Dialer) Dial(network, address string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, address)
+}
+
+// DialContext connects to the address on the named network through
+// the proxy server using the provided context.
+//
+// For the networks "tcp", "tcp4" and "tcp6", it issues a CONNECT
+// command and returns a [*Conn]. For the networks "udp", "udp4" and
+// "udp6", it issues a UDP ASSOCIATE command and returns a
+// [*PacketConn] whose Write method sends datagrams to address.
+// The host in address may be a name, in which case it is resolved by
+// the proxy server.
+//
+// The returned error value may be a [net.OpError]. When the Op field
+// of net.OpError contains "socks", the Source field contains a proxy
+// server address and the Addr field contains a command target
+// address.
+func (d *Dialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	cmd := CmdConnect
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+	case "udp", "udp4", "udp6":
+		cmd = CmdUDPAssociate
+	default:
+		return nil, d.opError(cmd, network, address, errors.New("network not implemented"))
+	}
+	if ctx == nil {
+		return nil, d.opError(cmd, network, address, errors.New("nil context"))
+	}
+	dst, err := parseAddr(address)
+	if err == nil && dst.Port == 0 {
+		err = errors.New("port number out of range 0")
+	}
+	if err != nil {
+		return nil, d.opError(cmd, network, address, err)
+	}
+	if cmd == CmdUDPAssociate {
+		c, err := d.associate(ctx, network, dst)
+		if err != nil {
+			return nil, d.opError(cmd, network, address, err)
+		}
+		return c, nil
+	}
+	c, err := d.dialProxy(ctx)
+	if err != nil {
+		return nil, d.opError(cmd, network, address, err)
+	}
+	a, err := d.connect(ctx, c, cmd, dst)
+	if err != nil {
+		c.Close()
+		return nil, d.opError(cmd, network, address, err)
+	}
+	return &Conn{Conn: c, boundAddr: a}, nil
+}
+
+// DialWithConn initiates a connection from SOCKS server to the target
+// network and address using the connection c that is already
+// connected to the SOCKS server. The network must be "tcp", "tcp4"
+// or "tcp6".
+//
+// It returns the connection's local address assigned by the SOCKS
+// server.
+func (d *Dialer) DialWithConn(ctx context.Context, c net.Conn, network, address string) (net.Addr, error) {
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+	default:
+		return nil, d.opError(CmdConnect, network, address, errors.New("network not implemented"))
+	}
+	if ctx == nil {
+		return nil, d.opError(CmdConnect, network, address, errors.New("nil context"))
+	}
+	dst, err := parseAddr(address)
+	if err != nil {
+		return nil, d.opError(CmdConnect, network, address, err)
+	}
+	a, err := d.connect(ctx, c, CmdConnect, dst)
+	if err != nil {
+		return nil, d.opError(CmdConnect, network, address, err)
+	}
+	return a, nil
+}
+
+// ListenPacket issues a UDP ASSOCIATE command and returns a
+// [*PacketConn] that exchanges datagrams with any address through the
+// proxy server. The network must be "udp", "udp4" or "udp6".
+func (d *Dialer) ListenPacket(ctx context.Context, network string) (*PacketConn, error) {
+	switch network {
+	case "udp", "udp4", "udp6":
+	default:
+		return nil, d.opError(CmdUDPAssociate, network, "", errors.New("network not implemented"))
+	}
+	c, err := d.associate(ctx, network, nil)
+	if err != nil {
+		return nil, d.opError(CmdUDPAssociate, network, "", err)
+	}
+	return c, nil
+}
+
+func (d *Dialer) opError(cmd Command, network, address string, err error) error {
+	proxy, _ := parseAddr(d.proxyAddress)
+	dst, _ := parseAddr(address)
+	return &net.OpError{Op: cmd.String(), Net: network, Source: addrOrNil(proxy), Addr: addrOrNil(dst), Err: err}
+}
+
+// addrOrNil avoids a non-nil net.Addr holding a nil *Addr.
+func addrOrNil(a *Addr) net.Addr {
+	if a == nil {
+		return nil
+	}
+	return a
+}
+
+func (d *Dialer) dialProxy(ctx context.Context) (net.Conn, error) {
+	if d.ProxyDial != nil {
+		return d.ProxyDial(ctx, d.proxyNetwork, d.proxyAddress)
+	}
+	var dd net.Dialer
+	return dd.DialContext(ctx, d.proxyNetwork, d.proxyAddress)
+}
+
+// connect negotiates authentication on c and issues cmd for dst.
+// It returns the address in the server's reply.
+func (d *Dialer) connect(ctx context.Context, c net.Conn, cmd Command, dst *Addr) (_ *Addr, ctxErr error) {
+	if deadline, ok := ctx.Deadline(); ok && !deadline.IsZero() {
+		c.SetDeadline(deadline)
+		defer c.SetDeadline(noDeadline)
+	}
+	if ctx != context.Background() {
+		errCh := make(chan error, 1)
+		done := make(chan struct{})
+		defer func() {
+			close(done)
+			// An error caused by cancellation is reported
+			// as the context's error.
+			if err := <-errCh; err != nil {
+				ctxErr = err
+			}
+		}()
+		go func() {
+			select {
+			case <-ctx.Done():
+				c.SetDeadline(aLongTimeAgo)
+				errCh <- ctx.Err()
+			case <-done:
+				errCh <- nil
+			}
+		}()
+	}
+
+	b := make([]byte, 0, 6+len(dst.Name)) // the size here is just an estimate
+	b = append(b, version5)
+	if len(d.AuthMethods) == 0 || d.Authenticate == nil {
+		b = append(b, 1, byte(AuthMethodNotRequired))
+	} else {
+		ams := d.AuthMethods
+		if len(ams) > 255 {
+			return nil, errors.New("too many authentication methods")
+		}
+		b = append(b, byte(len(ams)))
+		for _, am := range ams {
+			b = append(b, byte(am))
+		}
+	}
+	if _, ctxErr = c.Write(b); ctxErr != nil {
+		return
+	}
+
+	if _, ctxErr = io.ReadFull(c, b[:2]); ctxErr != nil {
+		return
+	}
+	if b[0] != version5 {
+		return nil, errors.New("unexpected protocol version " + strconv.Itoa(int(b[0])))
+	}
+	am := AuthMethod(b[1])
+	if am == AuthMethodNoAcceptableMethods {
+		return nil, errors.New("no acceptable authentication methods")
+	}
+	if d.Authenticate != nil {
+		if ctxErr = d.Authenticate(ctx, c, am); ctxErr != nil {
+			return
+		}
+	}
+
+	b = append(b[:0], version5, byte(cmd), 0)
+	if b, ctxErr = appendAddr(b, dst); ctxErr != nil {
+		return
+	}
+	if _, ctxErr = c.Write(b); ctxErr != nil {
+		return
+	}
+
+	if _, ctxErr = io.ReadFull(c, b[:3]); ctxErr != nil {
+		return
+	}
+	if b[0] != version5 {
+		return nil, errors.New("unexpected protocol version " + strconv.Itoa(int(b[0])))
+	}
+	if code := Reply(b[1]); code != StatusSucceeded {
+		return nil, &ReplyError{Reply: code}
+	}
+	if b[2] != 0 {
+		return nil, errors.New("non-zero reserved field")
+	}
+	a, err := readAddr(c)
+	if err == errAddrType {
+		return nil, errors.New("unknown address type")
+	}
+	return a, err
+}
+
+// associate issues a UDP ASSOCIATE command and sets up the relay.
+// If dst is not nil, it is the default destination of the returned
+// PacketConn.
+func (d *Dialer) associate(ctx context.Context, network string, dst *Addr) (*PacketConn, error) {
+	ctrl, err := d.dialProxy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// The client's address is not known until the relay has
+	// been set up, so the request leaves it unspecified.
+	relay, err := d.connect(ctx, ctrl, CmdUDPAssociate, &Addr{IP: net.IPv4zero, Port: 0})
+	if err != nil {
+		ctrl.Close()
+		return nil, err
+	}
+	if relay.IP == nil || relay.IP.IsUnspecified() {
+		// The relay is on the host of the proxy server.
+		if tcp, ok := ctrl.RemoteAddr().(*net.TCPAddr); ok {
+			relay = &Addr{IP: tcp.IP, Port: relay.Port}
+		}
+	}
+	var dd net.Dialer
+	udp, err := dd.DialContext(ctx, network, relay.String())
+	if err != nil {
+		ctrl.Close()
+		return nil, err
+	}
+	c := &PacketConn{ctrl: ctrl, udp: udp.(*net.UDPConn), relay: relay, raddr: dst}
+	go c.watch()
+	return c, nil
+}
+
+// A Conn represents a forward proxy connection.
+type Conn struct {
+	net.Conn
+
+	boundAddr net.Addr
+}
+
+// BoundAddr returns the address assigned by the proxy server for
+// connecting to the command target address from the proxy server.
+func (c *Conn) BoundAddr() net.Addr {
+	if c == nil {
+		return nil
+	}
+	return c.boundAddr
+}
+
+// maxDatagramHeader is the size of the largest UDP relay header:
+// RSV, FRAG, ATYP, a 255-byte FQDN with its length, and the port.
+const maxDatagramHeader = 3 + 1 + 1 + 255 + 2
+
+// A PacketConn represents a UDP relay through a proxy server,
+// established by the UDP ASSOCIATE command. It implements the
+// [net.PacketConn] interface and, when returned by
+// [Dialer.DialContext], the [net.Conn] interface.
+//
+// The relay lasts as long as the TCP connection on which it was
+// established, which is closed by Close.
+type PacketConn struct {
+	ctrl  net.Conn     // connection that carried the command
+	udp   *net.UDPConn // socket connected to the relay
+	relay *Addr        // address of the relay
+	raddr *Addr        // default destination, or nil
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// watch closes the relay socket when the proxy server closes the
+// control connection, which ends the association.
+func (c *PacketConn) watch() {
+	io.Copy(io.Discard, c.ctrl)
+	c.udp.Close()
+}
+
+// RelayAddr returns the address of the proxy server's UDP relay.
+func (c *PacketConn) RelayAddr() net.Addr {
+	return c.relay
+}
+
+// ReadFrom reads a datagram relayed by the proxy server, returning
+// the payload and the address of its sender.
+func (c *PacketConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	n, from, err := c.readFrom(p)
+	if err != nil {
+		return n, nil, c.opError("read", nil, err)
+	}
+	return n, from, nil
+}
+
+func (c *PacketConn) readFrom(p []byte) (int, *Addr, error) {
+	buf := make([]byte, maxDatagramHeader+len(p))
+	for {
+		n, err := c.udp.Read(buf)
+		if err != nil {
+			return 0, nil, err
+		}
+		from, payload, err := parseDatagram(buf[:n])
+		if err != nil {
+			// Not a datagram we can deliver.
+			continue
+		}
+		return copy(p, payload), from, nil
+	}
+}
+
+// WriteTo sends the payload p to addr through the proxy server.
+// The address may be a [*net.UDPAddr], an [*Addr], or any [net.Addr]
+// whose String method returns a host:port pair.
+func (c *PacketConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	var dst *Addr
+	switch a := addr.(type) {
+	case *Addr:
+		dst = a
+	case *net.UDPAddr:
+		dst = &Addr{IP: a.IP, Port: a.Port}
+	default:
+		var err error
+		if dst, err = parseAddr(addr.String()); err != nil {
+			return 0, c.opError("write", addr, err)
+		}
+	}
+	n, err := c.writeTo(p, dst)
+	if err != nil {
+		return n, c.opError("write", addr, err)
+	}
+	return n, nil
+}
+
+func (c *PacketConn) writeTo(p []byte, dst *Addr) (int, error) {
+	b, err := appendDatagram(make([]byte, 0, maxDatagramHeader+len(p)), dst)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := c.udp.Write(append(b, p...)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Read reads a datagram sent by the remote address.
+// Datagrams from other addresses are discarded.
+// It is only valid on a PacketConn returned by [Dialer.DialContext].
+func (c *PacketConn) Read(p []byte) (int, error) {
+	if c.raddr == nil {
+		return 0, c.opError("read", nil, errors.New("not connected"))
+	}
+	for {
+		n, from, err := c.readFrom(p)
+		if err != nil {
+			return 0, c.opError("read", c.raddr, err)
+		}
+		if c.raddr.IP == nil || c.raddr.IP.Equal(from.IP) && c.raddr.Port == from.Port {
+			return n, nil
+		}
+	}
+}
+
+// Write sends a datagram to the remote address.
+// It is only valid on a PacketConn returned by [Dialer.DialContext].
+func (c *PacketConn) Write(p []byte) (int, error) {
+	if c.raddr == nil {
+		return 0, c.opError("write", nil, errors.New("not connected"))
+	}
+	n, err := c.writeTo(p, c.raddr)
+	if err != nil {
+		return n, c.opError("write", c.raddr, err)
+	}
+	return n, nil
+}
+
+// Close ends the association and closes the connection.
+func (c *PacketConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.closeErr = c.ctrl.Close()
+		if err := c.udp.Close(); c.closeErr == nil {
+			c.closeErr = err
+		}
+	})
+	return c.closeErr
+}
+
+// LocalAddr returns the local address of the socket that exchanges
+// datagrams with the relay.
+func (c *PacketConn) LocalAddr() net.Addr { return c.udp.LocalAddr() }
+
+// RemoteAddr returns the remote address given to [Dialer.DialContext],
+// or nil if c was returned by [Dialer.ListenPacket].
+func (c *PacketConn) RemoteAddr() net.Addr { return addrOrNil(c.raddr) }
+
+// SetDeadline implements the [net.Conn] SetDeadline method.
+func (c *PacketConn) SetDeadline(t time.Time) error { return c.udp.SetDeadline(t) }
+
+// SetReadDeadline implements the [net.Conn] SetReadDeadline method.
+func (c *PacketConn) SetReadDeadline(t time.Time) error { return c.udp.SetReadDeadline(t) }
+
+// SetWriteDeadline implements the [net.Conn] SetWriteDeadline method.
+func (c *PacketConn) SetWriteDeadline(t time.Time) error { return c.udp.SetWriteDeadline(t) }
+
+func (c *PacketConn) opError(op string, addr net.Addr, err error) error {
+	return &net.OpError{Op: op, Net: "socks", Source: c.udp.LocalAddr(), Addr: addr, Err: err}
+}
+
+// UsernamePassword are the credentials for the username/password
+// authentication method.
+type UsernamePassword struct {
+	Username string
+	Password string
+}
+
+// Authenticate authenticates a pair of username and password with the
+// proxy server.
+func (up *UsernamePassword) Authenticate(ctx context.Context, rw io.ReadWriter, auth AuthMethod) error {
+	switch auth {
+	case AuthMethodNotRequired:
+		return nil
+	case AuthMethodUsernamePassword:
+		if len(up.Username) == 0 || len(up.Username) > 255 || len(up.Password) > 255 {
+			return errors.New("invalid username/password")
+		}
+		b := []byte{authUsernamePasswordVersion}
+		b = append(b, byte(len(up.Username)))
+		b = append(b, up.Username...)
+		b = append(b, byte(len(up.Password)))
+		b = append(b, up.Password...)
+		if _, err := rw.Write(b); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(rw, b[:2]); err != nil {
+			return err
+		}
+		if b[0] != authUsernamePasswordVersion {
+			return errors.New("invalid username/password version")
+		}
+		if b[1] != authStatusSucceeded {
+			return errors.New("username/password authentication failed")
+		}
+		return nil
+	}
+	return errors.New("unsupported authentication method " + strconv.Itoa(int(auth)))
+}
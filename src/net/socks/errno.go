@@ -0,0 +1,26 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !plan9
+
+package socks
+
+import (
+	"errors"
+	"syscall"
+)
+
+// replyForErrno returns the reply code describing a system call
+// error from dialing.
+func replyForErrno(err error) Reply {
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return StatusConnectionRefused
+	case errors.Is(err, syscall.ENETUNREACH):
+		return StatusNetworkUnreachable
+	case errors.Is(err, syscall.EHOSTUNREACH):
+		return StatusHostUnreachable
+	}
+	return StatusGeneralFailure
+}
@@ -0,0 +1,11 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package socks
+
+// replyForErrno returns the reply code describing a system call
+// error from dialing.
+func replyForErrno(err error) Reply {
+	return StatusGeneralFailure
+}
@@ -0,0 +1,41 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package socks_test
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/socks"
+)
+
+func ExampleDialer() {
+	d := socks.NewDialer("tcp", "bastion.example:1080")
+	auth := &socks.UsernamePassword{Username: "gopher", Password: "secret"}
+	d.AuthMethods = []socks.AuthMethod{socks.AuthMethodNotRequired, socks.AuthMethodUsernamePassword}
+	d.Authenticate = auth.Authenticate
+
+	c, err := d.Dial("tcp", "internal.example:80")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer c.Close()
+	fmt.Fprintf(c, "GET / HTTP/1.0\r\nHost: internal.example\r\n\r\n")
+	io.Copy(io.Discard, c)
+}
+
+func ExampleServer() {
+	ln, err := net.Listen("tcp", "127.0.0.1:1080")
+	if err != nil {
+		log.Fatal(err)
+	}
+	s := &socks.Server{
+		Credentials: func(username, password string) bool {
+			return username == "gopher" && password == "secret"
+		},
+	}
+	log.Fatal(s.Serve(ln))
+}
@@ -0,0 +1,513 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package socks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrNotAllowed may be returned by the Allow or Dial functions of a
+// [Server] to refuse a connection. The client is sent
+// [StatusNotAllowed].
+var ErrNotAllowed = errors.New("socks: connection not allowed by ruleset")
+
+// A Server is a SOCKS version 5 proxy server.
+// It serves the CONNECT and UDP ASSOCIATE commands.
+type Server struct {
+	// Allow, if non-nil, is the policy for the destinations of
+	// commands. It is called with the command and the destination
+	// address, as a host and port, where the host may be a domain
+	// name, and reports an error if the client may not reach it.
+	//
+	// For CONNECT commands, Allow is called before Dial, and an
+	// error is reported to the client as for Dial. For UDP
+	// ASSOCIATE commands, Allow is called once for each destination
+	// the client sends datagrams to during the association, and
+	// datagrams for refused destinations are dropped.
+	Allow func(ctx context.Context, cmd Command, address string) error
+
+	// Dial specifies the function used to connect to the targets
+	// of CONNECT commands. If nil, a zero net.Dialer is used.
+	//
+	// The reply sent to the client is derived from the error
+	// returned by Dial: ErrNotAllowed results in StatusNotAllowed,
+	// and errors for refused connections and unreachable
+	// hosts and networks result in the corresponding codes.
+	//
+	// Dial is not used for the datagrams of UDP ASSOCIATE commands;
+	// use Allow for a policy that covers both commands.
+	Dial func(ctx context.Context, network, address string) (net.Conn, error)
+
+	// ListenPacket specifies the function used to create the
+	// socket of the relay for a UDP ASSOCIATE command. It is called
+	// with the network "udp" and the local IP address of the
+	// client's connection with port 0. If nil, a zero
+	// net.ListenConfig is used.
+	//
+	// If ListenPacket returns an error wrapping
+	// errors.ErrUnsupported, the command is refused with
+	// StatusCommandNotSupported.
+	ListenPacket func(ctx context.Context, network, address string) (net.PacketConn, error)
+
+	// Credentials, if non-nil, requires clients to authenticate
+	// with the username/password method, and reports whether
+	// the username and password they provided are valid.
+	Credentials func(username, password string) bool
+
+	// HandshakeTimeout is the maximum amount of time allowed for a
+	// client to authenticate and send its command. If zero, a
+	// default of 30 seconds is used. If negative, there is no
+	// limit.
+	HandshakeTimeout time.Duration
+
+	// ErrorLog specifies an optional logger for errors accepting
+	// connections and serving clients. If nil, errors are not
+	// logged.
+	ErrorLog *log.Logger
+}
+
+// Serve accepts connections on l, serving each one in a new goroutine
+// with [Server.ServeConn]. It returns when l.Accept fails with an
+// error that is not temporary.
+func (s *Server) Serve(l net.Listener) error {
+	var tempDelay time.Duration // how long to sleep on accept failure
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				if tempDelay == 0 {
+					tempDelay = 5 * time.Millisecond
+				} else {
+					tempDelay *= 2
+				}
+				if max := 1 * time.Second; tempDelay > max {
+					tempDelay = max
+				}
+				s.logf("socks: Accept error: %v; retrying in %v", err, tempDelay)
+				time.Sleep(tempDelay)
+				continue
+			}
+			return err
+		}
+		tempDelay = 0
+		go func() {
+			if err := s.ServeConn(context.Background(), c); err != nil {
+				s.logf("socks: serving %v: %v", c.RemoteAddr(), err)
+			}
+		}()
+	}
+}
+
+// ServeConn serves a single client connection: it negotiates
+// authentication, reads a command and carries it out. It closes c
+// before returning.
+//
+// For CONNECT commands, ServeConn returns once both directions of
+// the proxied connection are finished. For UDP ASSOCIATE commands,
+// it returns when the client closes c, which ends the association.
+// Canceling ctx also ends either command.
+func (s *Server) ServeConn(ctx context.Context, c net.Conn) error {
+	defer c.Close()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if d := s.handshakeTimeout(); d > 0 {
+		c.SetDeadline(time.Now().Add(d))
+	}
+	stop := context.AfterFunc(ctx, func() { c.SetDeadline(aLongTimeAgo) })
+	defer stop()
+
+	if err := s.negotiate(c); err != nil {
+		return err
+	}
+
+	var b [3]byte
+	if _, err := io.ReadFull(c, b[:]); err != nil {
+		return err
+	}
+	if b[0] != version5 {
+		return errors.New("unexpected protocol version " + strconv.Itoa(int(b[0])))
+	}
+	cmd := Command(b[1])
+	dst, err := readAddr(c)
+	if err == errAddrType {
+		writeReply(c, StatusAddressTypeNotSupported, nil)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	// Clear the handshake deadline, unless ctx has set its own.
+	c.SetDeadline(time.Time{})
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	switch cmd {
+	case CmdConnect:
+		return s.connect(ctx, c, dst)
+	case CmdUDPAssociate:
+		return s.associate(ctx, c, dst)
+	default:
+		writeReply(c, StatusCommandNotSupported, nil)
+		return errors.New("unsupported command " + cmd.String())
+	}
+}
+
+// defaultHandshakeTimeout is the HandshakeTimeout used if it is zero.
+const defaultHandshakeTimeout = 30 * time.Second
+
+func (s *Server) handshakeTimeout() time.Duration {
+	if s.HandshakeTimeout == 0 {
+		return defaultHandshakeTimeout
+	}
+	return s.HandshakeTimeout
+}
+
+func (s *Server) logf(format string, args ...any) {
+	if s.ErrorLog != nil {
+		s.ErrorLog.Printf(format, args...)
+	}
+}
+
+// negotiate selects an authentication method and authenticates the
+// client.
+func (s *Server) negotiate(c net.Conn) error {
+	var b [2 + 255]byte
+	if _, err := io.ReadFull(c, b[:2]); err != nil {
+		return err
+	}
+	if b[0] != version5 {
+		return errors.New("unexpected protocol version " + strconv.Itoa(int(b[0])))
+	}
+	methods := b[2 : 2+int(b[1])]
+	if _, err := io.ReadFull(c, methods); err != nil {
+		return err
+	}
+	want := AuthMethodNotRequired
+	if s.Credentials != nil {
+		want = AuthMethodUsernamePassword
+	}
+	if !slices.Contains(methods, byte(want)) {
+		c.Write([]byte{version5, byte(AuthMethodNoAcceptableMethods)})
+		return errors.New("no acceptable authentication methods")
+	}
+	if _, err := c.Write([]byte{version5, byte(want)}); err != nil {
+		return err
+	}
+	if want == AuthMethodUsernamePassword {
+		return s.authenticate(c)
+	}
+	return nil
+}
+
+// authenticate carries out username/password authentication.
+func (s *Server) authenticate(c net.Conn) error {
+	var b [255]byte
+	if _, err := io.ReadFull(c, b[:2]); err != nil {
+		return err
+	}
+	if b[0] != authUsernamePasswordVersion {
+		return errors.New("invalid username/password version")
+	}
+	username := b[:b[1]]
+	if _, err := io.ReadFull(c, username); err != nil {
+		return err
+	}
+	user := string(username)
+	if _, err := io.ReadFull(c, b[:1]); err != nil {
+		return err
+	}
+	password := b[:b[0]]
+	if _, err := io.ReadFull(c, password); err != nil {
+		return err
+	}
+	if !s.Credentials(user, string(password)) {
+		c.Write([]byte{authUsernamePasswordVersion, authStatusFailed})
+		return errors.New("username/password authentication failed")
+	}
+	_, err := c.Write([]byte{authUsernamePasswordVersion, authStatusSucceeded})
+	return err
+}
+
+// writeReply sends a reply with the given code and bound address.
+func writeReply(c net.Conn, code Reply, bound net.Addr) error {
+	a := &Addr{IP: net.IPv4zero}
+	switch bound := bound.(type) {
+	case *net.TCPAddr:
+		a = &Addr{IP: bound.IP, Port: bound.Port}
+	case *net.UDPAddr:
+		a = &Addr{IP: bound.IP, Port: bound.Port}
+	}
+	b, err := appendAddr([]byte{version5, byte(code), 0}, a)
+	if err != nil {
+		return err
+	}
+	_, err = c.Write(b)
+	return err
+}
+
+// connect carries out a CONNECT command.
+func (s *Server) connect(ctx context.Context, c net.Conn, dst *Addr) error {
+	if dst.Port == 0 {
+		writeReply(c, StatusGeneralFailure, nil)
+		return errors.New("port number out of range 0")
+	}
+	if s.Allow != nil {
+		if err := s.Allow(ctx, CmdConnect, dst.String()); err != nil {
+			writeReply(c, replyFor(err), nil)
+			return err
+		}
+	}
+	dial := s.Dial
+	if dial == nil {
+		var d net.Dialer
+		dial = d.DialContext
+	}
+	target, err := dial(ctx, "tcp", dst.String())
+	if err != nil {
+		writeReply(c, replyFor(err), nil)
+		return err
+	}
+	defer target.Close()
+	stop := context.AfterFunc(ctx, func() { target.SetDeadline(aLongTimeAgo) })
+	defer stop()
+	if err := writeReply(c, StatusSucceeded, target.LocalAddr()); err != nil {
+		return err
+	}
+
+	errc := make(chan error, 2)
+	go func() { errc <- pipe(target, c) }()
+	go func() { errc <- pipe(c, target) }()
+	err = <-errc
+	if err2 := <-errc; err == nil {
+		err = err2
+	}
+	return err
+}
+
+// pipe copies from src to dst, then closes the write side of dst so
+// that the peer sees the end of the stream.
+func pipe(dst, src net.Conn) error {
+	_, err := io.Copy(dst, src)
+	if cw, ok := dst.(interface{ CloseWrite() error }); ok {
+		cw.CloseWrite()
+	} else {
+		dst.Close()
+	}
+	return err
+}
+
+// replyFor returns the reply code describing a failure to dial.
+func replyFor(err error) Reply {
+	var dnsErr *net.DNSError
+	switch {
+	case errors.Is(err, ErrNotAllowed):
+		return StatusNotAllowed
+	case errors.Is(err, errors.ErrUnsupported):
+		return StatusCommandNotSupported
+	case errors.As(err, &dnsErr):
+		return StatusHostUnreachable
+	}
+	return replyForErrno(err)
+}
+
+// associate carries out a UDP ASSOCIATE command. The client
+// announced that it would send datagrams from client, whose
+// address and port may be zero if it does not know them yet.
+// Only the port is used: datagrams are accepted only from the host
+// the request came from, so that a client cannot direct the relay
+// at another host.
+func (s *Server) associate(ctx context.Context, c net.Conn, client *Addr) error {
+	host := ""
+	if tcp, ok := c.LocalAddr().(*net.TCPAddr); ok {
+		host = tcp.IP.String()
+	}
+	listen := s.ListenPacket
+	if listen == nil {
+		var lc net.ListenConfig
+		listen = lc.ListenPacket
+	}
+	pc, err := listen(ctx, "udp", net.JoinHostPort(host, "0"))
+	if err != nil {
+		writeReply(c, replyFor(err), nil)
+		return err
+	}
+	defer pc.Close()
+	if err := writeReply(c, StatusSucceeded, pc.LocalAddr()); err != nil {
+		return err
+	}
+
+	rctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	r := &relay{ctx: rctx, allow: s.Allow, pc: pc}
+	if tcp, ok := c.RemoteAddr().(*net.TCPAddr); ok {
+		r.clientIP = tcp.IP
+		if client.Port != 0 {
+			r.client = &net.UDPAddr{IP: r.clientIP, Port: client.Port}
+		}
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		r.run()
+	}()
+
+	// The association ends when the client closes the connection.
+	_, err = io.Copy(io.Discard, c)
+	cancel()
+	pc.Close()
+	wg.Wait()
+	r.lookups.Wait()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// A relay forwards datagrams between a client and the hosts it
+// exchanges datagrams with.
+type relay struct {
+	ctx      context.Context
+	allow    func(ctx context.Context, cmd Command, address string) error
+	pc       net.PacketConn
+	clientIP net.IP
+	client   *net.UDPAddr // learned from the first datagram, if not announced
+
+	mu      sync.Mutex
+	dests   map[string]*relayDest // by destination address from the client
+	lookups sync.WaitGroup
+}
+
+// A relayDest is a destination of the client's datagrams, which is
+// checked against the server's policy and resolved once, without
+// blocking the relay.
+type relayDest struct {
+	done   bool
+	addr   *net.UDPAddr // nil if the destination is refused or unknown
+	queued [][]byte     // datagrams waiting for the lookup
+}
+
+// Limits on the state a relay keeps for destinations.
+const (
+	maxRelayDests  = 256
+	maxRelayQueued = 8
+)
+
+func (r *relay) run() {
+	buf := make([]byte, 64<<10)
+	for {
+		n, from, err := r.pc.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		src, ok := from.(*net.UDPAddr)
+		if !ok {
+			continue
+		}
+		if r.isClient(src) {
+			dst, payload, err := parseDatagram(buf[:n])
+			if err != nil {
+				continue
+			}
+			r.forward(dst, payload)
+			continue
+		}
+		if r.client == nil {
+			continue
+		}
+		// Make room for the header before the payload.
+		hdr, err := appendDatagram(make([]byte, 0, maxDatagramHeader), &Addr{IP: src.IP, Port: src.Port})
+		if err != nil {
+			continue
+		}
+		r.pc.WriteTo(append(hdr, buf[:n]...), r.client)
+	}
+}
+
+// forward sends a datagram from the client to dst. The first datagram
+// to a destination starts a lookup, and datagrams are queued until it
+// is done, or dropped if too many are.
+func (r *relay) forward(dst *Addr, payload []byte) {
+	key := dst.String()
+	r.mu.Lock()
+	d, ok := r.dests[key]
+	if !ok {
+		if r.dests == nil || len(r.dests) >= maxRelayDests {
+			r.dests = make(map[string]*relayDest)
+		}
+		d = &relayDest{}
+		r.dests[key] = d
+		r.lookups.Add(1)
+		go r.lookup(dst, d)
+	}
+	switch {
+	case d.addr != nil:
+		addr := d.addr
+		r.mu.Unlock()
+		r.pc.WriteTo(payload, addr)
+		return
+	case !d.done && len(d.queued) < maxRelayQueued:
+		d.queued = append(d.queued, bytes.Clone(payload))
+	}
+	r.mu.Unlock()
+}
+
+// lookup checks and resolves the destination dst, then sends the
+// datagrams queued for it.
+func (r *relay) lookup(dst *Addr, d *relayDest) {
+	defer r.lookups.Done()
+	addr := r.resolve(dst)
+	r.mu.Lock()
+	d.done = true
+	d.addr = addr
+	queued := d.queued
+	d.queued = nil
+	r.mu.Unlock()
+	if addr == nil {
+		return
+	}
+	for _, p := range queued {
+		r.pc.WriteTo(p, addr)
+	}
+}
+
+// resolve returns the address of the destination dst, or nil if the
+// server's policy refuses it or it cannot be resolved.
+func (r *relay) resolve(dst *Addr) *net.UDPAddr {
+	if r.allow != nil && r.allow(r.ctx, CmdUDPAssociate, dst.String()) != nil {
+		return nil
+	}
+	if dst.Name == "" {
+		return &net.UDPAddr{IP: dst.IP, Port: dst.Port}
+	}
+	ips, err := net.DefaultResolver.LookupIPAddr(r.ctx, dst.Name)
+	if err != nil || len(ips) == 0 {
+		return nil
+	}
+	return &net.UDPAddr{IP: ips[0].IP, Port: dst.Port, Zone: ips[0].Zone}
+}
+
+// isClient reports whether a datagram from src was sent by the
+// client, learning the client's address on its first datagram.
+func (r *relay) isClient(src *net.UDPAddr) bool {
+	if r.client != nil {
+		return r.client.IP.Equal(src.IP) && r.client.Port == src.Port
+	}
+	if r.clientIP == nil || r.clientIP.Equal(src.IP) {
+		r.client = src
+		return true
+	}
+	return false
+}
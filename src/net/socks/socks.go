@@ -0,0 +1,259 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package socks implements SOCKS version 5 clients and servers.
+//
+// SOCKS protocol version 5 is defined in RFC 1928.
+// Username/Password authentication for SOCKS version 5 is defined in
+// RFC 1929.
+//
+// A [Dialer] connects to TCP targets through a proxy with the CONNECT
+// command, and exchanges UDP datagrams through a proxy with the
+// UDP ASSOCIATE command. A [Server] serves both commands.
+// The BIND command is not supported.
+package socks
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"net/netip"
+	"strconv"
+)
+
+// A Command represents a SOCKS command.
+type Command int
+
+// SOCKS commands.
+const (
+	CmdConnect      Command = 0x01 // establishes an active-open forward proxy connection
+	CmdBind         Command = 0x02 // establishes a passive-open forward proxy connection
+	CmdUDPAssociate Command = 0x03 // establishes a UDP relay
+)
+
+func (cmd Command) String() string {
+	switch cmd {
+	case CmdConnect:
+		return "socks connect"
+	case CmdBind:
+		return "socks bind"
+	case CmdUDPAssociate:
+		return "socks udp associate"
+	default:
+		return "socks " + strconv.Itoa(int(cmd))
+	}
+}
+
+// An AuthMethod represents a SOCKS authentication method.
+type AuthMethod int
+
+// SOCKS authentication methods.
+const (
+	AuthMethodNotRequired         AuthMethod = 0x00 // no authentication required
+	AuthMethodUsernamePassword    AuthMethod = 0x02 // use username/password
+	AuthMethodNoAcceptableMethods AuthMethod = 0xff // no acceptable authentication methods
+)
+
+// A Reply represents a SOCKS command reply code.
+type Reply int
+
+// SOCKS reply codes.
+const (
+	StatusSucceeded               Reply = 0x00
+	StatusGeneralFailure          Reply = 0x01
+	StatusNotAllowed              Reply = 0x02
+	StatusNetworkUnreachable      Reply = 0x03
+	StatusHostUnreachable         Reply = 0x04
+	StatusConnectionRefused       Reply = 0x05
+	StatusTTLExpired              Reply = 0x06
+	StatusCommandNotSupported     Reply = 0x07
+	StatusAddressTypeNotSupported Reply = 0x08
+)
+
+func (code Reply) String() string {
+	switch code {
+	case StatusSucceeded:
+		return "succeeded"
+	case StatusGeneralFailure:
+		return "general SOCKS server failure"
+	case StatusNotAllowed:
+		return "connection not allowed by ruleset"
+	case StatusNetworkUnreachable:
+		return "network unreachable"
+	case StatusHostUnreachable:
+		return "host unreachable"
+	case StatusConnectionRefused:
+		return "connection refused"
+	case StatusTTLExpired:
+		return "TTL expired"
+	case StatusCommandNotSupported:
+		return "command not supported"
+	case StatusAddressTypeNotSupported:
+		return "address type not supported"
+	default:
+		return "unknown code: " + strconv.Itoa(int(code))
+	}
+}
+
+// A ReplyError is returned by a [Dialer] when the proxy server
+// rejects a command.
+type ReplyError struct {
+	Reply Reply
+}
+
+func (e *ReplyError) Error() string {
+	return "socks: " + e.Reply.String()
+}
+
+// Wire protocol constants.
+const (
+	version5 = 0x05
+
+	addrTypeIPv4 = 0x01
+	addrTypeFQDN = 0x03
+	addrTypeIPv6 = 0x04
+
+	authUsernamePasswordVersion = 0x01
+	authStatusSucceeded         = 0x00
+	authStatusFailed            = 0x01
+)
+
+// An Addr represents a SOCKS-specific address.
+// Either Name or IP is used exclusively.
+type Addr struct {
+	Name string // fully-qualified domain name
+	IP   net.IP
+	Port int
+}
+
+// Network returns the address's network name, "socks".
+func (a *Addr) Network() string { return "socks" }
+
+func (a *Addr) String() string {
+	if a == nil {
+		return "<nil>"
+	}
+	port := strconv.Itoa(a.Port)
+	if a.IP == nil {
+		return net.JoinHostPort(a.Name, port)
+	}
+	return net.JoinHostPort(a.IP.String(), port)
+}
+
+// parseAddr parses a host:port address.
+func parseAddr(address string) (*Addr, error) {
+	host, port, err := splitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	a := &Addr{Port: port}
+	if ip, err := netip.ParseAddr(host); err == nil {
+		a.IP = net.IP(ip.Unmap().AsSlice())
+	} else {
+		a.Name = host
+	}
+	return a, nil
+}
+
+func splitHostPort(address string) (string, int, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", 0, err
+	}
+	portnum, err := strconv.Atoi(port)
+	if err != nil {
+		return "", 0, err
+	}
+	if 0 > portnum || portnum > 0xffff {
+		return "", 0, errors.New("port number out of range " + port)
+	}
+	return host, portnum, nil
+}
+
+// appendAddr appends the wire form of a to b:
+// the address type, the address and the port.
+func appendAddr(b []byte, a *Addr) ([]byte, error) {
+	switch {
+	case a.IP.To4() != nil:
+		b = append(b, addrTypeIPv4)
+		b = append(b, a.IP.To4()...)
+	case a.IP != nil:
+		if len(a.IP) != net.IPv6len {
+			return nil, errors.New("unknown address type")
+		}
+		b = append(b, addrTypeIPv6)
+		b = append(b, a.IP...)
+	default:
+		if len(a.Name) > 255 {
+			return nil, errors.New("FQDN too long")
+		}
+		b = append(b, addrTypeFQDN, byte(len(a.Name)))
+		b = append(b, a.Name...)
+	}
+	return append(b, byte(a.Port>>8), byte(a.Port)), nil
+}
+
+// errAddrType is returned when a message holds an address of an
+// unknown type.
+var errAddrType = errors.New("address type not supported")
+
+// readAddr reads the wire form of an address from r.
+func readAddr(r io.Reader) (*Addr, error) {
+	var b [1 + 255 + 2]byte
+	if _, err := io.ReadFull(r, b[:1]); err != nil {
+		return nil, err
+	}
+	var a Addr
+	l := 2
+	switch b[0] {
+	case addrTypeIPv4:
+		l += net.IPv4len
+		a.IP = make(net.IP, net.IPv4len)
+	case addrTypeIPv6:
+		l += net.IPv6len
+		a.IP = make(net.IP, net.IPv6len)
+	case addrTypeFQDN:
+		if _, err := io.ReadFull(r, b[:1]); err != nil {
+			return nil, err
+		}
+		l += int(b[0])
+	default:
+		return nil, errAddrType
+	}
+	if _, err := io.ReadFull(r, b[:l]); err != nil {
+		return nil, err
+	}
+	if a.IP != nil {
+		copy(a.IP, b[:l])
+	} else {
+		a.Name = string(b[:l-2])
+	}
+	a.Port = int(b[l-2])<<8 | int(b[l-1])
+	return &a, nil
+}
+
+// parseDatagram parses the header of a UDP relay datagram,
+// returning the address and the payload.
+func parseDatagram(b []byte) (*Addr, []byte, error) {
+	// RSV, FRAG, then an address.
+	if len(b) < 4 {
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+	if b[2] != 0 {
+		return nil, nil, errors.New("fragmented datagrams are not supported")
+	}
+	r := bytes.NewReader(b[3:])
+	a, err := readAddr(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return a, b[len(b)-r.Len():], nil
+}
+
+// appendDatagram appends the header of a UDP relay datagram for
+// address a to b.
+func appendDatagram(b []byte, a *Addr) ([]byte, error) {
+	return appendAddr(append(b, 0, 0, 0), a)
+}
@@ -0,0 +1,496 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package socks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// startServer serves s on a loopback listener and returns its address.
+func startServer(t *testing.T, s *Server) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go s.Serve(ln)
+	return ln.Addr().String()
+}
+
+// startTCPEcho starts a TCP server that echoes what it reads.
+func startTCPEcho(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				io.Copy(c, c)
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// startUDPEcho starts a UDP server that echoes the datagrams it reads.
+func startUDPEcho(t *testing.T) *net.UDPAddr {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pc.Close() })
+	go func() {
+		b := make([]byte, 1500)
+		for {
+			n, addr, err := pc.ReadFrom(b)
+			if err != nil {
+				return
+			}
+			pc.WriteTo(b[:n], addr)
+		}
+	}()
+	return pc.LocalAddr().(*net.UDPAddr)
+}
+
+func testEcho(t *testing.T, c net.Conn) {
+	t.Helper()
+	c.SetDeadline(time.Now().Add(10 * time.Second))
+	msg := []byte("hello, socks")
+	if _, err := c.Write(msg); err != nil {
+		t.Fatal(err)
+	}
+	b := make([]byte, 100)
+	n, err := c.Read(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b[:n], msg) {
+		t.Errorf("read %q; want %q", b[:n], msg)
+	}
+}
+
+func TestConnect(t *testing.T) {
+	echo := startTCPEcho(t)
+	proxy := startServer(t, &Server{})
+
+	d := NewDialer("tcp", proxy)
+	c, err := d.Dial("tcp", echo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if c.(*Conn).BoundAddr() == nil {
+		t.Error("BoundAddr = nil")
+	}
+	testEcho(t, c)
+}
+
+func TestConnectAuth(t *testing.T) {
+	echo := startTCPEcho(t)
+	proxy := startServer(t, &Server{
+		Credentials: func(username, password string) bool {
+			return username == "gopher" && password == "secret"
+		},
+	})
+
+	for _, tt := range []struct {
+		name string
+		auth *UsernamePassword
+		ok   bool
+	}{
+		{"valid", &UsernamePassword{"gopher", "secret"}, true},
+		{"invalid", &UsernamePassword{"gopher", "guess"}, false},
+		{"none", nil, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDialer("tcp", proxy)
+			if tt.auth != nil {
+				d.AuthMethods = []AuthMethod{AuthMethodNotRequired, AuthMethodUsernamePassword}
+				d.Authenticate = tt.auth.Authenticate
+			}
+			c, err := d.Dial("tcp", echo)
+			if !tt.ok {
+				if err == nil {
+					c.Close()
+					t.Fatal("Dial succeeded; want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+			testEcho(t, c)
+		})
+	}
+}
+
+func TestConnectReply(t *testing.T) {
+	// Find a port with no listener.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := ln.Addr().String()
+	ln.Close()
+
+	var dialed string
+	proxy := startServer(t, &Server{
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			dialed = address
+			switch address {
+			case "forbidden.example:80":
+				return nil, ErrNotAllowed
+			case closed:
+				var d net.Dialer
+				return d.DialContext(ctx, network, address)
+			}
+			return nil, errors.New("unexpected address")
+		},
+	})
+
+	for _, tt := range []struct {
+		address string
+		reply   Reply
+	}{
+		{"forbidden.example:80", StatusNotAllowed},
+		{closed, StatusConnectionRefused},
+		{"other.example:80", StatusGeneralFailure},
+	} {
+		d := NewDialer("tcp", proxy)
+		c, err := d.Dial("tcp", tt.address)
+		if err == nil {
+			c.Close()
+			t.Errorf("Dial %s succeeded; want error", tt.address)
+			continue
+		}
+		if dialed != tt.address {
+			t.Errorf("server dialed %q; want %q", dialed, tt.address)
+		}
+		var re *ReplyError
+		if !errors.As(err, &re) || re.Reply != tt.reply {
+			t.Errorf("Dial %s: %v; want reply %v", tt.address, err, tt.reply)
+		}
+		var oe *net.OpError
+		if !errors.As(err, &oe) || oe.Addr.String() != tt.address || oe.Source.String() != proxy {
+			t.Errorf("Dial %s: %#v; want OpError with proxy and target addresses", tt.address, err)
+		}
+	}
+}
+
+func TestUDPAssociate(t *testing.T) {
+	echo := startUDPEcho(t)
+	proxy := startServer(t, &Server{})
+	d := NewDialer("tcp", proxy)
+
+	t.Run("Dial", func(t *testing.T) {
+		c, err := d.Dial("udp", echo.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+		if got := c.RemoteAddr().String(); got != echo.String() {
+			t.Errorf("RemoteAddr = %s; want %s", got, echo)
+		}
+		testEcho(t, c)
+	})
+
+	t.Run("ListenPacket", func(t *testing.T) {
+		c, err := d.ListenPacket(context.Background(), "udp")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+		c.SetDeadline(time.Now().Add(10 * time.Second))
+		msg := []byte("datagram")
+		if _, err := c.WriteTo(msg, echo); err != nil {
+			t.Fatal(err)
+		}
+		b := make([]byte, 100)
+		n, from, err := c.ReadFrom(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(b[:n], msg) {
+			t.Errorf("read %q; want %q", b[:n], msg)
+		}
+		if from.String() != echo.String() {
+			t.Errorf("datagram from %s; want %s", from, echo)
+		}
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		proxy := startServer(t, &Server{
+			ListenPacket: func(ctx context.Context, network, address string) (net.PacketConn, error) {
+				return nil, errors.ErrUnsupported
+			},
+		})
+		_, err := NewDialer("tcp", proxy).ListenPacket(context.Background(), "udp")
+		var re *ReplyError
+		if !errors.As(err, &re) || re.Reply != StatusCommandNotSupported {
+			t.Errorf("ListenPacket: %v; want reply %v", err, StatusCommandNotSupported)
+		}
+	})
+}
+
+func TestUDPAssociateForeignClient(t *testing.T) {
+	// A client that announces the address of another host still
+	// exchanges datagrams from its own host, and the relay does not
+	// send them to the other host.
+	echo := startUDPEcho(t)
+	proxy := startServer(t, &Server{})
+	d := NewDialer("tcp", proxy)
+	ctx := context.Background()
+	ctrl, err := d.dialProxy(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctrl.Close()
+	udp, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer udp.Close()
+	foreign := &Addr{IP: net.IPv4(192, 0, 2, 1), Port: udp.LocalAddr().(*net.UDPAddr).Port}
+	relay, err := d.connect(ctx, ctrl, CmdUDPAssociate, foreign)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := []byte("datagram")
+	b, err := appendDatagram(nil, &Addr{IP: echo.IP, Port: echo.Port})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := udp.WriteTo(append(b, msg...), &net.UDPAddr{IP: relay.IP, Port: relay.Port}); err != nil {
+		t.Fatal(err)
+	}
+	udp.SetReadDeadline(time.Now().Add(10 * time.Second))
+	reply := make([]byte, 100)
+	n, _, err := udp.ReadFrom(reply)
+	if err != nil {
+		t.Fatalf("no reply from the relay: %v", err)
+	}
+	from, payload, err := parseDatagram(reply[:n])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(payload, msg) || from.Port != echo.Port {
+		t.Errorf("relay sent %q from %s; want %q from %s", payload, from, msg, echo)
+	}
+}
+
+func TestAllow(t *testing.T) {
+	allowed := startUDPEcho(t)
+	denied := startUDPEcho(t)
+	tcpEcho := startTCPEcho(t)
+	var checks []string
+	var mu sync.Mutex
+	proxy := startServer(t, &Server{
+		Allow: func(ctx context.Context, cmd Command, address string) error {
+			mu.Lock()
+			checks = append(checks, cmd.String()+" "+address)
+			mu.Unlock()
+			if address == allowed.String() {
+				return nil
+			}
+			return ErrNotAllowed
+		},
+	})
+	d := NewDialer("tcp", proxy)
+
+	_, err := d.Dial("tcp", tcpEcho)
+	var re *ReplyError
+	if !errors.As(err, &re) || re.Reply != StatusNotAllowed {
+		t.Errorf("Dial tcp: %v; want reply %v", err, StatusNotAllowed)
+	}
+
+	c, err := d.ListenPacket(context.Background(), "udp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	b := make([]byte, 100)
+	for range 3 {
+		if _, err := c.WriteTo([]byte("dropped"), denied); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := c.WriteTo([]byte("relayed"), allowed); err != nil {
+		t.Fatal(err)
+	}
+	c.SetReadDeadline(time.Now().Add(10 * time.Second))
+	n, from, err := c.ReadFrom(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b[:n]) != "relayed" || from.String() != allowed.String() {
+		t.Errorf("read %q from %s; want %q from %s", b[:n], from, "relayed", allowed)
+	}
+	c.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if n, from, err := c.ReadFrom(b); err == nil {
+		t.Errorf("read %q from %s; want datagrams to refused destination dropped", b[:n], from)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{
+		"socks connect " + tcpEcho,
+		"socks udp associate " + denied.String(),
+		"socks udp associate " + allowed.String(),
+	}
+	slices.Sort(checks)
+	slices.Sort(want)
+	if !slices.Equal(checks, want) {
+		t.Errorf("Allow called with %q; want %q", checks, want)
+	}
+}
+
+func TestHandshakeTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	s := &Server{HandshakeTimeout: 50 * time.Millisecond}
+	errc := make(chan error, 1)
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			errc <- err
+			return
+		}
+		errc <- s.ServeConn(context.Background(), c)
+	}()
+
+	// A client that connects and sends nothing.
+	c, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	select {
+	case err := <-errc:
+		if ne, ok := err.(net.Error); !ok || !ne.Timeout() {
+			t.Errorf("ServeConn: %v; want timeout", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("ServeConn did not time out")
+	}
+}
+
+func TestUDPAssociateEnd(t *testing.T) {
+	// When the server ends the association, reads on the relay fail.
+	ctx, cancel := context.WithCancel(context.Background())
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	s := &Server{}
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		s.ServeConn(ctx, c)
+	}()
+
+	c, err := NewDialer("tcp", ln.Addr().String()).ListenPacket(context.Background(), "udp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	cancel()
+	c.SetReadDeadline(time.Now().Add(10 * time.Second))
+	_, _, err = c.ReadFrom(make([]byte, 10))
+	if err == nil {
+		t.Fatal("ReadFrom succeeded after the association ended")
+	}
+	if ne, ok := err.(net.Error); ok && ne.Timeout() {
+		t.Fatalf("ReadFrom timed out; want it to fail when the association ends")
+	}
+}
+
+func TestDialContextCancel(t *testing.T) {
+	// A proxy that never answers.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		io.Copy(io.Discard, c)
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	_, err = NewDialer("tcp", ln.Addr().String()).DialContext(ctx, "tcp", "example.com:80")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("DialContext: %v; want %v", err, context.Canceled)
+	}
+}
+
+func TestAddrWireFormat(t *testing.T) {
+	for _, a := range []*Addr{
+		{IP: net.IPv4(192, 0, 2, 1).To4(), Port: 80},
+		{IP: net.ParseIP("2001:db8::1"), Port: 443},
+		{Name: "example.com", Port: 8080},
+	} {
+		b, err := appendAddr(nil, a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := readAddr(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.String() != a.String() {
+			t.Errorf("round trip of %v = %v", a, got)
+		}
+
+		d, err := appendDatagram(nil, a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, payload, err := parseDatagram(append(d, "payload"...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.String() != a.String() || string(payload) != "payload" {
+			t.Errorf("datagram round trip of %v = %v, %q", a, got, payload)
+		}
+	}
+
+	if _, err := appendAddr(nil, &Addr{Name: string(make([]byte, 256))}); err == nil {
+		t.Error("appendAddr accepted a 256-byte name")
+	}
+	if _, _, err := parseDatagram([]byte{0, 0, 1, addrTypeIPv4, 127, 0, 0, 1, 0, 80}); err == nil {
+		t.Error("parseDatagram accepted a fragment")
+	}
+}
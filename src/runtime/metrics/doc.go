@@ -338,6 +338,11 @@ Below is the full list of supported metrics, ordered lexicographically.
 		The number of non-default behaviors executed by the go/build
 		package due to a non-default GODEBUG=installgoroot=... setting.
 
+	/godebug/non-default-behavior/iouring:events
+		The number of non-default behaviors executed by the
+		internal/poll package due to a non-default GODEBUG=iouring=...
+		setting.
+
 	/godebug/non-default-behavior/multipartmaxheaders:events
 		The number of non-default behaviors executed by
 		the mime/multipart package due to a non-default
@@ -162,6 +162,16 @@ func isPinned(ptr unsafe.Pointer) bool {
 	return pinState.isPinned()
 }
 
+// poll_runtime_isHeapPointer reports whether p points into the Go heap,
+// where an object can be pinned. internal/poll copies the buffers of
+// io_uring operations that are not on the heap, since a buffer on a
+// goroutine stack may move while the kernel uses it.
+//
+//go:linkname poll_runtime_isHeapPointer internal/poll.runtime_isHeapPointer
+func poll_runtime_isHeapPointer(p unsafe.Pointer) bool {
+	return spanOfHeap(uintptr(p)) != nil
+}
+
 // setPinned marks or unmarks a Go pointer as pinned, when the ptr is a Go pointer.
 // It will be ignored while trying to pin a non-Go pointer.
 // It will panic while trying to unpin a non-Go pointer,